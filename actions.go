@@ -13,6 +13,8 @@ type Actions struct {
 }
 
 // tcamsg is Actions specific
+//
+//structgen:binary
 type tcaMsg struct {
 	Family uint8
 	Pad1   uint8
@@ -66,77 +68,186 @@ func (a *Actions) Delete(info []*Action) error {
 	}, options)
 }
 
+// ActionsDumpOptions restricts the actions that are returned by Dump.
+type ActionsDumpOptions struct {
+	// TimeDelta limits the dump to actions that were used within the last
+	// TimeDelta milliseconds. If it is 0, all actions are returned.
+	TimeDelta uint32
+}
+
+// ActionsDump contains the result of Dump.
+type ActionsDump struct {
+	Actions []*Action
+	// Count is the number of actions as reported by the kernel.
+	Count uint32
+	// ExtWarnMsg contains the warning the kernel attached to the dump, if any.
+	ExtWarnMsg string
+}
+
 // Get fetches a specific kind of actions. kind is the name of the action, e.g. "bpf", "gact", etc.
 func (a *Actions) Get(kind string) ([]*Action, error) {
-	var results []*Action
-	var data []byte
-	tcminfo, err := marshalStruct(tcaMsg{
-		Family: unix.AF_UNSPEC,
+	dump, err := a.Dump(kind, nil)
+	return dump.Actions, err
+}
+
+// Dump fetches a specific kind of actions, similar to Get, and returns further
+// information, that is reported by the kernel.
+func (a *Actions) Dump(kind string, opts *ActionsDumpOptions) (*ActionsDump, error) {
+	dump := &ActionsDump{}
+	err := a.walkActions(kind, opts, dump, func(act *Action) int {
+		dump.Actions = append(dump.Actions, act)
+		return 0
 	})
-	if err != nil {
-		return results, err
+	return dump, err
+}
+
+// Walk calls fn for each action of kind as soon as it is received. The actions are not
+// collected in memory, which makes Walk suitable for large numbers of actions.
+func (a *Actions) Walk(kind string, fn ActionWalkFunc) error {
+	if fn == nil {
+		return ErrNoArg
 	}
-	data = append(data, tcminfo...)
+	return a.walkActions(kind, nil, &ActionsDump{}, fn)
+}
 
-	innerOptions := []tcOption{
-		{
-			Interpretation: vtString,
-			Type:           1,
-			Data:           kind,
-		},
+// walkActions dumps the actions of kind and calls fn for each of them. The further
+// information of the dump is stored in dump.
+func (a *Actions) walkActions(kind string, opts *ActionsDumpOptions, dump *ActionsDump,
+	fn ActionWalkFunc) error {
+	rootOptions := []tcOption{
 		{
 			Interpretation: vtUint64,
-			Type:           2,
+			Type:           tcaRootFlags,
 			Data:           uint64(1<<32 | 1), // TCA_FLAG_LARGE_DUMP_ON
 		},
 	}
-	inner, err := marshalAttributes(innerOptions)
+	if opts != nil && opts.TimeDelta != 0 {
+		rootOptions = append(rootOptions, tcOption{Interpretation: vtUint32, Type: tcaRootTimeDelta, Data: opts.TimeDelta})
+	}
+	req, err := newActionsRequest(unix.RTM_GETACTION, netlink.Dump, kind, 0, rootOptions)
 	if err != nil {
-		return results, err
+		return err
 	}
-	options := []tcOption{
-		{
-			Interpretation: vtBytes, // Outer attribute, nested
-			Type:           1,
-			Data:           inner,
-		},
+	return a.dump(req, func(msg netlink.Message) (bool, error) {
+		if len(msg.Data) < 4 {
+			return true, fmt.Errorf("Actions: short message: %w", ErrInvalidArg)
+		}
+		// The first 4 bytes contain tcaMsg - which is skipped here.
+		part := &ActionsDump{}
+		if err := unmarshalRoot(msg.Data[4:], part); err != nil {
+			return true, err
+		}
+		dump.Count += part.Count
+		if part.ExtWarnMsg != "" {
+			dump.ExtWarnMsg = part.ExtWarnMsg
+		}
+		for _, act := range part.Actions {
+			if fn(act) != 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// GetByIndex fetches a single action of kind by its index.
+func (a *Actions) GetByIndex(kind string, index uint32) (*Action, error) {
+	if index == 0 {
+		return nil, fmt.Errorf("Actions: index: %w", ErrNoArg)
 	}
-	got, err := marshalAttributes(options)
+	msgs, err := a.queryActions(unix.RTM_GETACTION, 0, kind, index, nil)
 	if err != nil {
-		return results, err
+		return nil, err
 	}
+	dump := &ActionsDump{}
+	for _, msg := range msgs {
+		if len(msg.Data) < 4 {
+			return nil, fmt.Errorf("Actions: short message: %w", ErrInvalidArg)
+		}
+		if err := unmarshalRoot(msg.Data[4:], dump); err != nil {
+			return nil, err
+		}
+	}
+	for _, act := range dump.Actions {
+		if act.Kind != kind {
+			continue
+		}
+		// The kernel reports the index only as part of the action specific parameters.
+		if act.Index == 0 {
+			act.Index = act.parmsIndex()
+		}
+		if act.Index == index {
+			return act, nil
+		}
+	}
+	return nil, fmt.Errorf("Actions: %s with index %d: %w", kind, index, unix.ENOENT)
+}
 
-	abs, err := marshalAttributes([]tcOption{{
-		Interpretation: vtBytes,
-		Type:           1, // TCA_ACT_TAB
-		Data:           got,
-	}})
+// Flush removes all actions of the given kind.
+func (a *Actions) Flush(kind string) error {
+	if len(kind) == 0 {
+		return fmt.Errorf("Actions: kind: %w", ErrNoArg)
+	}
+	data, err := marshalActionKey(kind, 0)
 	if err != nil {
-		return results, err
+		return err
 	}
+	return a.action(unix.RTM_DELACTION, netlink.Root, tcaMsg{
+		Family: unix.AF_UNSPEC,
+	}, []tcOption{{Interpretation: vtBytes, Type: tcaRootTab, Data: data}})
+}
 
-	data = append(data, abs...)
-
-	req := netlink.Message{
-		Header: netlink.Header{
-			Type:  netlink.HeaderType(unix.RTM_GETACTION),
-			Flags: netlink.Request | netlink.Dump,
-		},
-		Data: data,
+// marshalActionKey returns the TCA_ACT_TAB content, that identifies actions by kind and index.
+func marshalActionKey(kind string, index uint32) ([]byte, error) {
+	options := []tcOption{{Interpretation: vtString, Type: tcaActKind, Data: kind}}
+	if index != 0 {
+		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaActIndex, Data: index})
 	}
+	inner, err := marshalAttributes(options)
+	if err != nil {
+		return []byte{}, err
+	}
+	return marshalAttributes([]tcOption{{Interpretation: vtBytes, Type: 1, Data: inner}})
+}
 
-	msgs, err := a.query(req)
+func (a *Actions) queryActions(cmd int, flags netlink.HeaderFlags, kind string, index uint32,
+	rootOptions []tcOption) ([]netlink.Message, error) {
+	req, err := newActionsRequest(cmd, flags, kind, index, rootOptions)
 	if err != nil {
-		return results, err
+		return nil, err
 	}
+	return a.query(req)
+}
 
-	for _, msg := range msgs {
-		// The first 4 bytes contain tcaMsg - which is skipped here.
-		if err := unmarshalRoot(msg.Data[4:], &results); err != nil {
-			return results, err
-		}
+// newActionsRequest returns the request for actions of kind and, if it is not 0, index.
+func newActionsRequest(cmd int, flags netlink.HeaderFlags, kind string, index uint32,
+	rootOptions []tcOption) (netlink.Message, error) {
+	if len(kind) == 0 {
+		return netlink.Message{}, fmt.Errorf("Actions: kind: %w", ErrNoArg)
+	}
+	tcminfo, err := marshalStruct(&tcaMsg{
+		Family: unix.AF_UNSPEC,
+	})
+	if err != nil {
+		return netlink.Message{}, err
 	}
-	return results, nil
+	key, err := marshalActionKey(kind, index)
+	if err != nil {
+		return netlink.Message{}, err
+	}
+	options := append([]tcOption{{Interpretation: vtBytes, Type: tcaRootTab, Data: key}}, rootOptions...)
+	attrs, err := marshalAttributes(options)
+	if err != nil {
+		return netlink.Message{}, err
+	}
+
+	return netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(cmd),
+			Flags: netlink.Request | flags,
+		},
+		Data: append(tcminfo, attrs...),
+	}, nil
 }
 
 func validateActionsObject(cmd int, info []*Action) ([]tcOption, error) {
@@ -160,7 +271,7 @@ const (
 	tcaRootExtWarnMsg
 )
 
-func unmarshalRoot(data []byte, actions *[]*Action) error {
+func unmarshalRoot(data []byte, dump *ActionsDump) error {
 	ad, err := netlink.NewAttributeDecoder(data)
 	if err != nil {
 		return err
@@ -169,16 +280,16 @@ func unmarshalRoot(data []byte, actions *[]*Action) error {
 	for ad.Next() {
 		switch ad.Type() {
 		case tcaRootTab:
-			err := unmarshalActions(ad.Bytes(), actions)
+			err := unmarshalActions(ad.Bytes(), &dump.Actions)
 			multiError = concatError(multiError, err)
 		case tcaRootFlags:
 			_ = ad.Uint64()
 		case tcaRootCount:
-			_ = ad.Uint32()
+			dump.Count += ad.Uint32()
 		case tcaRootTimeDelta:
 			_ = ad.Uint32()
 		case tcaRootExtWarnMsg:
-			_ = ad.String()
+			dump.ExtWarnMsg = ad.String()
 		default:
 			return fmt.Errorf("unmarshalRoot()\t%d\n\t%v", ad.Type(), ad.Bytes())
 		}
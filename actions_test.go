@@ -1,6 +1,13 @@
 package tc
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
 
 func TestActions(t *testing.T) {
 	tcSocket, done := testConn(t)
@@ -57,3 +64,144 @@ func TestActions(t *testing.T) {
 		}
 	})
 }
+
+// actionsReply returns a reply with the actions and the additional root attributes.
+func actionsReply(t *testing.T, actions []*Action, root ...tcOption) func(netlink.Message) ([]netlink.Message, error) {
+	t.Helper()
+	tab, err := marshalActions(unix.RTM_NEWACTION, actions)
+	if err != nil {
+		t.Fatalf("could not encode actions: %v", err)
+	}
+	attrs, err := marshalAttributes(append([]tcOption{{Interpretation: vtBytes, Type: tcaRootTab, Data: tab}}, root...))
+	if err != nil {
+		t.Fatalf("could not encode attributes: %v", err)
+	}
+	data, _ := marshalStruct(tcaMsg{Family: unix.AF_UNSPEC})
+	return func(req netlink.Message) ([]netlink.Message, error) {
+		return []netlink.Message{{
+			Header: netlink.Header{Type: unix.RTM_NEWACTION, Sequence: req.Header.Sequence, PID: req.Header.PID},
+			Data:   append(data, attrs...),
+		}}, nil
+	}
+}
+
+// actionsRequest decodes the root attributes of an action request.
+func actionsRequest(t *testing.T, req netlink.Message) map[uint16][]byte {
+	t.Helper()
+	ad, err := netlink.NewAttributeDecoder(req.Data[4:])
+	if err != nil {
+		t.Fatalf("could not decode request: %v", err)
+	}
+	attrs := make(map[uint16][]byte)
+	for ad.Next() {
+		attrs[ad.Type()] = ad.Bytes()
+	}
+	return attrs
+}
+
+func TestActionsDump(t *testing.T) {
+	gacts := []*Action{
+		{Kind: "gact", Index: 1, Gact: &Gact{Parms: &GactParms{Index: 1, Action: ActShot}}},
+		{Kind: "gact", Index: 2, Gact: &Gact{Parms: &GactParms{Index: 2, Action: ActOk}}},
+	}
+
+	t.Run("Dump", func(t *testing.T) {
+		var reqs []netlink.Message
+		tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+			unix.RTM_GETACTION: actionsReply(t, gacts,
+				tcOption{Interpretation: vtUint32, Type: tcaRootCount, Data: uint32(2)},
+				tcOption{Interpretation: vtString, Type: tcaRootExtWarnMsg, Data: "warning"}),
+		}, &reqs)
+
+		dump, err := tcSocket.Actions().Dump("gact", &ActionsDumpOptions{TimeDelta: 1000})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if dump.Count != 2 || dump.ExtWarnMsg != "warning" || len(dump.Actions) != 2 {
+			t.Fatalf("unexpected dump: %#v", dump)
+		}
+		if diff := cmp.Diff(gacts[1].Gact, dump.Actions[1].Gact); diff != "" {
+			t.Fatalf("action missmatch (want +got):\n%s", diff)
+		}
+		if reqs[0].Header.Flags != netlink.Request|netlink.Dump {
+			t.Fatalf("unexpected flags: %v", reqs[0].Header.Flags)
+		}
+		attrs := actionsRequest(t, reqs[0])
+		if got := nativeEndian.Uint32(attrs[tcaRootTimeDelta]); got != 1000 {
+			t.Fatalf("unexpected time delta: %d", got)
+		}
+		if _, ok := attrs[tcaRootFlags]; !ok {
+			t.Fatalf("missing root flags")
+		}
+
+		actions, err := tcSocket.Actions().Get("gact")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(actions) != 2 {
+			t.Fatalf("expected 2 actions but got %d", len(actions))
+		}
+		if _, ok := actionsRequest(t, reqs[1])[tcaRootTimeDelta]; ok {
+			t.Fatalf("unexpected time delta in Get()")
+		}
+	})
+
+	t.Run("GetByIndex", func(t *testing.T) {
+		var reqs []netlink.Message
+		// Like tcf_action_dump_1(), the kernel reports the index only within the
+		// parameters of the action.
+		tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+			unix.RTM_GETACTION: actionsReply(t, []*Action{{Kind: "gact", Gact: gacts[1].Gact}}),
+		}, &reqs)
+
+		act, err := tcSocket.Actions().GetByIndex("gact", 2)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(gacts[1], act); diff != "" {
+			t.Fatalf("action missmatch (want +got):\n%s", diff)
+		}
+		if reqs[0].Header.Flags != netlink.Request {
+			t.Fatalf("unexpected flags: %v", reqs[0].Header.Flags)
+		}
+		var key []*Action
+		if err := unmarshalActions(actionsRequest(t, reqs[0])[tcaRootTab], &key); err != nil {
+			t.Fatalf("could not decode request: %v", err)
+		}
+		if diff := cmp.Diff([]*Action{{Kind: "gact", Index: 2}}, key); diff != "" {
+			t.Fatalf("request missmatch (want +got):\n%s", diff)
+		}
+
+		if _, err := tcSocket.Actions().GetByIndex("gact", 3); !errors.Is(err, unix.ENOENT) {
+			t.Fatalf("expected ENOENT but got %v", err)
+		}
+		if _, err := tcSocket.Actions().GetByIndex("gact", 0); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("expected ErrNoArg but got %v", err)
+		}
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		var reqs []netlink.Message
+		tcSocket := bpfAttachConn(t, nil, &reqs)
+
+		if err := tcSocket.Actions().Flush("gact"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if reqs[0].Header.Type != unix.RTM_DELACTION {
+			t.Fatalf("unexpected type: %v", reqs[0].Header.Type)
+		}
+		if reqs[0].Header.Flags&netlink.Root == 0 {
+			t.Fatalf("missing NLM_F_ROOT: %v", reqs[0].Header.Flags)
+		}
+		var key []*Action
+		if err := unmarshalActions(actionsRequest(t, reqs[0])[tcaRootTab], &key); err != nil {
+			t.Fatalf("could not decode request: %v", err)
+		}
+		if diff := cmp.Diff([]*Action{{Kind: "gact"}}, key); diff != "" {
+			t.Fatalf("request missmatch (want +got):\n%s", diff)
+		}
+		if err := tcSocket.Actions().Flush(""); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("expected ErrNoArg but got %v", err)
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package tc
 
 import (
-	"encoding/binary"
 	"fmt"
 
 	"github.com/mdlayher/netlink"
@@ -85,12 +84,8 @@ func extractTcmsgAttributes(action int, data []byte, info *Attribute) error {
 }
 
 func hasQOpt(kind string) bool {
-	classful := map[string]bool{
-		"hfsc": true,
-		"qfq":  true,
-		"htb":  true,
-	}
-	if _, ok := classful[kind]; ok {
+	switch kind {
+	case "hfsc", "qfq", "htb":
 		return true
 	}
 	return false
@@ -393,7 +388,7 @@ func extractXStats(data []byte, tc *XStats, kind string) error {
 		info := &FqQdStats{}
 		// Pad out data to size of our FqQdStats struct to handle
 		// unmarshalling data from older kernel versions with smaller structs
-		qd := make([]byte, binary.Size(info))
+		qd := make([]byte, info.binaryLen())
 		copy(qd, data)
 		err := unmarshalStruct(qd, info)
 		multiError = concatError(multiError, err)
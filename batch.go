@@ -0,0 +1,671 @@
+package tc
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
+)
+
+const (
+	// batchMaxMessages and batchMaxBytes limit the requests, that are sent at once.
+	// The kernel answers each request with an acknowledgement and the acknowledgements
+	// of a single send have to fit into the receive buffer of the socket.
+	batchMaxMessages = 64
+	batchMaxBytes    = 32 << 10
+)
+
+// batchKind defines the type of object an operation of a Batch alters.
+type batchKind int
+
+const (
+	batchQdisc batchKind = iota
+	batchClass
+	batchFilter
+	batchActions
+)
+
+// batchVerb defines the operation of a Batch.
+type batchVerb int
+
+const (
+	batchAdd batchVerb = iota
+	batchReplace
+	batchDelete
+)
+
+// batchOp is a single operation of a Batch.
+type batchOp struct {
+	kind    batchKind
+	verb    batchVerb
+	object  *Object
+	actions []*Action
+	req     netlink.Message
+}
+
+// Batch queues operations on qdiscs, classes, filters and actions. Send transmits all
+// queued operations at once and so avoids to wait for the acknowledgement of each
+// operation before the next one is sent.
+type Batch struct {
+	tc  *Tc
+	ops []batchOp
+}
+
+// BatchOptions defines the behavior of Batch.Send.
+type BatchOptions struct {
+	// Rollback stops a batch on the first operation, that fails, and undoes all
+	// operations, that were applied by then. To undo replace and delete operations
+	// the affected objects are fetched from the kernel before the batch is sent.
+	//
+	// Rollback requires each object to be identified by its handle. For filters also
+	// the priority has to be set and actions require an index.
+	Rollback bool
+}
+
+// BatchResult contains the result of a single operation of a Batch.
+type BatchResult struct {
+	// Err is the error reported by the kernel for the operation. With
+	// BatchOptions.Rollback operations, that were not sent, report ErrAborted.
+	Err error
+	// Warning is the message of the extended acknowledgement of a successful operation.
+	Warning string
+	// RolledBack reports whether the operation was undone.
+	RolledBack bool
+	// RollbackErr is the error, that prevented the operation from being undone.
+	RollbackErr error
+}
+
+// Batch returns an empty batch of operations.
+func (tc *Tc) Batch() *Batch {
+	return &Batch{tc: tc}
+}
+
+// Len returns the number of queued operations.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// AddQdisc queues the creation of a queueing discipline.
+func (b *Batch) AddQdisc(info *Object) error {
+	return b.queue(batchOp{kind: batchQdisc, verb: batchAdd, object: info})
+}
+
+// ReplaceQdisc queues the replacement of a queueing discipline.
+func (b *Batch) ReplaceQdisc(info *Object) error {
+	return b.queue(batchOp{kind: batchQdisc, verb: batchReplace, object: info})
+}
+
+// DeleteQdisc queues the removal of a queueing discipline.
+func (b *Batch) DeleteQdisc(info *Object) error {
+	return b.queue(batchOp{kind: batchQdisc, verb: batchDelete, object: info})
+}
+
+// AddClass queues the creation of a class.
+func (b *Batch) AddClass(info *Object) error {
+	return b.queue(batchOp{kind: batchClass, verb: batchAdd, object: info})
+}
+
+// ReplaceClass queues the replacement of a class.
+func (b *Batch) ReplaceClass(info *Object) error {
+	return b.queue(batchOp{kind: batchClass, verb: batchReplace, object: info})
+}
+
+// DeleteClass queues the removal of a class.
+func (b *Batch) DeleteClass(info *Object) error {
+	return b.queue(batchOp{kind: batchClass, verb: batchDelete, object: info})
+}
+
+// AddFilter queues the creation of a filter.
+func (b *Batch) AddFilter(info *Object) error {
+	return b.queue(batchOp{kind: batchFilter, verb: batchAdd, object: info})
+}
+
+// ReplaceFilter queues the replacement of a filter.
+func (b *Batch) ReplaceFilter(info *Object) error {
+	return b.queue(batchOp{kind: batchFilter, verb: batchReplace, object: info})
+}
+
+// DeleteFilter queues the removal of a filter.
+func (b *Batch) DeleteFilter(info *Object) error {
+	return b.queue(batchOp{kind: batchFilter, verb: batchDelete, object: info})
+}
+
+// AddActions queues the creation of actions.
+func (b *Batch) AddActions(info []*Action) error {
+	return b.queue(batchOp{kind: batchActions, verb: batchAdd, actions: info})
+}
+
+// ReplaceActions queues the replacement of actions.
+func (b *Batch) ReplaceActions(info []*Action) error {
+	return b.queue(batchOp{kind: batchActions, verb: batchReplace, actions: info})
+}
+
+// DeleteActions queues the removal of actions.
+func (b *Batch) DeleteActions(info []*Action) error {
+	return b.queue(batchOp{kind: batchActions, verb: batchDelete, actions: info})
+}
+
+// queue validates op and appends it to the batch.
+func (b *Batch) queue(op batchOp) error {
+	if op.kind == batchActions && len(op.actions) == 0 {
+		return ErrNoArg
+	}
+	if op.kind != batchActions && op.object == nil {
+		return ErrNoArg
+	}
+
+	var cmd int
+	var options []tcOption
+	var err error
+	switch op.kind {
+	case batchQdisc:
+		cmd = unix.RTM_NEWQDISC
+		if op.verb == batchDelete {
+			cmd = unix.RTM_DELQDISC
+		}
+	case batchClass:
+		cmd = unix.RTM_NEWTCLASS
+		if op.verb == batchDelete {
+			cmd = unix.RTM_DELTCLASS
+		}
+	case batchFilter:
+		cmd = unix.RTM_NEWTFILTER
+		if op.verb == batchDelete {
+			cmd = unix.RTM_DELTFILTER
+		}
+	case batchActions:
+		cmd = unix.RTM_NEWACTION
+		if op.verb == batchDelete {
+			cmd = unix.RTM_DELACTION
+		}
+	}
+	if op.kind == batchActions {
+		options, err = validateActionsObject(cmd, op.actions)
+	} else {
+		options, err = validateObject(cmd, op.object)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Use the same flags as the corresponding methods of Qdisc, Class, Filter and Actions.
+	var flags netlink.HeaderFlags
+	switch op.verb {
+	case batchAdd:
+		flags = netlink.Create | netlink.Excl
+	case batchReplace:
+		flags = netlink.Create
+		if op.kind == batchQdisc {
+			flags |= netlink.Replace
+		}
+	}
+
+	var msg interface{} = &tcaMsg{Family: unix.AF_UNSPEC}
+	if op.kind != batchActions {
+		msg = &op.object.Msg
+	}
+	if op.req, err = newRequest(cmd, flags, msg, options); err != nil {
+		return err
+	}
+	b.ops = append(b.ops, op)
+	return nil
+}
+
+// Send transmits all queued operations and returns the result of each of them. The
+// operations are applied by the kernel in the order they were queued.
+//
+// If an operation fails, the returned error refers to the first failed operation. Errors
+// that prevent the communication with the kernel are returned as well and leave the
+// results incomplete.
+//
+// The operations are sent in chunks. With BatchOptions.Rollback no further chunk is sent
+// after an operation failed, but the operations, that were sent along with the failed one,
+// are applied by the kernel and undone as well.
+//
+// Rollback restores the objects, that were replaced or deleted, but not the objects,
+// that the kernel removed along with them, like the classes and filters of a deleted
+// queueing discipline.
+func (b *Batch) Send(opts *BatchOptions) ([]BatchResult, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	results := make([]BatchResult, len(b.ops))
+
+	var undo []func(*Batch) error
+	if opts.Rollback {
+		var err error
+		if undo, err = b.snapshot(); err != nil {
+			return nil, err
+		}
+	}
+
+	sent, err := b.transmit(results, opts.Rollback)
+	if err != nil {
+		return results, err
+	}
+	for i := sent; i < len(b.ops); i++ {
+		results[i].Err = ErrAborted
+	}
+	for i := range results {
+		if results[i].Err != nil && !errors.Is(results[i].Err, ErrAborted) {
+			err = fmt.Errorf("batch: operation %d: %w", i, results[i].Err)
+			break
+		}
+	}
+	if err != nil && opts.Rollback {
+		b.rollback(undo, results[:sent])
+	}
+	return results, err
+}
+
+// transmit sends the queued operations in chunks and stores their results in results.
+// If stop is set, no further chunks are sent after an operation failed. It returns the
+// number of operations, that were sent.
+func (b *Batch) transmit(results []BatchResult, stop bool) (int, error) {
+	sent := 0
+	for sent < len(b.ops) {
+		end := sent + 1
+		size := len(b.ops[sent].req.Data)
+		for end < len(b.ops) && end-sent < batchMaxMessages {
+			size += len(b.ops[end].req.Data)
+			if size > batchMaxBytes {
+				break
+			}
+			end++
+		}
+
+		reqs := make([]netlink.Message, end-sent)
+		for i := range reqs {
+			reqs[i] = b.ops[sent+i].req
+		}
+		chunk, err := b.tc.pipeline(reqs)
+		if err != nil {
+			return sent, err
+		}
+		copy(results[sent:], chunk)
+		sent = end
+
+		if stop && failed(chunk) {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// failed reports whether one of results contains an error.
+func failed(results []BatchResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rollback undoes the applied operations of results in reverse order.
+func (b *Batch) rollback(undo []func(*Batch) error, results []BatchResult) {
+	reverse := b.tc.Batch()
+	var owner []int
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].Err != nil {
+			continue
+		}
+		queued := reverse.Len()
+		if err := undo[i](reverse); err != nil {
+			results[i].RollbackErr = err
+			reverse.ops = reverse.ops[:queued]
+			continue
+		}
+		for j := queued; j < reverse.Len(); j++ {
+			owner = append(owner, i)
+		}
+		results[i].RolledBack = true
+	}
+
+	undone := make([]BatchResult, reverse.Len())
+	if _, err := reverse.transmit(undone, false); err != nil {
+		for j := range undone {
+			undone[j].Err = err
+		}
+	}
+	for j, result := range undone {
+		i := owner[j]
+		if result.Err != nil && results[i].RollbackErr == nil {
+			results[i].RolledBack = false
+			results[i].RollbackErr = result.Err
+		}
+	}
+}
+
+// snapshot returns for each operation a function, that queues the operations to undo it.
+func (b *Batch) snapshot() ([]func(*Batch) error, error) {
+	for i, op := range b.ops {
+		if err := rollbackIdentity(op); err != nil {
+			return nil, fmt.Errorf("batch: operation %d: %w", i, err)
+		}
+	}
+
+	type dumpKey struct {
+		kind            batchKind
+		ifindex, parent uint32
+	}
+	dumps := make(map[dumpKey][]Object)
+	lookup := func(op batchOp) (*Object, error) {
+		key := dumpKey{kind: op.kind}
+		switch op.kind {
+		case batchClass:
+			key.ifindex = op.object.Ifindex
+		case batchFilter:
+			key.ifindex, key.parent = op.object.Ifindex, op.object.Parent
+		}
+		objs, ok := dumps[key]
+		if !ok {
+			var err error
+			switch op.kind {
+			case batchQdisc:
+				objs, err = b.tc.Qdisc().Get()
+			case batchClass:
+				objs, err = b.tc.Class().Get(&Msg{Family: unix.AF_UNSPEC, Ifindex: key.ifindex})
+			case batchFilter:
+				objs, err = b.tc.Filter().Get(&Msg{Family: unix.AF_UNSPEC, Ifindex: key.ifindex, Parent: key.parent})
+			}
+			if err != nil {
+				return nil, err
+			}
+			dumps[key] = objs
+		}
+		for _, obj := range objs {
+			if sameObject(op.kind, op.object, &obj) {
+				return withoutReadOnly(&obj), nil
+			}
+		}
+		return nil, nil
+	}
+
+	undo := make([]func(*Batch) error, len(b.ops))
+	for i, op := range b.ops {
+		op := op
+		if op.kind == batchActions {
+			fn, err := b.snapshotActions(op)
+			if err != nil {
+				return nil, fmt.Errorf("batch: operation %d: %w", i, err)
+			}
+			undo[i] = fn
+			continue
+		}
+
+		var prev *Object
+		if op.verb != batchAdd {
+			var err error
+			if prev, err = lookup(op); err != nil {
+				return nil, fmt.Errorf("batch: operation %d: %w", i, err)
+			}
+		}
+		undo[i] = func(reverse *Batch) error {
+			switch {
+			case op.verb == batchAdd || (op.verb == batchReplace && prev == nil):
+				return reverse.queue(batchOp{kind: op.kind, verb: batchDelete, object: identity(op.object)})
+			case prev == nil:
+				return fmt.Errorf("deleted object is unknown: %w", ErrNoArg)
+			case op.verb == batchReplace:
+				return reverse.queue(batchOp{kind: op.kind, verb: batchReplace, object: prev})
+			default:
+				return reverse.queue(batchOp{kind: op.kind, verb: batchAdd, object: prev})
+			}
+		}
+	}
+	return undo, nil
+}
+
+// snapshotActions returns a function, that queues the operations to undo op.
+func (b *Batch) snapshotActions(op batchOp) (func(*Batch) error, error) {
+	var created []*Action
+	var previous []*Action
+	for _, act := range op.actions {
+		if op.verb == batchAdd {
+			created = append(created, &Action{Kind: act.Kind, Index: act.Index})
+			continue
+		}
+		prev, err := b.tc.Actions().GetByIndex(act.Kind, act.Index)
+		if errors.Is(err, unix.ENOENT) {
+			if op.verb == batchDelete {
+				return func(*Batch) error {
+					return fmt.Errorf("deleted action is unknown: %w", ErrNoArg)
+				}, nil
+			}
+			created = append(created, &Action{Kind: act.Kind, Index: act.Index})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, prev.withoutReadOnly())
+	}
+
+	return func(reverse *Batch) error {
+		if len(created) > 0 {
+			if err := reverse.DeleteActions(created); err != nil {
+				return err
+			}
+		}
+		if len(previous) == 0 {
+			return nil
+		}
+		if op.verb == batchDelete {
+			return reverse.AddActions(previous)
+		}
+		return reverse.ReplaceActions(previous)
+	}, nil
+}
+
+// rollbackIdentity checks, that the object of op can be identified to undo op.
+func rollbackIdentity(op batchOp) error {
+	if op.kind == batchActions {
+		for _, act := range op.actions {
+			if act.Index == 0 {
+				return fmt.Errorf("rollback requires the index of %s: %w", act.Kind, ErrInvalidArg)
+			}
+		}
+		return nil
+	}
+	if op.object.Handle == 0 {
+		return fmt.Errorf("rollback requires the handle: %w", ErrInvalidArg)
+	}
+	if op.kind == batchFilter && op.object.Info>>16 == 0 {
+		return fmt.Errorf("rollback requires the priority of the filter: %w", ErrInvalidArg)
+	}
+	return nil
+}
+
+// sameObject reports whether obj, which was received from the kernel, is info.
+func sameObject(kind batchKind, info, obj *Object) bool {
+	if obj.Ifindex != info.Ifindex || obj.Handle != info.Handle {
+		return false
+	}
+	if kind != batchFilter {
+		return true
+	}
+	return obj.Parent == info.Parent && obj.Info>>16 == info.Info>>16 &&
+		uint32Value(obj.Chain) == uint32Value(info.Chain)
+}
+
+// identity returns the parts of info, that are required to delete it.
+func identity(info *Object) *Object {
+	return &Object{
+		Msg: info.Msg,
+		Attribute: Attribute{
+			Kind:  info.Kind,
+			Chain: info.Chain,
+		},
+	}
+}
+
+// withoutReadOnly returns a copy of info without the attributes, that are reported by the
+// kernel but can not be altered, like statistics, reference counts and the offload state of
+// filters. This allows to send an object, that was received from the kernel, back to it.
+// info is not modified.
+func withoutReadOnly(info *Object) *Object {
+	res := *info
+	res.Stats, res.XStats, res.Stats2 = nil, nil, nil
+	switch {
+	case info.Basic != nil:
+		basic := *info.Basic
+		basic.Pcnt = nil
+		basic.Police = basic.Police.withoutReadOnly()
+		basic.Actions = actionsWithoutReadOnly(basic.Actions)
+		res.Basic = &basic
+	case info.BPF != nil:
+		bpf := *info.BPF
+		bpf.Action = bpf.Action.withoutReadOnly()
+		bpf.Police = bpf.Police.withoutReadOnly()
+		bpf.FlagsGen = withoutOffloadState(bpf.FlagsGen)
+		res.BPF = &bpf
+	case info.Cgroup != nil:
+		cgroup := *info.Cgroup
+		cgroup.Action = cgroup.Action.withoutReadOnly()
+		res.Cgroup = &cgroup
+	case info.U32 != nil:
+		u32 := *info.U32
+		u32.Pcnt = nil
+		u32.Police = u32.Police.withoutReadOnly()
+		u32.Actions = actionsWithoutReadOnly(u32.Actions)
+		u32.Flags = withoutOffloadState(u32.Flags)
+		res.U32 = &u32
+	case info.Rsvp != nil:
+		rsvp := *info.Rsvp
+		rsvp.Police = rsvp.Police.withoutReadOnly()
+		rsvp.Actions = actionsWithoutReadOnly(rsvp.Actions)
+		res.Rsvp = &rsvp
+	case info.Route4 != nil:
+		route4 := *info.Route4
+		route4.Actions = actionsWithoutReadOnly(route4.Actions)
+		res.Route4 = &route4
+	case info.Fw != nil:
+		fw := *info.Fw
+		fw.Police = fw.Police.withoutReadOnly()
+		fw.Actions = actionsWithoutReadOnly(fw.Actions)
+		res.Fw = &fw
+	case info.Flow != nil:
+		flow := *info.Flow
+		flow.Actions = actionsWithoutReadOnly(flow.Actions)
+		res.Flow = &flow
+	case info.Flower != nil:
+		flower := *info.Flower
+		flower.Actions = actionsWithoutReadOnly(flower.Actions)
+		flower.Flags = withoutOffloadState(flower.Flags)
+		flower.InHwCount = nil
+		res.Flower = &flower
+	case info.Matchall != nil:
+		matchall := *info.Matchall
+		matchall.Pcnt = nil
+		matchall.Actions = actionsWithoutReadOnly(matchall.Actions)
+		matchall.Flags = withoutOffloadState(matchall.Flags)
+		res.Matchall = &matchall
+	case info.TcIndex != nil:
+		tcIndex := *info.TcIndex
+		tcIndex.Actions = actionsWithoutReadOnly(tcIndex.Actions)
+		res.TcIndex = &tcIndex
+	}
+	return &res
+}
+
+// withoutOffloadState returns the flags of a filter without the offload state. The kernel
+// reports the state, but does not accept it.
+func withoutOffloadState(flags *uint32) *uint32 {
+	if flags == nil {
+		return nil
+	}
+	return uint32Ptr(*flags &^ (InHw | NotInHw))
+}
+
+// messageSender is implemented by connections, that send several messages at once,
+// like netlink.Conn.
+type messageSender interface {
+	SendMessages(msgs []netlink.Message) ([]netlink.Message, error)
+}
+
+// pipeline sends reqs without waiting for the acknowledgements in between and returns
+// the result of each request.
+func (tc *Tc) pipeline(reqs []netlink.Message) ([]BatchResult, error) {
+	tc.shared.mu.Lock()
+	defer tc.shared.mu.Unlock()
+
+	sent := make([]netlink.Message, len(reqs))
+	copy(sent, reqs)
+	if sender, ok := tc.con.(messageSender); ok {
+		var err error
+		if sent, err = sender.SendMessages(sent); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range sent {
+			var err error
+			if sent[i], err = tc.con.Send(sent[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	results := make([]BatchResult, len(sent))
+	receive := batchReceiver(tc.con)
+	if receive == nil {
+		return results, tc.receiveAcks(results)
+	}
+
+	// Match the acknowledgements by their sequence number.
+	pending := make(map[uint32]int, len(sent))
+	for i, msg := range sent {
+		pending[msg.Header.Sequence] = i
+	}
+	for len(pending) > 0 {
+		msgs, err := receive()
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			i, ok := pending[msg.Header.Sequence]
+			if !ok || msg.Header.Type != netlink.Error {
+				continue
+			}
+			delete(pending, msg.Header.Sequence)
+			if results[i].Err = checkMessage(msg); results[i].Err == nil {
+				results[i].Warning, _ = extendedAck(msg)
+			}
+		}
+	}
+	return results, nil
+}
+
+// receiveAcks receives the acknowledgements of the connection one after another, if the
+// connection does not provide access to its socket.
+func (tc *Tc) receiveAcks(results []BatchResult) error {
+	for i := 0; i < len(results); {
+		msgs, err := tc.con.Receive()
+		if err != nil {
+			// An error reported by the kernel is the acknowledgement of a request.
+			var opErr *netlink.OpError
+			if !errors.As(err, &opErr) {
+				return err
+			}
+			if _, ok := opErr.Err.(syscall.Errno); !ok {
+				return err
+			}
+			results[i].Err = err
+			i++
+			continue
+		}
+		if len(msgs) == 0 {
+			return fmt.Errorf("missing acknowledgement of %d requests: %w", len(results)-i, ErrNoArg)
+		}
+		for _, msg := range msgs {
+			if msg.Header.Type != netlink.Error || i == len(results) {
+				continue
+			}
+			results[i].Warning, _ = extendedAck(msg)
+			i++
+		}
+	}
+	return nil
+}
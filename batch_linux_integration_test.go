@@ -0,0 +1,131 @@
+//go:build integration && linux
+// +build integration,linux
+
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
+
+func TestLinuxBatch(t *testing.T) {
+	const filters = 2000
+
+	ns, err := newScratchNetNS()
+	if err != nil {
+		t.Skipf("could not create network namespace: %v", err)
+	}
+	defer ns.Close()
+
+	tcSocket, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open socket for TC: %v", err)
+	}
+	defer tcSocket.Close()
+	if err := tcSocket.SetOption(netlink.ExtendedAcknowledge, true); err != nil {
+		t.Fatalf("could not enable extended acknowledgements: %v", err)
+	}
+
+	if err := tcSocket.Qdisc().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{
+			Kind: "clsact",
+		},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+
+	parent := core.BuildHandle(HandleRoot, HandleMinIngress)
+	filter := func(node, classID uint32) *Object {
+		return &Object{
+			Msg: Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: probeLoopbackIndex,
+				// Node of the default hash table 800:
+				Handle: 0x80000000 | node,
+				Parent: parent,
+				Info:   core.FilterInfo(1, unix.ETH_P_ALL),
+			},
+			Attribute: Attribute{
+				Kind: "u32",
+				U32: &U32{
+					ClassID: uint32Ptr(classID),
+					Sel:     &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{{}}},
+				},
+			},
+		}
+	}
+	classIDs := func() map[uint32]uint32 {
+		all, err := tcSocket.Filter().Get(&Msg{Family: unix.AF_UNSPEC, Ifindex: probeLoopbackIndex, Parent: parent})
+		if err != nil {
+			t.Fatalf("could not get filters: %v", err)
+		}
+		ids := make(map[uint32]uint32)
+		for _, f := range all {
+			if f.U32 != nil && f.U32.ClassID != nil {
+				ids[f.Handle] = *f.U32.ClassID
+			}
+		}
+		return ids
+	}
+
+	batch := tcSocket.Batch()
+	want := make(map[uint32]uint32)
+	for node := uint32(1); node <= filters; node++ {
+		if err := batch.AddFilter(filter(node, 0x10001)); err != nil {
+			t.Fatalf("could not queue filter %d: %v", node, err)
+		}
+		want[0x80000000|node] = 0x10001
+	}
+	if _, err := batch.Send(nil); err != nil {
+		t.Fatalf("could not send batch: %v", err)
+	}
+	if diff := cmp.Diff(want, classIDs()); diff != "" {
+		t.Fatalf("filters missmatch (want +got):\n%s", diff)
+	}
+
+	batch = tcSocket.Batch()
+	for node := uint32(1); node <= 100; node++ {
+		if err := batch.ReplaceFilter(filter(node, 0x10002)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for node := uint32(filters + 1); node <= filters+100; node++ {
+		if err := batch.AddFilter(filter(node, 0x10002)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.DeleteFilter(filter(filters+1000, 0x10002)); err != nil {
+		t.Fatal(err)
+	}
+	for node := uint32(filters + 101); node <= filters+200; node++ {
+		if err := batch.AddFilter(filter(node, 0x10002)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := batch.Send(&BatchOptions{Rollback: true})
+	var opErr *netlink.OpError
+	if !errors.As(err, &opErr) || !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Logf("extended acknowledgement: %q", opErr.Message)
+	for i, result := range results {
+		if result.RollbackErr != nil {
+			t.Fatalf("could not roll back operation %d: %v", i, result.RollbackErr)
+		}
+	}
+	if diff := cmp.Diff(want, classIDs()); diff != "" {
+		t.Fatalf("filters missmatch after rollback (want +got):\n%s", diff)
+	}
+}
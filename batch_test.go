@@ -0,0 +1,397 @@
+package tc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/florianl/go-tc/tctest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/josharian/native"
+	"github.com/mdlayher/netlink"
+)
+
+// countingConn counts the calls, that send messages to the kernel.
+type countingConn struct {
+	*netlink.Conn
+	sends int
+}
+
+func (c *countingConn) Send(m netlink.Message) (netlink.Message, error) {
+	c.sends++
+	return c.Conn.Send(m)
+}
+
+func (c *countingConn) SendMessages(msgs []netlink.Message) ([]netlink.Message, error) {
+	c.sends++
+	return c.Conn.SendMessages(msgs)
+}
+
+const batchIfindex = 2
+
+// batchFilter returns a fw filter with handle on the ingress of batchIfindex.
+func batchFilter(handle, classID uint32) *tc.Object {
+	return &tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: batchIfindex,
+			Handle:  handle,
+			Parent:  core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress),
+			Info:    0x10300,
+		},
+		Attribute: tc.Attribute{
+			Kind: "fw",
+			Fw:   &tc.Fw{ClassID: &classID},
+		},
+	}
+}
+
+// batchKernel returns a fake kernel with a clsact qdisc and the fw filter 1 on batchIfindex.
+func batchKernel(t *testing.T) (*tctest.Kernel, *tc.Tc) {
+	t.Helper()
+	k := tctest.NewKernel()
+	k.AddLink(batchIfindex)
+	tcnl := k.Open()
+	t.Cleanup(func() { tcnl.Close() })
+
+	if err := tcnl.Qdisc().Add(&tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: batchIfindex,
+			Handle:  core.BuildHandle(0xFFFF, 0),
+			Parent:  tc.HandleIngress,
+		},
+		Attribute: tc.Attribute{Kind: "clsact"},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+	if err := tcnl.Filter().Add(batchFilter(1, 0x10001)); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+	return k, tcnl
+}
+
+// classIDs returns the class ID of each fw filter by its handle.
+func classIDs(t *testing.T, tcnl *tc.Tc) map[uint32]uint32 {
+	t.Helper()
+	filters, err := tcnl.Filter().Get(&tc.Msg{
+		Family:  unix.AF_UNSPEC,
+		Ifindex: batchIfindex,
+		Parent:  core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress),
+	})
+	if err != nil {
+		t.Fatalf("could not get filters: %v", err)
+	}
+	ids := make(map[uint32]uint32)
+	for _, f := range filters {
+		if f.Fw != nil && f.Fw.ClassID != nil {
+			ids[f.Handle] = *f.Fw.ClassID
+		}
+	}
+	return ids
+}
+
+// addDumpedFilter adds or changes the fw filter with handle in k, as if it was reported by
+// the kernel with statistics, the Tcft and the reference count refCnt of its gact action.
+// The encoding of tc.Object does not allow to set these read only attributes.
+func addDumpedFilter(t *testing.T, k *tctest.Kernel, handle, classID, refCnt uint32) {
+	t.Helper()
+	const (
+		tcaKind       = 1
+		tcaOptions    = 2
+		tcaStats      = 3
+		tcaFwClassID  = 1
+		tcaFwAct      = 4
+		tcaActKind    = 1
+		tcaActOptions = 2
+		tcaGactTm     = 1
+		tcaGactParms  = 2
+	)
+	u32 := func(vs ...uint32) []byte {
+		b := make([]byte, 4*len(vs))
+		for i, v := range vs {
+			native.Endian.PutUint32(b[4*i:], v)
+		}
+		return b
+	}
+	encode := func(fn func(ae *netlink.AttributeEncoder)) []byte {
+		ae := netlink.NewAttributeEncoder()
+		fn(ae)
+		data, err := ae.Encode()
+		if err != nil {
+			t.Fatalf("could not encode attributes: %v", err)
+		}
+		return data
+	}
+	gact := encode(func(ae *netlink.AttributeEncoder) {
+		ae.Bytes(tcaGactTm, make([]byte, 32))
+		// index, capab, action, refcnt and bindcnt of tc_gact
+		ae.Bytes(tcaGactParms, u32(1, 0, uint32(tc.ActOk), refCnt, 2))
+	})
+	act := encode(func(ae *netlink.AttributeEncoder) {
+		ae.String(tcaActKind, "gact")
+		ae.Bytes(tcaActOptions|netlink.Nested, gact)
+	})
+	fw := encode(func(ae *netlink.AttributeEncoder) {
+		ae.Uint32(tcaFwClassID, classID)
+		ae.Bytes(tcaFwAct|netlink.Nested, encode(func(ae *netlink.AttributeEncoder) {
+			ae.Bytes(1|netlink.Nested, act)
+		}))
+	})
+	attrs := encode(func(ae *netlink.AttributeEncoder) {
+		ae.String(tcaKind, "fw")
+		ae.Bytes(tcaOptions|netlink.Nested, fw)
+		// bytes, packets, drops, overlimits, bps, pps, qlen and backlog of tc_stats
+		stats := make([]byte, 8)
+		native.Endian.PutUint64(stats, 1500)
+		ae.Bytes(tcaStats, append(stats, u32(1, 0, 0, 0, 0, 0, 0)...))
+	})
+
+	f := batchFilter(handle, classID)
+	msg := u32(0, f.Ifindex, f.Handle, f.Parent, f.Info)
+	msg[0] = unix.AF_UNSPEC
+	con := k.Dial()
+	defer con.Close()
+	if _, err := con.Send(netlink.Message{
+		Header: netlink.Header{
+			Type:  unix.RTM_NEWTFILTER,
+			Flags: netlink.Request | netlink.Acknowledge | netlink.Create,
+		},
+		Data: append(msg, attrs...),
+	}); err != nil {
+		t.Fatalf("could not send filter: %v", err)
+	}
+	msgs, err := con.Receive()
+	if err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Header.Type != netlink.Error || native.Endian.Uint32(msgs[0].Data) != 0 {
+		t.Fatalf("could not add filter: %+v", msgs)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("pipelined", func(t *testing.T) {
+		k, tcnl := batchKernel(t)
+		conn := &countingConn{Conn: k.Dial()}
+		batch := tc.NewWithConn(conn).Batch()
+
+		want := map[uint32]uint32{1: 0x10001}
+		for handle := uint32(2); handle <= 200; handle++ {
+			if err := batch.AddFilter(batchFilter(handle, 0x10000|handle)); err != nil {
+				t.Fatalf("could not queue filter %d: %v", handle, err)
+			}
+			want[handle] = 0x10000 | handle
+		}
+		results, err := batch.Send(nil)
+		if err != nil {
+			t.Fatalf("could not send batch: %v", err)
+		}
+		if diff := cmp.Diff(make([]tc.BatchResult, 199), results); diff != "" {
+			t.Fatalf("results missmatch (want +got):\n%s", diff)
+		}
+		// 199 operations are sent in chunks of 64 messages.
+		if conn.sends != 4 {
+			t.Fatalf("expected 4 sends but got %d", conn.sends)
+		}
+		if diff := cmp.Diff(want, classIDs(t, tcnl)); diff != "" {
+			t.Fatalf("filters missmatch (want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, tcnl := batchKernel(t)
+		batch := tcnl.Batch()
+		for _, f := range []*tc.Object{batchFilter(2, 0x10002), batchFilter(1, 0x10003), batchFilter(3, 0x10003)} {
+			if err := batch.AddFilter(f); err != nil {
+				t.Fatalf("could not queue filter: %v", err)
+			}
+		}
+		results, err := batch.Send(nil)
+		if !errors.Is(err, unix.EEXIST) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 3 || results[0].Err != nil || !errors.Is(results[1].Err, unix.EEXIST) || results[2].Err != nil {
+			t.Fatalf("unexpected results: %+v", results)
+		}
+		want := map[uint32]uint32{1: 0x10001, 2: 0x10002, 3: 0x10003}
+		if diff := cmp.Diff(want, classIDs(t, tcnl)); diff != "" {
+			t.Fatalf("filters missmatch (want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		_, tcnl := batchKernel(t)
+		batch := tcnl.Batch()
+		if err := batch.ReplaceFilter(batchFilter(1, 0x10002)); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.AddFilter(batchFilter(2, 0x10002)); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.DeleteFilter(batchFilter(9, 0x10009)); err != nil {
+			t.Fatal(err)
+		}
+		for handle := uint32(10); handle < 80; handle++ {
+			if err := batch.AddFilter(batchFilter(handle, 0x10000|handle)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		results, err := batch.Send(&tc.BatchOptions{Rollback: true})
+		if !errors.Is(err, unix.ENOENT) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, result := range results {
+			switch {
+			case i == 2:
+				if !errors.Is(result.Err, unix.ENOENT) || result.RolledBack {
+					t.Fatalf("unexpected result of failed operation: %+v", result)
+				}
+			case i < 64:
+				// Operations, that were sent along with the failed one, are undone as well.
+				if result.Err != nil || !result.RolledBack || result.RollbackErr != nil {
+					t.Fatalf("unexpected result of operation %d: %+v", i, result)
+				}
+			default:
+				if !errors.Is(result.Err, tc.ErrAborted) || result.RolledBack {
+					t.Fatalf("unexpected result of operation %d: %+v", i, result)
+				}
+			}
+		}
+		if diff := cmp.Diff(map[uint32]uint32{1: 0x10001}, classIDs(t, tcnl)); diff != "" {
+			t.Fatalf("filters missmatch (want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("rollback read only attributes", func(t *testing.T) {
+		k, tcnl := batchKernel(t)
+		addDumpedFilter(t, k, 5, 0x10005, 3)
+
+		batch := tcnl.Batch()
+		if err := batch.ReplaceFilter(batchFilter(5, 0x10006)); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.DeleteFilter(batchFilter(9, 0x10009)); err != nil {
+			t.Fatal(err)
+		}
+		results, err := batch.Send(&tc.BatchOptions{Rollback: true})
+		if !errors.Is(err, unix.ENOENT) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !results[0].RolledBack || results[0].RollbackErr != nil {
+			t.Fatalf("unexpected result: %+v", results[0])
+		}
+
+		filters, err := tcnl.Filter().Get(&tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: batchIfindex,
+			Parent:  core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress),
+		})
+		if err != nil {
+			t.Fatalf("could not get filters: %v", err)
+		}
+		var restored *tc.Object
+		for i := range filters {
+			if filters[i].Handle == 5 {
+				restored = &filters[i]
+			}
+		}
+		if restored == nil || restored.Fw == nil || restored.Fw.Actions == nil || len(*restored.Fw.Actions) != 1 {
+			t.Fatalf("filter was not restored: %+v", restored)
+		}
+		// The restored filter was sent without the attributes, that the kernel reported.
+		want := &tc.Fw{
+			ClassID: restored.Fw.ClassID,
+			Actions: &[]*tc.Action{{
+				Kind: "gact",
+				Gact: &tc.Gact{Parms: &tc.GactParms{Index: 1, Action: tc.ActOk}},
+			}},
+		}
+		if diff := cmp.Diff(want, restored.Fw); diff != "" {
+			t.Fatalf("restored filter missmatch (want +got):\n%s", diff)
+		}
+		if *restored.Fw.ClassID != 0x10005 || restored.Stats != nil {
+			t.Fatalf("unexpected restored filter: %+v", restored)
+		}
+	})
+
+	t.Run("rollback actions", func(t *testing.T) {
+		_, tcnl := batchKernel(t)
+		gact := func(index uint32, action tc.Verdict) *tc.Action {
+			return &tc.Action{Kind: "gact", Index: index, Gact: &tc.Gact{Parms: &tc.GactParms{Index: index, Action: action}}}
+		}
+		if err := tcnl.Actions().Add([]*tc.Action{gact(2, tc.ActOk)}); err != nil {
+			t.Fatalf("could not add action: %v", err)
+		}
+
+		batch := tcnl.Batch()
+		if err := batch.AddActions([]*tc.Action{gact(1, tc.ActShot)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.ReplaceActions([]*tc.Action{gact(2, tc.ActShot), gact(3, tc.ActShot)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.AddActions([]*tc.Action{gact(2, tc.ActShot)}); err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := batch.Send(&tc.BatchOptions{Rollback: true})
+		if !errors.Is(err, unix.EEXIST) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !results[0].RolledBack || !results[1].RolledBack || results[2].Err == nil {
+			t.Fatalf("unexpected results: %+v", results)
+		}
+		for _, index := range []uint32{1, 3} {
+			if _, err := tcnl.Actions().GetByIndex("gact", index); !errors.Is(err, unix.ENOENT) {
+				t.Fatalf("action %d: unexpected error: %v", index, err)
+			}
+		}
+		act, err := tcnl.Actions().GetByIndex("gact", 2)
+		if err != nil {
+			t.Fatalf("could not get action: %v", err)
+		}
+		if act.Gact == nil || act.Gact.Parms == nil || act.Gact.Parms.Action != tc.ActOk {
+			t.Fatalf("action was not restored: %+v", act.Gact)
+		}
+	})
+
+	t.Run("rollback requires handle", func(t *testing.T) {
+		k, _ := batchKernel(t)
+		conn := &countingConn{Conn: k.Dial()}
+		batch := tc.NewWithConn(conn).Batch()
+		if err := batch.AddFilter(batchFilter(0, 0x10002)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := batch.Send(&tc.BatchOptions{Rollback: true}); !errors.Is(err, tc.ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conn.sends != 0 {
+			t.Fatalf("expected no sends but got %d", conn.sends)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, tcnl := batchKernel(t)
+		batch := tcnl.Batch()
+		for name, err := range map[string]error{
+			"qdisc":   batch.AddQdisc(nil),
+			"class":   batch.ReplaceClass(nil),
+			"filter":  batch.DeleteFilter(nil),
+			"actions": batch.AddActions(nil),
+		} {
+			if !errors.Is(err, tc.ErrNoArg) {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+		}
+		if err := batch.AddFilter(&tc.Object{}); !errors.Is(err, tc.ErrInvalidDev) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if batch.Len() != 0 {
+			t.Fatalf("expected an empty batch but got %d operations", batch.Len())
+		}
+	})
+}
@@ -0,0 +1,315 @@
+package tc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+// BpfDirection defines the hook of qdisc/clsact an eBPF program is attached to.
+type BpfDirection uint8
+
+// Hooks of qdisc/clsact
+const (
+	BpfIngress BpfDirection = iota
+	BpfEgress
+)
+
+func (d BpfDirection) String() string {
+	switch d {
+	case BpfIngress:
+		return "ingress"
+	case BpfEgress:
+		return "egress"
+	}
+	return fmt.Sprintf("BpfDirection(%d)", uint8(d))
+}
+
+func (d BpfDirection) parent() (uint32, error) {
+	switch d {
+	case BpfIngress:
+		return core.BuildHandle(HandleRoot, HandleMinIngress), nil
+	case BpfEgress:
+		return core.BuildHandle(HandleRoot, HandleMinEgress), nil
+	}
+	return 0, fmt.Errorf("direction %d: %w", d, ErrInvalidArg)
+}
+
+// BpfAttachOptions defines where the filter holding the eBPF program is placed.
+// Zero values are replaced by defaults.
+type BpfAttachOptions struct {
+	// Priority of the filter. Defaults to 1.
+	Priority uint16
+	// Handle of the filter. Defaults to 1.
+	Handle uint32
+	// Protocol the filter applies to. Defaults to ETH_P_ALL.
+	Protocol uint16
+	// Name of the filter. Defaults to the name of the program.
+	Name string
+}
+
+// BpfAttachment represents an eBPF program that is attached in direct-action mode
+// to an interface by a bpf filter on qdisc/clsact.
+type BpfAttachment struct {
+	tc *Tc
+
+	Ifindex   uint32
+	Direction BpfDirection
+	Priority  uint16
+	Handle    uint32
+	Protocol  uint16
+	Name      string
+
+	// ID and Tag of the attached program.
+	ID  uint32
+	Tag string
+}
+
+// bpfProgram contains the details of an eBPF program to attach or look up.
+type bpfProgram struct {
+	fd   uint32
+	id   uint32
+	tag  string
+	name string
+}
+
+func newBpfProgram(prog *ebpf.Program) (*bpfProgram, error) {
+	if prog == nil {
+		return nil, ErrNoArg
+	}
+	if prog.Type() != ebpf.SchedCLS && prog.Type() != ebpf.SchedACT {
+		return nil, fmt.Errorf("program type %s: %w", prog.Type(), ErrInvalidArg)
+	}
+	info, err := prog.Info()
+	if err != nil {
+		return nil, err
+	}
+	p := &bpfProgram{fd: uint32(prog.FD()), tag: info.Tag, name: info.Name}
+	if id, ok := info.ID(); ok {
+		p.id = uint32(id)
+	}
+	return p, nil
+}
+
+// AttachBpf attaches prog in direct-action mode to the given direction of the interface.
+// A qdisc/clsact is created, if it does not exist yet. If prog, or a program with the same
+// tag, is already attached with the given options, the existing attachment is returned.
+func (tc *Tc) AttachBpf(ifindex uint32, dir BpfDirection, prog *ebpf.Program, opts *BpfAttachOptions) (*BpfAttachment, error) {
+	p, err := newBpfProgram(prog)
+	if err != nil {
+		return nil, err
+	}
+	return tc.attachBpf(ifindex, dir, p, opts)
+}
+
+func (tc *Tc) attachBpf(ifindex uint32, dir BpfDirection, p *bpfProgram, opts *BpfAttachOptions) (*BpfAttachment, error) {
+	if ifindex == 0 {
+		return nil, ErrInvalidDev
+	}
+	if _, err := dir.parent(); err != nil {
+		return nil, err
+	}
+	a := &BpfAttachment{
+		tc:        tc,
+		Ifindex:   ifindex,
+		Direction: dir,
+		Priority:  1,
+		Handle:    1,
+		Protocol:  unix.ETH_P_ALL,
+		Name:      p.name,
+	}
+	if opts != nil {
+		if opts.Priority != 0 {
+			a.Priority = opts.Priority
+		}
+		if opts.Handle != 0 {
+			a.Handle = opts.Handle
+		}
+		if opts.Protocol != 0 {
+			a.Protocol = opts.Protocol
+		}
+		if opts.Name != "" {
+			a.Name = opts.Name
+		}
+	}
+
+	if err := tc.ensureClsact(ifindex); err != nil {
+		return nil, err
+	}
+
+	obj := a.object(p)
+	if err := tc.Filter().Add(obj); err != nil {
+		if !errors.Is(err, unix.EEXIST) {
+			return nil, err
+		}
+		// Check if the filter in place is holding the very same program. After a restart
+		// the program was loaded again and is recognized by its tag only.
+		existing, lookupErr := tc.lookupBpf(ifindex, dir, p)
+		if lookupErr != nil || existing.Handle != a.Handle || existing.Priority != a.Priority {
+			return nil, err
+		}
+		if (p.id == 0 || existing.ID != p.id) && (p.tag == "" || existing.Tag != p.tag) {
+			return nil, err
+		}
+		return existing, nil
+	}
+	a.ID = p.id
+	a.Tag = p.tag
+	return a, nil
+}
+
+// ensureClsact adds qdisc/clsact to the interface, if it does not exist yet.
+func (tc *Tc) ensureClsact(ifindex uint32) error {
+	qdisc := &Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: ifindex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{
+			Kind: "clsact",
+		},
+	}
+	err := tc.Qdisc().Add(qdisc)
+	if err == nil || !errors.Is(err, unix.EEXIST) {
+		return err
+	}
+
+	// A qdisc/ingress uses the same parent but does not offer the egress hook.
+	qdiscs, getErr := tc.Qdisc().Get()
+	if getErr != nil {
+		return getErr
+	}
+	for _, q := range qdiscs {
+		if q.Ifindex == ifindex && q.Parent == HandleIngress && q.Kind != "clsact" {
+			return fmt.Errorf("qdisc/%s is in place of qdisc/clsact: %w", q.Kind, err)
+		}
+	}
+	return nil
+}
+
+// LookupBpf returns the attachment of prog on the given direction of the interface.
+// The attached program is recognized by its ID or, e.g. if the program was loaded again
+// after a restart, by its tag. If no such attachment exists, an error wrapping
+// ENOENT is returned.
+func (tc *Tc) LookupBpf(ifindex uint32, dir BpfDirection, prog *ebpf.Program) (*BpfAttachment, error) {
+	p, err := newBpfProgram(prog)
+	if err != nil {
+		return nil, err
+	}
+	return tc.lookupBpf(ifindex, dir, p)
+}
+
+func (tc *Tc) lookupBpf(ifindex uint32, dir BpfDirection, p *bpfProgram) (*BpfAttachment, error) {
+	if ifindex == 0 {
+		return nil, ErrInvalidDev
+	}
+	parent, err := dir.parent()
+	if err != nil {
+		return nil, err
+	}
+	filters, err := tc.Filter().Get(&Msg{
+		Family:  unix.AF_UNSPEC,
+		Ifindex: ifindex,
+		Parent:  parent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var byTag *BpfAttachment
+	for _, f := range filters {
+		// The kernel reports the filter also without handle for each priority.
+		if f.Kind != "bpf" || f.BPF == nil || f.Handle == 0 {
+			continue
+		}
+		a := &BpfAttachment{
+			tc:        tc,
+			Ifindex:   ifindex,
+			Direction: dir,
+			Priority:  uint16(f.Info >> 16),
+			Handle:    f.Handle,
+			Protocol:  endianSwapUint16(uint16(f.Info & 0xFFFF)),
+			ID:        uint32Value(f.BPF.ID),
+			Name:      stringValue(f.BPF.Name),
+		}
+		if f.BPF.Tag != nil {
+			a.Tag = hex.EncodeToString(*f.BPF.Tag)
+		}
+		if p.id != 0 && a.ID == p.id {
+			return a, nil
+		}
+		if byTag == nil && p.tag != "" && a.Tag == p.tag {
+			byTag = a
+		}
+	}
+	if byTag != nil {
+		return byTag, nil
+	}
+	return nil, fmt.Errorf("program %d with tag %s on %d/%s: %w", p.id, p.tag, ifindex, dir, unix.ENOENT)
+}
+
+func (a *BpfAttachment) object(p *bpfProgram) *Object {
+	// Parent is validated by the constructors of BpfAttachment.
+	parent, _ := a.Direction.parent()
+	bpf := &Bpf{
+		FD:    uint32Ptr(p.fd),
+		Flags: uint32Ptr(BpfActDirect),
+	}
+	if a.Name != "" {
+		bpf.Name = stringPtr(a.Name)
+	}
+	return &Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: a.Ifindex,
+			Handle:  a.Handle,
+			Parent:  parent,
+			Info:    core.FilterInfo(a.Priority, a.Protocol),
+		},
+		Attribute: Attribute{
+			Kind: "bpf",
+			BPF:  bpf,
+		},
+	}
+}
+
+// Replace atomically replaces the attached program with prog.
+func (a *BpfAttachment) Replace(prog *ebpf.Program) error {
+	p, err := newBpfProgram(prog)
+	if err != nil {
+		return err
+	}
+	return a.replace(p)
+}
+
+func (a *BpfAttachment) replace(p *bpfProgram) error {
+	if a.tc == nil {
+		return ErrNoArg
+	}
+	if p.name != "" {
+		a.Name = p.name
+	}
+	if err := a.tc.Filter().Replace(a.object(p)); err != nil {
+		return err
+	}
+	a.ID = p.id
+	a.Tag = p.tag
+	return nil
+}
+
+// Detach removes the filter holding the attached program. The qdisc/clsact is kept,
+// as other filters might depend on it.
+func (a *BpfAttachment) Detach() error {
+	if a.tc == nil {
+		return ErrNoArg
+	}
+	obj := a.object(&bpfProgram{})
+	obj.BPF = nil
+	return a.tc.Filter().Delete(obj)
+}
@@ -0,0 +1,288 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nltest"
+)
+
+// bpfAttachConn returns a Tc that answers requests by their type with replies.
+// Types missing in replies are acknowledged. All requests are recorded in reqs.
+func bpfAttachConn(t *testing.T, replies map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error),
+	reqs *[]netlink.Message) *Tc {
+	t.Helper()
+	return NewWithConn(nltest.Dial(func(req []netlink.Message) ([]netlink.Message, error) {
+		if len(req) == 0 {
+			return []netlink.Message{}, nil
+		}
+		*reqs = append(*reqs, req[0])
+		if fn, ok := replies[req[0].Header.Type]; ok {
+			return fn(req[0])
+		}
+		return nltest.Error(0, req)
+	}))
+}
+
+func bpfAttachErrno(errno int) func(netlink.Message) ([]netlink.Message, error) {
+	return func(req netlink.Message) ([]netlink.Message, error) {
+		return nltest.Error(errno, []netlink.Message{req})
+	}
+}
+
+func bpfAttachDump(t *testing.T, action int, objs ...Object) func(netlink.Message) ([]netlink.Message, error) {
+	t.Helper()
+	var msgs []netlink.Message
+	for _, obj := range objs {
+		var options []tcOption
+		var err error
+		if action == unix.RTM_NEWTFILTER {
+			options, err = validateFilterObject(action, &obj)
+		} else {
+			options, err = validateQdiscObject(action, &obj)
+		}
+		if err != nil {
+			t.Fatalf("could not encode %#v: %v", obj, err)
+		}
+		data, err := marshalStruct(&obj.Msg)
+		if err != nil {
+			t.Fatalf("could not encode %#v: %v", obj.Msg, err)
+		}
+		attrs, err := marshalAttributes(options)
+		if err != nil {
+			t.Fatalf("could not encode attributes: %v", err)
+		}
+		msgs = append(msgs, netlink.Message{Data: append(data, attrs...)})
+	}
+	return func(req netlink.Message) ([]netlink.Message, error) {
+		for i := range msgs {
+			msgs[i].Header.Sequence = req.Header.Sequence
+			msgs[i].Header.PID = req.Header.PID
+		}
+		return msgs, nil
+	}
+}
+
+func bpfAttachFilter(handle uint32, prio uint16, id uint32, tag []byte) Object {
+	return Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: 2,
+			Handle:  handle,
+			Parent:  core.BuildHandle(HandleRoot, HandleMinIngress),
+			Info:    core.FilterInfo(prio, unix.ETH_P_ALL),
+		},
+		Attribute: Attribute{
+			Kind: "bpf",
+			BPF:  &Bpf{ID: uint32Ptr(id), Tag: bytesPtr(tag), Name: stringPtr("prog"), Flags: uint32Ptr(BpfActDirect)},
+		},
+	}
+}
+
+func TestAttachBpf(t *testing.T) {
+	prog := &bpfProgram{fd: 10, id: 42, tag: "0102030405060708", name: "prog"}
+	tag := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := map[string]struct {
+		dir     BpfDirection
+		opts    *BpfAttachOptions
+		replies map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error)
+		want    *BpfAttachment
+		err     error
+	}{
+		"new": {
+			want: &BpfAttachment{Ifindex: 2, Direction: BpfIngress, Priority: 1, Handle: 1,
+				Protocol: unix.ETH_P_ALL, Name: "prog", ID: 42, Tag: prog.tag},
+		},
+		"options": {
+			dir:  BpfEgress,
+			opts: &BpfAttachOptions{Priority: 3, Handle: 5, Protocol: unix.ETH_P_IP, Name: "foo"},
+			replies: map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_NEWQDISC: bpfAttachErrno(int(unix.EEXIST)),
+				unix.RTM_GETQDISC: bpfAttachDump(t, unix.RTM_NEWQDISC, Object{
+					Msg:       Msg{Ifindex: 2, Handle: 0xFFFF0000, Parent: HandleIngress},
+					Attribute: Attribute{Kind: "clsact"},
+				}),
+			},
+			want: &BpfAttachment{Ifindex: 2, Direction: BpfEgress, Priority: 3, Handle: 5,
+				Protocol: unix.ETH_P_IP, Name: "foo", ID: 42, Tag: prog.tag},
+		},
+		"already attached": {
+			replies: map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_NEWTFILTER: bpfAttachErrno(int(unix.EEXIST)),
+				unix.RTM_GETTFILTER: bpfAttachDump(t, unix.RTM_NEWTFILTER, bpfAttachFilter(1, 1, 42, tag)),
+			},
+			want: &BpfAttachment{Ifindex: 2, Direction: BpfIngress, Priority: 1, Handle: 1,
+				Protocol: unix.ETH_P_ALL, Name: "prog", ID: 42, Tag: prog.tag},
+		},
+		"attached before restart": {
+			replies: map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_NEWTFILTER: bpfAttachErrno(int(unix.EEXIST)),
+				unix.RTM_GETTFILTER: bpfAttachDump(t, unix.RTM_NEWTFILTER, bpfAttachFilter(1, 1, 7, tag)),
+			},
+			want: &BpfAttachment{Ifindex: 2, Direction: BpfIngress, Priority: 1, Handle: 1,
+				Protocol: unix.ETH_P_ALL, Name: "prog", ID: 7, Tag: prog.tag},
+		},
+		"same program on other handle": {
+			replies: map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_NEWTFILTER: bpfAttachErrno(int(unix.EEXIST)),
+				unix.RTM_GETTFILTER: bpfAttachDump(t, unix.RTM_NEWTFILTER, bpfAttachFilter(2, 1, 42, tag)),
+			},
+			err: unix.EEXIST,
+		},
+		"other program attached": {
+			replies: map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_NEWTFILTER: bpfAttachErrno(int(unix.EEXIST)),
+				unix.RTM_GETTFILTER: bpfAttachDump(t, unix.RTM_NEWTFILTER, bpfAttachFilter(1, 1, 7, []byte{0xFF})),
+			},
+			err: unix.EEXIST,
+		},
+		"qdisc/ingress": {
+			replies: map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_NEWQDISC: bpfAttachErrno(int(unix.EEXIST)),
+				unix.RTM_GETQDISC: bpfAttachDump(t, unix.RTM_NEWQDISC, Object{
+					Msg:       Msg{Ifindex: 2, Handle: 0xFFFF0000, Parent: HandleIngress},
+					Attribute: Attribute{Kind: "ingress"},
+				}),
+			},
+			err: unix.EEXIST,
+		},
+		"invalid direction": {dir: BpfDirection(7), err: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var reqs []netlink.Message
+			tcSocket := bpfAttachConn(t, testcase.replies, &reqs)
+			got, err := tcSocket.attachBpf(2, testcase.dir, prog, testcase.opts)
+			if err != nil {
+				if errors.Is(err, testcase.err) {
+					return
+				}
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testcase.err != nil {
+				t.Fatalf("Expected error %v but got none", testcase.err)
+			}
+			if diff := cmp.Diff(testcase.want, got, cmpopts.IgnoreUnexported(BpfAttachment{})); diff != "" {
+				t.Fatalf("BpfAttachment missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLookupBpf(t *testing.T) {
+	tag := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	replies := map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+		unix.RTM_GETTFILTER: bpfAttachDump(t, unix.RTM_NEWTFILTER,
+			bpfAttachFilter(0, 1, 0, nil),
+			bpfAttachFilter(1, 1, 7, []byte{0xFF}),
+			bpfAttachFilter(1, 2, 8, tag),
+			bpfAttachFilter(1, 3, 9, tag)),
+	}
+
+	tests := map[string]struct {
+		prog *bpfProgram
+		want *BpfAttachment
+		err  error
+	}{
+		"by id": {
+			prog: &bpfProgram{id: 9, tag: "0102030405060708"},
+			want: &BpfAttachment{Ifindex: 2, Priority: 3, Handle: 1, Protocol: unix.ETH_P_ALL,
+				Name: "prog", ID: 9, Tag: "0102030405060708"},
+		},
+		"by tag": {
+			prog: &bpfProgram{id: 100, tag: "0102030405060708"},
+			want: &BpfAttachment{Ifindex: 2, Priority: 2, Handle: 1, Protocol: unix.ETH_P_ALL,
+				Name: "prog", ID: 8, Tag: "0102030405060708"},
+		},
+		"missing": {
+			prog: &bpfProgram{id: 100, tag: "aa"},
+			err:  unix.ENOENT,
+		},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var reqs []netlink.Message
+			tcSocket := bpfAttachConn(t, replies, &reqs)
+			got, err := tcSocket.lookupBpf(2, BpfIngress, testcase.prog)
+			if err != nil {
+				if errors.Is(err, testcase.err) {
+					return
+				}
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testcase.err != nil {
+				t.Fatalf("Expected error %v but got none", testcase.err)
+			}
+			if diff := cmp.Diff(testcase.want, got, cmpopts.IgnoreUnexported(BpfAttachment{})); diff != "" {
+				t.Fatalf("BpfAttachment missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBpfAttachmentReplaceDetach(t *testing.T) {
+	var reqs []netlink.Message
+	tcSocket := bpfAttachConn(t, nil, &reqs)
+	a, err := tcSocket.attachBpf(2, BpfEgress, &bpfProgram{fd: 10, id: 1, tag: "01", name: "old"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := a.replace(&bpfProgram{fd: 11, id: 2, tag: "02", name: "new"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.ID != 2 || a.Tag != "02" || a.Name != "new" {
+		t.Fatalf("attachment not updated: %#v", a)
+	}
+	if err := a.Detach(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	types := []netlink.HeaderType{}
+	for _, req := range reqs {
+		types = append(types, req.Header.Type)
+	}
+	wantTypes := []netlink.HeaderType{unix.RTM_NEWQDISC, unix.RTM_NEWTFILTER, unix.RTM_NEWTFILTER, unix.RTM_DELTFILTER}
+	if diff := cmp.Diff(wantTypes, types); diff != "" {
+		t.Fatalf("request missmatch (want +got):\n%s", diff)
+	}
+	if reqs[1].Header.Flags&netlink.Excl == 0 || reqs[2].Header.Flags&netlink.Excl != 0 {
+		t.Fatalf("unexpected flags for add (%v) and replace (%v)", reqs[1].Header.Flags, reqs[2].Header.Flags)
+	}
+
+	// Replace keeps the position of the filter and uses the new program.
+	obj := Object{}
+	if err := unmarshalStruct(reqs[2].Data[:20], &obj.Msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := extractTcmsgAttributes(unix.RTM_NEWTFILTER, reqs[2].Data[20:], &obj.Attribute); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: 2,
+			Handle:  1,
+			Parent:  core.BuildHandle(HandleRoot, HandleMinEgress),
+			Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+		},
+		Attribute: Attribute{
+			Kind: "bpf",
+			BPF:  &Bpf{FD: uint32Ptr(11), Name: stringPtr("new"), Flags: uint32Ptr(BpfActDirect)},
+		},
+	}
+	if diff := cmp.Diff(want, obj); diff != "" {
+		t.Fatalf("filter missmatch (want +got):\n%s", diff)
+	}
+
+	if err := (&BpfAttachment{}).Detach(); !errors.Is(err, ErrNoArg) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package tc
+
+import (
+	"fmt"
+
+	"golang.org/x/net/bpf"
+)
+
+// sizeof(struct sock_filter) from include/uapi/linux/filter.h
+const sockFilterSize = 8
+
+// marshalClassicBpf returns the encoding of insns as array of struct sock_filter.
+func marshalClassicBpf(insns []bpf.Instruction) ([]byte, uint16, error) {
+	if len(insns) == 0 {
+		return []byte{}, 0, ErrNoArg
+	}
+	// BPF_MAXINSNS from include/uapi/linux/bpf_common.h
+	if len(insns) > 4096 {
+		return []byte{}, 0, fmt.Errorf("%d instructions exceed limit of 4096: %w", len(insns), ErrInvalidArg)
+	}
+	raw, err := bpf.Assemble(insns)
+	if err != nil {
+		return []byte{}, 0, fmt.Errorf("%v: %w", err, ErrInvalidArg)
+	}
+	data := make([]byte, len(raw)*sockFilterSize)
+	for i, ins := range raw {
+		b := data[i*sockFilterSize:]
+		nativeEndian.PutUint16(b[0:2], ins.Op)
+		b[2] = ins.Jt
+		b[3] = ins.Jf
+		nativeEndian.PutUint32(b[4:8], ins.K)
+	}
+	return data, uint16(len(raw)), nil
+}
+
+// unmarshalClassicBpf decodes an array of struct sock_filter.
+func unmarshalClassicBpf(data []byte) ([]bpf.Instruction, error) {
+	if len(data)%sockFilterSize != 0 {
+		return nil, fmt.Errorf("%d bytes are no multiple of struct sock_filter: %w", len(data), ErrInvalidArg)
+	}
+	raw := make([]bpf.RawInstruction, 0, len(data)/sockFilterSize)
+	for i := 0; i+sockFilterSize <= len(data); i += sockFilterSize {
+		raw = append(raw, bpf.RawInstruction{
+			Op: nativeEndian.Uint16(data[i : i+2]),
+			Jt: data[i+2],
+			Jf: data[i+3],
+			K:  nativeEndian.Uint32(data[i+4 : i+8]),
+		})
+	}
+	// Instructions that can not be decoded are returned as bpf.RawInstruction.
+	insns, _ := bpf.Disassemble(raw)
+	return insns, nil
+}
+
+// SetInstructions sets Ops and OpsLen of the filter to the classic BPF program insns.
+func (b *Bpf) SetInstructions(insns []bpf.Instruction) error {
+	ops, opsLen, err := marshalClassicBpf(insns)
+	if err != nil {
+		return err
+	}
+	b.Ops = &ops
+	b.OpsLen = &opsLen
+	return nil
+}
+
+// Instructions returns the classic BPF program of the filter.
+func (b *Bpf) Instructions() ([]bpf.Instruction, error) {
+	if b.Ops == nil {
+		return nil, ErrNoArg
+	}
+	return unmarshalClassicBpf(*b.Ops)
+}
+
+// SetInstructions sets Ops and OpsLen of the action to the classic BPF program insns.
+func (b *ActBpf) SetInstructions(insns []bpf.Instruction) error {
+	ops, opsLen, err := marshalClassicBpf(insns)
+	if err != nil {
+		return err
+	}
+	b.Ops = &ops
+	b.OpsLen = &opsLen
+	return nil
+}
+
+// Instructions returns the classic BPF program of the action.
+func (b *ActBpf) Instructions() ([]bpf.Instruction, error) {
+	if b.Ops == nil {
+		return nil, ErrNoArg
+	}
+	return unmarshalClassicBpf(*b.Ops)
+}
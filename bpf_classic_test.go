@@ -0,0 +1,82 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/bpf"
+)
+
+func TestClassicBpf(t *testing.T) {
+	tests := map[string]struct {
+		val  []bpf.Instruction
+		err1 error
+	}{
+		"simple": {val: []bpf.Instruction{
+			bpf.LoadAbsolute{Off: 12, Size: 2},
+			bpf.JumpIf{Cond: bpf.JumpNotEqual, Val: 0x0800, SkipTrue: 1},
+			bpf.RetConstant{Val: 0xFFFFFFFF},
+			bpf.RetConstant{Val: 0},
+		}},
+		"extension": {val: []bpf.Instruction{
+			bpf.LoadExtension{Num: bpf.ExtVLANTagPresent},
+			bpf.RetA{},
+		}},
+		"empty":   {err1: ErrNoArg},
+		"invalid": {val: []bpf.Instruction{bpf.LoadAbsolute{Off: 12, Size: 3}}, err1: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter := &Bpf{}
+			if err := filter.SetInstructions(testcase.val); err != nil {
+				if errors.Is(err, testcase.err1) {
+					return
+				}
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			data, err := marshalBpf(filter)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			decoded := &Bpf{}
+			if err := unmarshalBpf(data, decoded); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *decoded.OpsLen != uint16(len(testcase.val)) {
+				t.Fatalf("unexpected OpsLen %d", *decoded.OpsLen)
+			}
+			insns, err := decoded.Instructions()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testcase.val, insns); diff != "" {
+				t.Fatalf("Instructions missmatch (want +got):\n%s", diff)
+			}
+
+			action := &ActBpf{}
+			if err := action.SetInstructions(testcase.val); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			insns, err = action.Instructions()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testcase.val, insns); diff != "" {
+				t.Fatalf("Instructions missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("no ops", func(t *testing.T) {
+		if _, err := (&ActBpf{}).Instructions(); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := (&Bpf{Ops: bytesPtr([]byte{0x1, 0x2, 0x3})}).Instructions(); !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package tc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cilium/ebpf"
+)
+
+// BpfProgramInfo contains details of a loaded eBPF program, as reported by the kernel.
+type BpfProgramInfo struct {
+	ID   uint32
+	Type ebpf.ProgramType
+	Tag  string
+	Name string
+
+	// LoadTime is the time the program was loaded into the kernel.
+	LoadTime     time.Time
+	CreatedByUID uint32
+	MapIDs       []ebpf.MapID
+
+	// RunCount and RunTime are only collected while BPF statistics are enabled.
+	// See ebpf.EnableStats().
+	RunCount uint64
+	RunTime  time.Duration
+
+	// XlatedSize and JitedSize are the sizes in bytes of the program as rewritten
+	// by the verifier and as compiled by the JIT.
+	XlatedSize uint32
+	JitedSize  uint32
+}
+
+// bpfProgramFromID returns the eBPF program for the given ID as reported by the kernel.
+func bpfProgramFromID(id *uint32) (*ebpf.Program, error) {
+	if id == nil || *id == 0 {
+		return nil, fmt.Errorf("program ID: %w", ErrNoArg)
+	}
+	return ebpf.NewProgramFromID(ebpf.ProgramID(*id))
+}
+
+// bpfProgramInfo returns the details of the eBPF program for the given ID.
+func bpfProgramInfo(id *uint32) (*BpfProgramInfo, error) {
+	prog, err := bpfProgramFromID(id)
+	if err != nil {
+		return nil, err
+	}
+	defer prog.Close()
+
+	info, err := prog.Info()
+	if err != nil {
+		return nil, err
+	}
+	details := &BpfProgramInfo{
+		ID:   *id,
+		Type: info.Type,
+		Tag:  info.Tag,
+		Name: info.Name,
+	}
+	if ids, ok := info.MapIDs(); ok {
+		details.MapIDs = ids
+	}
+	if cnt, ok := info.RunCount(); ok {
+		details.RunCount = cnt
+	}
+	if rt, ok := info.Runtime(); ok {
+		details.RunTime = rt
+	}
+	if err := bpfObjInfo(prog.FD(), details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// bpfPinProgram pins the eBPF program for the given ID to fileName on a bpffs.
+func bpfPinProgram(id *uint32, fileName string) error {
+	prog, err := bpfProgramFromID(id)
+	if err != nil {
+		return err
+	}
+	defer prog.Close()
+	return prog.Pin(fileName)
+}
+
+// Program returns the eBPF program of the filter. The caller is responsible for closing it.
+func (b *Bpf) Program() (*ebpf.Program, error) {
+	return bpfProgramFromID(b.ID)
+}
+
+// ProgramInfo returns details about the eBPF program of the filter, that are not part of
+// the netlink message.
+func (b *Bpf) ProgramInfo() (*BpfProgramInfo, error) {
+	return bpfProgramInfo(b.ID)
+}
+
+// PinProgram pins the eBPF program of the filter to fileName on a bpffs.
+func (b *Bpf) PinProgram(fileName string) error {
+	return bpfPinProgram(b.ID, fileName)
+}
+
+// Program returns the eBPF program of the action. The caller is responsible for closing it.
+func (b *ActBpf) Program() (*ebpf.Program, error) {
+	return bpfProgramFromID(b.ID)
+}
+
+// ProgramInfo returns details about the eBPF program of the action, that are not part of
+// the netlink message.
+func (b *ActBpf) ProgramInfo() (*BpfProgramInfo, error) {
+	return bpfProgramInfo(b.ID)
+}
+
+// PinProgram pins the eBPF program of the action to fileName on a bpffs.
+func (b *ActBpf) PinProgram(fileName string) error {
+	return bpfPinProgram(b.ID, fileName)
+}
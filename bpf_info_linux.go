@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package tc
+
+import (
+	"runtime"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// bpfProgInfo is the leading part of struct bpf_prog_info from include/uapi/linux/bpf.h.
+// The kernel fills only as many bytes as requested.
+type bpfProgInfo struct {
+	Type            uint32
+	ID              uint32
+	Tag             [8]byte
+	JitedProgLen    uint32
+	XlatedProgLen   uint32
+	JitedProgInsns  uint64
+	XlatedProgInsns uint64
+	LoadTime        uint64
+	CreatedByUID    uint32
+	NrMapIDs        uint32
+}
+
+// bpfObjInfoAttr is the info part of union bpf_attr from include/uapi/linux/bpf.h.
+// info holds the address of the buffer as __aligned_u64 on all platforms.
+type bpfObjInfoAttr struct {
+	fd      uint32
+	infoLen uint32
+	info    uint64
+}
+
+// bpfObjInfo adds the details to info, that are not exposed by ebpf.ProgramInfo.
+func bpfObjInfo(fd int, info *BpfProgramInfo) error {
+	progInfo := &bpfProgInfo{}
+	attr := bpfObjInfoAttr{
+		fd:      uint32(fd),
+		infoLen: uint32(unsafe.Sizeof(*progInfo)),
+		info:    uint64(uintptr(unsafe.Pointer(progInfo))),
+	}
+	_, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_OBJ_GET_INFO_BY_FD,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	// The address in attr does not keep progInfo alive during the syscall.
+	runtime.KeepAlive(progInfo)
+	if errno != 0 {
+		return errno
+	}
+
+	info.XlatedSize = progInfo.XlatedProgLen
+	info.JitedSize = progInfo.JitedProgLen
+	info.CreatedByUID = progInfo.CreatedByUID
+
+	// The kernel reports the load time in nanoseconds since boot.
+	var now unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_BOOTTIME, &now); err != nil {
+		return err
+	}
+	sinceLoad := time.Duration(now.Nano() - int64(progInfo.LoadTime))
+	info.LoadTime = time.Now().Add(-sinceLoad)
+	return nil
+}
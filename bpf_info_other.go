@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package tc
+
+import "fmt"
+
+// bpfObjInfo adds the details to info, that are not exposed by ebpf.ProgramInfo.
+func bpfObjInfo(fd int, info *BpfProgramInfo) error {
+	return fmt.Errorf("bpf program info: %w", ErrNotImplemented)
+}
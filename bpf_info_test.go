@@ -0,0 +1,72 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+)
+
+func TestBpfProgramInfo(t *testing.T) {
+	t.Run("missing ID", func(t *testing.T) {
+		if _, err := (&Bpf{}).ProgramInfo(); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := (&ActBpf{ID: uint32Ptr(0)}).Program(); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := (&ActBpf{}).PinProgram("/sys/fs/bpf/foo"); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("loaded program", func(t *testing.T) {
+		prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+			Name: "info",
+			Type: ebpf.SchedCLS,
+			Instructions: asm.Instructions{
+				asm.Mov.Imm(asm.R0, 0),
+				asm.Return(),
+			},
+			License: "GPL",
+		})
+		if err != nil {
+			t.Skipf("could not load eBPF program: %v", err)
+		}
+		defer prog.Close()
+		progInfo, err := prog.Info()
+		if err != nil {
+			t.Fatalf("could not get program info: %v", err)
+		}
+		id, ok := progInfo.ID()
+		if !ok {
+			t.Skip("kernel does not report program IDs")
+		}
+
+		info, err := (&Bpf{ID: uint32Ptr(uint32(id))}).ProgramInfo()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.ID != uint32(id) || info.Type != ebpf.SchedCLS || info.Tag != progInfo.Tag || info.Name != "info" {
+			t.Fatalf("unexpected program info: %#v", info)
+		}
+		// Two instructions of 8 bytes each.
+		if info.XlatedSize != 16 {
+			t.Fatalf("unexpected xlated size: %d", info.XlatedSize)
+		}
+		if since := time.Since(info.LoadTime); since < -time.Second || since > time.Minute {
+			t.Fatalf("unexpected load time: %v", info.LoadTime)
+		}
+
+		same, err := (&ActBpf{ID: uint32Ptr(uint32(id))}).Program()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer same.Close()
+		if same.Type() != ebpf.SchedCLS {
+			t.Fatalf("unexpected program type: %v", same.Type())
+		}
+	})
+}
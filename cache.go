@@ -0,0 +1,703 @@
+package tc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+// defaultCacheResync is the interval of full resyncs of a Cache, if CacheOptions does not
+// specify one.
+const defaultCacheResync = 5 * time.Minute
+
+// CacheOptions configures a Cache.
+type CacheOptions struct {
+	// ResyncInterval is the interval, in which the cache is compared with a full dump of
+	// the kernel to recover from missed notifications. If it is zero, the cache is
+	// resynced every 5 minutes. A negative interval disables the periodic resync.
+	ResyncInterval time.Duration
+
+	// ErrorFunc is called with errors of the underlying monitor and of resyncs. Return
+	// something different than 0, to stop the cache from being updated.
+	ErrorFunc ErrorFunc
+}
+
+// Cache is an in-memory copy of the qdiscs, classes, filters and chains of all network
+// interfaces. It is populated by a full dump and kept up to date by notifications of
+// the kernel. Lookups are answered from the cache without a request to the kernel.
+//
+// The objects of the cache are organized by ifindex and parent. Filters and chains of
+// shared blocks are kept with the ifindex MagicBlock and the index of the block as parent,
+// like the kernel reports them. Objects returned by a Cache must not be modified.
+type Cache struct {
+	// notifyMu serializes the updates of the cache together with the calls of the
+	// subscribers. So subscribers see the changes in the order they are applied.
+	notifyMu sync.Mutex
+
+	mu         sync.RWMutex
+	tree       *cacheTree
+	generation uint64
+	// journal holds the changes, that are applied while a periodic resync is in
+	// progress.
+	journal   []cacheEvent
+	resyncing bool
+
+	subsMu sync.Mutex
+	subs   map[int]HookFunc
+	nextID int
+}
+
+// cacheEvent is a change of a Cache.
+type cacheEvent struct {
+	action uint16
+	obj    Object
+}
+
+// NewCache creates a Cache, that is kept up to date with notifications received by tc.
+// It returns once the cache is populated. The cache stops to receive updates, if ctx is
+// done or tc is closed. It requires a Tc, that was created by Open or NewWithDialer.
+func NewCache(ctx context.Context, tc *Tc, opts *CacheOptions) (*Cache, error) {
+	if tc == nil {
+		return nil, ErrNoArg
+	}
+	if opts == nil {
+		opts = &CacheOptions{}
+	}
+	errfn := opts.ErrorFunc
+	if errfn == nil {
+		errfn = func(error) int { return 0 }
+	}
+
+	c := &Cache{
+		tree: newCacheTree(),
+		subs: make(map[int]HookFunc),
+	}
+	stopped := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() { close(stopped) })
+	}
+	synced := make(chan struct{})
+	var syncOnce sync.Once
+
+	if err := tc.MonitorResilient(ctx, func(action uint16, m Object) int {
+		c.apply(action, m)
+		return 0
+	}, func(s *Snapshot) int {
+		c.replace(s)
+		syncOnce.Do(func() { close(synced) })
+		return 0
+	}, func(err error) int {
+		if errfn(err) != 0 {
+			stop()
+			return 1
+		}
+		return 0
+	}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-synced:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	interval := opts.ResyncInterval
+	if interval == 0 {
+		interval = defaultCacheResync
+	}
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-stopped:
+					return
+				case <-ticker.C:
+				}
+				if err := c.resync(tc); err != nil {
+					if errors.Is(err, net.ErrClosed) {
+						return
+					}
+					if errfn(err) != 0 {
+						stop()
+						return
+					}
+				}
+			}
+		}()
+	}
+	return c, nil
+}
+
+// Subscribe registers fn to be called for each change of the cache with the action of
+// the change, e.g. RTM_NEWQDISC or RTM_DELTFILTER, and the changed object. At first fn is
+// called with RTM_NEW* for each object in the cache. Changes detected by a resync are
+// reported the same way. Return something different than 0, to unsubscribe. fn must not
+// call Subscribe or the returned function.
+func (c *Cache) Subscribe(fn HookFunc) (func(), error) {
+	if fn == nil {
+		return nil, ErrNoArg
+	}
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+
+	c.mu.RLock()
+	current := c.tree.events()
+	c.mu.RUnlock()
+	for _, e := range current {
+		if fn(e.action, e.obj) != 0 {
+			return func() {}, nil
+		}
+	}
+
+	c.subsMu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.subs[id] = fn
+	c.subsMu.Unlock()
+	return func() {
+		c.subsMu.Lock()
+		delete(c.subs, id)
+		c.subsMu.Unlock()
+	}, nil
+}
+
+// Links returns the ifindexes of the network interfaces, that have qdiscs, classes,
+// filters or chains in the cache.
+func (c *Cache) Links() []uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var links []uint32
+	for ifindex, l := range c.tree.links {
+		if ifindex != MagicBlock && !l.empty() {
+			links = append(links, ifindex)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i] < links[j] })
+	return links
+}
+
+// Qdiscs returns the qdiscs of the network interface ifindex.
+func (c *Cache) Qdiscs(ifindex uint32) []Object {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if l := c.tree.links[ifindex]; l != nil {
+		return sortedObjects(l.qdiscs)
+	}
+	return nil
+}
+
+// Qdisc returns the qdisc with handle of the network interface ifindex.
+func (c *Cache) Qdisc(ifindex, handle uint32) (Object, bool) {
+	return c.lookup(ifindex, handle, func(l *cacheLink) map[cacheKey]Object { return l.qdiscs })
+}
+
+// Classes returns the classes of the network interface ifindex.
+func (c *Cache) Classes(ifindex uint32) []Object {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if l := c.tree.links[ifindex]; l != nil {
+		return sortedObjects(l.classes)
+	}
+	return nil
+}
+
+// Class returns the class with handle of the network interface ifindex.
+func (c *Cache) Class(ifindex, handle uint32) (Object, bool) {
+	return c.lookup(ifindex, handle, func(l *cacheLink) map[cacheKey]Object { return l.classes })
+}
+
+// Filters returns the filters of the network interface ifindex, that are attached to
+// parent.
+func (c *Cache) Filters(ifindex, parent uint32) []Object {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if p := c.tree.parent(ifindex, parent, false); p != nil {
+		return sortedObjects(p.filters)
+	}
+	return nil
+}
+
+// Chains returns the chains of the network interface ifindex, that are attached to
+// parent.
+func (c *Cache) Chains(ifindex, parent uint32) []Object {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if p := c.tree.parent(ifindex, parent, false); p != nil {
+		return sortedObjects(p.chains)
+	}
+	return nil
+}
+
+// BlockFilters returns the filters of the shared block with index block.
+func (c *Cache) BlockFilters(block uint32) []Object {
+	return c.Filters(MagicBlock, block)
+}
+
+// BlockChains returns the chains of the shared block with index block.
+func (c *Cache) BlockChains(block uint32) []Object {
+	return c.Chains(MagicBlock, block)
+}
+
+func (c *Cache) lookup(ifindex, handle uint32, objects func(*cacheLink) map[cacheKey]Object) (Object, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	l := c.tree.links[ifindex]
+	if l == nil {
+		return Object{}, false
+	}
+	for key, obj := range objects(l) {
+		if key.handle == handle {
+			return obj, true
+		}
+	}
+	return Object{}, false
+}
+
+// apply updates the cache with a notification of the kernel.
+func (c *Cache) apply(action uint16, obj Object) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+
+	c.mu.Lock()
+	changed := c.tree.apply(action, obj)
+	if c.resyncing {
+		c.journal = append(c.journal, cacheEvent{action: action, obj: obj})
+	}
+	c.mu.Unlock()
+
+	if changed {
+		c.notify([]cacheEvent{{action: action, obj: obj}})
+	}
+}
+
+// replace sets the content of the cache to snapshot and reports the differences to the
+// subscribers.
+func (c *Cache) replace(snapshot *Snapshot) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+
+	tree := newCacheTree()
+	tree.load(snapshot)
+
+	c.mu.Lock()
+	old := c.tree
+	c.tree = tree
+	c.generation++
+	c.mu.Unlock()
+
+	c.notify(diffCacheTrees(old, tree))
+}
+
+// resync compares the cache with a full dump of the kernel. The changes, that are
+// applied while the dump is in progress, are applied on top of it.
+func (c *Cache) resync(tc *Tc) error {
+	c.mu.Lock()
+	c.resyncing = true
+	c.journal = nil
+	generation := c.generation
+	c.mu.Unlock()
+
+	snapshot, err := tc.Snapshot()
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+
+	c.mu.Lock()
+	journal := c.journal
+	c.resyncing = false
+	c.journal = nil
+	if err != nil || generation != c.generation {
+		// Either there is nothing to compare with or the monitor resynced the cache
+		// with a more recent snapshot in the meantime.
+		c.mu.Unlock()
+		return err
+	}
+	tree := newCacheTree()
+	tree.load(snapshot)
+	for _, e := range journal {
+		tree.apply(e.action, e.obj)
+	}
+	old := c.tree
+	c.tree = tree
+	c.generation++
+	c.mu.Unlock()
+
+	c.notify(diffCacheTrees(old, tree))
+	return nil
+}
+
+// notify calls the subscribers with events. The caller must hold notifyMu.
+func (c *Cache) notify(events []cacheEvent) {
+	if len(events) == 0 {
+		return
+	}
+	c.subsMu.Lock()
+	subs := make(map[int]HookFunc, len(c.subs))
+	for id, fn := range c.subs {
+		subs[id] = fn
+	}
+	c.subsMu.Unlock()
+
+	for id, fn := range subs {
+		for _, e := range events {
+			if fn(e.action, e.obj) != 0 {
+				c.subsMu.Lock()
+				delete(c.subs, id)
+				c.subsMu.Unlock()
+				break
+			}
+		}
+	}
+}
+
+// cacheKey identifies an object within a cacheLink. Fields, that do not apply to the kind
+// of the object, are zero.
+type cacheKey struct {
+	parent uint32
+	handle uint32
+	info   uint32
+	chain  uint32
+}
+
+// cacheTree holds the objects of a Cache by ifindex.
+type cacheTree struct {
+	links map[uint32]*cacheLink
+}
+
+// cacheLink holds the objects of a network interface or, for ifindex MagicBlock, of the
+// shared blocks.
+type cacheLink struct {
+	qdiscs  map[cacheKey]Object
+	classes map[cacheKey]Object
+	parents map[uint32]*cacheParent
+}
+
+// cacheParent holds the filters and chains, that are attached to a qdisc, class or
+// shared block.
+type cacheParent struct {
+	filters map[cacheKey]Object
+	chains  map[cacheKey]Object
+}
+
+func newCacheTree() *cacheTree {
+	return &cacheTree{links: make(map[uint32]*cacheLink)}
+}
+
+func (t *cacheTree) link(ifindex uint32, create bool) *cacheLink {
+	l := t.links[ifindex]
+	if l == nil && create {
+		l = &cacheLink{
+			qdiscs:  make(map[cacheKey]Object),
+			classes: make(map[cacheKey]Object),
+			parents: make(map[uint32]*cacheParent),
+		}
+		t.links[ifindex] = l
+	}
+	return l
+}
+
+func (t *cacheTree) parent(ifindex, parent uint32, create bool) *cacheParent {
+	l := t.link(ifindex, create)
+	if l == nil {
+		return nil
+	}
+	p := l.parents[parent]
+	if p == nil && create {
+		p = &cacheParent{
+			filters: make(map[cacheKey]Object),
+			chains:  make(map[cacheKey]Object),
+		}
+		l.parents[parent] = p
+	}
+	return p
+}
+
+// empty reports whether l holds no objects.
+func (l *cacheLink) empty() bool {
+	if len(l.qdiscs) > 0 || len(l.classes) > 0 {
+		return false
+	}
+	for _, p := range l.parents {
+		if len(p.filters) > 0 || len(p.chains) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// load adds the objects of snapshot to t.
+func (t *cacheTree) load(snapshot *Snapshot) {
+	for _, objs := range []struct {
+		action uint16
+		objs   []Object
+	}{
+		{unix.RTM_NEWQDISC, snapshot.Qdiscs},
+		{unix.RTM_NEWTCLASS, snapshot.Classes},
+		{unix.RTM_NEWCHAIN, snapshot.Chains},
+		{unix.RTM_NEWTFILTER, snapshot.Filters},
+	} {
+		for _, obj := range objs.objs {
+			t.apply(objs.action, obj)
+		}
+	}
+}
+
+// apply updates t with a notification of the kernel. It reports whether the
+// notification was applied.
+func (t *cacheTree) apply(action uint16, obj Object) bool {
+	switch action {
+	case unix.RTM_NEWQDISC:
+		t.link(obj.Ifindex, true).qdiscs[cacheKey{parent: obj.Parent, handle: obj.Handle}] = obj
+	case unix.RTM_DELQDISC:
+		t.removeQdisc(obj.Ifindex, obj.Parent, obj.Handle)
+	case unix.RTM_NEWTCLASS:
+		t.link(obj.Ifindex, true).classes[cacheKey{parent: obj.Parent, handle: obj.Handle}] = obj
+	case unix.RTM_DELTCLASS:
+		t.removeClass(obj.Ifindex, obj.Parent, obj.Handle)
+	case unix.RTM_NEWTFILTER:
+		if obj.Handle == 0 {
+			// The classifier itself carries no configuration besides its kind.
+			return false
+		}
+		t.parent(obj.Ifindex, obj.Parent, true).filters[filterKey(obj)] = obj
+	case unix.RTM_DELTFILTER:
+		p := t.parent(obj.Ifindex, obj.Parent, false)
+		if p == nil {
+			return true
+		}
+		if obj.Handle != 0 {
+			delete(p.filters, filterKey(obj))
+			return true
+		}
+		// Without a handle the whole classifier or even the whole chain is removed.
+		chain := uint32Value(obj.Chain)
+		for key := range p.filters {
+			if key.chain != chain {
+				continue
+			}
+			if prio := obj.Info >> 16; prio != 0 && prio != key.info>>16 {
+				continue
+			}
+			if protocol := obj.Info & 0xFFFF; protocol != 0 && protocol != key.info&0xFFFF {
+				continue
+			}
+			delete(p.filters, key)
+		}
+	case unix.RTM_NEWCHAIN:
+		t.parent(obj.Ifindex, obj.Parent, true).chains[cacheKey{parent: obj.Parent, chain: uint32Value(obj.Chain)}] = obj
+	case unix.RTM_DELCHAIN:
+		p := t.parent(obj.Ifindex, obj.Parent, false)
+		if p == nil {
+			return true
+		}
+		chain := uint32Value(obj.Chain)
+		delete(p.chains, cacheKey{parent: obj.Parent, chain: chain})
+		for key := range p.filters {
+			if key.chain == chain {
+				delete(p.filters, key)
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// removeQdisc removes a qdisc together with its classes, filters, chains and child
+// qdiscs. The kernel does not send notifications for them.
+func (t *cacheTree) removeQdisc(ifindex, parent, handle uint32) {
+	l := t.link(ifindex, false)
+	if l == nil {
+		return
+	}
+	delete(l.qdiscs, cacheKey{parent: parent, handle: handle})
+	major := handleMajor(handle)
+	if major == 0 {
+		return
+	}
+	for key := range l.classes {
+		if handleMajor(key.handle) == major {
+			delete(l.classes, key)
+		}
+	}
+	for p := range l.parents {
+		if handleMajor(p) == major {
+			delete(l.parents, p)
+		}
+	}
+	for key := range l.qdiscs {
+		if key.parent != HandleRoot && handleMajor(key.parent) == major {
+			t.removeQdisc(ifindex, key.parent, key.handle)
+		}
+	}
+}
+
+// removeClass removes a class together with its filters, chains and child qdiscs.
+func (t *cacheTree) removeClass(ifindex, parent, handle uint32) {
+	l := t.link(ifindex, false)
+	if l == nil {
+		return
+	}
+	delete(l.classes, cacheKey{parent: parent, handle: handle})
+	delete(l.parents, handle)
+	for key := range l.qdiscs {
+		if key.parent == handle {
+			t.removeQdisc(ifindex, key.parent, key.handle)
+		}
+	}
+}
+
+func handleMajor(handle uint32) uint32 {
+	major, _ := core.SplitHandle(handle)
+	return major
+}
+
+func filterKey(obj Object) cacheKey {
+	return cacheKey{parent: obj.Parent, handle: obj.Handle, info: obj.Info, chain: uint32Value(obj.Chain)}
+}
+
+// cacheEntry is an object of a cacheTree together with its position in the tree.
+type cacheEntry struct {
+	action  uint16
+	ifindex uint32
+	key     cacheKey
+}
+
+// entries returns all objects of t by their position.
+func (t *cacheTree) entries() map[cacheEntry]Object {
+	all := make(map[cacheEntry]Object)
+	add := func(action uint16, ifindex uint32, objs map[cacheKey]Object) {
+		for key, obj := range objs {
+			all[cacheEntry{action: action, ifindex: ifindex, key: key}] = obj
+		}
+	}
+	for ifindex, l := range t.links {
+		add(unix.RTM_NEWQDISC, ifindex, l.qdiscs)
+		add(unix.RTM_NEWTCLASS, ifindex, l.classes)
+		for _, p := range l.parents {
+			add(unix.RTM_NEWCHAIN, ifindex, p.chains)
+			add(unix.RTM_NEWTFILTER, ifindex, p.filters)
+		}
+	}
+	return all
+}
+
+// events returns RTM_NEW* events for all objects of t. Qdiscs are reported before their
+// classes, chains and filters.
+func (t *cacheTree) events() []cacheEvent {
+	return sortedEntries(t.entries(), false)
+}
+
+// cacheOrder is the order in which the kinds of objects are reported.
+var cacheOrder = map[uint16]int{
+	unix.RTM_NEWQDISC:   0,
+	unix.RTM_NEWTCLASS:  1,
+	unix.RTM_NEWCHAIN:   2,
+	unix.RTM_NEWTFILTER: 3,
+}
+
+// deleteAction maps the action, that adds an object, to the one, that removes it.
+var deleteAction = map[uint16]uint16{
+	unix.RTM_NEWQDISC:   unix.RTM_DELQDISC,
+	unix.RTM_NEWTCLASS:  unix.RTM_DELTCLASS,
+	unix.RTM_NEWCHAIN:   unix.RTM_DELCHAIN,
+	unix.RTM_NEWTFILTER: unix.RTM_DELTFILTER,
+}
+
+// sortedEntries returns the objects of entries as events in a stable order. If remove is
+// set, the events remove the objects in reverse order.
+func sortedEntries(entries map[cacheEntry]Object, remove bool) []cacheEvent {
+	keys := make([]cacheEntry, 0, len(entries))
+	for e := range entries {
+		keys = append(keys, e)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if remove {
+			return lessCacheEntry(keys[j], keys[i])
+		}
+		return lessCacheEntry(keys[i], keys[j])
+	})
+	events := make([]cacheEvent, 0, len(keys))
+	for _, e := range keys {
+		action := e.action
+		if remove {
+			action = deleteAction[action]
+		}
+		events = append(events, cacheEvent{action: action, obj: entries[e]})
+	}
+	return events
+}
+
+func lessCacheEntry(a, b cacheEntry) bool {
+	if a.action != b.action {
+		return cacheOrder[a.action] < cacheOrder[b.action]
+	}
+	if a.ifindex != b.ifindex {
+		return a.ifindex < b.ifindex
+	}
+	return lessCacheKey(a.key, b.key)
+}
+
+func lessCacheKey(a, b cacheKey) bool {
+	if a.parent != b.parent {
+		return a.parent < b.parent
+	}
+	if a.chain != b.chain {
+		return a.chain < b.chain
+	}
+	if a.info != b.info {
+		return a.info < b.info
+	}
+	return a.handle < b.handle
+}
+
+// diffCacheTrees returns the events, that turn old into updated.
+func diffCacheTrees(old, updated *cacheTree) []cacheEvent {
+	before := old.entries()
+	after := updated.entries()
+	removed := make(map[cacheEntry]Object)
+	for e, obj := range before {
+		if _, ok := after[e]; !ok {
+			removed[e] = obj
+		}
+	}
+	added := make(map[cacheEntry]Object)
+	for e, obj := range after {
+		if prev, ok := before[e]; !ok || !sameConfig(&prev, &obj) {
+			added[e] = obj
+		}
+	}
+	return append(sortedEntries(removed, true), sortedEntries(added, false)...)
+}
+
+func sortedObjects(objs map[cacheKey]Object) []Object {
+	keys := make([]cacheKey, 0, len(objs))
+	for key := range objs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessCacheKey(keys[i], keys[j]) })
+	res := make([]Object, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, objs[key])
+	}
+	return res
+}
+
+// sameConfig reports whether a and b are equal except for their read only attributes like
+// statistics and reference counts, which change all the time.
+func sameConfig(a, b *Object) bool {
+	return reflect.DeepEqual(withoutReadOnly(a), withoutReadOnly(b))
+}
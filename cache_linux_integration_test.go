@@ -0,0 +1,133 @@
+//go:build integration && linux
+// +build integration,linux
+
+package tc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+func TestLinuxCache(t *testing.T) {
+	const block = 10
+
+	ns, err := newScratchNetNS()
+	if err != nil {
+		t.Skipf("could not create network namespace: %v", err)
+	}
+	defer ns.Close()
+
+	tcSocket, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open socket for TC: %v", err)
+	}
+	defer tcSocket.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cache, err := NewCache(ctx, tcSocket, &CacheOptions{
+		ResyncInterval: 50 * time.Millisecond,
+		ErrorFunc: func(err error) int {
+			if ctx.Err() == nil {
+				t.Errorf("cache failed: %v", err)
+			}
+			return 1
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not create cache: %v", err)
+	}
+	filters := make(chan Object, 16)
+	unsubscribe, err := cache.Subscribe(func(action uint16, m Object) int {
+		if action == unix.RTM_NEWTFILTER {
+			filters <- m
+		}
+		return 0
+	})
+	if err != nil {
+		t.Fatalf("could not subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	if err := tcSocket.Qdisc().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{
+			Kind:         "clsact",
+			IngressBlock: uint32Ptr(block),
+		},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+	if err := tcSocket.Filter().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: MagicBlock,
+			Parent:  block,
+			Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+		},
+		Attribute: Attribute{
+			Kind: "u32",
+			U32: &U32{
+				ClassID: uint32Ptr(0x10001),
+				Sel:     &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{{}}},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("could not add filter to block: %v", err)
+	}
+
+	select {
+	case f := <-filters:
+		if f.Ifindex != MagicBlock || f.Parent != block {
+			t.Fatalf("filter is not reported for the shared block: %+v", f.Msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("missing filter")
+	}
+	if len(cache.BlockFilters(block)) == 0 {
+		t.Fatal("filters of shared block are missing")
+	}
+	if len(cache.BlockChains(block)) == 0 {
+		t.Fatal("chains of shared block are missing")
+	}
+	qdiscs := 0
+	for _, q := range cache.Qdiscs(probeLoopbackIndex) {
+		if q.Kind == "clsact" && uint32Value(q.IngressBlock) == block {
+			qdiscs++
+		}
+	}
+	if qdiscs != 1 {
+		t.Fatalf("clsact is missing: %+v", cache.Qdiscs(probeLoopbackIndex))
+	}
+
+	// The kernel creates the hash table 800: of u32 without a notification. So it is
+	// reported by a resync.
+	for {
+		select {
+		case f := <-filters:
+			if f.Handle != 0x80000000 {
+				continue
+			}
+		case <-ctx.Done():
+			t.Fatal("hash table was not found by a resync")
+		}
+		break
+	}
+
+	// Resyncs do not report filters, whose statistics changed only.
+	select {
+	case f := <-filters:
+		t.Fatalf("unexpected filter: %+v", f.Msg)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -0,0 +1,273 @@
+package tc_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/florianl/go-tc/tctest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
+
+// cacheEvent is a change reported by a tc.Cache.
+type cacheEvent struct {
+	Action uint16
+	Handle uint32
+}
+
+// subscribe returns a channel, that receives the changes of c.
+func subscribe(t *testing.T, c *tc.Cache) (<-chan cacheEvent, func()) {
+	t.Helper()
+	events := make(chan cacheEvent, 64)
+	unsubscribe, err := c.Subscribe(func(action uint16, m tc.Object) int {
+		events <- cacheEvent{Action: action, Handle: m.Handle}
+		return 0
+	})
+	if err != nil {
+		t.Fatalf("could not subscribe: %v", err)
+	}
+	return events, unsubscribe
+}
+
+// nextEvents returns the next n changes from events.
+func nextEvents(ctx context.Context, t *testing.T, events <-chan cacheEvent, n int) []cacheEvent {
+	t.Helper()
+	var got []cacheEvent
+	for len(got) < n {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-ctx.Done():
+			t.Fatalf("missing events, got %v", got)
+		}
+	}
+	return got
+}
+
+func TestCache(t *testing.T) {
+	_, tcnl := batchKernel(t)
+	ingress := core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := tc.NewCache(ctx, tcnl, &tc.CacheOptions{ResyncInterval: -1})
+	if err != nil {
+		t.Fatalf("could not create cache: %v", err)
+	}
+
+	if diff := cmp.Diff([]uint32{batchIfindex}, c.Links()); diff != "" {
+		t.Fatalf("links missmatch (want +got):\n%s", diff)
+	}
+	qdiscs := c.Qdiscs(batchIfindex)
+	if len(qdiscs) != 1 || qdiscs[0].Kind != "clsact" {
+		t.Fatalf("unexpected qdiscs: %+v", qdiscs)
+	}
+	if _, ok := c.Qdisc(batchIfindex, qdiscs[0].Handle); !ok {
+		t.Fatal("clsact qdisc is missing")
+	}
+	if diff := cmp.Diff([]uint32{1}, handles(c.Filters(batchIfindex, ingress))); diff != "" {
+		t.Fatalf("filters missmatch (want +got):\n%s", diff)
+	}
+
+	events, unsubscribe := subscribe(t, c)
+	// A new subscriber learns about the content of the cache first.
+	if diff := cmp.Diff([]cacheEvent{
+		{Action: unix.RTM_NEWQDISC, Handle: qdiscs[0].Handle},
+		{Action: unix.RTM_NEWCHAIN},
+		{Action: unix.RTM_NEWTFILTER, Handle: 1},
+	}, nextEvents(ctx, t, events, 3)); diff != "" {
+		t.Fatalf("initial events missmatch (want +got):\n%s", diff)
+	}
+
+	if err := tcnl.Filter().Add(batchFilter(2, 0x10002)); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+	if err := tcnl.Filter().Delete(batchFilter(1, 0x10001)); err != nil {
+		t.Fatalf("could not delete filter: %v", err)
+	}
+	if diff := cmp.Diff([]cacheEvent{
+		{Action: unix.RTM_NEWTFILTER, Handle: 2},
+		{Action: unix.RTM_DELTFILTER, Handle: 1},
+	}, nextEvents(ctx, t, events, 2)); diff != "" {
+		t.Fatalf("events missmatch (want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint32{2}, handles(c.Filters(batchIfindex, ingress))); diff != "" {
+		t.Fatalf("filters missmatch (want +got):\n%s", diff)
+	}
+
+	// Deleting the qdisc removes its filters and chains, too.
+	if err := tcnl.Qdisc().Delete(&qdiscs[0]); err != nil {
+		t.Fatalf("could not delete qdisc: %v", err)
+	}
+	if diff := cmp.Diff([]cacheEvent{
+		{Action: unix.RTM_DELQDISC, Handle: qdiscs[0].Handle},
+	}, nextEvents(ctx, t, events, 1)); diff != "" {
+		t.Fatalf("events missmatch (want +got):\n%s", diff)
+	}
+	if objs := c.Qdiscs(batchIfindex); len(objs) != 0 {
+		t.Fatalf("unexpected qdiscs: %+v", objs)
+	}
+	if objs := c.Filters(batchIfindex, ingress); len(objs) != 0 {
+		t.Fatalf("unexpected filters: %+v", objs)
+	}
+	if links := c.Links(); len(links) != 0 {
+		t.Fatalf("unexpected links: %v", links)
+	}
+
+	unsubscribe()
+	if err := tcnl.Qdisc().Add(&qdiscs[0]); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	for len(c.Qdiscs(batchIfindex)) == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("qdisc is missing")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event after unsubscribe: %v", e)
+	default:
+	}
+}
+
+func TestCacheResync(t *testing.T) {
+	_, tcnl := batchKernel(t)
+	ingress := core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := tc.NewCache(ctx, tcnl, &tc.CacheOptions{ResyncInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("could not create cache: %v", err)
+	}
+	events, unsubscribe := subscribe(t, c)
+	defer unsubscribe()
+	nextEvents(ctx, t, events, 3)
+
+	// The chain is created implicitly by the filter without a notification. So only a
+	// resync reveals it.
+	chain := uint32(5)
+	filter := batchFilter(2, 0x10002)
+	filter.Chain = &chain
+	if err := tcnl.Filter().Add(filter); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+	if diff := cmp.Diff([]cacheEvent{
+		{Action: unix.RTM_NEWTFILTER, Handle: 2},
+		{Action: unix.RTM_NEWCHAIN},
+	}, nextEvents(ctx, t, events, 2)); diff != "" {
+		t.Fatalf("events missmatch (want +got):\n%s", diff)
+	}
+	var chains []uint32
+	for _, chain := range c.Chains(batchIfindex, ingress) {
+		chains = append(chains, *chain.Chain)
+	}
+	if diff := cmp.Diff([]uint32{0, 5}, chains); diff != "" {
+		t.Fatalf("chains missmatch (want +got):\n%s", diff)
+	}
+
+	// Resyncs do not report objects, that did not change.
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// notificationFilter is a tc.Conn, that drops the notifications for which drop returns true.
+type notificationFilter struct {
+	tc.Conn
+	drop func(netlink.Message) bool
+}
+
+func (c *notificationFilter) Receive() ([]netlink.Message, error) {
+	msgs, err := c.Conn.Receive()
+	var res []netlink.Message
+	for _, msg := range msgs {
+		if msg.Header.Sequence != 0 || !c.drop(msg) {
+			res = append(res, msg)
+		}
+	}
+	return res, err
+}
+
+func TestCacheResyncReadOnly(t *testing.T) {
+	k := tctest.NewKernel()
+	k.AddLink(batchIfindex)
+	var dropping int32
+	tcnl, err := tc.NewWithDialer(func() (tc.Conn, error) {
+		return &notificationFilter{Conn: k.Dial(), drop: func(netlink.Message) bool {
+			return atomic.LoadInt32(&dropping) != 0
+		}}, nil
+	})
+	if err != nil {
+		t.Fatalf("could not open: %v", err)
+	}
+	defer tcnl.Close()
+	if err := tcnl.Qdisc().Add(&tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: batchIfindex,
+			Handle:  core.BuildHandle(0xFFFF, 0),
+			Parent:  tc.HandleIngress,
+		},
+		Attribute: tc.Attribute{Kind: "clsact"},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+	addDumpedFilter(t, k, 5, 0x10005, 3)
+	ingress := core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := tc.NewCache(ctx, tcnl, &tc.CacheOptions{ResyncInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("could not create cache: %v", err)
+	}
+	events, unsubscribe := subscribe(t, c)
+	defer unsubscribe()
+	nextEvents(ctx, t, events, 3)
+
+	// Another filter binds the shared action, which only changes its reference count. Only
+	// a resync reveals the change, as the notification is dropped.
+	atomic.StoreInt32(&dropping, 1)
+	addDumpedFilter(t, k, 5, 0x10005, 4)
+	refCnt := func() uint32 {
+		for _, f := range c.Filters(batchIfindex, ingress) {
+			if f.Handle == 5 && f.Fw != nil && f.Fw.Actions != nil && len(*f.Fw.Actions) == 1 {
+				return (*f.Fw.Actions)[0].RefCnt()
+			}
+		}
+		return 0
+	}
+	for refCnt() != 4 {
+		select {
+		case e := <-events:
+			t.Fatalf("unexpected event: %v", e)
+		case <-ctx.Done():
+			t.Fatal("missing resync")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %v", e)
+	default:
+	}
+}
+
+func TestCacheInvalid(t *testing.T) {
+	if _, err := tc.NewCache(context.Background(), nil, nil); err != tc.ErrNoArg {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
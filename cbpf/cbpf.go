@@ -0,0 +1,509 @@
+package cbpf
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/florianl/go-tc"
+	"golang.org/x/net/bpf"
+)
+
+// Return values of programs created by Compile. For the bpf filter, ReturnMatch
+// classifies the packet with the classid of the filter.
+const (
+	ReturnMatch   = 0xFFFFFFFF
+	ReturnNoMatch = 0
+)
+
+// Ethernet types and IP protocols
+const (
+	ethIPv4 = 0x0800
+	ethARP  = 0x0806
+	ethIPv6 = 0x86DD
+
+	protoICMP   = 1
+	protoTCP    = 6
+	protoUDP    = 17
+	protoICMPv6 = 58
+	protoSCTP   = 132
+)
+
+// Offsets within a packet starting with the Ethernet header
+const (
+	offEthType  = 12
+	offIPv4     = 14
+	offIPv4Frag = offIPv4 + 6
+	offIPv4Prot = offIPv4 + 9
+	offIPv4Src  = offIPv4 + 12
+	offIPv4Dst  = offIPv4 + 16
+	offIPv6     = 14
+	offIPv6Next = offIPv6 + 6
+	offIPv6Src  = offIPv6 + 8
+	offIPv6Dst  = offIPv6 + 24
+	offIPv6L4   = offIPv6 + 40
+)
+
+var protoNames = map[string]uint8{
+	"icmp":  protoICMP,
+	"tcp":   protoTCP,
+	"udp":   protoUDP,
+	"icmp6": protoICMPv6,
+	"sctp":  protoSCTP,
+}
+
+// node is an element of the expression tree.
+type node interface{}
+
+type andNode struct{ l, r node }
+
+type orNode struct{ l, r node }
+
+type notNode struct{ x node }
+
+// testNode loads a value into register A and compares it.
+type testNode struct {
+	loads []bpf.Instruction
+	cond  bpf.JumpTest
+	val   uint32
+}
+
+// Compile translates expr into a classic BPF program that returns ReturnMatch for
+// matching packets and ReturnNoMatch otherwise.
+func Compile(expr string) ([]bpf.Instruction, error) {
+	return CompileWithReturn(expr, ReturnMatch, ReturnNoMatch)
+}
+
+// CompileWithReturn translates expr into a classic BPF program that returns match for
+// matching packets and noMatch otherwise. E.g. for the bpf action the return values
+// are TC_ACT_* verdicts.
+func CompileWithReturn(expr string, match, noMatch uint32) ([]bpf.Instruction, error) {
+	p := &parser{tokens: tokenize(expr)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty expression: %w", tc.ErrNoArg)
+	}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.more() {
+		return nil, fmt.Errorf("unexpected '%s': %w", p.peek(), tc.ErrInvalidArg)
+	}
+
+	c := &compiler{}
+	t, f := c.newLabel(), c.newLabel()
+	c.gen(root, t, f)
+	c.place(t)
+	c.emit(bpf.RetConstant{Val: match})
+	c.place(f)
+	c.emit(bpf.RetConstant{Val: noMatch})
+	return c.resolve()
+}
+
+func tokenize(expr string) []string {
+	for _, op := range []string{"(", ")", "&&", "||"} {
+		expr = strings.ReplaceAll(expr, op, " "+op+" ")
+	}
+	expr = strings.ReplaceAll(expr, "!", " ! ")
+	return strings.Fields(expr)
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) more() bool {
+	return p.pos < len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	if !p.more() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() (string, error) {
+	if !p.more() {
+		return "", fmt.Errorf("unexpected end of expression: %w", tc.ErrInvalidArg)
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+// parseExpr parses terms joined by and and or from left to right.
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.more() {
+		switch p.peek() {
+		case "and", "&&":
+			p.pos++
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			left = andNode{left, right}
+		case "or", "||":
+			p.pos++
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			left = orNode{left, right}
+		default:
+			return left, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case "not", "!":
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case "(":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, err := p.next(); err != nil || tok != ")" {
+			return nil, fmt.Errorf("missing ')': %w", tc.ErrInvalidArg)
+		}
+		return x, nil
+	}
+	p.pos--
+	return p.parsePrimitive()
+}
+
+func isDir(tok string) bool {
+	return tok == "src" || tok == "dst"
+}
+
+func isType(tok string) bool {
+	return tok == "host" || tok == "net" || tok == "port" || tok == "proto"
+}
+
+func (p *parser) parsePrimitive() (node, error) {
+	var qualifier, dir, typ string
+	tok, _ := p.next()
+
+	if tok == "vlan" {
+		if id, err := strconv.ParseUint(p.peek(), 0, 12); err == nil {
+			p.pos++
+			return vlan(int(id)), nil
+		}
+		return vlan(-1), nil
+	}
+
+	switch tok {
+	case "ip", "ip6", "arp", "tcp", "udp", "sctp", "icmp", "icmp6":
+		if !isDir(p.peek()) && !isType(p.peek()) {
+			return protocol(tok), nil
+		}
+		qualifier = tok
+		tok, _ = p.next()
+	}
+	if isDir(tok) {
+		dir = tok
+		if isType(p.peek()) {
+			tok, _ = p.next()
+		} else {
+			// "src ADDR" is short for "src host ADDR" or "src net ADDR/LEN".
+			tok = ""
+		}
+	}
+	if isType(tok) {
+		typ = tok
+	} else if tok != "" {
+		return nil, fmt.Errorf("unexpected '%s': %w", tok, tc.ErrInvalidArg)
+	}
+
+	id, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if typ == "" {
+		typ = "host"
+		if strings.Contains(id, "/") {
+			typ = "net"
+		}
+	}
+
+	switch typ {
+	case "host", "net":
+		if qualifier != "" && qualifier != "ip" && qualifier != "ip6" {
+			return nil, fmt.Errorf("%s %s: %w", qualifier, typ, tc.ErrInvalidArg)
+		}
+		var prefix netip.Prefix
+		if typ == "host" {
+			addr, err := netip.ParseAddr(id)
+			if err != nil {
+				return nil, fmt.Errorf("invalid host '%s': %w", id, tc.ErrInvalidArg)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		} else if prefix, err = netip.ParsePrefix(id); err != nil {
+			return nil, fmt.Errorf("invalid net '%s': %w", id, tc.ErrInvalidArg)
+		}
+		if (qualifier == "ip" && !prefix.Addr().Is4()) || (qualifier == "ip6" && !prefix.Addr().Is6()) {
+			return nil, fmt.Errorf("%s %s %s: %w", qualifier, typ, id, tc.ErrInvalidArg)
+		}
+		return address(prefix.Masked(), dir), nil
+	case "port":
+		if qualifier != "" && qualifier != "tcp" && qualifier != "udp" && qualifier != "sctp" {
+			return nil, fmt.Errorf("%s port: %w", qualifier, tc.ErrInvalidArg)
+		}
+		port, err := strconv.ParseUint(id, 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port '%s': %w", id, tc.ErrInvalidArg)
+		}
+		return portMatch(qualifier, dir, uint32(port)), nil
+	case "proto":
+		if dir != "" || (qualifier != "" && qualifier != "ip" && qualifier != "ip6") {
+			return nil, fmt.Errorf("%s %s proto: %w", qualifier, dir, tc.ErrInvalidArg)
+		}
+		proto, ok := protoNames[strings.TrimPrefix(id, "\\")]
+		if !ok {
+			v, err := strconv.ParseUint(id, 0, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid protocol '%s': %w", id, tc.ErrInvalidArg)
+			}
+			proto = uint8(v)
+		}
+		switch qualifier {
+		case "ip":
+			return ipv4Proto(proto), nil
+		case "ip6":
+			return ipv6Proto(proto), nil
+		}
+		return orNode{ipv4Proto(proto), ipv6Proto(proto)}, nil
+	}
+	return nil, fmt.Errorf("unexpected '%s': %w", typ, tc.ErrInvalidArg)
+}
+
+func load(off, size int) bpf.Instruction {
+	return bpf.LoadAbsolute{Off: uint32(off), Size: size}
+}
+
+func ethType(t uint32) node {
+	return testNode{loads: []bpf.Instruction{load(offEthType, 2)}, cond: bpf.JumpEqual, val: t}
+}
+
+func ipv4Proto(proto uint8) node {
+	return andNode{ethType(ethIPv4),
+		testNode{loads: []bpf.Instruction{load(offIPv4Prot, 1)}, cond: bpf.JumpEqual, val: uint32(proto)}}
+}
+
+func ipv6Proto(proto uint8) node {
+	return andNode{ethType(ethIPv6),
+		testNode{loads: []bpf.Instruction{load(offIPv6Next, 1)}, cond: bpf.JumpEqual, val: uint32(proto)}}
+}
+
+func protocol(name string) node {
+	switch name {
+	case "ip":
+		return ethType(ethIPv4)
+	case "ip6":
+		return ethType(ethIPv6)
+	case "arp":
+		return ethType(ethARP)
+	case "icmp":
+		return ipv4Proto(protoICMP)
+	case "icmp6":
+		return ipv6Proto(protoICMPv6)
+	}
+	proto := protoNames[name]
+	return orNode{ipv4Proto(proto), ipv6Proto(proto)}
+}
+
+func vlan(id int) node {
+	present := testNode{loads: []bpf.Instruction{bpf.LoadExtension{Num: bpf.ExtVLANTagPresent}},
+		cond: bpf.JumpNotEqual, val: 0}
+	if id < 0 {
+		return present
+	}
+	return andNode{present, testNode{
+		loads: []bpf.Instruction{
+			bpf.LoadExtension{Num: bpf.ExtVLANTag},
+			bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0xFFF},
+		},
+		cond: bpf.JumpEqual, val: uint32(id)}}
+}
+
+// prefixAt matches the masked prefix at off.
+func prefixAt(prefix netip.Prefix, off int) node {
+	var match node
+	addr := prefix.Addr().AsSlice()
+	for i, bits := 0, prefix.Bits(); bits > 0; i, bits = i+4, bits-32 {
+		word := uint32(addr[i])<<24 | uint32(addr[i+1])<<16 | uint32(addr[i+2])<<8 | uint32(addr[i+3])
+		test := testNode{loads: []bpf.Instruction{load(off+i, 4)}, cond: bpf.JumpEqual, val: word}
+		if bits < 32 {
+			mask := ^uint32(0) << (32 - bits)
+			test.loads = append(test.loads, bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: mask})
+		}
+		if match == nil {
+			match = test
+		} else {
+			match = andNode{match, test}
+		}
+	}
+	return match
+}
+
+func address(prefix netip.Prefix, dir string) node {
+	family, src, dst := ethType(ethIPv4), offIPv4Src, offIPv4Dst
+	if prefix.Addr().Is6() {
+		family, src, dst = ethType(ethIPv6), offIPv6Src, offIPv6Dst
+	}
+	if prefix.Bits() == 0 {
+		return family
+	}
+	switch dir {
+	case "src":
+		return andNode{family, prefixAt(prefix, src)}
+	case "dst":
+		return andNode{family, prefixAt(prefix, dst)}
+	}
+	return andNode{family, orNode{prefixAt(prefix, src), prefixAt(prefix, dst)}}
+}
+
+func portMatch(qualifier, dir string, port uint32) node {
+	var srcOff, dstOff []uint32
+	switch dir {
+	case "src":
+		srcOff = []uint32{0}
+	case "dst":
+		dstOff = []uint32{2}
+	default:
+		srcOff, dstOff = []uint32{0}, []uint32{2}
+	}
+	ports := func(loads func(off uint32) []bpf.Instruction) node {
+		var match node
+		for _, off := range append(srcOff, dstOff...) {
+			test := testNode{loads: loads(off), cond: bpf.JumpEqual, val: port}
+			if match == nil {
+				match = test
+			} else {
+				match = orNode{match, test}
+			}
+		}
+		return match
+	}
+	ipv4Ports := ports(func(off uint32) []bpf.Instruction {
+		// The IPv4 header has a variable length.
+		return []bpf.Instruction{
+			bpf.LoadMemShift{Off: offIPv4},
+			bpf.LoadIndirect{Off: offIPv4 + off, Size: 2},
+		}
+	})
+	ipv6Ports := ports(func(off uint32) []bpf.Instruction {
+		return []bpf.Instruction{load(offIPv6L4+int(off), 2)}
+	})
+	// Only the first fragment contains the L4 header.
+	unfragmented := notNode{testNode{loads: []bpf.Instruction{load(offIPv4Frag, 2)}, cond: bpf.JumpBitsSet, val: 0x1FFF}}
+
+	var match node
+	for _, name := range []string{"tcp", "udp", "sctp"} {
+		if qualifier != "" && qualifier != name {
+			continue
+		}
+		proto := protoNames[name]
+		m := orNode{
+			andNode{andNode{ipv4Proto(proto), unfragmented}, ipv4Ports},
+			andNode{ipv6Proto(proto), ipv6Ports},
+		}
+		if match == nil {
+			match = m
+		} else {
+			match = orNode{match, m}
+		}
+	}
+	return match
+}
+
+type label int
+
+// instruction is an instruction with symbolic jump targets.
+type instruction struct {
+	ins  bpf.Instruction
+	jump bool
+	cond bpf.JumpTest
+	val  uint32
+	t, f label
+}
+
+type compiler struct {
+	code   []instruction
+	labels []int
+}
+
+func (c *compiler) newLabel() label {
+	c.labels = append(c.labels, -1)
+	return label(len(c.labels) - 1)
+}
+
+func (c *compiler) place(l label) {
+	c.labels[l] = len(c.code)
+}
+
+func (c *compiler) emit(ins bpf.Instruction) {
+	c.code = append(c.code, instruction{ins: ins})
+}
+
+// gen emits n so that execution continues at t if n matches and at f otherwise.
+func (c *compiler) gen(n node, t, f label) {
+	switch n := n.(type) {
+	case andNode:
+		mid := c.newLabel()
+		c.gen(n.l, mid, f)
+		c.place(mid)
+		c.gen(n.r, t, f)
+	case orNode:
+		mid := c.newLabel()
+		c.gen(n.l, t, mid)
+		c.place(mid)
+		c.gen(n.r, t, f)
+	case notNode:
+		c.gen(n.x, f, t)
+	case testNode:
+		for _, ins := range n.loads {
+			c.emit(ins)
+		}
+		c.code = append(c.code, instruction{jump: true, cond: n.cond, val: n.val, t: t, f: f})
+	}
+}
+
+// resolve replaces symbolic jump targets by relative offsets.
+func (c *compiler) resolve() ([]bpf.Instruction, error) {
+	// BPF_MAXINSNS from include/uapi/linux/bpf_common.h
+	if len(c.code) > 4096 {
+		return nil, fmt.Errorf("program exceeds 4096 instructions: %w", tc.ErrInvalidArg)
+	}
+	insns := make([]bpf.Instruction, 0, len(c.code))
+	for i, ins := range c.code {
+		if !ins.jump {
+			insns = append(insns, ins.ins)
+			continue
+		}
+		skipTrue := c.labels[ins.t] - i - 1
+		skipFalse := c.labels[ins.f] - i - 1
+		if skipTrue > 0xFF || skipFalse > 0xFF {
+			return nil, fmt.Errorf("expression too complex for conditional jumps: %w", tc.ErrInvalidArg)
+		}
+		insns = append(insns, bpf.JumpIf{Cond: ins.cond, Val: ins.val,
+			SkipTrue: uint8(skipTrue), SkipFalse: uint8(skipFalse)})
+	}
+	return insns, nil
+}
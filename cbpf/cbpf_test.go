@@ -0,0 +1,153 @@
+package cbpf
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/florianl/go-tc"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/bpf"
+)
+
+// packet returns an Ethernet frame with an IP header of the family of src and an L4 header
+// starting with the ports.
+func packet(src, dst string, proto uint8, sport, dport uint16) []byte {
+	s, d := netip.MustParseAddr(src), netip.MustParseAddr(dst)
+	pkt := make([]byte, 14)
+	var l3 []byte
+	if s.Is4() {
+		binary.BigEndian.PutUint16(pkt[12:], ethIPv4)
+		// IPv4 header with options to check the variable header length.
+		l3 = make([]byte, 24)
+		l3[0] = 0x46
+		l3[9] = proto
+		copy(l3[12:], s.AsSlice())
+		copy(l3[16:], d.AsSlice())
+	} else {
+		binary.BigEndian.PutUint16(pkt[12:], ethIPv6)
+		l3 = make([]byte, 40)
+		l3[0] = 0x60
+		l3[6] = proto
+		copy(l3[8:], s.AsSlice())
+		copy(l3[24:], d.AsSlice())
+	}
+	l4 := make([]byte, 8)
+	binary.BigEndian.PutUint16(l4[0:], sport)
+	binary.BigEndian.PutUint16(l4[2:], dport)
+	return append(append(pkt, l3...), l4...)
+}
+
+func TestCompile(t *testing.T) {
+	tcp4 := packet("10.0.0.1", "192.168.1.2", protoTCP, 1234, 80)
+	udp6 := packet("2001:db8::1", "fe80::2", protoUDP, 53, 4321)
+	icmp4 := packet("10.0.0.1", "10.0.0.2", protoICMP, 0, 0)
+	arp := make([]byte, 42)
+	binary.BigEndian.PutUint16(arp[12:], ethARP)
+	frag := packet("10.0.0.1", "192.168.1.2", protoTCP, 1234, 80)
+	binary.BigEndian.PutUint16(frag[offIPv4Frag:], 0x0010)
+
+	packets := map[string][]byte{"tcp4": tcp4, "udp6": udp6, "icmp4": icmp4, "arp": arp, "frag": frag}
+
+	tests := map[string]struct {
+		expr  string
+		match []string
+		err   error
+	}{
+		"ip":                {expr: "ip", match: []string{"tcp4", "icmp4", "frag"}},
+		"ip6 or arp":        {expr: "ip6 or arp", match: []string{"udp6", "arp"}},
+		"not ip":            {expr: "not ip", match: []string{"udp6", "arp"}},
+		"tcp":               {expr: "tcp", match: []string{"tcp4", "frag"}},
+		"udp":               {expr: "udp", match: []string{"udp6"}},
+		"icmp":              {expr: "icmp", match: []string{"icmp4"}},
+		"host":              {expr: "host 10.0.0.1", match: []string{"tcp4", "icmp4", "frag"}},
+		"dst host":          {expr: "dst host 192.168.1.2", match: []string{"tcp4", "frag"}},
+		"src host":          {expr: "src host 192.168.1.2"},
+		"host v6":           {expr: "host fe80::2", match: []string{"udp6"}},
+		"src v6 short":      {expr: "src 2001:db8::1", match: []string{"udp6"}},
+		"net":               {expr: "net 192.168.0.0/16", match: []string{"tcp4", "frag"}},
+		"ip6 net":           {expr: "ip6 src net 2001:db8::/33", match: []string{"udp6"}},
+		"net zero":          {expr: "net 0.0.0.0/0", match: []string{"tcp4", "icmp4", "frag"}},
+		"port":              {expr: "port 80", match: []string{"tcp4"}},
+		"src port":          {expr: "src port 80"},
+		"udp port":          {expr: "udp port 53", match: []string{"udp6"}},
+		"tcp port":          {expr: "tcp port 53"},
+		"proto":             {expr: "proto 17", match: []string{"udp6"}},
+		"ip proto":          {expr: "ip proto \\tcp", match: []string{"tcp4", "frag"}},
+		"left to right":     {expr: "arp or ip and icmp", match: []string{"icmp4"}},
+		"parentheses":       {expr: "arp or (ip and icmp)", match: []string{"arp", "icmp4"}},
+		"operators":         {expr: "!arp && (tcp || udp) && !port 80", match: []string{"udp6", "frag"}},
+		"empty":             {expr: "", err: tc.ErrNoArg},
+		"unknown":           {expr: "foo", err: tc.ErrInvalidArg},
+		"unbalanced":        {expr: "(ip or ip6", err: tc.ErrInvalidArg},
+		"family missmatch":  {expr: "ip host ::1", err: tc.ErrInvalidArg},
+		"invalid port":      {expr: "port 65536", err: tc.ErrInvalidArg},
+		"invalid qualifier": {expr: "icmp port 1", err: tc.ErrInvalidArg},
+		"trailing":          {expr: "ip ip6", err: tc.ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			insns, err := Compile(testcase.expr)
+			if err != nil {
+				if errors.Is(err, testcase.err) {
+					return
+				}
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testcase.err != nil {
+				t.Fatalf("Expected error %v but got none", testcase.err)
+			}
+			vm, err := bpf.NewVM(insns)
+			if err != nil {
+				t.Fatalf("Invalid program: %v\n%v", err, insns)
+			}
+			want := map[string]bool{}
+			for _, m := range testcase.match {
+				want[m] = true
+			}
+			got := map[string]bool{}
+			for name, pkt := range packets {
+				ret, err := vm.Run(pkt)
+				if err != nil {
+					t.Fatalf("Unexpected error on %s: %v", name, err)
+				}
+				if uint32(ret) == ReturnMatch {
+					got[name] = true
+				}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("matches missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompileVlan(t *testing.T) {
+	insns, err := CompileWithReturn("vlan 100", 2, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []bpf.Instruction{
+		bpf.LoadExtension{Num: bpf.ExtVLANTagPresent},
+		bpf.JumpIf{Cond: bpf.JumpNotEqual, Val: 0, SkipFalse: 4},
+		bpf.LoadExtension{Num: bpf.ExtVLANTag},
+		bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0xFFF},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 100, SkipFalse: 1},
+		bpf.RetConstant{Val: 2},
+		bpf.RetConstant{Val: 1},
+	}
+	if diff := cmp.Diff(want, insns); diff != "" {
+		t.Fatalf("instructions missmatch (want +got):\n%s", diff)
+	}
+
+	// The program can be used with the bpf filter.
+	filter := &tc.Bpf{}
+	if err := filter.SetInstructions(insns); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *filter.OpsLen != uint16(len(want)) {
+		t.Fatalf("unexpected OpsLen: %d", *filter.OpsLen)
+	}
+}
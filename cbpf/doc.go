@@ -0,0 +1,22 @@
+/*
+Package cbpf translates a small subset of the pcap-filter(7) syntax, as used by tcpdump, into
+classic BPF programs for the bpf filter and the bpf action of the package
+github.com/florianl/go-tc.
+
+Supported primitives are
+
+	[ip|ip6] [src|dst] host ADDR
+	[ip|ip6] [src|dst] net ADDR/LEN
+	[tcp|udp|sctp] [src|dst] port PORT
+	[ip|ip6] proto PROTO
+	ip, ip6, arp, tcp, udp, sctp, icmp, icmp6
+	vlan [ID]
+
+which can be combined with not (!), and (&&), or (||) and parentheses. Like in pcap-filter(7),
+and and or have the same precedence and are evaluated from left to right.
+
+Programs expect the packet to start with the Ethernet header, as it is the case for bpf filters
+and actions. VLAN tags are expected to be stripped into the packet metadata, as the kernel does
+before the ingress hook of tc.
+*/
+package cbpf
@@ -46,3 +46,11 @@ func (c *Chain) Get(i *Msg) ([]Object, error) {
 	}
 	return c.get(unix.RTM_GETCHAIN, i)
 }
+
+// Walk calls fn for each chain, that matches i, as soon as it is received.
+func (c *Chain) Walk(i *Msg, fn WalkFunc) error {
+	if i == nil || fn == nil {
+		return ErrNoArg
+	}
+	return c.walk(unix.RTM_GETCHAIN, i, fn)
+}
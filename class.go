@@ -61,6 +61,14 @@ func (c *Class) Get(i *Msg) ([]Object, error) {
 	return c.get(unix.RTM_GETTCLASS, i)
 }
 
+// Walk calls fn for each class, that matches i, as soon as it is received.
+func (c *Class) Walk(i *Msg, fn WalkFunc) error {
+	if i == nil || fn == nil {
+		return ErrNoArg
+	}
+	return c.walk(unix.RTM_GETTCLASS, i, fn)
+}
+
 func validateClassObject(action int, info *Object) ([]tcOption, error) {
 	options := []tcOption{}
 	if info.Ifindex == 0 {
@@ -90,6 +90,17 @@ func uint32Value(v *uint32) uint32 {
 	return 0
 }
 
+func verdictPtr(v Verdict) *Verdict {
+	return &v
+}
+
+func verdictValue(v *Verdict) Verdict {
+	if v != nil {
+		return *v
+	}
+	return 0
+}
+
 func uint64Ptr(v uint64) *uint64 {
 	return &v
 }
@@ -110,12 +110,16 @@ type Ematch struct {
 }
 
 // EmatchTreeHdr from tcf_ematch_tree_hdr in include/uapi/linux/pkt_cls.h
+//
+//structgen:binary
 type EmatchTreeHdr struct {
 	NMatches uint16
 	ProgID   uint16
 }
 
 // EmatchHdr from tcf_ematch_hdr in include/uapi/linux/pkt_cls.h
+//
+//structgen:binary
 type EmatchHdr struct {
 	MatchID uint16
 	Kind    EmatchKind
@@ -133,6 +137,9 @@ type EmatchMatch struct {
 	ContainerMatch *ContainerMatch
 	NByteMatch     *NByteMatch
 	MetaMatch      *MetaMatch
+	TextMatch      *TextMatch
+	VLanMatch      *VLanMatch
+	CanIDMatch     *CanIDMatch
 }
 
 // unmarshalEmatch parses the Ematch-encoded data and stores the result in the value pointed to by info.
@@ -195,6 +202,9 @@ func unmarshalEmatchTreeList(data []byte, info *[]EmatchMatch) error {
 	for ad.Next() {
 		match := EmatchMatch{}
 		tmp := ad.Bytes()
+		if len(tmp) < 8 {
+			return fmt.Errorf("unmarshalEmatchTreeList(): short match header: %w", ErrInvalidArg)
+		}
 		if err := unmarshalStruct(tmp[:8], &match.Hdr); err != nil {
 			return err
 		}
@@ -234,6 +244,21 @@ func unmarshalEmatchTreeList(data []byte, info *[]EmatchMatch) error {
 			err := unmarshalMetaMatch(tmp[8:], expr)
 			multiError = concatError(multiError, err)
 			match.MetaMatch = expr
+		case EmatchText:
+			expr := &TextMatch{}
+			err := unmarshalTextMatch(tmp[8:], expr)
+			multiError = concatError(multiError, err)
+			match.TextMatch = expr
+		case EmatchVLan:
+			expr := &VLanMatch{}
+			err := unmarshalVLanMatch(tmp[8:], expr)
+			multiError = concatError(multiError, err)
+			match.VLanMatch = expr
+		case EmatchCanID:
+			expr := &CanIDMatch{}
+			err := unmarshalCanIDMatch(tmp[8:], expr)
+			multiError = concatError(multiError, err)
+			match.CanIDMatch = expr
 		default:
 			return fmt.Errorf("unmarshalEmatchTreeList() kind %d is not yet implemented", match.Hdr.Kind)
 		}
@@ -246,7 +271,7 @@ func marshalEmatchTreeList(info *[]EmatchMatch) ([]byte, error) {
 	options := []tcOption{}
 
 	for i, m := range *info {
-		payload, err := marshalStruct(m.Hdr)
+		payload, err := marshalStruct(&m.Hdr)
 		if err != nil {
 			return []byte{}, err
 		}
@@ -266,6 +291,12 @@ func marshalEmatchTreeList(info *[]EmatchMatch) ([]byte, error) {
 			expr, err = marshalNByteMatch(m.NByteMatch)
 		case EmatchMeta:
 			expr, err = marshalMetaMatch(m.MetaMatch)
+		case EmatchText:
+			expr, err = marshalTextMatch(m.TextMatch)
+		case EmatchVLan:
+			expr, err = marshalVLanMatch(m.VLanMatch)
+		case EmatchCanID:
+			expr, err = marshalCanIDMatch(m.CanIDMatch)
 		default:
 			return []byte{}, fmt.Errorf("marshalEmatchTreeList() kind %d is not yet implemented", m.Hdr.Kind)
 		}
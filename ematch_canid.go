@@ -0,0 +1,73 @@
+package tc
+
+import (
+	"fmt"
+)
+
+// Flags and masks of CAN identifiers from include/uapi/linux/can.h
+const (
+	CanEFFFlag uint32 = 0x80000000
+	CanRTRFlag uint32 = 0x40000000
+	CanErrFlag uint32 = 0x20000000
+
+	CanSFFMask uint32 = 0x000007FF
+	CanEFFMask uint32 = 0x1FFFFFFF
+)
+
+// EM_CAN_RULES_MAX from net/sched/em_canid.c
+const canIDMatchRulesMax = 500
+
+// CanFilter from include/uapi/linux/can.h
+//
+//structgen:binary
+type CanFilter struct {
+	ID   uint32
+	Mask uint32
+}
+
+// CanIDMatch contains attributes of the can id match discipline
+type CanIDMatch struct {
+	Rules []CanFilter
+}
+
+// CanSFFRule returns a rule, that matches CAN frames with the standard frame format
+// identifier id, where mask selects the relevant bits of the identifier.
+func CanSFFRule(id, mask uint32) CanFilter {
+	return CanFilter{
+		ID:   id & CanSFFMask,
+		Mask: (mask & CanSFFMask) | CanEFFFlag | CanRTRFlag,
+	}
+}
+
+// CanEFFRule returns a rule, that matches CAN frames with the extended frame format
+// identifier id, where mask selects the relevant bits of the identifier.
+func CanEFFRule(id, mask uint32) CanFilter {
+	return CanFilter{
+		ID:   (id & CanEFFMask) | CanEFFFlag,
+		Mask: (mask & CanEFFMask) | CanEFFFlag | CanRTRFlag,
+	}
+}
+
+func unmarshalCanIDMatch(data []byte, info *CanIDMatch) error {
+	if len(data)%8 != 0 {
+		return fmt.Errorf("unmarshalCanIDMatch: unexpected length %d: %w", len(data), ErrInvalidArg)
+	}
+	info.Rules = []CanFilter{}
+	for i := 0; i+8 <= len(data); i += 8 {
+		info.Rules = append(info.Rules, CanFilter{
+			ID:   nativeEndian.Uint32(data[i : i+4]),
+			Mask: nativeEndian.Uint32(data[i+4 : i+8]),
+		})
+	}
+	return nil
+}
+
+func marshalCanIDMatch(info *CanIDMatch) ([]byte, error) {
+	if info == nil {
+		return []byte{}, fmt.Errorf("marshalCanIDMatch: %w", ErrNoArg)
+	}
+	if len(info.Rules) == 0 || len(info.Rules) > canIDMatchRulesMax {
+		return []byte{}, fmt.Errorf("marshalCanIDMatch: %d rules: %w", len(info.Rules), ErrInvalidArg)
+	}
+	return marshalStruct(info.Rules)
+}
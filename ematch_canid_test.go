@@ -0,0 +1,66 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCanIDMatch(t *testing.T) {
+	tests := map[string]struct {
+		val  CanIDMatch
+		err1 error
+		err2 error
+	}{
+		"sff and eff": {
+			val: CanIDMatch{Rules: []CanFilter{
+				CanSFFRule(0x123, CanSFFMask),
+				CanEFFRule(0x1234567, 0x1FFFFF00),
+			}},
+		},
+		"empty": {err1: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err1 := marshalCanIDMatch(&testcase.val)
+			if !errors.Is(err1, testcase.err1) {
+				t.Fatalf("Unexpected error: %v", err1)
+			}
+			if err1 != nil {
+				return
+			}
+			val := CanIDMatch{}
+			err2 := unmarshalCanIDMatch(data, &val)
+			if !errors.Is(err2, testcase.err2) {
+				t.Fatalf("Unexpected error: %v", err2)
+			}
+			if diff := cmp.Diff(val, testcase.val); diff != "" {
+				t.Fatalf("CanIDMatch missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+	t.Run("nil", func(t *testing.T) {
+		_, err := marshalCanIDMatch(nil)
+		if !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("rules", func(t *testing.T) {
+		sff := CanSFFRule(0xFFF, 0x7F0)
+		if sff.ID != 0x7FF || sff.Mask != 0x7F0|CanEFFFlag|CanRTRFlag {
+			t.Fatalf("unexpected SFF rule: %#v", sff)
+		}
+		eff := CanEFFRule(0x1, CanEFFMask)
+		if eff.ID != 0x1|CanEFFFlag || eff.Mask != CanEFFMask|CanEFFFlag|CanRTRFlag {
+			t.Fatalf("unexpected EFF rule: %#v", eff)
+		}
+	})
+	t.Run("invalid length", func(t *testing.T) {
+		info := CanIDMatch{}
+		if err := unmarshalCanIDMatch([]byte{0x1, 0x2, 0x3}, &info); !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
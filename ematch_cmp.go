@@ -19,6 +19,8 @@ const (
 )
 
 // CmpMatch contains attributes of the cmp match discipline
+//
+//structgen:binary
 type CmpMatch struct {
 	Val   uint32
 	Mask  uint32
@@ -29,6 +31,7 @@ type CmpMatch struct {
 	Opnd  EmatchOpnd
 }
 
+//structgen:binary
 type cmpMatch struct {
 	Val  uint32
 	Mask uint32
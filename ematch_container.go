@@ -1,6 +1,8 @@
 package tc
 
 // ContainerMatch contains attributes of the container match discipline
+//
+//structgen:binary
 type ContainerMatch struct {
 	Pos uint32
 }
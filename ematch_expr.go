@@ -0,0 +1,916 @@
+package tc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TCF_EM_PROG_TC from include/uapi/linux/pkt_cls.h
+const ematchProgTC = 2
+
+// Meta types from include/uapi/linux/tc_ematch/tc_em_meta.h
+const (
+	metaTypeVar = 0
+	metaTypeInt = 1
+)
+
+// metaIntIDs maps the names of integer meta objects, as used by tc-ematch(8),
+// to their TCF_META_ID_* from include/uapi/linux/tc_ematch/tc_em_meta.h
+var metaIntIDs = map[string]uint16{
+	"random":         1,
+	"loadavg_0":      2,
+	"loadavg_1":      3,
+	"loadavg_2":      4,
+	"priority":       6,
+	"protocol":       7,
+	"pkt_type":       8,
+	"pkt_len":        9,
+	"data_len":       10,
+	"mac_len":        11,
+	"nf_mark":        12,
+	"tc_index":       13,
+	"rt_classid":     14,
+	"rt_iif":         15,
+	"sk_family":      16,
+	"sk_state":       17,
+	"sk_reuse":       18,
+	"sk_bound_if":    19,
+	"sk_refcnt":      20,
+	"sk_shutdown":    21,
+	"sk_proto":       22,
+	"sk_type":        23,
+	"sk_rcvbuf":      24,
+	"sk_rmem_alloc":  25,
+	"sk_wmem_alloc":  26,
+	"sk_omem_alloc":  27,
+	"sk_wmem_queued": 28,
+	"sk_rcv_qlen":    29,
+	"sk_snd_qlen":    30,
+	"sk_err_qlen":    31,
+	"sk_fwd_alloc":   32,
+	"sk_sndbuf":      33,
+	"sk_allocs":      34,
+	"sk_hash":        36,
+	"sk_lingertime":  37,
+	"sk_ack_bl":      38,
+	"sk_max_ack_bl":  39,
+	"sk_prio":        40,
+	"sk_rcvlowat":    41,
+	"sk_rcvtimeo":    42,
+	"sk_sndtimeo":    43,
+	"sk_sendmsg_off": 44,
+	"sk_write_pend":  45,
+	"vlan":           46,
+	"rxhash":         47,
+}
+
+var ematchLayerNames = map[string]EmatchLayer{
+	"link":      EmatchLayerLink,
+	"network":   EmatchLayerNetwork,
+	"transport": EmatchLayerTransport,
+}
+
+var ematchOpndNames = map[string]EmatchOpnd{
+	"eq": EmatchOpndEq,
+	"gt": EmatchOpndGt,
+	"lt": EmatchOpndLt,
+}
+
+type ematchTokenKind int
+
+const (
+	ematchTokenEOF ematchTokenKind = iota
+	ematchTokenWord
+	ematchTokenString
+	ematchTokenLParen
+	ematchTokenRParen
+)
+
+type ematchToken struct {
+	kind ematchTokenKind
+	val  string
+}
+
+func (t ematchToken) String() string {
+	switch t.kind {
+	case ematchTokenEOF:
+		return "end of expression"
+	case ematchTokenLParen:
+		return "'('"
+	case ematchTokenRParen:
+		return "')'"
+	case ematchTokenString:
+		return strconv.Quote(t.val)
+	}
+	return fmt.Sprintf("'%s'", t.val)
+}
+
+func tokenizeEmatch(expr string) ([]ematchToken, error) {
+	var tokens []ematchToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, ematchToken{kind: ematchTokenLParen})
+			i++
+		case c == ')':
+			tokens = append(tokens, ematchToken{kind: ematchTokenRParen})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(expr) && expr[end] != '"'; end++ {
+				if expr[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at %d: %w", i, ErrInvalidArg)
+			}
+			val, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", i, ErrInvalidArg)
+			}
+			tokens = append(tokens, ematchToken{kind: ematchTokenString, val: val})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(expr) && !strings.ContainsRune(" \t\n()\"", rune(expr[end])); end++ {
+			}
+			tokens = append(tokens, ematchToken{kind: ematchTokenWord, val: expr[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// ematchNode is a term of a parsed ematch expression. It is either a single match
+// or a nested sequence of terms.
+type ematchNode struct {
+	invert bool
+	rel    uint16
+	match  *EmatchMatch
+	sub    []ematchNode
+}
+
+type ematchParser struct {
+	tokens []ematchToken
+	pos    int
+}
+
+func (p *ematchParser) peek() ematchToken {
+	if p.pos >= len(p.tokens) {
+		return ematchToken{kind: ematchTokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *ematchParser) next() ematchToken {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *ematchParser) parseSequence() ([]ematchNode, error) {
+	var seq []ematchNode
+	for {
+		node, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		t := p.peek()
+		if t.kind == ematchTokenWord && (t.val == "and" || t.val == "or") {
+			p.next()
+			node.rel = EmatchRelAnd
+			if t.val == "or" {
+				node.rel = EmatchRelOr
+			}
+			seq = append(seq, node)
+			continue
+		}
+		seq = append(seq, node)
+		return seq, nil
+	}
+}
+
+func (p *ematchParser) parseTerm() (ematchNode, error) {
+	var node ematchNode
+	for t := p.peek(); t.kind == ematchTokenWord && t.val == "not"; t = p.peek() {
+		p.next()
+		node.invert = !node.invert
+	}
+	t := p.next()
+	switch t.kind {
+	case ematchTokenLParen:
+		sub, err := p.parseSequence()
+		if err != nil {
+			return node, err
+		}
+		if t := p.next(); t.kind != ematchTokenRParen {
+			return node, fmt.Errorf("expected ')' but got %v: %w", t, ErrInvalidArg)
+		}
+		node.sub = sub
+		return node, nil
+	case ematchTokenWord:
+		if open := p.next(); open.kind != ematchTokenLParen {
+			return node, fmt.Errorf("expected '(' after %s but got %v: %w", t.val, open, ErrInvalidArg)
+		}
+		var args []ematchToken
+		for a := p.next(); a.kind != ematchTokenRParen; a = p.next() {
+			if a.kind == ematchTokenEOF || a.kind == ematchTokenLParen {
+				return node, fmt.Errorf("unexpected %v in %s(): %w", a, t.val, ErrInvalidArg)
+			}
+			args = append(args, a)
+		}
+		match, err := parseEmatchModule(t.val, args)
+		if err != nil {
+			return node, fmt.Errorf("%s(): %w", t.val, err)
+		}
+		node.match = match
+		return node, nil
+	}
+	return node, fmt.Errorf("unexpected %v: %w", t, ErrInvalidArg)
+}
+
+// ParseEmatch compiles an ematch expression in the syntax of tc-ematch(8), e.g.
+// "cmp(u16 at 0 layer 2 mask 0xff00 gt 41) and not meta(nf_mark eq 42)", into an Ematch.
+// Supported modules are cmp, u32, nbyte, meta, ipset, text and canid. Sets of the ipset
+// module are referenced by their numeric index.
+func ParseEmatch(expr string) (*Ematch, error) {
+	tokens, err := tokenizeEmatch(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression: %w", ErrNoArg)
+	}
+	p := &ematchParser{tokens: tokens}
+	seq, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != ematchTokenEOF {
+		return nil, fmt.Errorf("unexpected %v: %w", t, ErrInvalidArg)
+	}
+
+	matches := []EmatchMatch{}
+	emitEmatchSequence(seq, &matches)
+	if len(matches) > 0xFFFF {
+		return nil, fmt.Errorf("too many matches: %w", ErrInvalidArg)
+	}
+	return &Ematch{
+		Hdr:     &EmatchTreeHdr{NMatches: uint16(len(matches)), ProgID: ematchProgTC},
+		Matches: &matches,
+	}, nil
+}
+
+// emitEmatchSequence appends the sequence to matches. Nested sequences are
+// appended after the sequence, so containers always reference forward.
+func emitEmatchSequence(seq []ematchNode, matches *[]EmatchMatch) {
+	start := len(*matches)
+	for _, node := range seq {
+		m := EmatchMatch{}
+		if node.match != nil {
+			m = *node.match
+		}
+		m.Hdr.Flags = node.rel
+		if node.invert {
+			m.Hdr.Flags |= EmatchInvert
+		}
+		*matches = append(*matches, m)
+	}
+	for i, node := range seq {
+		if node.sub == nil {
+			continue
+		}
+		pos := len(*matches)
+		emitEmatchSequence(node.sub, matches)
+		(*matches)[start+i].Hdr.Kind = EmatchContainer
+		(*matches)[start+i].ContainerMatch = &ContainerMatch{Pos: uint32(pos)}
+	}
+}
+
+// ematchArgs helps to parse the arguments of an ematch module.
+type ematchArgs struct {
+	tokens []ematchToken
+	pos    int
+}
+
+func (a *ematchArgs) more() bool {
+	return a.pos < len(a.tokens)
+}
+
+func (a *ematchArgs) word() (string, error) {
+	if !a.more() {
+		return "", fmt.Errorf("missing argument: %w", ErrInvalidArg)
+	}
+	t := a.tokens[a.pos]
+	a.pos++
+	if t.kind != ematchTokenWord {
+		return "", fmt.Errorf("unexpected %v: %w", t, ErrInvalidArg)
+	}
+	return t.val, nil
+}
+
+func (a *ematchArgs) str() (string, error) {
+	if !a.more() {
+		return "", fmt.Errorf("missing argument: %w", ErrInvalidArg)
+	}
+	t := a.tokens[a.pos]
+	a.pos++
+	if t.kind != ematchTokenString && t.kind != ematchTokenWord {
+		return "", fmt.Errorf("unexpected %v: %w", t, ErrInvalidArg)
+	}
+	return t.val, nil
+}
+
+func (a *ematchArgs) keyword(kw string) error {
+	w, err := a.word()
+	if err != nil {
+		return err
+	}
+	if w != kw {
+		return fmt.Errorf("expected '%s' but got '%s': %w", kw, w, ErrInvalidArg)
+	}
+	return nil
+}
+
+func (a *ematchArgs) uint(bitSize int) (uint64, error) {
+	w, err := a.word()
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(w, 0, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s': %w", w, ErrInvalidArg)
+	}
+	return v, nil
+}
+
+func (a *ematchArgs) layer() (EmatchLayer, error) {
+	w, err := a.word()
+	if err != nil {
+		return 0, err
+	}
+	if l, ok := ematchLayerNames[w]; ok {
+		return l, nil
+	}
+	v, err := strconv.ParseUint(w, 0, 4)
+	if err != nil || v > uint64(EmatchLayerTransport) {
+		return 0, fmt.Errorf("invalid layer '%s': %w", w, ErrInvalidArg)
+	}
+	return EmatchLayer(v), nil
+}
+
+func parseEmatchAlign(w string) (CmpMatchAlign, error) {
+	switch w {
+	case "u8":
+		return CmpMatchU8, nil
+	case "u16":
+		return CmpMatchU16, nil
+	case "u32":
+		return CmpMatchU32, nil
+	}
+	return 0, fmt.Errorf("invalid alignment '%s': %w", w, ErrInvalidArg)
+}
+
+func parseEmatchModule(kind string, tokens []ematchToken) (*EmatchMatch, error) {
+	args := &ematchArgs{tokens: tokens}
+	var m *EmatchMatch
+	var err error
+	switch kind {
+	case "cmp":
+		m, err = parseCmpExpr(args)
+	case "u32":
+		m, err = parseU32Expr(args)
+	case "nbyte":
+		m, err = parseNByteExpr(args)
+	case "meta":
+		m, err = parseMetaExpr(args)
+	case "ipset":
+		m, err = parseIPSetExpr(args)
+	case "text":
+		m, err = parseTextExpr(args)
+	case "canid":
+		m, err = parseCanIDExpr(args)
+	default:
+		return nil, fmt.Errorf("unknown module: %w", ErrNotImplemented)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if args.more() {
+		return nil, fmt.Errorf("unexpected %v: %w", args.tokens[args.pos], ErrInvalidArg)
+	}
+	return m, nil
+}
+
+// cmp(ALIGN at OFFSET [ layer LAYER ] [ mask MASK ] [ trans ] OPERAND VALUE)
+func parseCmpExpr(args *ematchArgs) (*EmatchMatch, error) {
+	info := &CmpMatch{}
+	w, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	if info.Align, err = parseEmatchAlign(w); err != nil {
+		return nil, err
+	}
+	if err := args.keyword("at"); err != nil {
+		return nil, err
+	}
+	off, err := args.uint(16)
+	if err != nil {
+		return nil, err
+	}
+	info.Off = uint16(off)
+	limit := uint64(1)<<(8*uint(info.Align)) - 1
+	for {
+		w, err := args.word()
+		if err != nil {
+			return nil, err
+		}
+		switch w {
+		case "layer":
+			if info.Layer, err = args.layer(); err != nil {
+				return nil, err
+			}
+			continue
+		case "mask":
+			mask, err := args.uint(32)
+			if err != nil {
+				return nil, err
+			}
+			if mask > limit {
+				return nil, fmt.Errorf("mask 0x%x exceeds alignment: %w", mask, ErrInvalidArg)
+			}
+			info.Mask = uint32(mask)
+			continue
+		case "trans":
+			info.Flags |= CmpMatchTrans
+			continue
+		}
+		opnd, ok := ematchOpndNames[w]
+		if !ok {
+			return nil, fmt.Errorf("unexpected '%s': %w", w, ErrInvalidArg)
+		}
+		info.Opnd = opnd
+		val, err := args.uint(32)
+		if err != nil {
+			return nil, err
+		}
+		if val > limit {
+			return nil, fmt.Errorf("value 0x%x exceeds alignment: %w", val, ErrInvalidArg)
+		}
+		info.Val = uint32(val)
+		return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchCmp}, CmpMatch: info}, nil
+	}
+}
+
+// u32(ALIGN VALUE MASK at [ nexthdr+ ] OFFSET)
+func parseU32Expr(args *ematchArgs) (*EmatchMatch, error) {
+	w, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	align, err := parseEmatchAlign(w)
+	if err != nil {
+		return nil, err
+	}
+	val, err := args.uint(32)
+	if err != nil {
+		return nil, err
+	}
+	mask, err := args.uint(32)
+	if err != nil {
+		return nil, err
+	}
+	limit := uint64(1)<<(8*uint(align)) - 1
+	if val > limit || mask > limit {
+		return nil, fmt.Errorf("value or mask exceeds alignment: %w", ErrInvalidArg)
+	}
+	if err := args.keyword("at"); err != nil {
+		return nil, err
+	}
+	at, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	info := &U32Match{}
+	if strings.HasPrefix(at, "nexthdr+") {
+		at = strings.TrimPrefix(at, "nexthdr+")
+		info.OffMask = 0xFFFFFFFF
+	}
+	off, err := strconv.ParseInt(at, 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid offset '%s': %w", at, ErrInvalidArg)
+	}
+	if off%int64(align) != 0 {
+		return nil, fmt.Errorf("offset %d is not aligned to %d: %w", off, align, ErrInvalidArg)
+	}
+	// The kernel compares the 32-bit word which contains the given offset.
+	shift := uint(4-int64(align)-(off&3)) * 8
+	info.Mask = ematchHtonl(uint32(mask) << shift)
+	info.Value = ematchHtonl(uint32(val&mask) << shift)
+	info.Off = int32(off &^ 3)
+	return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchU32}, U32Match: info}, nil
+}
+
+// nbyte(NEEDLE at OFFSET [ layer LAYER ])
+func parseNByteExpr(args *ematchArgs) (*EmatchMatch, error) {
+	needle, err := args.str()
+	if err != nil {
+		return nil, err
+	}
+	if len(needle) == 0 {
+		return nil, fmt.Errorf("empty needle: %w", ErrInvalidArg)
+	}
+	if err := args.keyword("at"); err != nil {
+		return nil, err
+	}
+	off, err := args.uint(16)
+	if err != nil {
+		return nil, err
+	}
+	info := &NByteMatch{Needle: []byte(needle), Offset: uint16(off)}
+	if args.more() {
+		if err := args.keyword("layer"); err != nil {
+			return nil, err
+		}
+		layer, err := args.layer()
+		if err != nil {
+			return nil, err
+		}
+		info.Layer = uint8(layer)
+	}
+	return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchNByte}, NByteMatch: info}, nil
+}
+
+// meta(OBJECT [ shift SHIFT ] [ mask MASK ] OPERAND { VALUE | OBJECT })
+func parseMetaExpr(args *ematchArgs) (*EmatchMatch, error) {
+	name, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	id, ok := metaIntIDs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown meta object '%s': %w", name, ErrNotImplemented)
+	}
+	info := &MetaMatch{Hdr: &MetaHdr{}}
+	info.Hdr.Left.Kind = metaTypeInt<<12 | id
+	for {
+		w, err := args.word()
+		if err != nil {
+			return nil, err
+		}
+		switch w {
+		case "shift":
+			shift, err := args.uint(8)
+			if err != nil {
+				return nil, err
+			}
+			info.Hdr.Left.Shift = uint8(shift)
+			continue
+		case "mask":
+			mask, err := args.uint(32)
+			if err != nil {
+				return nil, err
+			}
+			info.Left = &MetaValueType{Int: uint32Ptr(uint32(mask))}
+			continue
+		}
+		opnd, ok := ematchOpndNames[w]
+		if !ok {
+			return nil, fmt.Errorf("unexpected '%s': %w", w, ErrInvalidArg)
+		}
+		info.Hdr.Left.Op = uint8(opnd)
+		break
+	}
+	right, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := metaIntIDs[right]; ok {
+		info.Hdr.Right.Kind = metaTypeInt<<12 | id
+	} else {
+		val, err := strconv.ParseUint(right, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s': %w", right, ErrInvalidArg)
+		}
+		info.Hdr.Right.Kind = metaTypeInt << 12
+		info.Right = &MetaValueType{Int: uint32Ptr(uint32(val))}
+	}
+	return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchMeta}, MetaMatch: info}, nil
+}
+
+// ipset(INDEX DIR[,DIR...])
+func parseIPSetExpr(args *ematchArgs) (*EmatchMatch, error) {
+	id, err := args.uint(16)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	info := &IPSetMatch{IPSetID: uint16(id)}
+	for _, dir := range strings.Split(dirs, ",") {
+		switch dir {
+		case "src":
+			info.Dir = append(info.Dir, IPSetSrc)
+		case "dst":
+			info.Dir = append(info.Dir, IPSetDst)
+		default:
+			return nil, fmt.Errorf("invalid direction '%s': %w", dir, ErrInvalidArg)
+		}
+	}
+	if len(info.Dir) > 3 {
+		return nil, fmt.Errorf("too many directions: %w", ErrInvalidArg)
+	}
+	return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchIPSet}, IPSetMatch: info}, nil
+}
+
+// text(ALGO PATTERN [ from OFFSET [ layer LAYER ] ] [ to OFFSET [ layer LAYER ] ])
+func parseTextExpr(args *ematchArgs) (*EmatchMatch, error) {
+	algo, err := args.word()
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := args.str()
+	if err != nil {
+		return nil, err
+	}
+	info := &TextMatch{Algo: TextMatchAlgo(algo), Pattern: []byte(pattern), ToOffset: 0xFFFF}
+	for args.more() {
+		w, err := args.word()
+		if err != nil {
+			return nil, err
+		}
+		off, err := args.uint(16)
+		if err != nil {
+			return nil, err
+		}
+		var layer EmatchLayer
+		if args.more() && args.tokens[args.pos].val == "layer" {
+			args.pos++
+			if layer, err = args.layer(); err != nil {
+				return nil, err
+			}
+		}
+		switch w {
+		case "from":
+			info.FromOffset, info.FromLayer = uint16(off), layer
+		case "to":
+			info.ToOffset, info.ToLayer = uint16(off), layer
+		default:
+			return nil, fmt.Errorf("unexpected '%s': %w", w, ErrInvalidArg)
+		}
+	}
+	return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchText}, TextMatch: info}, nil
+}
+
+// canid({ sff | eff } ID[:MASK] ...)
+func parseCanIDExpr(args *ematchArgs) (*EmatchMatch, error) {
+	info := &CanIDMatch{}
+	for args.more() {
+		format, err := args.word()
+		if err != nil {
+			return nil, err
+		}
+		rule, err := args.word()
+		if err != nil {
+			return nil, err
+		}
+		idStr, maskStr, hasMask := strings.Cut(rule, ":")
+		id, err := strconv.ParseUint(idStr, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id '%s': %w", idStr, ErrInvalidArg)
+		}
+		var mask uint64
+		if hasMask {
+			if mask, err = strconv.ParseUint(maskStr, 0, 32); err != nil {
+				return nil, fmt.Errorf("invalid mask '%s': %w", maskStr, ErrInvalidArg)
+			}
+		}
+		switch format {
+		case "sff":
+			if id > uint64(CanSFFMask) {
+				return nil, fmt.Errorf("sff id 0x%x: %w", id, ErrInvalidArg)
+			}
+			if !hasMask {
+				mask = uint64(CanSFFMask)
+			}
+			info.Rules = append(info.Rules, CanSFFRule(uint32(id), uint32(mask)))
+		case "eff":
+			if id > uint64(CanEFFMask) {
+				return nil, fmt.Errorf("eff id 0x%x: %w", id, ErrInvalidArg)
+			}
+			if !hasMask {
+				mask = uint64(CanEFFMask)
+			}
+			info.Rules = append(info.Rules, CanEFFRule(uint32(id), uint32(mask)))
+		default:
+			return nil, fmt.Errorf("unexpected '%s': %w", format, ErrInvalidArg)
+		}
+	}
+	if len(info.Rules) == 0 {
+		return nil, fmt.Errorf("missing rules: %w", ErrInvalidArg)
+	}
+	return &EmatchMatch{Hdr: EmatchHdr{Kind: EmatchCanID}, CanIDMatch: info}, nil
+}
+
+// FormatEmatch returns the expression of info in the syntax of tc-ematch(8).
+// It can be used to print the Ematch of dumped basic and flow filters.
+func FormatEmatch(info *Ematch) (string, error) {
+	if info == nil || info.Matches == nil || len(*info.Matches) == 0 {
+		return "", ErrNoArg
+	}
+	return formatEmatchSequence(*info.Matches, 0)
+}
+
+func formatEmatchSequence(matches []EmatchMatch, start int) (string, error) {
+	var sb strings.Builder
+	for i := start; ; i++ {
+		if i >= len(matches) {
+			return "", fmt.Errorf("sequence starting at %d is not terminated: %w", start, ErrInvalidArg)
+		}
+		m := matches[i]
+		if m.Hdr.Flags&EmatchInvert != 0 {
+			sb.WriteString("not ")
+		}
+		if m.Hdr.Kind == EmatchContainer {
+			if m.ContainerMatch == nil {
+				return "", fmt.Errorf("container at %d: %w", i, ErrNoArg)
+			}
+			pos := int(m.ContainerMatch.Pos)
+			// The kernel accepts only forward references.
+			if pos <= i {
+				return "", fmt.Errorf("container at %d references %d: %w", i, pos, ErrInvalidArg)
+			}
+			sub, err := formatEmatchSequence(matches, pos)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("(" + sub + ")")
+		} else {
+			expr, err := formatEmatchMatch(m)
+			if err != nil {
+				return "", fmt.Errorf("match at %d: %w", i, err)
+			}
+			sb.WriteString(expr)
+		}
+		switch {
+		case m.Hdr.Flags&EmatchRelAnd != 0:
+			sb.WriteString(" and ")
+		case m.Hdr.Flags&EmatchRelOr != 0:
+			sb.WriteString(" or ")
+		default:
+			return sb.String(), nil
+		}
+	}
+}
+
+func formatEmatchAlign(align CmpMatchAlign) string {
+	switch align {
+	case CmpMatchU8:
+		return "u8"
+	case CmpMatchU16:
+		return "u16"
+	}
+	return "u32"
+}
+
+func formatEmatchOpnd(opnd EmatchOpnd) string {
+	for name, v := range ematchOpndNames {
+		if v == opnd {
+			return name
+		}
+	}
+	return strconv.Itoa(int(opnd))
+}
+
+func formatMetaObject(kind uint16) string {
+	id := kind & 0xFFF
+	for name, v := range metaIntIDs {
+		if v == id {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%x", kind)
+}
+
+func formatEmatchMatch(m EmatchMatch) (string, error) {
+	switch m.Hdr.Kind {
+	case EmatchCmp:
+		if m.CmpMatch == nil {
+			return "", ErrNoArg
+		}
+		c := m.CmpMatch
+		expr := fmt.Sprintf("cmp(%s at %d layer %d", formatEmatchAlign(c.Align), c.Off, c.Layer)
+		if c.Mask != 0 {
+			expr += fmt.Sprintf(" mask 0x%x", c.Mask)
+		}
+		if c.Flags&CmpMatchTrans != 0 {
+			expr += " trans"
+		}
+		return expr + fmt.Sprintf(" %s %d)", formatEmatchOpnd(c.Opnd), c.Val), nil
+	case EmatchU32:
+		if m.U32Match == nil {
+			return "", ErrNoArg
+		}
+		u := m.U32Match
+		at := strconv.Itoa(int(u.Off))
+		if u.OffMask != 0 {
+			at = "nexthdr+" + at
+		}
+		return fmt.Sprintf("u32(u32 0x%08x 0x%08x at %s)", ematchNtohl(u.Value), ematchNtohl(u.Mask), at), nil
+	case EmatchNByte:
+		if m.NByteMatch == nil {
+			return "", ErrNoArg
+		}
+		n := m.NByteMatch
+		return fmt.Sprintf("nbyte(%s at %d layer %d)", strconv.Quote(string(n.Needle)), n.Offset, n.Layer), nil
+	case EmatchMeta:
+		if m.MetaMatch == nil || m.MetaMatch.Hdr == nil {
+			return "", ErrNoArg
+		}
+		meta := m.MetaMatch
+		if meta.Hdr.Left.Kind>>12 != metaTypeInt || meta.Hdr.Right.Kind>>12 != metaTypeInt {
+			return "", fmt.Errorf("meta: only integer objects are supported: %w", ErrNotImplemented)
+		}
+		expr := "meta(" + formatMetaObject(meta.Hdr.Left.Kind)
+		if meta.Hdr.Left.Shift != 0 {
+			expr += fmt.Sprintf(" shift %d", meta.Hdr.Left.Shift)
+		}
+		if meta.Left != nil && meta.Left.Int != nil {
+			expr += fmt.Sprintf(" mask 0x%x", *meta.Left.Int)
+		}
+		expr += " " + formatEmatchOpnd(EmatchOpnd(meta.Hdr.Left.Op)) + " "
+		if meta.Hdr.Right.Kind&0xFFF == 0 {
+			var val uint32
+			if meta.Right != nil && meta.Right.Int != nil {
+				val = *meta.Right.Int
+			}
+			return expr + fmt.Sprintf("%d)", val), nil
+		}
+		return expr + formatMetaObject(meta.Hdr.Right.Kind) + ")", nil
+	case EmatchIPSet:
+		if m.IPSetMatch == nil {
+			return "", ErrNoArg
+		}
+		var dirs []string
+		for _, dir := range m.IPSetMatch.Dir {
+			if dir == IPSetSrc {
+				dirs = append(dirs, "src")
+			} else {
+				dirs = append(dirs, "dst")
+			}
+		}
+		return fmt.Sprintf("ipset(%d %s)", m.IPSetMatch.IPSetID, strings.Join(dirs, ",")), nil
+	case EmatchText:
+		if m.TextMatch == nil {
+			return "", ErrNoArg
+		}
+		t := m.TextMatch
+		expr := fmt.Sprintf("text(%s %s", t.Algo, strconv.Quote(string(t.Pattern)))
+		if t.FromOffset != 0 || t.FromLayer != 0 {
+			expr += fmt.Sprintf(" from %d layer %d", t.FromOffset, t.FromLayer)
+		}
+		if t.ToOffset != 0xFFFF || t.ToLayer != 0 {
+			expr += fmt.Sprintf(" to %d layer %d", t.ToOffset, t.ToLayer)
+		}
+		return expr + ")", nil
+	case EmatchCanID:
+		if m.CanIDMatch == nil {
+			return "", ErrNoArg
+		}
+		var rules []string
+		for _, r := range m.CanIDMatch.Rules {
+			if r.ID&CanEFFFlag != 0 {
+				rules = append(rules, fmt.Sprintf("eff 0x%x:0x%x", r.ID&CanEFFMask, r.Mask&CanEFFMask))
+			} else {
+				rules = append(rules, fmt.Sprintf("sff 0x%x:0x%x", r.ID&CanSFFMask, r.Mask&CanSFFMask))
+			}
+		}
+		return "canid(" + strings.Join(rules, " ") + ")", nil
+	}
+	return "", fmt.Errorf("kind %d: %w", m.Hdr.Kind, ErrNotImplemented)
+}
+
+// ematchHtonl converts v into the representation of a be32 in U32Match.
+func ematchHtonl(v uint32) uint32 {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return nativeEndian.Uint32(b[:])
+}
+
+// ematchNtohl converts a be32 of U32Match into its value in host byte order.
+func ematchNtohl(v uint32) uint32 {
+	var b [4]byte
+	nativeEndian.PutUint32(b[:], v)
+	return binary.BigEndian.Uint32(b[:])
+}
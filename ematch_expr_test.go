@@ -0,0 +1,139 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEmatch(t *testing.T) {
+	tests := map[string]struct {
+		expr string
+		val  *Ematch
+		err  error
+	}{
+		"cmp": {
+			expr: "cmp(u16 at 0 layer 2 mask 0xff00 gt 41)",
+			val: &Ematch{
+				Hdr: &EmatchTreeHdr{NMatches: 1, ProgID: 2},
+				Matches: &[]EmatchMatch{
+					{Hdr: EmatchHdr{Kind: EmatchCmp}, CmpMatch: &CmpMatch{Val: 41, Mask: 0xff00, Off: 0,
+						Align: CmpMatchU16, Layer: EmatchLayerTransport, Opnd: EmatchOpndGt}},
+				},
+			},
+		},
+		"and not": {
+			expr: `nbyte("abc" at 4 layer network) and not meta(nf_mark eq 42)`,
+			val: &Ematch{
+				Hdr: &EmatchTreeHdr{NMatches: 2, ProgID: 2},
+				Matches: &[]EmatchMatch{
+					{Hdr: EmatchHdr{Kind: EmatchNByte, Flags: EmatchRelAnd},
+						NByteMatch: &NByteMatch{Needle: []byte("abc"), Offset: 4, Layer: 1}},
+					{Hdr: EmatchHdr{Kind: EmatchMeta, Flags: EmatchInvert},
+						MetaMatch: &MetaMatch{
+							Hdr:   &MetaHdr{Left: MetaValue{Kind: 0x100c}, Right: MetaValue{Kind: 0x1000}},
+							Right: &MetaValueType{Int: uint32Ptr(42)},
+						}},
+				},
+			},
+		},
+		"nested": {
+			expr: "ipset(1 src,dst) or (u32(u16 0x0016 0xffff at nexthdr+2) and canid(sff 0x123))",
+			val: &Ematch{
+				Hdr: &EmatchTreeHdr{NMatches: 4, ProgID: 2},
+				Matches: &[]EmatchMatch{
+					{Hdr: EmatchHdr{Kind: EmatchIPSet, Flags: EmatchRelOr},
+						IPSetMatch: &IPSetMatch{IPSetID: 1, Dir: []IPSetDir{IPSetSrc, IPSetDst}}},
+					{Hdr: EmatchHdr{Kind: EmatchContainer}, ContainerMatch: &ContainerMatch{Pos: 2}},
+					{Hdr: EmatchHdr{Kind: EmatchU32, Flags: EmatchRelAnd},
+						U32Match: &U32Match{Mask: ematchHtonl(0xffff), Value: ematchHtonl(0x16),
+							OffMask: 0xFFFFFFFF}},
+					{Hdr: EmatchHdr{Kind: EmatchCanID},
+						CanIDMatch: &CanIDMatch{Rules: []CanFilter{CanSFFRule(0x123, CanSFFMask)}}},
+				},
+			},
+		},
+		"empty":            {expr: "", err: ErrNoArg},
+		"unknown module":   {expr: "foo(bar)", err: ErrNotImplemented},
+		"unbalanced":       {expr: "(cmp(u8 at 0 eq 1)", err: ErrInvalidArg},
+		"missing operator": {expr: "cmp(u8 at 0 eq 1) cmp(u8 at 0 eq 1)", err: ErrInvalidArg},
+		"value too large":  {expr: "cmp(u8 at 0 eq 256)", err: ErrInvalidArg},
+		"unaligned u32":    {expr: "u32(u16 1 0xffff at 1)", err: ErrInvalidArg},
+		"unterminated":     {expr: `nbyte("abc at 0)`, err: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			val, err := ParseEmatch(testcase.expr)
+			if err != nil {
+				if errors.Is(err, testcase.err) {
+					return
+				}
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testcase.err != nil {
+				t.Fatalf("Expected error %v but got none", testcase.err)
+			}
+			if diff := cmp.Diff(val, testcase.val); diff != "" {
+				t.Fatalf("Ematch missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatEmatch(t *testing.T) {
+	tests := map[string]string{
+		"cmp":    "cmp(u16 at 0 layer 2 mask 0xff00 trans gt 41)",
+		"meta":   "not meta(nf_mark shift 2 mask 0xff lt priority)",
+		"u32":    "u32(u32 0x00160000 0xffff0000 at nexthdr+0)",
+		"nested": `ipset(1 src,dst) or (nbyte("a\"b" at 4 layer 1) and not (meta(vlan eq 3) or text(kmp "foo" from 2 layer 0 to 40 layer 2)))`,
+		"canid":  "canid(sff 0x123:0x7ff eff 0x1234:0x1fffffff)",
+	}
+
+	for name, expr := range tests {
+		t.Run(name, func(t *testing.T) {
+			info, err := ParseEmatch(expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			data, err := marshalEmatch(info)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			decoded := &Ematch{}
+			if err := unmarshalEmatch(data, decoded); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := FormatEmatch(decoded)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(expr, got); diff != "" {
+				t.Fatalf("expression missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("backward reference", func(t *testing.T) {
+		_, err := FormatEmatch(&Ematch{Matches: &[]EmatchMatch{
+			{Hdr: EmatchHdr{Kind: EmatchContainer}, ContainerMatch: &ContainerMatch{Pos: 0}},
+		}})
+		if !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("unterminated", func(t *testing.T) {
+		_, err := FormatEmatch(&Ematch{Matches: &[]EmatchMatch{
+			{Hdr: EmatchHdr{Kind: EmatchIPSet, Flags: EmatchRelAnd}, IPSetMatch: &IPSetMatch{IPSetID: 1, Dir: []IPSetDir{IPSetSrc}}},
+		}})
+		if !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("nil", func(t *testing.T) {
+		if _, err := FormatEmatch(nil); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
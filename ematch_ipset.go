@@ -1,5 +1,7 @@
 package tc
 
+import "fmt"
+
 // IPSetDir defines the packet direction.
 type IPSetDir uint8
 
@@ -15,6 +17,10 @@ type IPSetMatch struct {
 	Dir     []IPSetDir
 }
 
+// ipsetDimMax is IPSET_DIM_MAX from include/uapi/linux/netfilter/ipset/ip_set.h
+const ipsetDimMax = 6
+
+//structgen:binary
 type ipsetMatch struct {
 	ID    uint16
 	Dim   uint8
@@ -27,6 +33,9 @@ func unmarshalIPSetMatch(data []byte, info *IPSetMatch) error {
 		return err
 	}
 
+	if tmp.Dim > ipsetDimMax {
+		return fmt.Errorf("unmarshalIPSetMatch(): invalid dimension %d: %w", tmp.Dim, ErrInvalidArg)
+	}
 	info.IPSetID = tmp.ID
 	for i := uint8(1); i <= tmp.Dim; i++ {
 		if (tmp.Flags & (1 << i)) == (1 << i) {
@@ -19,11 +19,13 @@ type MetaMatch struct {
 	Right *MetaValueType
 }
 
+//structgen:binary
 type MetaHdr struct {
 	Left  MetaValue
 	Right MetaValue
 }
 
+//structgen:binary
 type MetaValue struct {
 	Kind  uint16
 	Shift uint8
@@ -77,6 +79,9 @@ func unmarshalMetaMatch(data []byte, info *MetaMatch) error {
 		}
 	}
 
+	if info.Hdr == nil && (len(lValue) != 0 || len(rValue) != 0) {
+		return fmt.Errorf("unmarshalMetaMatch(): values without header: %w", ErrInvalidArg)
+	}
 	if len(lValue) != 0 {
 		err = unmarshalMetaMatchValue(int(info.Hdr.Left.Kind>>12), int(info.Hdr.Left.Kind&0x7ff),
 			lValue, &info.Left)
@@ -98,6 +103,9 @@ func marshalMetaMatchValue(kind int, value *MetaValueType) ([]byte, error) {
 		return []byte{}, ErrNotImplemented
 	case 1:
 		// TCF_META_TYPE_INT
+		if value.Int == nil {
+			return []byte{}, fmt.Errorf("MetaValueType: %w", ErrNoArg)
+		}
 		return []byte{
 			byte(*value.Int),
 			byte(*value.Int >> 8),
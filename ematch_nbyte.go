@@ -12,6 +12,7 @@ type NByteMatch struct {
 	Needle []byte
 }
 
+//structgen:binary
 type tcfEmNByte struct {
 	off   uint16
 	len   uint16
@@ -48,7 +49,7 @@ func marshalNByteMatch(info *NByteMatch) ([]byte, error) {
 		layer: info.Layer,
 	}
 
-	tmp, err := marshalAndAlignStruct(nbyte)
+	tmp, err := marshalAndAlignStruct(&nbyte)
 	if err != nil {
 		return []byte{}, err
 	}
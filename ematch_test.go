@@ -120,6 +120,21 @@ func TestEmatch(t *testing.T) {
 				},
 			},
 		},
+		"match 'text(kmp foo)' and 'canid(sff 0x123)'": {
+			val: Ematch{
+				Hdr: &EmatchTreeHdr{NMatches: 2, ProgID: 42},
+				Matches: &[]EmatchMatch{
+					{
+						Hdr:       EmatchHdr{MatchID: 0, Kind: EmatchText, Flags: EmatchRelAnd},
+						TextMatch: &TextMatch{Algo: TextMatchKMP, ToOffset: 0xFFFF, Pattern: []byte("foo")},
+					},
+					{
+						Hdr:        EmatchHdr{MatchID: 0, Kind: EmatchCanID},
+						CanIDMatch: &CanIDMatch{Rules: []CanFilter{CanSFFRule(0x123, CanSFFMask)}},
+					},
+				},
+			},
+		},
 		// A AND (B1 OR B2) AND NOT C
 		// EmatchHMatch:
 		//   ----------------------------
@@ -0,0 +1,75 @@
+package tc
+
+import (
+	"fmt"
+)
+
+// TextMatchAlgo defines the algorithm used to search the pattern.
+type TextMatchAlgo string
+
+// Various text search algorithms.
+const (
+	TextMatchKMP = TextMatchAlgo("kmp")
+	TextMatchBM  = TextMatchAlgo("bm")
+)
+
+// TC_EMATCH_TEXT_ALGO_SIZE from include/uapi/linux/tc_ematch/tc_em_text.h
+const textMatchAlgoSize = 16
+
+// size of struct tcf_em_text without the pattern
+const textMatchHdrSize = textMatchAlgoSize + 8
+
+// TextMatch contains attributes of the text match discipline
+type TextMatch struct {
+	Algo       TextMatchAlgo
+	FromOffset uint16
+	ToOffset   uint16
+	FromLayer  EmatchLayer
+	ToLayer    EmatchLayer
+	Pattern    []byte
+}
+
+func unmarshalTextMatch(data []byte, info *TextMatch) error {
+	if len(data) < textMatchHdrSize {
+		return fmt.Errorf("unmarshalTextMatch: incomplete data: %w", ErrInvalidArg)
+	}
+
+	algo := data[:textMatchAlgoSize]
+	for i, b := range algo {
+		if b == 0x0 {
+			algo = algo[:i]
+			break
+		}
+	}
+	info.Algo = TextMatchAlgo(algo)
+	info.FromOffset = nativeEndian.Uint16(data[16:18])
+	info.ToOffset = nativeEndian.Uint16(data[18:20])
+	patternLen := int(nativeEndian.Uint16(data[20:22]))
+	info.FromLayer = EmatchLayer(data[22] & 0xf)
+	info.ToLayer = EmatchLayer((data[22] >> 4) & 0xf)
+	if len(data) < textMatchHdrSize+patternLen {
+		return fmt.Errorf("unmarshalTextMatch: invalid pattern: %w", ErrInvalidArg)
+	}
+	info.Pattern = data[textMatchHdrSize : textMatchHdrSize+patternLen]
+	return nil
+}
+
+func marshalTextMatch(info *TextMatch) ([]byte, error) {
+	if info == nil {
+		return []byte{}, fmt.Errorf("marshalTextMatch: %w", ErrNoArg)
+	}
+	if len(info.Algo) == 0 || len(info.Algo) >= textMatchAlgoSize {
+		return []byte{}, fmt.Errorf("marshalTextMatch: algo %q: %w", info.Algo, ErrInvalidArg)
+	}
+	if len(info.Pattern) == 0 || len(info.Pattern) > 0xFFFF {
+		return []byte{}, fmt.Errorf("marshalTextMatch: pattern: %w", ErrInvalidArg)
+	}
+
+	data := make([]byte, textMatchHdrSize, textMatchHdrSize+len(info.Pattern))
+	copy(data, info.Algo)
+	nativeEndian.PutUint16(data[16:18], info.FromOffset)
+	nativeEndian.PutUint16(data[18:20], info.ToOffset)
+	nativeEndian.PutUint16(data[20:22], uint16(len(info.Pattern)))
+	data[22] = uint8(info.FromLayer&0xf) | uint8(info.ToLayer&0xf)<<4
+	return append(data, info.Pattern...), nil
+}
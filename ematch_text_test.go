@@ -0,0 +1,70 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTextMatch(t *testing.T) {
+	tests := map[string]struct {
+		val  TextMatch
+		err1 error
+		err2 error
+	}{
+		"kmp": {
+			val: TextMatch{
+				Algo:       TextMatchKMP,
+				FromOffset: 20,
+				ToOffset:   1500,
+				FromLayer:  EmatchLayerNetwork,
+				ToLayer:    EmatchLayerTransport,
+				Pattern:    []byte("GET /"),
+			},
+		},
+		"bm":         {val: TextMatch{Algo: TextMatchBM, Pattern: []byte{0xde, 0xad, 0xbe, 0xef}}},
+		"no algo":    {val: TextMatch{Pattern: []byte("foo")}, err1: ErrInvalidArg},
+		"long algo":  {val: TextMatch{Algo: "abcdefghijklmnopq", Pattern: []byte("foo")}, err1: ErrInvalidArg},
+		"no pattern": {val: TextMatch{Algo: TextMatchKMP}, err1: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err1 := marshalTextMatch(&testcase.val)
+			if !errors.Is(err1, testcase.err1) {
+				t.Fatalf("Unexpected error: %v", err1)
+			}
+			if err1 != nil {
+				return
+			}
+			val := TextMatch{}
+			err2 := unmarshalTextMatch(data, &val)
+			if !errors.Is(err2, testcase.err2) {
+				t.Fatalf("Unexpected error: %v", err2)
+			}
+			if diff := cmp.Diff(val, testcase.val); diff != "" {
+				t.Fatalf("TextMatch missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+	t.Run("nil", func(t *testing.T) {
+		_, err := marshalTextMatch(nil)
+		if !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("incomplete", func(t *testing.T) {
+		info := TextMatch{}
+		if err := unmarshalTextMatch([]byte{0x6b, 0x6d, 0x70}, &info); !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("invalid pattern", func(t *testing.T) {
+		data, _ := marshalTextMatch(&TextMatch{Algo: TextMatchKMP, Pattern: []byte("foo")})
+		info := TextMatch{}
+		if err := unmarshalTextMatch(data[:len(data)-1], &info); !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -5,6 +5,8 @@ import (
 )
 
 // U32Match contains attributes of the u32 match discipline
+//
+//structgen:binary
 type U32Match struct {
 	Mask    uint32 // big endian
 	Value   uint32 // big endian
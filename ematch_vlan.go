@@ -0,0 +1,23 @@
+package tc
+
+// VLanMatch contains attributes of the vlan match discipline.
+// The kernel does not define a payload for this kind. So the data is passed
+// unmodified to and from the kernel.
+type VLanMatch struct {
+	Data []byte
+}
+
+func unmarshalVLanMatch(data []byte, info *VLanMatch) error {
+	if info == nil {
+		return ErrNoArg
+	}
+	info.Data = data
+	return nil
+}
+
+func marshalVLanMatch(info *VLanMatch) ([]byte, error) {
+	if info == nil {
+		return []byte{}, ErrNoArg
+	}
+	return info.Data, nil
+}
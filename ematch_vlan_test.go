@@ -0,0 +1,32 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVLanMatch(t *testing.T) {
+	val := VLanMatch{Data: []byte{0x1, 0x2, 0x3, 0x4}}
+	data, err := marshalVLanMatch(&val)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := VLanMatch{}
+	if err := unmarshalVLanMatch(data, &got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(got, val); diff != "" {
+		t.Fatalf("VLanMatch missmatch (want +got):\n%s", diff)
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		if _, err := marshalVLanMatch(nil); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := unmarshalVLanMatch(nil, nil); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -4,9 +4,11 @@
 package tc_test
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/florianl/go-tc"
 	"github.com/florianl/go-tc/core"
@@ -43,6 +45,214 @@ func ExampleQdisc_Get() {
 	}
 }
 
+// This example demonstrates how Walk() can be used to process a large number of filters
+// without holding all of them in memory
+func ExampleFilter_Walk() {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open rtnetlink socket: %v\n", err)
+		return
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	devID, err := net.InterfaceByName("lo")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get interface ID: %v\n", err)
+		return
+	}
+
+	var flower int
+	err = rtnl.Filter().Walk(&tc.Msg{
+		Family:  unix.AF_UNSPEC,
+		Ifindex: uint32(devID.Index),
+		Parent:  core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress),
+	}, func(filter tc.Object) int {
+		if filter.Kind == "flower" {
+			flower++
+		}
+		// Return something different than 0 to stop the dump early.
+		return 0
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not walk filters: %v\n", err)
+		return
+	}
+	fmt.Printf("%d flower filters\n", flower)
+}
+
+// This example demonstrates how to install many filters at once and to undo all of them,
+// if one of them can not be installed.
+func ExampleBatch() {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open rtnetlink socket: %v\n", err)
+		return
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	devID, err := net.InterfaceByName("lo")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get interface ID: %v\n", err)
+		return
+	}
+
+	batch := rtnl.Batch()
+	for mark := uint32(1); mark <= 1000; mark++ {
+		classID := core.BuildHandle(0x1, mark)
+		if err := batch.AddFilter(&tc.Object{
+			Msg: tc.Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: uint32(devID.Index),
+				Handle:  mark,
+				Parent:  core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress),
+				Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+			},
+			Attribute: tc.Attribute{
+				Kind: "fw",
+				Fw:   &tc.Fw{ClassID: &classID},
+			},
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "could not queue filter: %v\n", err)
+			return
+		}
+	}
+
+	results, err := batch.Send(&tc.BatchOptions{Rollback: true})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not install filters: %v\n", err)
+		for i, result := range results {
+			if result.RollbackErr != nil {
+				fmt.Fprintf(os.Stderr, "could not undo filter %d: %v\n", i+1, result.RollbackErr)
+			}
+		}
+		return
+	}
+}
+
+// This example demonstrates how to list the qdiscs of all named network namespaces.
+func ExampleOpenNamedNetNS() {
+	all, err := tc.OpenNamedNetNS()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open network namespaces: %v\n", err)
+		return
+	}
+	for name, rtnl := range all {
+		qdiscs, err := rtnl.Qdisc().Get()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not get qdiscs of %s: %v\n", name, err)
+		}
+		fmt.Printf("%s: %d qdiscs\n", name, len(qdiscs))
+		if err := rtnl.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not close rtnetlink socket: %v\n", err)
+		}
+	}
+}
+
+// This example demonstrates how to keep track of all filters even if notifications get lost
+func ExampleTc_MonitorResilient() {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open rtnetlink socket: %v\n", err)
+		return
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	filters := make(map[tc.Msg]string)
+	updates := make(chan func(), 16)
+	if err := rtnl.MonitorResilient(ctx, func(action uint16, m tc.Object) int {
+		updates <- func() {
+			switch action {
+			case unix.RTM_NEWTFILTER:
+				filters[m.Msg] = m.Kind
+			case unix.RTM_DELTFILTER:
+				delete(filters, m.Msg)
+			}
+		}
+		return 0
+	}, func(s *tc.Snapshot) int {
+		updates <- func() {
+			filters = make(map[tc.Msg]string)
+			for _, f := range s.Filters {
+				filters[f.Msg] = f.Kind
+			}
+		}
+		return 0
+	}, func(err error) int {
+		fmt.Fprintf(os.Stderr, "resynchronizing after: %v\n", err)
+		return 0
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "could not start monitor: %v\n", err)
+		return
+	}
+
+	for {
+		select {
+		case update := <-updates:
+			update()
+			fmt.Printf("%d filters\n", len(filters))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// This example demonstrates how to answer lookups from a local cache instead of the kernel
+func ExampleNewCache() {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open rtnetlink socket: %v\n", err)
+		return
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache, err := tc.NewCache(ctx, rtnl, &tc.CacheOptions{ResyncInterval: time.Minute})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not create cache: %v\n", err)
+		return
+	}
+
+	devID, err := net.InterfaceByName("lo")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get interface ID: %v\n", err)
+		return
+	}
+	for _, qdisc := range cache.Qdiscs(uint32(devID.Index)) {
+		fmt.Printf("%20s\t%s\n", devID.Name, qdisc.Kind)
+	}
+
+	unsubscribe, err := cache.Subscribe(func(action uint16, m tc.Object) int {
+		fmt.Printf("%d: %s on %d\n", action, m.Kind, m.Ifindex)
+		return 0
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not subscribe: %v\n", err)
+		return
+	}
+	defer unsubscribe()
+}
+
 // This example demonstraces how to add a qdisc to an interface and delete it again
 func ExampleQdisc() {
 	tcIface := "ExampleQdisc"
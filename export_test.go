@@ -0,0 +1,6 @@
+package tc
+
+// ProbeLink exposes probe to the tests in package tc_test, which use a fake kernel.
+func (tc *Tc) ProbeLink(ifindex uint32) (*Capabilities, error) {
+	return tc.probe(ifindex)
+}
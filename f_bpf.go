@@ -54,7 +54,9 @@ func unmarshalBpf(data []byte, info *Bpf) error {
 			actions := &[]*Action{}
 			err := unmarshalActions(ad.Bytes(), actions)
 			multiError = concatError(multiError, err)
-			info.Action = (*actions)[0]
+			if len(*actions) > 0 {
+				info.Action = (*actions)[0]
+			}
 		case tcaBpfPolice:
 			pol := &Police{}
 			err := unmarshalPolice(ad.Bytes(), pol)
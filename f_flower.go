@@ -137,6 +137,10 @@ type Flower struct {
 	KeyIPv4SrcMask       *net.IP
 	KeyIPv4Dst           *net.IP
 	KeyIPv4DstMask       *net.IP
+	KeyIPv6Src           *net.IP
+	KeyIPv6SrcMask       *net.IP
+	KeyIPv6Dst           *net.IP
+	KeyIPv6DstMask       *net.IP
 	KeyTCPSrc            *uint16 /* be16 */
 	KeyTCPDst            *uint16 /* be16 */
 	KeyUDPSrc            *uint16 /* be16 */
@@ -278,6 +282,22 @@ func unmarshalFlower(data []byte, info *Flower) error {
 		case tcaFlowerKeyIPv4DstMask:
 			tmp := uint32ToIP(ad.Uint32())
 			info.KeyIPv4DstMask = &tmp
+		case tcaFlowerKeyIPv6Src:
+			tmp, err := bytesToIP(ad.Bytes())
+			multiError = concatError(multiError, err)
+			info.KeyIPv6Src = &tmp
+		case tcaFlowerKeyIPv6SrcMask:
+			tmp, err := bytesToIP(ad.Bytes())
+			multiError = concatError(multiError, err)
+			info.KeyIPv6SrcMask = &tmp
+		case tcaFlowerKeyIPv6Dst:
+			tmp, err := bytesToIP(ad.Bytes())
+			multiError = concatError(multiError, err)
+			info.KeyIPv6Dst = &tmp
+		case tcaFlowerKeyIPV6DstMask:
+			tmp, err := bytesToIP(ad.Bytes())
+			multiError = concatError(multiError, err)
+			info.KeyIPv6DstMask = &tmp
 		case tcaFlowerKeyTCPSrc:
 			tmp := endianSwapUint16(ad.Uint16())
 			info.KeyTCPSrc = &tmp
@@ -575,6 +595,18 @@ func marshalFlower(info *Flower) ([]byte, error) {
 		multiError = concatError(multiError, err)
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaFlowerKeyIPv4DstMask, Data: tmp})
 	}
+	if info.KeyIPv6Src != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaFlowerKeyIPv6Src, Data: ipToBytes(info.KeyIPv6Src.To16())})
+	}
+	if info.KeyIPv6SrcMask != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaFlowerKeyIPv6SrcMask, Data: ipToBytes(info.KeyIPv6SrcMask.To16())})
+	}
+	if info.KeyIPv6Dst != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaFlowerKeyIPv6Dst, Data: ipToBytes(info.KeyIPv6Dst.To16())})
+	}
+	if info.KeyIPv6DstMask != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaFlowerKeyIPV6DstMask, Data: ipToBytes(info.KeyIPv6DstMask.To16())})
+	}
 	if info.KeyTCPSrc != nil {
 		options = append(options, tcOption{Interpretation: vtUint16Be, Type: tcaFlowerKeyTCPSrc, Data: *info.KeyTCPSrc})
 	}
@@ -0,0 +1,513 @@
+package tc
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Ethernet protocols used by FlowerBuilder.
+const (
+	ethPIPv4   uint16 = 0x0800
+	ethP8021Q  uint16 = 0x8100
+	ethP8021AD uint16 = 0x88A8
+	ethPIPv6   uint16 = 0x86DD
+)
+
+// IP protocols used by FlowerBuilder.
+const (
+	ipProtoICMP   uint8 = 1
+	ipProtoTCP    uint8 = 6
+	ipProtoUDP    uint8 = 17
+	ipProtoICMPv6 uint8 = 58
+	ipProtoSCTP   uint8 = 132
+)
+
+// Flags for the connection tracking state from include/uapi/linux/pkt_cls.h
+const (
+	FlowerCtFlagsNew uint16 = 1 << iota
+	FlowerCtFlagsEstablished
+	FlowerCtFlagsRelated
+	FlowerCtFlagsTracked
+	FlowerCtFlagsInvalid
+	FlowerCtFlagsReply
+)
+
+var flowerCtStateNames = map[string]uint16{
+	"new": FlowerCtFlagsNew,
+	"est": FlowerCtFlagsEstablished,
+	"rel": FlowerCtFlagsRelated,
+	"trk": FlowerCtFlagsTracked,
+	"inv": FlowerCtFlagsInvalid,
+	"rpl": FlowerCtFlagsReply,
+}
+
+var flowerTCPFlagNames = map[string]uint16{
+	"fin": 0x01,
+	"syn": 0x02,
+	"rst": 0x04,
+	"psh": 0x08,
+	"ack": 0x10,
+	"urg": 0x20,
+	"ece": 0x40,
+	"cwr": 0x80,
+}
+
+var flowerIPProtoNames = map[string]uint8{
+	"icmp":   ipProtoICMP,
+	"tcp":    ipProtoTCP,
+	"udp":    ipProtoUDP,
+	"icmpv6": ipProtoICMPv6,
+	"sctp":   ipProtoSCTP,
+}
+
+type flowerPortMatch struct {
+	port     *uint16
+	min, max uint16
+}
+
+// FlowerBuilder helps to construct a Flower filter from semantic matches.
+// Byte order, the choice between IPv4 and IPv6 keys and the L4 keys for
+// the given ip_proto are handled by the builder. Errors are collected and
+// returned by Build.
+type FlowerBuilder struct {
+	err error
+
+	ethType  *uint16
+	ipProto  *uint8
+	src, dst *netip.Prefix
+
+	srcPort, dstPort flowerPortMatch
+
+	vlanID, cvlanID     *uint16
+	vlanPrio, cvlanPrio *uint8
+
+	tcpFlags, tcpFlagsMask *uint16
+	ctState, ctStateMask   *uint16
+
+	classID *uint32
+	flags   *uint32
+	actions *[]*Action
+}
+
+// NewFlowerBuilder returns a new and empty FlowerBuilder.
+func NewFlowerBuilder() *FlowerBuilder {
+	return &FlowerBuilder{}
+}
+
+func (b *FlowerBuilder) addErr(format string, a ...interface{}) {
+	b.err = concatError(b.err, fmt.Errorf(format, a...))
+}
+
+// EthType sets the network protocol in host byte order, e.g. 0x0800 for IPv4.
+// Setting it is optional, if it can be derived from other matches.
+func (b *FlowerBuilder) EthType(proto uint16) *FlowerBuilder {
+	b.ethType = &proto
+	return b
+}
+
+// IPProto sets the IP protocol by its name (icmp, icmpv6, tcp, udp, sctp)
+// or by its decimal number.
+func (b *FlowerBuilder) IPProto(name string) *FlowerBuilder {
+	proto, ok := flowerIPProtoNames[strings.ToLower(name)]
+	if !ok {
+		tmp, err := strconv.ParseUint(name, 10, 8)
+		if err != nil {
+			b.addErr("ip_proto %q: %w", name, ErrInvalidArg)
+			return b
+		}
+		proto = uint8(tmp)
+	}
+	b.ipProto = &proto
+	return b
+}
+
+// Src matches the source address against the given prefix.
+func (b *FlowerBuilder) Src(prefix netip.Prefix) *FlowerBuilder {
+	if !prefix.IsValid() {
+		b.addErr("src %v: %w", prefix, ErrInvalidArg)
+		return b
+	}
+	tmp := unmapPrefix(prefix)
+	b.src = &tmp
+	return b
+}
+
+// Dst matches the destination address against the given prefix.
+func (b *FlowerBuilder) Dst(prefix netip.Prefix) *FlowerBuilder {
+	if !prefix.IsValid() {
+		b.addErr("dst %v: %w", prefix, ErrInvalidArg)
+		return b
+	}
+	tmp := unmapPrefix(prefix)
+	b.dst = &tmp
+	return b
+}
+
+// SrcPort matches the L4 source port.
+func (b *FlowerBuilder) SrcPort(port uint16) *FlowerBuilder {
+	b.srcPort.port = &port
+	return b
+}
+
+// DstPort matches the L4 destination port.
+func (b *FlowerBuilder) DstPort(port uint16) *FlowerBuilder {
+	b.dstPort.port = &port
+	return b
+}
+
+// SrcPortRange matches the L4 source port against the inclusive range [min, max].
+func (b *FlowerBuilder) SrcPortRange(min, max uint16) *FlowerBuilder {
+	if min >= max {
+		b.addErr("src port range %d-%d: %w", min, max, ErrInvalidArg)
+		return b
+	}
+	b.srcPort.min, b.srcPort.max = min, max
+	return b
+}
+
+// DstPortRange matches the L4 destination port against the inclusive range [min, max].
+func (b *FlowerBuilder) DstPortRange(min, max uint16) *FlowerBuilder {
+	if min >= max {
+		b.addErr("dst port range %d-%d: %w", min, max, ErrInvalidArg)
+		return b
+	}
+	b.dstPort.min, b.dstPort.max = min, max
+	return b
+}
+
+// VLAN matches the outer VLAN ID.
+func (b *FlowerBuilder) VLAN(id uint16) *FlowerBuilder {
+	if id > 4095 {
+		b.addErr("vlan id %d: %w", id, ErrInvalidArg)
+		return b
+	}
+	b.vlanID = &id
+	return b
+}
+
+// VLANPrio matches the outer VLAN priority.
+func (b *FlowerBuilder) VLANPrio(prio uint8) *FlowerBuilder {
+	if prio > 7 {
+		b.addErr("vlan prio %d: %w", prio, ErrInvalidArg)
+		return b
+	}
+	b.vlanPrio = &prio
+	return b
+}
+
+// CVLAN matches the inner (customer) VLAN ID of a QinQ frame.
+func (b *FlowerBuilder) CVLAN(id uint16) *FlowerBuilder {
+	if id > 4095 {
+		b.addErr("cvlan id %d: %w", id, ErrInvalidArg)
+		return b
+	}
+	b.cvlanID = &id
+	return b
+}
+
+// CVLANPrio matches the inner (customer) VLAN priority of a QinQ frame.
+func (b *FlowerBuilder) CVLANPrio(prio uint8) *FlowerBuilder {
+	if prio > 7 {
+		b.addErr("cvlan prio %d: %w", prio, ErrInvalidArg)
+		return b
+	}
+	b.cvlanPrio = &prio
+	return b
+}
+
+// TCPFlags matches TCP flags given by their names. A flag prefixed with '+'
+// has to be set, a flag prefixed with '-' has to be unset and flags not
+// mentioned are ignored, e.g. "+syn-ack".
+func (b *FlowerBuilder) TCPFlags(spec string) *FlowerBuilder {
+	flags, mask, err := parseFlowerFlags(spec, flowerTCPFlagNames)
+	if err != nil {
+		b.addErr("tcp_flags: %w", err)
+		return b
+	}
+	b.tcpFlags, b.tcpFlagsMask = &flags, &mask
+	return b
+}
+
+// CtState matches the connection tracking state in the notation of tc(8),
+// e.g. "+trk+est" or "+trk-new".
+func (b *FlowerBuilder) CtState(spec string) *FlowerBuilder {
+	state, mask, err := parseFlowerFlags(spec, flowerCtStateNames)
+	if err != nil {
+		b.addErr("ct_state: %w", err)
+		return b
+	}
+	b.ctState, b.ctStateMask = &state, &mask
+	return b
+}
+
+// ClassID sets the class the matched packets are assigned to.
+func (b *FlowerBuilder) ClassID(classID uint32) *FlowerBuilder {
+	b.classID = &classID
+	return b
+}
+
+// Flags sets flags like SkipHw or SkipSw.
+func (b *FlowerBuilder) Flags(flags uint32) *FlowerBuilder {
+	b.flags = &flags
+	return b
+}
+
+// Actions sets the actions that are applied to matched packets.
+func (b *FlowerBuilder) Actions(actions []*Action) *FlowerBuilder {
+	b.actions = &actions
+	return b
+}
+
+// Build validates the combination of the given matches and returns the
+// resulting Flower. The protocol of the filter, which is set via
+// core.FilterInfo, has to match Flower.KeyEthType.
+func (b *FlowerBuilder) Build() (*Flower, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	info := &Flower{
+		ClassID: b.classID,
+		Flags:   b.flags,
+		Actions: b.actions,
+	}
+
+	netProto, err := b.networkProtocol()
+	if err != nil {
+		return nil, err
+	}
+
+	// Frames with VLAN tags carry the network protocol in the innermost tag.
+	switch {
+	case b.cvlanID != nil || b.cvlanPrio != nil:
+		if b.vlanID == nil && b.vlanPrio == nil {
+			return nil, fmt.Errorf("cvlan requires an outer vlan: %w", ErrInvalidArg)
+		}
+		info.KeyEthType = uint16Ptr(ethP8021AD)
+		info.KeyVlanEthType = uint16Ptr(ethP8021Q)
+		info.KeyVlanID = b.vlanID
+		info.KeyVlanPrio = b.vlanPrio
+		info.KeyCVlanID = b.cvlanID
+		info.KeyCVlanPrio = b.cvlanPrio
+		if netProto != 0 {
+			info.KeyCVlanEthType = uint16Ptr(netProto)
+		}
+	case b.vlanID != nil || b.vlanPrio != nil:
+		info.KeyEthType = uint16Ptr(ethP8021Q)
+		info.KeyVlanID = b.vlanID
+		info.KeyVlanPrio = b.vlanPrio
+		if netProto != 0 {
+			info.KeyVlanEthType = uint16Ptr(netProto)
+		}
+	default:
+		if netProto != 0 {
+			info.KeyEthType = uint16Ptr(netProto)
+		}
+	}
+
+	if b.src != nil {
+		addr, mask := prefixToIPMask(*b.src)
+		if b.src.Addr().Is4() {
+			info.KeyIPv4Src, info.KeyIPv4SrcMask = &addr, &mask
+		} else {
+			info.KeyIPv6Src, info.KeyIPv6SrcMask = &addr, &mask
+		}
+	}
+	if b.dst != nil {
+		addr, mask := prefixToIPMask(*b.dst)
+		if b.dst.Addr().Is4() {
+			info.KeyIPv4Dst, info.KeyIPv4DstMask = &addr, &mask
+		} else {
+			info.KeyIPv6Dst, info.KeyIPv6DstMask = &addr, &mask
+		}
+	}
+
+	info.KeyIPProto = b.ipProto
+	if err := b.buildL4(info); err != nil {
+		return nil, err
+	}
+
+	if b.ctState != nil {
+		if err := validateFlowerCtState(*b.ctState, *b.ctStateMask); err != nil {
+			return nil, err
+		}
+		info.KeyCtState = b.ctState
+		info.KeyCtStateMask = b.ctStateMask
+	}
+
+	return info, nil
+}
+
+// networkProtocol returns the L3 protocol derived from the given matches
+// or 0 if no L3 protocol is required.
+func (b *FlowerBuilder) networkProtocol() (uint16, error) {
+	var proto uint16
+	if b.ethType != nil {
+		proto = *b.ethType
+	}
+	for _, prefix := range []*netip.Prefix{b.src, b.dst} {
+		if prefix == nil {
+			continue
+		}
+		want := ethPIPv6
+		if prefix.Addr().Is4() {
+			want = ethPIPv4
+		}
+		if proto != 0 && proto != want {
+			return 0, fmt.Errorf("address %v does not match protocol 0x%04x: %w",
+				prefix, proto, ErrInvalidArg)
+		}
+		proto = want
+	}
+	if b.ipProto == nil {
+		return proto, nil
+	}
+	switch *b.ipProto {
+	case ipProtoICMP:
+		if proto == 0 {
+			proto = ethPIPv4
+		}
+	case ipProtoICMPv6:
+		if proto == 0 {
+			proto = ethPIPv6
+		}
+	}
+	if proto != ethPIPv4 && proto != ethPIPv6 {
+		return 0, fmt.Errorf("ip_proto requires protocol ip or ipv6: %w", ErrInvalidArg)
+	}
+	if (*b.ipProto == ipProtoICMP && proto != ethPIPv4) ||
+		(*b.ipProto == ipProtoICMPv6 && proto != ethPIPv6) {
+		return 0, fmt.Errorf("ip_proto %d does not match protocol 0x%04x: %w",
+			*b.ipProto, proto, ErrInvalidArg)
+	}
+	return proto, nil
+}
+
+// buildL4 sets the L4 keys depending on the ip_proto.
+func (b *FlowerBuilder) buildL4(info *Flower) error {
+	hasPorts := b.srcPort.port != nil || b.srcPort.max != 0 ||
+		b.dstPort.port != nil || b.dstPort.max != 0
+	if b.ipProto == nil {
+		if hasPorts {
+			return fmt.Errorf("ports require ip_proto tcp, udp or sctp: %w", ErrInvalidArg)
+		}
+		if b.tcpFlags != nil {
+			return fmt.Errorf("tcp_flags require ip_proto tcp: %w", ErrInvalidArg)
+		}
+		return nil
+	}
+	if b.tcpFlags != nil {
+		if *b.ipProto != ipProtoTCP {
+			return fmt.Errorf("tcp_flags require ip_proto tcp: %w", ErrInvalidArg)
+		}
+		info.KeyTCPFlags = b.tcpFlags
+		info.KeyTCPFlagsMask = b.tcpFlagsMask
+	}
+	if !hasPorts {
+		return nil
+	}
+	for _, pm := range []flowerPortMatch{b.srcPort, b.dstPort} {
+		if pm.port != nil && pm.max != 0 {
+			return fmt.Errorf("port and port range are mutually exclusive: %w", ErrInvalidArg)
+		}
+	}
+
+	var src, dst **uint16
+	switch *b.ipProto {
+	case ipProtoTCP:
+		src, dst = &info.KeyTCPSrc, &info.KeyTCPDst
+	case ipProtoUDP:
+		src, dst = &info.KeyUDPSrc, &info.KeyUDPDst
+	case ipProtoSCTP:
+		src, dst = &info.KeySctpSrc, &info.KeySctpDst
+	default:
+		return fmt.Errorf("ports are not supported for ip_proto %d: %w", *b.ipProto, ErrInvalidArg)
+	}
+	*src = b.srcPort.port
+	*dst = b.dstPort.port
+	if b.srcPort.max != 0 {
+		info.KeyPortSrcMin = uint16Ptr(b.srcPort.min)
+		info.KeyPortSrcMax = uint16Ptr(b.srcPort.max)
+	}
+	if b.dstPort.max != 0 {
+		info.KeyPortDstMin = uint16Ptr(b.dstPort.min)
+		info.KeyPortDstMax = uint16Ptr(b.dstPort.max)
+	}
+	return nil
+}
+
+// validateFlowerCtState rejects combinations of ct_state flags, that are
+// also rejected by the kernel.
+func validateFlowerCtState(state, mask uint16) error {
+	if state&^FlowerCtFlagsTracked != 0 && state&FlowerCtFlagsTracked == 0 {
+		return fmt.Errorf("ct_state flags require +trk: %w", ErrInvalidArg)
+	}
+	if state&FlowerCtFlagsNew != 0 && state&FlowerCtFlagsEstablished != 0 {
+		return fmt.Errorf("ct_state +new and +est are mutually exclusive: %w", ErrInvalidArg)
+	}
+	if state&FlowerCtFlagsNew != 0 && state&FlowerCtFlagsReply != 0 {
+		return fmt.Errorf("ct_state +new and +rpl are mutually exclusive: %w", ErrInvalidArg)
+	}
+	if state&FlowerCtFlagsInvalid != 0 && mask&^(FlowerCtFlagsInvalid|FlowerCtFlagsTracked) != 0 {
+		return fmt.Errorf("ct_state +inv is mutually exclusive with other states: %w", ErrInvalidArg)
+	}
+	return nil
+}
+
+// parseFlowerFlags parses a sequence of named flags, each prefixed by
+// '+' or '-', and returns the resulting value and mask.
+func parseFlowerFlags(spec string, names map[string]uint16) (uint16, uint16, error) {
+	var value, mask uint16
+	if len(spec) == 0 {
+		return 0, 0, ErrInvalidArg
+	}
+	for len(spec) > 0 {
+		set := spec[0] == '+'
+		if !set && spec[0] != '-' {
+			return 0, 0, fmt.Errorf("%q: missing +/- prefix: %w", spec, ErrInvalidArg)
+		}
+		spec = spec[1:]
+		end := strings.IndexAny(spec, "+-")
+		if end < 0 {
+			end = len(spec)
+		}
+		name := strings.ToLower(spec[:end])
+		spec = spec[end:]
+		flag, ok := names[name]
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown flag %q: %w", name, ErrInvalidArg)
+		}
+		if mask&flag != 0 {
+			return 0, 0, fmt.Errorf("flag %q given twice: %w", name, ErrInvalidArg)
+		}
+		mask |= flag
+		if set {
+			value |= flag
+		}
+	}
+	return value, mask, nil
+}
+
+// unmapPrefix converts IPv4-mapped IPv6 prefixes to IPv4 and masks the host bits.
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	addr := prefix.Addr()
+	bits := prefix.Bits()
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits -= 96
+		if bits < 0 {
+			bits = 0
+		}
+	}
+	return netip.PrefixFrom(addr, bits).Masked()
+}
+
+// prefixToIPMask returns the address and mask of prefix as net.IP.
+func prefixToIPMask(prefix netip.Prefix) (net.IP, net.IP) {
+	addr := net.IP(prefix.Addr().AsSlice())
+	mask := net.IP(net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen()))
+	return addr, mask
+}
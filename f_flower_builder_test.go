@@ -0,0 +1,114 @@
+package tc
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFlowerBuilder(t *testing.T) {
+	tests := map[string]struct {
+		builder *FlowerBuilder
+		val     *Flower
+		err     error
+	}{
+		"ipv4 tcp": {
+			builder: NewFlowerBuilder().
+				Src(netip.MustParsePrefix("192.0.2.1/24")).
+				IPProto("tcp").
+				DstPort(443),
+			val: &Flower{
+				KeyEthType:     uint16Ptr(0x0800),
+				KeyIPProto:     uint8Ptr(6),
+				KeyIPv4Src:     netIPPtr(net.IP{192, 0, 2, 0}),
+				KeyIPv4SrcMask: netIPPtr(net.IP{255, 255, 255, 0}),
+				KeyTCPDst:      uint16Ptr(443),
+			},
+		},
+		"ipv6 udp range": {
+			builder: NewFlowerBuilder().
+				Dst(netip.MustParsePrefix("2001:db8::/32")).
+				IPProto("udp").
+				SrcPortRange(1000, 2000),
+			val: &Flower{
+				KeyEthType:     uint16Ptr(0x86DD),
+				KeyIPProto:     uint8Ptr(17),
+				KeyIPv6Dst:     netIPPtr(net.ParseIP("2001:db8::")),
+				KeyIPv6DstMask: netIPPtr(net.IP(net.CIDRMask(32, 128))),
+				KeyPortSrcMin:  uint16Ptr(1000),
+				KeyPortSrcMax:  uint16Ptr(2000),
+			},
+		},
+		"qinq icmp": {
+			builder: NewFlowerBuilder().VLAN(100).CVLAN(200).CVLANPrio(3).IPProto("icmp"),
+			val: &Flower{
+				KeyEthType:      uint16Ptr(0x88A8),
+				KeyVlanEthType:  uint16Ptr(0x8100),
+				KeyVlanID:       uint16Ptr(100),
+				KeyCVlanID:      uint16Ptr(200),
+				KeyCVlanPrio:    uint8Ptr(3),
+				KeyCVlanEthType: uint16Ptr(0x0800),
+				KeyIPProto:      uint8Ptr(1),
+			},
+		},
+		"tcp flags and ct_state": {
+			builder: NewFlowerBuilder().EthType(0x0800).IPProto("6").TCPFlags("+syn-ack").CtState("+trk+est"),
+			val: &Flower{
+				KeyEthType:      uint16Ptr(0x0800),
+				KeyIPProto:      uint8Ptr(6),
+				KeyTCPFlags:     uint16Ptr(0x02),
+				KeyTCPFlagsMask: uint16Ptr(0x12),
+				KeyCtState:      uint16Ptr(FlowerCtFlagsTracked | FlowerCtFlagsEstablished),
+				KeyCtStateMask:  uint16Ptr(FlowerCtFlagsTracked | FlowerCtFlagsEstablished),
+			},
+		},
+		"ports without ip_proto": {builder: NewFlowerBuilder().EthType(0x0800).DstPort(80), err: ErrInvalidArg},
+		"ip_proto without ip":    {builder: NewFlowerBuilder().IPProto("tcp"), err: ErrInvalidArg},
+		"mixed families": {
+			builder: NewFlowerBuilder().
+				Src(netip.MustParsePrefix("192.0.2.0/24")).
+				Dst(netip.MustParsePrefix("2001:db8::/32")),
+			err: ErrInvalidArg,
+		},
+		"icmpv6 with ipv4": {builder: NewFlowerBuilder().EthType(0x0800).IPProto("icmpv6"), err: ErrInvalidArg},
+		"ports for icmp":   {builder: NewFlowerBuilder().IPProto("icmp").DstPort(1), err: ErrInvalidArg},
+		"port and range": {
+			builder: NewFlowerBuilder().EthType(0x0800).IPProto("udp").DstPort(53).DstPortRange(1, 2),
+			err:     ErrInvalidArg,
+		},
+		"tcp flags for udp": {builder: NewFlowerBuilder().EthType(0x0800).IPProto("udp").TCPFlags("+syn"), err: ErrInvalidArg},
+		"unknown proto":     {builder: NewFlowerBuilder().IPProto("foo"), err: ErrInvalidArg},
+		"invalid range":     {builder: NewFlowerBuilder().SrcPortRange(2, 1), err: ErrInvalidArg},
+		"invalid vlan":      {builder: NewFlowerBuilder().VLAN(4096), err: ErrInvalidArg},
+		"cvlan only":        {builder: NewFlowerBuilder().CVLAN(1), err: ErrInvalidArg},
+		"ct_state w/o trk":  {builder: NewFlowerBuilder().CtState("+est"), err: ErrInvalidArg},
+		"ct_state new+est":  {builder: NewFlowerBuilder().CtState("+trk+new+est"), err: ErrInvalidArg},
+		"ct_state unknown":  {builder: NewFlowerBuilder().CtState("+trk+foo"), err: ErrInvalidArg},
+		"ct_state prefix":   {builder: NewFlowerBuilder().CtState("trk"), err: ErrInvalidArg},
+		"ct_state twice":    {builder: NewFlowerBuilder().CtState("+trk-trk"), err: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			val, err := testcase.builder.Build()
+			if err != nil {
+				if testcase.err != nil && errors.Is(err, testcase.err) {
+					return
+				}
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testcase.err != nil {
+				t.Fatalf("Expected error %v but got none", testcase.err)
+			}
+			if diff := cmp.Diff(val, testcase.val); diff != "" {
+				t.Fatalf("Flower missmatch (want +got):\n%s", diff)
+			}
+			if _, err := marshalFlower(val); err != nil {
+				t.Fatalf("Failed to marshal Flower: %v", err)
+			}
+		})
+	}
+}
@@ -33,6 +33,10 @@ func TestFlower(t *testing.T) {
 			KeyIPv4SrcMask:       netIPPtr(net.ParseIP("255.255.255.0")),
 			KeyIPv4Dst:           netIPPtr(net.ParseIP("4.3.2.1")),
 			KeyIPv4DstMask:       netIPPtr(net.ParseIP("255.255.0.0")),
+			KeyIPv6Src:           netIPPtr(net.ParseIP("2001:db8::1")),
+			KeyIPv6SrcMask:       netIPPtr(net.ParseIP("ffff:ffff::")),
+			KeyIPv6Dst:           netIPPtr(net.ParseIP("2001:db8::2")),
+			KeyIPv6DstMask:       netIPPtr(net.ParseIP("ffff:ffff:ffff::")),
 			KeyTCPSrc:            uint16Ptr(4),
 			KeyTCPDst:            uint16Ptr(5),
 			KeyUDPSrc:            uint16Ptr(6),
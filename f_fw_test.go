@@ -22,7 +22,7 @@ func TestFw(t *testing.T) {
 		err2 error
 	}{
 		"simple":   {val: Fw{ClassID: uint32Ptr(12), InDev: stringPtr("lo"), Mask: uint32Ptr(0xFFFF)}},
-		"extended": {val: Fw{ClassID: uint32Ptr(12), InDev: stringPtr("lo"), Mask: uint32Ptr(0xFFFF), Police: &Police{AvRate: uint32Ptr(1337), Result: uint32Ptr(12)}}},
+		"extended": {val: Fw{ClassID: uint32Ptr(12), InDev: stringPtr("lo"), Mask: uint32Ptr(0xFFFF), Police: &Police{AvRate: uint32Ptr(1337), Result: verdictPtr(12)}}},
 		"mixed":    {val: Fw{ClassID: uint32Ptr(12), InDev: stringPtr("lo"), Actions: &actions}},
 	}
 
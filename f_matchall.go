@@ -58,6 +58,9 @@ func marshalMatchall(info *Matchall) ([]byte, error) {
 	if info == nil {
 		return []byte{}, fmt.Errorf("Matchall: %w", ErrNoArg)
 	}
+	if info.Pcnt != nil {
+		return []byte{}, ErrNoArgAlter
+	}
 
 	// TODO: improve logic and check combinations
 	var multiError error
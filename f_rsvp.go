@@ -106,6 +106,8 @@ func marshalRsvp(info *Rsvp) ([]byte, error) {
 }
 
 // RsvpPInfo from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type RsvpPInfo struct {
 	Dpi       RsvpGpi
 	Spi       RsvpGpi
@@ -116,6 +118,8 @@ type RsvpPInfo struct {
 }
 
 // RsvpGpi from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type RsvpGpi struct {
 	Key    uint32
 	Mask   uint32
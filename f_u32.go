@@ -193,8 +193,8 @@ func validateU32SelOptions(info *U32Sel) ([]byte, error) {
 	if info.NKeys != 0 {
 		buf.WriteByte(0x00)
 	}
-	for _, v := range info.Keys {
-		data, err := marshalStruct(v)
+	for i := range info.Keys {
+		data, err := marshalStruct(&info.Keys[i])
 		if err != nil {
 			return []byte{}, err
 		}
@@ -230,6 +230,8 @@ func extractU32Sel(data []byte, info *U32Sel) error {
 }
 
 // U32Mark from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type U32Mark struct {
 	Val     uint32
 	Mask    uint32
@@ -237,6 +239,8 @@ type U32Mark struct {
 }
 
 // U32Key from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type U32Key struct {
 	Mask    uint32
 	Val     uint32
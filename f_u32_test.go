@@ -31,7 +31,7 @@ func TestU32(t *testing.T) {
 		"extended": {val: U32{
 			ClassID: uint32Ptr(0xFFFF),
 			Mark:    &U32Mark{Val: 0x55, Mask: 0xAA, Success: 0x1},
-			Police:  &Police{AvRate: uint32Ptr(1337), Result: uint32Ptr(12)},
+			Police:  &Police{AvRate: uint32Ptr(1337), Result: verdictPtr(12)},
 		}},
 		"policy": {val: U32{
 			Sel: &U32Sel{
@@ -62,6 +62,15 @@ func (f *Filter) Get(i *Msg) ([]Object, error) {
 	return f.get(unix.RTM_GETTFILTER, i)
 }
 
+// Walk calls fn for each filter, that matches i, as soon as it is received. The filters are
+// not collected in memory, which makes Walk suitable for large numbers of filters.
+func (f *Filter) Walk(i *Msg, fn WalkFunc) error {
+	if i == nil || fn == nil {
+		return ErrNoArg
+	}
+	return f.walk(unix.RTM_GETTFILTER, i, fn)
+}
+
 func marshalFilterOptions(kind string, info *Object) ([]byte, error) {
 	var data []byte
 	var err error
@@ -2,10 +2,12 @@ package tc
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/florianl/go-tc/core"
 	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
 )
 
 func TestFilter(t *testing.T) {
@@ -261,3 +263,91 @@ func TestMarshalFilterOptions(t *testing.T) {
 		})
 	}
 }
+
+// dumpConn answers every request with msgs.
+type dumpConn struct {
+	fakeConn
+	msgs []netlink.Message
+}
+
+func (c *dumpConn) Send(m netlink.Message) (netlink.Message, error) { return m, nil }
+func (c *dumpConn) Receive() ([]netlink.Message, error)             { return c.msgs, nil }
+
+func BenchmarkFilterGet(b *testing.B) {
+	filter := &Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: 2,
+			Handle:  0x800,
+			Parent:  core.BuildHandle(HandleRoot, HandleMinIngress),
+			Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+		},
+		Attribute: Attribute{
+			Kind: "u32",
+			U32: &U32{
+				ClassID: uint32Ptr(0x10001),
+				Sel: &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{
+					{Mask: 0xff, Val: 0x6, Off: 8},
+				}},
+				Actions: &[]*Action{
+					{
+						Kind: "gact",
+						Gact: &Gact{Parms: &GactParms{Index: 1, Action: ActShot}},
+						Stats: &GenStats{
+							Basic: &GenBasic{Bytes: 1500, Packets: 1},
+							Queue: &GenQueue{Drops: 1},
+						},
+					},
+				},
+			},
+		},
+	}
+	opts, err := validateFilterObject(unix.RTM_NEWTFILTER, filter)
+	if err != nil {
+		b.Fatal(err)
+	}
+	stats, err := marshalStruct(&Stats{Bytes: 1500, Packets: 1})
+	if err != nil {
+		b.Fatal(err)
+	}
+	stats2, err := marshalStruct(&Stats2{Bytes: 1500, Packets: 1})
+	if err != nil {
+		b.Fatal(err)
+	}
+	opts = append(opts,
+		tcOption{Interpretation: vtBytes, Type: tcaStats, Data: stats},
+		tcOption{Interpretation: vtBytes, Type: tcaStats2, Data: stats2})
+	attrs, err := marshalAttributes(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tcmsg, err := marshalStruct(&filter.Msg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := append(tcmsg, attrs...)
+
+	for _, n := range []int{1000, 100000} {
+		msgs := make([]netlink.Message, n)
+		for i := range msgs {
+			msgs[i] = netlink.Message{
+				Header: netlink.Header{Type: unix.RTM_NEWTFILTER},
+				Data:   data,
+			}
+		}
+		tcnl := NewWithConn(&dumpConn{msgs: msgs})
+
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				filters, err := tcnl.Filter().Get(&filter.Msg)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(filters) != n {
+					b.Fatalf("expected %d filters but got %d", n, len(filters))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package tc
+
+import (
+	"testing"
+
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+)
+
+// The seed corpora of the following fuzz targets in testdata/fuzz are taken from the
+// test vectors of the other tests. Each target checks, that a decoded value is encoded
+// and decoded again to an equal value.
+
+// optionsData returns the content of TCA_OPTIONS from options.
+func optionsData(options []tcOption) []byte {
+	for _, opt := range options {
+		if opt.Type == tcaOptions {
+			data, _ := opt.Data.([]byte)
+			return data
+		}
+	}
+	return nil
+}
+
+func FuzzExtractTcmsgAttributes(f *testing.F) {
+	f.Fuzz(func(t *testing.T, action uint16, data []byte) {
+		info := &Object{Msg: Msg{Ifindex: 1}}
+		if err := extractTcmsgAttributes(int(action), data, &info.Attribute); err != nil {
+			return
+		}
+		// Statistics and warnings are only reported by the kernel.
+		info.Stats = nil
+		info.Stats2 = nil
+		info.XStats = nil
+		info.ExtWarnMsg = ""
+
+		// Requests to delete objects and chains do not carry all attributes.
+		if isDelAction(int(action)) || isChainAction(int(action)) {
+			return
+		}
+		// Classes are sent without chain.
+		if int(action)&actionMask == actionClass {
+			info.Chain = nil
+		}
+
+		options, err := validateObject(int(action), info)
+		if err != nil {
+			return
+		}
+		encoded, err := marshalAttributes(options)
+		if err != nil {
+			return
+		}
+		got := &Object{Msg: Msg{Ifindex: 1}}
+		if err := extractTcmsgAttributes(int(action), encoded, &got.Attribute); err != nil {
+			t.Fatalf("could not decode encoded value: %v", err)
+		}
+		if diff := cmp.Diff(info.Attribute, got.Attribute); diff != "" {
+			t.Fatalf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+// optionsAction returns the type of message, that carries options of kind.
+func optionsAction(kind string, qopt bool) int {
+	switch {
+	case qopt:
+		return unix.RTM_NEWQDISC
+	case isFilter(kind):
+		return unix.RTM_NEWTFILTER
+	case kind == "hfsc" || kind == "htb" || kind == "qfq":
+		return unix.RTM_NEWTCLASS
+	}
+	return unix.RTM_NEWQDISC
+}
+
+func FuzzExtractTCAOptions(f *testing.F) {
+	decode := func(kind string, qopt bool, data []byte, info *Attribute) error {
+		if qopt && hasQOpt(kind) {
+			return extractQOpt(data, info, kind)
+		}
+		return extractTCAOptions(data, info, kind)
+	}
+	f.Fuzz(func(t *testing.T, kind string, qopt bool, data []byte) {
+		info := &Object{Msg: Msg{Ifindex: 1}, Attribute: Attribute{Kind: kind}}
+		if err := decode(kind, qopt, data, &info.Attribute); err != nil {
+			return
+		}
+		options, err := validateObject(optionsAction(kind, qopt), info)
+		if err != nil {
+			return
+		}
+		got := &Object{Msg: Msg{Ifindex: 1}, Attribute: Attribute{Kind: kind}}
+		if err := decode(kind, qopt, optionsData(options), &got.Attribute); err != nil {
+			t.Fatalf("could not decode encoded value: %v", err)
+		}
+		if diff := cmp.Diff(info.Attribute, got.Attribute); diff != "" {
+			t.Fatalf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func FuzzExtractActOptions(f *testing.F) {
+	f.Fuzz(func(t *testing.T, kind string, data []byte) {
+		act := &Action{Kind: kind}
+		if err := extractActOptions(data, act, kind); err != nil {
+			return
+		}
+		encoded, err := marshalAction(unix.RTM_NEWACTION, act, tcaActOptions|nlaFNnested)
+		if err != nil {
+			return
+		}
+		got := &Action{}
+		if err := unmarshalAction(encoded, got); err != nil {
+			t.Fatalf("could not decode encoded value: %v", err)
+		}
+		if diff := cmp.Diff(act, got); diff != "" {
+			t.Fatalf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func FuzzUnmarshalActions(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var actions []*Action
+		if err := unmarshalActions(data, &actions); err != nil {
+			return
+		}
+		encoded, err := marshalActions(unix.RTM_NEWACTION, actions)
+		if err != nil {
+			return
+		}
+		var got []*Action
+		if err := unmarshalActions(encoded, &got); err != nil {
+			t.Fatalf("could not decode encoded value: %v", err)
+		}
+		if diff := cmp.Diff(actions, got); diff != "" {
+			t.Fatalf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func FuzzUnmarshalEmatch(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info := &Ematch{}
+		if err := unmarshalEmatch(data, info); err != nil {
+			return
+		}
+		encoded, err := marshalEmatch(info)
+		if err != nil {
+			return
+		}
+		got := &Ematch{}
+		if err := unmarshalEmatch(encoded, got); err != nil {
+			t.Fatalf("could not decode encoded value: %v", err)
+		}
+		if diff := cmp.Diff(info, got); diff != "" {
+			t.Fatalf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -8,11 +8,11 @@ require (
 	github.com/josharian/native v1.1.0
 	github.com/jsimonetti/rtnetlink v0.0.0-20211022192332-93da33804786
 	github.com/mdlayher/netlink v1.7.1
+	golang.org/x/net v0.9.0
 	golang.org/x/sys v0.7.0
 )
 
 require (
 	github.com/mdlayher/socket v0.4.0 // indirect
-	golang.org/x/net v0.9.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
 )
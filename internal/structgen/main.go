@@ -0,0 +1,364 @@
+// Command structgen generates encoders and decoders for the fixed-size structs of
+// github.com/florianl/go-tc. The generated code encodes the structs in the same way
+// encoding/binary does with the native byte order, but without reflection.
+//
+// Only structs, that are marked with the directive
+//
+//	//structgen:binary
+//
+// in their doc comment, are generated. Structs nested in a marked struct need to be
+// marked as well.
+//
+// Usage:
+//
+//	go run ./internal/structgen -output structs_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// basicSizes holds the size in bytes of the fixed-size basic types.
+var basicSizes = map[string]int{
+	"bool":   1,
+	"int8":   1,
+	"uint8":  1,
+	"byte":   1,
+	"int16":  2,
+	"uint16": 2,
+	"int32":  4,
+	"uint32": 4,
+	"int64":  8,
+	"uint64": 8,
+}
+
+// marker is the directive, that selects the structs to generate.
+const marker = "//structgen:binary"
+
+// generator collects the type declarations of a package.
+type generator struct {
+	types  map[string]ast.Expr
+	marked map[string]bool
+	sizes  map[string]int
+	buf    bytes.Buffer
+}
+
+func main() {
+	output := flag.String("output", "structs_gen.go", "name of the generated file")
+	dir := flag.String("dir", ".", "directory of the package")
+	flag.Parse()
+
+	src, err := run(*dir, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run returns the generated code for the package in dir.
+func run(dir, output string) ([]byte, error) {
+	g := &generator{
+		types:  make(map[string]ast.Expr),
+		marked: make(map[string]bool),
+		sizes:  make(map[string]int),
+	}
+	pkg, err := g.parse(dir, output)
+	if err != nil {
+		return nil, err
+	}
+	return g.generate(pkg)
+}
+
+// parse collects the type declarations of all non-test files in dir, except output.
+func (g *generator) parse(dir, output string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	var pkg string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == output {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return "", err
+		}
+		pkg = f.Name.Name
+		if constrained(f) {
+			// The generated code is used on every OS.
+			continue
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.TypeParams != nil {
+					continue
+				}
+				g.types[ts.Name.Name] = ts.Type
+				if hasMarker(ts.Doc) || (gen.Lparen == token.NoPos && hasMarker(gen.Doc)) {
+					g.marked[ts.Name.Name] = true
+				}
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// hasMarker reports whether doc contains the directive, that selects a struct.
+func hasMarker(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if c.Text == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// constrained reports whether f has build constraints.
+func constrained(f *ast.File) bool {
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, "//go:build") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// size returns the encoded size of expr or -1, if expr is not of fixed size.
+func (g *generator) size(expr ast.Expr) int {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if s, ok := basicSizes[t.Name]; ok {
+			return s
+		}
+		if s, ok := g.sizes[t.Name]; ok {
+			return s
+		}
+		decl, ok := g.types[t.Name]
+		if !ok {
+			return -1
+		}
+		// Guard against recursive types.
+		g.sizes[t.Name] = -1
+		s := g.size(decl)
+		g.sizes[t.Name] = s
+		return s
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return -1
+		}
+		lit, ok := t.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return -1
+		}
+		n, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return -1
+		}
+		s := g.size(t.Elt)
+		if s < 0 {
+			return -1
+		}
+		return n * s
+	case *ast.StructType:
+		total := 0
+		for _, field := range t.Fields.List {
+			s := g.size(field.Type)
+			if s < 0 {
+				return -1
+			}
+			names := len(field.Names)
+			if names == 0 {
+				names = 1
+			}
+			total += names * s
+		}
+		return total
+	}
+	return -1
+}
+
+// underlying returns the name of the basic type of expr or an empty string, if expr is
+// not a basic type.
+func (g *generator) underlying(expr ast.Expr) string {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if _, ok := basicSizes[ident.Name]; ok {
+		return ident.Name
+	}
+	if decl, ok := g.types[ident.Name]; ok {
+		return g.underlying(decl)
+	}
+	return ""
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate(pkg string) ([]byte, error) {
+	var names []string
+	for name := range g.marked {
+		if _, ok := g.types[name].(*ast.StructType); !ok {
+			return nil, fmt.Errorf("%s is marked, but not a struct", name)
+		}
+		if g.size(&ast.Ident{Name: name}) < 0 {
+			return nil, fmt.Errorf("%s is marked, but not of fixed size", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.printf("// Code generated by \"structgen\"; DO NOT EDIT.\n\n")
+	g.printf("package %s\n", pkg)
+	for _, name := range names {
+		st := g.types[name].(*ast.StructType)
+		size := g.size(&ast.Ident{Name: name})
+
+		g.printf("\nfunc (s *%s) binaryLen() int {\n", name)
+		g.printf("if s == nil {\nreturn -1\n}\n")
+		g.printf("return %d\n}\n", size)
+
+		g.printf("\nfunc (s *%s) putBinary(b []byte) {\n", name)
+		if size > 0 {
+			g.printf("_ = b[%d]\n", size-1)
+		}
+		if err := g.fields(st, size, true); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		g.printf("}\n")
+
+		g.printf("\nfunc (s *%s) getBinary(b []byte) {\n", name)
+		if size > 0 {
+			g.printf("_ = b[%d]\n", size-1)
+		}
+		if err := g.fields(st, size, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		g.printf("}\n")
+	}
+	return format.Source(g.buf.Bytes())
+}
+
+// fields writes the encoding or decoding of the fields of st.
+func (g *generator) fields(st *ast.StructType, size int, encode bool) error {
+	offset := 0
+	for _, field := range st.Fields.List {
+		fsize := g.size(field.Type)
+		names := field.Names
+		if len(names) == 0 {
+			// Embedded fields are named after their type.
+			names = []*ast.Ident{field.Type.(*ast.Ident)}
+		}
+		for _, name := range names {
+			if name.Name != "_" {
+				if err := g.value("s."+name.Name, field.Type, strconv.Itoa(offset), encode); err != nil {
+					return err
+				}
+			}
+			offset += fsize
+		}
+	}
+	if offset != size {
+		return fmt.Errorf("size of struct does not match: %d != %d", offset, size)
+	}
+	return nil
+}
+
+// value writes the encoding or decoding of the value v of type expr at offset.
+func (g *generator) value(v string, expr ast.Expr, offset string, encode bool) error {
+	if arr, ok := expr.(*ast.ArrayType); ok {
+		esize := g.size(arr.Elt)
+		if ident, ok := arr.Elt.(*ast.Ident); ok && (ident.Name == "uint8" || ident.Name == "byte") {
+			if encode {
+				g.printf("copy(b[%s:], %s[:])\n", offset, v)
+			} else {
+				g.printf("copy(%s[:], b[%s:])\n", v, offset)
+			}
+			return nil
+		}
+		g.printf("for i := range %s {\n", v)
+		if err := g.value(v+"[i]", arr.Elt, fmt.Sprintf("%s+i*%d", offset, esize), encode); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	}
+
+	ident := expr.(*ast.Ident)
+	basic := g.underlying(expr)
+	if basic == "" {
+		// Nested struct.
+		if !g.marked[ident.Name] {
+			return fmt.Errorf("nested struct %s is not marked", ident.Name)
+		}
+		if encode {
+			g.printf("%s.putBinary(b[%s:])\n", v, offset)
+		} else {
+			g.printf("%s.getBinary(b[%s:])\n", v, offset)
+		}
+		return nil
+	}
+
+	switch basic {
+	case "bool":
+		if encode {
+			g.printf("if %s {\nb[%s] = 1\n}\n", v, offset)
+		} else {
+			g.printf("%s = b[%s] != 0\n", v, offset)
+		}
+	case "int8", "uint8", "byte":
+		if encode {
+			g.printf("b[%s] = %s\n", offset, convert("byte", ident.Name, v))
+		} else {
+			g.printf("%s = %s\n", v, convert(ident.Name, "byte", fmt.Sprintf("b[%s]", offset)))
+		}
+	default:
+		bits := strconv.Itoa(basicSizes[basic] * 8)
+		if encode {
+			g.printf("nativeEndian.PutUint%s(b[%s:], %s)\n", bits, offset, convert("uint"+bits, ident.Name, v))
+		} else {
+			g.printf("%s = %s\n", v, convert(ident.Name, "uint"+bits,
+				fmt.Sprintf("nativeEndian.Uint%s(b[%s:])", bits, offset)))
+		}
+	}
+	return nil
+}
+
+// convert returns the conversion of v of type from into type to.
+func convert(to, from, v string) string {
+	if to == from || (to == "byte" && from == "uint8") || (to == "uint8" && from == "byte") {
+		return v
+	}
+	return fmt.Sprintf("%s(%s)", to, v)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestGenerated fails, if structs_gen.go is not up to date with the marked structs.
+func TestGenerated(t *testing.T) {
+	const output = "structs_gen.go"
+	dir := filepath.Join("..", "..")
+
+	want, err := run(dir, output)
+	if err != nil {
+		t.Fatalf("could not generate code: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, output))
+	if err != nil {
+		t.Fatalf("could not read %s: %v", output, err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Fatalf("%s is outdated, run 'go generate' (want +got):\n%s", output, diff)
+	}
+}
@@ -52,6 +52,19 @@ const (
 	RTM_GETACTION = linux.RTM_GETACTION
 )
 
+// Make linter happy with this comment.
+const (
+	ENOENT     = linux.ENOENT
+	EEXIST     = linux.EEXIST
+	EINVAL     = linux.EINVAL
+	EBUSY      = linux.EBUSY
+	ENODEV     = linux.ENODEV
+	EOPNOTSUPP = linux.EOPNOTSUPP
+	ENOBUFS    = linux.ENOBUFS
+	EPERM      = linux.EPERM
+	EACCES     = linux.EACCES
+)
+
 // For tests:
 const (
 	ETH_P_IP  = linux.ETH_P_IP
@@ -3,6 +3,8 @@
 
 package unix
 
+import "syscall"
+
 type IfInfomsg struct {
 	Family uint8
 	_      uint8
@@ -50,6 +52,18 @@ const (
 	RTM_GETACTION = 50
 )
 
+const (
+	ENOENT     = syscall.Errno(0x2)
+	EEXIST     = syscall.Errno(0x11)
+	EINVAL     = syscall.Errno(0x16)
+	EBUSY      = syscall.Errno(0x10)
+	ENODEV     = syscall.Errno(0x13)
+	EOPNOTSUPP = syscall.Errno(0x5f)
+	ENOBUFS    = syscall.Errno(0x69)
+	EPERM      = syscall.Errno(0x1)
+	EACCES     = syscall.Errno(0xd)
+)
+
 const (
 	ETH_P_IP  = 0x800
 	ETH_P_ALL = 0x3
@@ -32,23 +32,104 @@ const (
 	ActNoReplace = 0
 )
 
+// Verdict is the control action, that defines how processing continues after an
+// action, as TC_ACT_* from include/uapi/linux/pkt_cls.h.
+type Verdict uint32
+
 // Various action returns.
 const (
-	ActOk         = 0
-	ActReclassify = 1
-	ActShot       = 2
-	ActPipe       = 3
-	ActStolen     = 4
-	ActQueued     = 5
-	ActRepeat     = 6
-	ActRedirect   = 7
-	ActTrap       = 8
+	ActOk         Verdict = 0
+	ActReclassify Verdict = 1
+	ActShot       Verdict = 2
+	ActPipe       Verdict = 3
+	ActStolen     Verdict = 4
+	ActQueued     Verdict = 5
+	ActRepeat     Verdict = 6
+	ActRedirect   Verdict = 7
+	ActTrap       Verdict = 8
+	ActUnspec     Verdict = 0xFFFFFFFF
+)
+
+const (
+	actExtShift   = 28
+	actExtValMask = 1<<actExtShift - 1
+	actJump       = 1 << actExtShift
+	actGotoChain  = 2 << actExtShift
 )
 
+// ActGotoChain returns the Verdict to continue processing with the filters of chain.
+func ActGotoChain(chain uint32) (Verdict, error) {
+	if chain > actExtValMask {
+		return ActUnspec, fmt.Errorf("chain %d: %w", chain, ErrInvalidArg)
+	}
+	return Verdict(actGotoChain | chain), nil
+}
+
+// ActJump returns the Verdict to skip the next n actions of the list.
+func ActJump(n uint32) (Verdict, error) {
+	if n > actExtValMask {
+		return ActUnspec, fmt.Errorf("jump %d: %w", n, ErrInvalidArg)
+	}
+	return Verdict(actJump | n), nil
+}
+
+// GotoChain returns the chain and true, if v continues with another chain.
+func (v Verdict) GotoChain() (uint32, bool) {
+	if v == ActUnspec || v&^actExtValMask != actGotoChain {
+		return 0, false
+	}
+	return uint32(v & actExtValMask), true
+}
+
+// Jump returns the number of skipped actions and true, if v is a jump.
+func (v Verdict) Jump() (uint32, bool) {
+	if v == ActUnspec || v&^actExtValMask != actJump {
+		return 0, false
+	}
+	return uint32(v & actExtValMask), true
+}
+
+// String returns the verdict in the notation of iproute2.
+func (v Verdict) String() string {
+	switch v {
+	case ActOk:
+		return "pass"
+	case ActReclassify:
+		return "reclassify"
+	case ActShot:
+		return "drop"
+	case ActPipe:
+		return "pipe"
+	case ActStolen:
+		return "stolen"
+	case ActQueued:
+		return "queued"
+	case ActRepeat:
+		return "repeat"
+	case ActRedirect:
+		return "redirect"
+	case ActTrap:
+		return "trap"
+	case ActUnspec:
+		return "continue"
+	}
+	if chain, ok := v.GotoChain(); ok {
+		return fmt.Sprintf("goto chain %d", chain)
+	}
+	if n, ok := v.Jump(); ok {
+		return fmt.Sprintf("jump %d", n)
+	}
+	return fmt.Sprintf("unknown verdict (%d)", uint32(v))
+}
+
 // Action represents action attributes of various filters and classes
 type Action struct {
-	Kind        string
-	Index       uint32
+	Kind  string
+	Index uint32
+	// Reference binds to the existing shared action of Kind with Index. If it
+	// is set, the attributes of the specific kind are not used.
+	Reference bool
+
 	Stats       *GenStats
 	Cookie      *[]byte
 	Flags       *uint64 // 32-bit bitfield value; 32-bit bitfield selector
@@ -99,6 +180,7 @@ func unmarshalAction(data []byte, info *Action) error {
 		return err
 	}
 	var actOptions []byte
+	var hasOptions bool
 	for ad.Next() {
 		switch ad.Type() {
 		case tcaActKind:
@@ -107,6 +189,7 @@ func unmarshalAction(data []byte, info *Action) error {
 			info.Index = ad.Uint32()
 		case tcaActOptions:
 			actOptions = ad.Bytes()
+			hasOptions = true
 		case tcaActCookie:
 			tmp := ad.Bytes()
 			info.Cookie = &tmp
@@ -134,7 +217,7 @@ func unmarshalAction(data []byte, info *Action) error {
 			return fmt.Errorf("unmarshalAction()\t%d\n\t%v", ad.Type(), ad.Bytes())
 		}
 	}
-	if len(actOptions) > 0 {
+	if hasOptions {
 		if err := extractActOptions(actOptions, info, info.Kind); err != nil {
 			return err
 		}
@@ -171,46 +254,56 @@ func marshalAction(cmd int, info *Action, actOption uint16) ([]byte, error) {
 	var err error
 	var data []byte
 
+	act := info
+	if info.Reference {
+		if info.Index == 0 {
+			return []byte{}, fmt.Errorf("Action: reference requires Index: %w", ErrNoArg)
+		}
+		if act, err = referenceAction(info.Kind, info.Index); err != nil {
+			return []byte{}, err
+		}
+	}
+
 	// TODO: improve logic and check combinations
-	switch info.Kind {
+	switch act.Kind {
 	case "bpf":
-		data, err = marshalActBpf(info.Bpf)
+		data, err = marshalActBpf(act.Bpf)
 	case "connmark":
-		data, err = marshalConnmark(info.ConnMark)
+		data, err = marshalConnmark(act.ConnMark)
 	case "csum":
-		data, err = marshalCsum(info.CSum)
+		data, err = marshalCsum(act.CSum)
 	case "ct":
-		data, err = marshalCt(info.Ct)
+		data, err = marshalCt(act.Ct)
 	case "ctinfo":
-		data, err = marshalCtInfo(info.CtInfo)
+		data, err = marshalCtInfo(act.CtInfo)
 	case "defact":
-		data, err = marshalDefact(info.Defact)
+		data, err = marshalDefact(act.Defact)
 	case "gact":
-		data, err = marshalGact(info.Gact)
+		data, err = marshalGact(act.Gact)
 	case "gate":
-		data, err = marshalGate(info.Gate)
+		data, err = marshalGate(act.Gate)
 	case "ife":
-		data, err = marshalIfe(info.Ife)
+		data, err = marshalIfe(act.Ife)
 	case "ipt":
-		data, err = marshalIpt(info.Ipt)
+		data, err = marshalIpt(act.Ipt)
 	case "mirred":
-		data, err = marshalMirred(info.Mirred)
+		data, err = marshalMirred(act.Mirred)
 	case "nat":
-		data, err = marshalNat(info.Nat)
+		data, err = marshalNat(act.Nat)
 	case "sample":
-		data, err = marshalSample(info.Sample)
+		data, err = marshalSample(act.Sample)
 	case "vlan":
-		data, err = marshalVlan(info.VLan)
+		data, err = marshalVlan(act.VLan)
 	case "police":
-		data, err = marshalPolice(info.Police)
+		data, err = marshalPolice(act.Police)
 	case "tunnel_key":
-		data, err = marshalTunnelKey(info.TunnelKey)
+		data, err = marshalTunnelKey(act.TunnelKey)
 	case "mpls":
-		data, err = marshalMPLS(info.MPLS)
+		data, err = marshalMPLS(act.MPLS)
 	case "skbedit":
-		data, err = marshalSkbEdit(info.SkbEdit)
+		data, err = marshalSkbEdit(act.SkbEdit)
 	case "skbmod":
-		data, err = marshalSkbMod(info.SkbMod)
+		data, err = marshalSkbMod(act.SkbMod)
 	default:
 		return []byte{}, fmt.Errorf("unknown kind '%s'", info.Kind)
 	}
@@ -219,7 +312,10 @@ func marshalAction(cmd int, info *Action, actOption uint16) ([]byte, error) {
 		return []byte{}, err
 	}
 
-	options = append(options, tcOption{Interpretation: vtBytes, Type: actOption, Data: data})
+	if err == nil {
+		// Without parameters, there are no options to send.
+		options = append(options, tcOption{Interpretation: vtBytes, Type: actOption, Data: data})
+	}
 	options = append(options, tcOption{Interpretation: vtString, Type: tcaActKind, Data: info.Kind})
 
 	if info.Index != 0 {
@@ -326,3 +422,350 @@ func extractActOptions(data []byte, act *Action, kind string) error {
 	}
 	return err
 }
+
+// referenceAction returns an Action of kind, that only carries index in the
+// parameters of the specific kind. The kernel uses this index to bind to an
+// existing action.
+func referenceAction(kind string, index uint32) (*Action, error) {
+	ref := &Action{Kind: kind, Index: index}
+	switch kind {
+	case "bpf":
+		ref.Bpf = &ActBpf{Parms: &ActBpfParms{Index: index}}
+	case "connmark":
+		ref.ConnMark = &Connmark{Parms: &ConnmarkParam{Index: index}}
+	case "csum":
+		ref.CSum = &Csum{Parms: &CsumParms{Index: index}}
+	case "ct":
+		ref.Ct = &Ct{Parms: &CtParms{Index: index}}
+	case "ctinfo":
+		ref.CtInfo = &CtInfo{Act: &CtInfoAct{Index: index}}
+	case "defact":
+		ref.Defact = &Defact{Parms: &DefactParms{Index: index}}
+	case "gact":
+		ref.Gact = &Gact{Parms: &GactParms{Index: index}}
+	case "gate":
+		ref.Gate = &Gate{Parms: &GateParms{Index: index}}
+	case "ife":
+		ref.Ife = &Ife{Parms: &IfeParms{Index: index}}
+	case "ipt":
+		ref.Ipt = &Ipt{Index: uint32Ptr(index)}
+	case "mirred":
+		ref.Mirred = &Mirred{Parms: &MirredParam{Index: index}}
+	case "nat":
+		ref.Nat = &Nat{Parms: &NatParms{Index: index}}
+	case "sample":
+		ref.Sample = &Sample{Parms: &SampleParms{Index: index}}
+	case "vlan":
+		ref.VLan = &VLan{Parms: &VLanParms{Index: index}}
+	case "police":
+		ref.Police = &Police{Tbf: &Policy{Index: index}}
+	case "tunnel_key":
+		ref.TunnelKey = &TunnelKey{Parms: &TunnelParms{Index: index}}
+	case "mpls":
+		ref.MPLS = &MPLS{Parms: &MPLSParam{Index: index}}
+	case "skbedit":
+		ref.SkbEdit = &SkbEdit{Parms: &SkbEditParms{Index: index}}
+	case "skbmod":
+		ref.SkbMod = &SkbMod{Parms: &SkbModParms{Index: index}}
+	default:
+		return nil, fmt.Errorf("unknown kind '%s'", kind)
+	}
+	return ref, nil
+}
+
+// RefCnt returns the reference count of a decoded action, independent of its kind.
+func (a *Action) RefCnt() uint32 {
+	refCnt, _ := a.counts()
+	return refCnt
+}
+
+// BindCnt returns the number of filters, that are bound to a decoded action,
+// independent of its kind.
+func (a *Action) BindCnt() uint32 {
+	_, bindCnt := a.counts()
+	return bindCnt
+}
+
+func (a *Action) counts() (uint32, uint32) {
+	switch {
+	case a.Bpf != nil && a.Bpf.Parms != nil:
+		return a.Bpf.Parms.Refcnt, a.Bpf.Parms.Bindcnt
+	case a.ConnMark != nil && a.ConnMark.Parms != nil:
+		return a.ConnMark.Parms.RefCnt, a.ConnMark.Parms.BindCnt
+	case a.CSum != nil && a.CSum.Parms != nil:
+		return a.CSum.Parms.RefCnt, a.CSum.Parms.BindCnt
+	case a.Ct != nil && a.Ct.Parms != nil:
+		return a.Ct.Parms.RefCnt, a.Ct.Parms.BindCnt
+	case a.CtInfo != nil && a.CtInfo.Act != nil:
+		return a.CtInfo.Act.RefCnt, a.CtInfo.Act.BindCnt
+	case a.Defact != nil && a.Defact.Parms != nil:
+		return a.Defact.Parms.RefCnt, a.Defact.Parms.BindCnt
+	case a.Gact != nil && a.Gact.Parms != nil:
+		return a.Gact.Parms.RefCnt, a.Gact.Parms.BindCnt
+	case a.Gate != nil && a.Gate.Parms != nil:
+		return a.Gate.Parms.RefCnt, a.Gate.Parms.BindCnt
+	case a.Ife != nil && a.Ife.Parms != nil:
+		return a.Ife.Parms.RefCnt, a.Ife.Parms.BindCnt
+	case a.Ipt != nil && a.Ipt.Cnt != nil:
+		return a.Ipt.Cnt.RefCnt, a.Ipt.Cnt.BindCnt
+	case a.Mirred != nil && a.Mirred.Parms != nil:
+		return a.Mirred.Parms.RefCnt, a.Mirred.Parms.BindCnt
+	case a.Nat != nil && a.Nat.Parms != nil:
+		return a.Nat.Parms.RefCnt, a.Nat.Parms.BindCnt
+	case a.Sample != nil && a.Sample.Parms != nil:
+		return a.Sample.Parms.RefCnt, a.Sample.Parms.BindCnt
+	case a.VLan != nil && a.VLan.Parms != nil:
+		return a.VLan.Parms.RefCnt, a.VLan.Parms.BindCnt
+	case a.Police != nil && a.Police.Tbf != nil:
+		return a.Police.Tbf.RefCnt, a.Police.Tbf.BindCnt
+	case a.TunnelKey != nil && a.TunnelKey.Parms != nil:
+		return a.TunnelKey.Parms.RefCnt, a.TunnelKey.Parms.BindCnt
+	case a.MPLS != nil && a.MPLS.Parms != nil:
+		return a.MPLS.Parms.RefCnt, a.MPLS.Parms.BindCnt
+	case a.SkbEdit != nil && a.SkbEdit.Parms != nil:
+		return a.SkbEdit.Parms.RefCnt, a.SkbEdit.Parms.BindCnt
+	case a.SkbMod != nil && a.SkbMod.Parms != nil:
+		return a.SkbMod.Parms.RefCnt, a.SkbMod.Parms.BindCnt
+	}
+	return 0, 0
+}
+
+// parmsIndex returns the index of a from the action specific parameters.
+func (a *Action) parmsIndex() uint32 {
+	switch {
+	case a.Bpf != nil && a.Bpf.Parms != nil:
+		return a.Bpf.Parms.Index
+	case a.ConnMark != nil && a.ConnMark.Parms != nil:
+		return a.ConnMark.Parms.Index
+	case a.CSum != nil && a.CSum.Parms != nil:
+		return a.CSum.Parms.Index
+	case a.Ct != nil && a.Ct.Parms != nil:
+		return a.Ct.Parms.Index
+	case a.CtInfo != nil && a.CtInfo.Act != nil:
+		return a.CtInfo.Act.Index
+	case a.Defact != nil && a.Defact.Parms != nil:
+		return a.Defact.Parms.Index
+	case a.Gact != nil && a.Gact.Parms != nil:
+		return a.Gact.Parms.Index
+	case a.Gate != nil && a.Gate.Parms != nil:
+		return a.Gate.Parms.Index
+	case a.Ife != nil && a.Ife.Parms != nil:
+		return a.Ife.Parms.Index
+	case a.Ipt != nil && a.Ipt.Index != nil:
+		return *a.Ipt.Index
+	case a.Mirred != nil && a.Mirred.Parms != nil:
+		return a.Mirred.Parms.Index
+	case a.Nat != nil && a.Nat.Parms != nil:
+		return a.Nat.Parms.Index
+	case a.Sample != nil && a.Sample.Parms != nil:
+		return a.Sample.Parms.Index
+	case a.VLan != nil && a.VLan.Parms != nil:
+		return a.VLan.Parms.Index
+	case a.Police != nil && a.Police.Tbf != nil:
+		return a.Police.Tbf.Index
+	case a.TunnelKey != nil && a.TunnelKey.Parms != nil:
+		return a.TunnelKey.Parms.Index
+	case a.MPLS != nil && a.MPLS.Parms != nil:
+		return a.MPLS.Parms.Index
+	case a.SkbEdit != nil && a.SkbEdit.Parms != nil:
+		return a.SkbEdit.Parms.Index
+	case a.SkbMod != nil && a.SkbMod.Parms != nil:
+		return a.SkbMod.Parms.Index
+	}
+	return 0
+}
+
+// withoutReadOnly returns a copy of a without the attributes, that are reported by the
+// kernel but can not be altered, like statistics and reference counts. a is not modified.
+func (a *Action) withoutReadOnly() *Action {
+	if a == nil {
+		return nil
+	}
+	res := *a
+	res.Stats = nil
+	res.UsedHwStats = nil
+	res.InHwCount = nil
+	switch {
+	case a.Bpf != nil:
+		bpf := *a.Bpf
+		bpf.Tm = nil
+		if bpf.Parms != nil {
+			parms := *bpf.Parms
+			parms.Refcnt, parms.Bindcnt = 0, 0
+			bpf.Parms = &parms
+		}
+		res.Bpf = &bpf
+	case a.ConnMark != nil:
+		connMark := *a.ConnMark
+		connMark.Tm = nil
+		if connMark.Parms != nil {
+			parms := *connMark.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			connMark.Parms = &parms
+		}
+		res.ConnMark = &connMark
+	case a.CSum != nil:
+		csum := *a.CSum
+		csum.Tm = nil
+		if csum.Parms != nil {
+			parms := *csum.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			csum.Parms = &parms
+		}
+		res.CSum = &csum
+	case a.Ct != nil:
+		ct := *a.Ct
+		ct.Tm = nil
+		if ct.Parms != nil {
+			parms := *ct.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			ct.Parms = &parms
+		}
+		res.Ct = &ct
+	case a.CtInfo != nil:
+		ctInfo := *a.CtInfo
+		ctInfo.Tm = nil
+		ctInfo.StatsDscpSet = nil
+		ctInfo.StatsDscpError = nil
+		ctInfo.StatsCpMarkSet = nil
+		if ctInfo.Act != nil {
+			act := *ctInfo.Act
+			act.RefCnt, act.BindCnt = 0, 0
+			ctInfo.Act = &act
+		}
+		res.CtInfo = &ctInfo
+	case a.Defact != nil:
+		defact := *a.Defact
+		defact.Tm = nil
+		if defact.Parms != nil {
+			parms := *defact.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			defact.Parms = &parms
+		}
+		res.Defact = &defact
+	case a.Gact != nil:
+		gact := *a.Gact
+		gact.Tm = nil
+		if gact.Parms != nil {
+			parms := *gact.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			gact.Parms = &parms
+		}
+		res.Gact = &gact
+	case a.Gate != nil:
+		gate := *a.Gate
+		gate.Tm = nil
+		if gate.Parms != nil {
+			parms := *gate.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			gate.Parms = &parms
+		}
+		res.Gate = &gate
+	case a.Ife != nil:
+		ife := *a.Ife
+		ife.Tm = nil
+		if ife.Parms != nil {
+			parms := *ife.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			ife.Parms = &parms
+		}
+		res.Ife = &ife
+	case a.Ipt != nil:
+		ipt := *a.Ipt
+		ipt.Tm = nil
+		if ipt.Cnt != nil {
+			cnt := *ipt.Cnt
+			cnt.RefCnt, cnt.BindCnt = 0, 0
+			ipt.Cnt = &cnt
+		}
+		res.Ipt = &ipt
+	case a.Mirred != nil:
+		mirred := *a.Mirred
+		mirred.Tm = nil
+		if mirred.Parms != nil {
+			parms := *mirred.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			mirred.Parms = &parms
+		}
+		res.Mirred = &mirred
+	case a.Nat != nil:
+		nat := *a.Nat
+		nat.Tm = nil
+		if nat.Parms != nil {
+			parms := *nat.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			nat.Parms = &parms
+		}
+		res.Nat = &nat
+	case a.Sample != nil:
+		sample := *a.Sample
+		sample.Tm = nil
+		if sample.Parms != nil {
+			parms := *sample.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			sample.Parms = &parms
+		}
+		res.Sample = &sample
+	case a.VLan != nil:
+		vlan := *a.VLan
+		vlan.Tm = nil
+		if vlan.Parms != nil {
+			parms := *vlan.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			vlan.Parms = &parms
+		}
+		res.VLan = &vlan
+	case a.Police != nil:
+		res.Police = a.Police.withoutReadOnly()
+	case a.TunnelKey != nil:
+		tunnelKey := *a.TunnelKey
+		tunnelKey.Tm = nil
+		if tunnelKey.Parms != nil {
+			parms := *tunnelKey.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			tunnelKey.Parms = &parms
+		}
+		res.TunnelKey = &tunnelKey
+	case a.MPLS != nil:
+		mpls := *a.MPLS
+		mpls.Tm = nil
+		if mpls.Parms != nil {
+			parms := *mpls.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			mpls.Parms = &parms
+		}
+		res.MPLS = &mpls
+	case a.SkbEdit != nil:
+		skbEdit := *a.SkbEdit
+		skbEdit.Tm = nil
+		if skbEdit.Parms != nil {
+			parms := *skbEdit.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			skbEdit.Parms = &parms
+		}
+		res.SkbEdit = &skbEdit
+	case a.SkbMod != nil:
+		skbMod := *a.SkbMod
+		skbMod.Tm = nil
+		if skbMod.Parms != nil {
+			parms := *skbMod.Parms
+			parms.RefCnt, parms.BindCnt = 0, 0
+			skbMod.Parms = &parms
+		}
+		res.SkbMod = &skbMod
+	}
+	return &res
+}
+
+// actionsWithoutReadOnly returns a copy of actions, in which each action is replaced by
+// Action.withoutReadOnly.
+func actionsWithoutReadOnly(actions *[]*Action) *[]*Action {
+	if actions == nil {
+		return nil
+	}
+	res := make([]*Action, 0, len(*actions))
+	for _, a := range *actions {
+		res = append(res, a.withoutReadOnly())
+	}
+	return &res
+}
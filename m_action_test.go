@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/florianl/go-tc/internal/unix"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -74,7 +75,7 @@ func TestAction(t *testing.T) {
 		}},
 		"police": {val: Action{
 			Kind:   "police",
-			Police: &Police{AvRate: uint32Ptr(1337), Result: uint32Ptr(42)},
+			Police: &Police{AvRate: uint32Ptr(1337), Result: verdictPtr(42)},
 		}},
 		"sample": {val: Action{
 			Kind:   "sample",
@@ -197,3 +198,126 @@ func Test_extractActOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestActionReference(t *testing.T) {
+	kinds := []string{"bpf", "connmark", "csum", "ct", "ctinfo", "defact", "gact", "gate", "ife", "ipt",
+		"mirred", "nat", "sample", "vlan", "police", "tunnel_key", "mpls", "skbedit", "skbmod"}
+	for _, kind := range kinds {
+		t.Run(kind, func(t *testing.T) {
+			data, err := marshalActions(unix.RTM_NEWTFILTER, []*Action{{Kind: kind, Index: 42, Reference: true}})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			val := []*Action{}
+			if err := unmarshalActions(data, &val); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want, _ := referenceAction(kind, 42)
+			if diff := cmp.Diff([]*Action{want}, val); diff != "" {
+				t.Fatalf("Action missmatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("without index", func(t *testing.T) {
+		_, err := marshalAction(unix.RTM_NEWTFILTER, &Action{Kind: "police", Reference: true}, tcaActOptions)
+		if !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("unknown kind", func(t *testing.T) {
+		_, err := marshalAction(unix.RTM_NEWTFILTER, &Action{Kind: "test", Index: 1, Reference: true}, tcaActOptions)
+		if err == nil {
+			t.Fatalf("expected error but got none")
+		}
+	})
+}
+
+func TestActionCounts(t *testing.T) {
+	tests := map[string]struct {
+		val     Action
+		refCnt  uint32
+		bindCnt uint32
+	}{
+		"empty":     {},
+		"bpf":       {val: Action{Bpf: &ActBpf{Parms: &ActBpfParms{Refcnt: 2, Bindcnt: 1}}}, refCnt: 2, bindCnt: 1},
+		"ctinfo":    {val: Action{CtInfo: &CtInfo{Act: &CtInfoAct{RefCnt: 3, BindCnt: 2}}}, refCnt: 3, bindCnt: 2},
+		"ipt":       {val: Action{Ipt: &Ipt{Cnt: &IptCnt{RefCnt: 4, BindCnt: 3}}}, refCnt: 4, bindCnt: 3},
+		"police":    {val: Action{Police: &Police{Tbf: &Policy{RefCnt: 5, BindCnt: 4}}}, refCnt: 5, bindCnt: 4},
+		"gact":      {val: Action{Gact: &Gact{Parms: &GactParms{RefCnt: 6, BindCnt: 5}}}, refCnt: 6, bindCnt: 5},
+		"nil parms": {val: Action{Mirred: &Mirred{}}},
+	}
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := testcase.val.RefCnt(); got != testcase.refCnt {
+				t.Fatalf("expected RefCnt %d but got %d", testcase.refCnt, got)
+			}
+			if got := testcase.val.BindCnt(); got != testcase.bindCnt {
+				t.Fatalf("expected BindCnt %d but got %d", testcase.bindCnt, got)
+			}
+		})
+	}
+}
+
+func TestVerdict(t *testing.T) {
+	gotoChain, err := ActGotoChain(42)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	jump, err := ActJump(3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := map[Verdict]string{
+		ActOk:         "pass",
+		ActShot:       "drop",
+		ActPipe:       "pipe",
+		ActReclassify: "reclassify",
+		ActStolen:     "stolen",
+		ActTrap:       "trap",
+		ActUnspec:     "continue",
+		gotoChain:     "goto chain 42",
+		jump:          "jump 3",
+		Verdict(42):   "unknown verdict (42)",
+	}
+	for verdict, want := range tests {
+		if got := verdict.String(); got != want {
+			t.Fatalf("expected %q but got %q", want, got)
+		}
+	}
+
+	if uint32(gotoChain) != 0x2000002A {
+		t.Fatalf("unexpected goto chain encoding: %#x", uint32(gotoChain))
+	}
+	if chain, ok := gotoChain.GotoChain(); !ok || chain != 42 {
+		t.Fatalf("unexpected chain: %d, %v", chain, ok)
+	}
+	if _, ok := gotoChain.Jump(); ok {
+		t.Fatalf("goto chain is not a jump")
+	}
+	if n, ok := jump.Jump(); !ok || n != 3 {
+		t.Fatalf("unexpected jump: %d, %v", n, ok)
+	}
+	if _, ok := ActUnspec.GotoChain(); ok {
+		t.Fatalf("unspec is not a goto chain")
+	}
+	if _, err := ActGotoChain(1 << 28); !errors.Is(err, ErrInvalidArg) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ActJump(1 << 28); !errors.Is(err, ErrInvalidArg) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Verdicts are encoded as part of the parameters of an action.
+	data, err := marshalGact(&Gact{Parms: &GactParms{Index: 1, Action: gotoChain}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	val := Gact{}
+	if err := unmarshalGact(data, &val); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if val.Parms.Action != gotoChain {
+		t.Fatalf("unexpected verdict: %s", val.Parms.Action)
+	}
+}
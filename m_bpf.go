@@ -111,10 +111,12 @@ func marshalActBpf(info *ActBpf) ([]byte, error) {
 }
 
 // ActBpfParms from include/uapi/linux/tc_act/tc_bpf.h
+//
+//structgen:binary
 type ActBpfParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	Refcnt  uint32
 	Bindcnt uint32
 }
@@ -20,10 +20,12 @@ type Connmark struct {
 }
 
 // ConnmarkParam from include/uapi/linux/tc_act/tc_connmark.h
+//
+//structgen:binary
 type ConnmarkParam struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 	Zone    uint16
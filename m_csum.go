@@ -69,10 +69,12 @@ func unmarshalCsum(data []byte, info *Csum) error {
 }
 
 // CsumParms from include/uapi/linux/tc_act/tc_csum.h
+//
+//structgen:binary
 type CsumParms struct {
 	Index       uint32
 	Capab       uint32
-	Action      uint32
+	Action      Verdict
 	RefCnt      uint32
 	BindCnt     uint32
 	UpdateFlags uint32
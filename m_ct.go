@@ -1,6 +1,7 @@
 package tc
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net"
 
@@ -29,6 +30,49 @@ const (
 	tcaCtHelperProto
 )
 
+// Flags for Ct.Action from include/uapi/linux/tc_act/tc_ct.h.
+const (
+	CtActCommit uint16 = 1 << iota
+	CtActForce
+	CtActClear
+	CtActNat
+	CtActNatSrc
+	CtActNatDst
+)
+
+const ctActNatMask = CtActNat | CtActNatSrc | CtActNatDst
+
+// CtLabel represents the 128 bit connection tracking label. The bytes are in the
+// same order as in the hexadecimal notation of iproute2.
+type CtLabel [16]byte
+
+// String returns the hexadecimal notation of the label.
+func (l CtLabel) String() string {
+	return hex.EncodeToString(l[:])
+}
+
+// CtNatMode defines the kind of NAT that is applied by Ct.
+type CtNatMode uint8
+
+// Various NAT modes of Ct.
+const (
+	// CtNatRestore applies the NAT of an existing connection.
+	CtNatRestore CtNatMode = iota
+	CtNatSrc
+	CtNatDst
+)
+
+// CtNat is the NAT configuration of Ct. AddrMin and AddrMax define the range of
+// addresses and determine the address family. If AddrMax is nil, only AddrMin is
+// used. PortMin and PortMax define the port range, where 0 means unset.
+type CtNat struct {
+	Mode    CtNatMode
+	AddrMin net.IP
+	AddrMax net.IP
+	PortMin uint16
+	PortMax uint16
+}
+
 // Ct contains attributes of the ct discipline
 type Ct struct {
 	Parms        *CtParms
@@ -39,18 +83,30 @@ type Ct struct {
 	MarkMask     *uint32
 	NatIPv4Min   *net.IP
 	NatIPv4Max   *net.IP
+	NatIPv6Min   *net.IP
+	NatIPv6Max   *net.IP
 	NatPortMin   *uint16
 	NatPortMax   *uint16
 	HelperName   *string
 	HelperFamily *uint8
 	HelperProto  *uint8
+	Labels       *CtLabel
+	LabelsMask   *CtLabel
+
+	// Nat is the typed alternative to the NatIP* and NatPort* fields. If it is set,
+	// the NAT flags of Action are derived from it and the NatIP* and NatPort* fields
+	// have to be unset or describe the same NAT. On decoding, Nat is populated in
+	// addition to these fields, if Action contains CtActNat.
+	Nat *CtNat
 }
 
 // CtParms contains further ct attributes.
+//
+//structgen:binary
 type CtParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
@@ -88,6 +144,22 @@ func unmarshalCt(data []byte, info *Ct) error {
 		case tcaCtNatIPv4Max:
 			tmp := uint32ToIP(ad.Uint32())
 			info.NatIPv4Max = &tmp
+		case tcaCtNatIPv6Min:
+			tmp, err := bytesToIP(ad.Bytes())
+			multiError = concatError(multiError, err)
+			info.NatIPv6Min = &tmp
+		case tcaCtNatIPv6Max:
+			tmp, err := bytesToIP(ad.Bytes())
+			multiError = concatError(multiError, err)
+			info.NatIPv6Max = &tmp
+		case tcaCtLabels:
+			tmp := CtLabel{}
+			copy(tmp[:], ad.Bytes())
+			info.Labels = &tmp
+		case tcaCtLabelsMask:
+			tmp := CtLabel{}
+			copy(tmp[:], ad.Bytes())
+			info.LabelsMask = &tmp
 		case tcaCtNatPortMin:
 			tmp := endianSwapUint16(ad.Uint16())
 			info.NatPortMin = &tmp
@@ -106,9 +178,100 @@ func unmarshalCt(data []byte, info *Ct) error {
 			return fmt.Errorf("UnmarshalCt()\t%d\n\t%v", ad.Type(), ad.Bytes())
 		}
 	}
+	if info.Action != nil && *info.Action&CtActNat != 0 {
+		info.Nat = ctNatOf(info)
+	}
 	return concatError(multiError, ad.Err())
 }
 
+// ctNatOf returns the NAT, that is described by the NAT flags of Action and the NatIP*
+// and NatPort* fields of info.
+func ctNatOf(info *Ct) *CtNat {
+	nat := &CtNat{}
+	action := uint16Value(info.Action)
+	switch {
+	case action&CtActNatSrc != 0:
+		nat.Mode = CtNatSrc
+	case action&CtActNatDst != 0:
+		nat.Mode = CtNatDst
+	}
+	for _, addr := range []struct {
+		src *net.IP
+		dst *net.IP
+	}{
+		{info.NatIPv4Min, &nat.AddrMin},
+		{info.NatIPv4Max, &nat.AddrMax},
+		{info.NatIPv6Min, &nat.AddrMin},
+		{info.NatIPv6Max, &nat.AddrMax},
+	} {
+		if addr.src != nil {
+			*addr.dst = *addr.src
+		}
+	}
+	nat.PortMin = uint16Value(info.NatPortMin)
+	nat.PortMax = uint16Value(info.NatPortMax)
+	return nat
+}
+
+// sameCtNat reports whether a and b describe the same NAT.
+func sameCtNat(a, b *CtNat) bool {
+	sameIP := func(x, y net.IP) bool {
+		return (x == nil) == (y == nil) && (x == nil || x.Equal(y))
+	}
+	return a.Mode == b.Mode && sameIP(a.AddrMin, b.AddrMin) && sameIP(a.AddrMax, b.AddrMax) &&
+		a.PortMin == b.PortMin && a.PortMax == b.PortMax
+}
+
+// marshalCtNat returns the NAT flags and attributes of nat.
+func marshalCtNat(nat *CtNat) (uint16, []tcOption, error) {
+	options := []tcOption{}
+	flags := CtActNat
+	switch nat.Mode {
+	case CtNatRestore:
+		if nat.AddrMin != nil || nat.AddrMax != nil || nat.PortMin != 0 || nat.PortMax != 0 {
+			return 0, options, fmt.Errorf("Ct: NAT restore does not take a range: %w", ErrInvalidArg)
+		}
+		return flags, options, nil
+	case CtNatSrc:
+		flags |= CtActNatSrc
+	case CtNatDst:
+		flags |= CtActNatDst
+	default:
+		return 0, options, fmt.Errorf("Ct: NAT mode %d: %w", nat.Mode, ErrInvalidArg)
+	}
+	if nat.AddrMin == nil && nat.AddrMax != nil {
+		return 0, options, fmt.Errorf("Ct: NAT AddrMax without AddrMin: %w", ErrInvalidArg)
+	}
+	if nat.AddrMin != nil {
+		addrs := []net.IP{nat.AddrMin}
+		if nat.AddrMax != nil {
+			addrs = append(addrs, nat.AddrMax)
+		}
+		isV4 := nat.AddrMin.To4() != nil
+		for i, addr := range addrs {
+			switch {
+			case isV4 && addr.To4() != nil:
+				tmp, _ := ipToUint32(addr)
+				options = append(options, tcOption{Interpretation: vtUint32, Type: uint16(tcaCtNatIPv4Min + i), Data: tmp})
+			case !isV4 && addr.To4() == nil && addr.To16() != nil:
+				options = append(options, tcOption{Interpretation: vtBytes, Type: uint16(tcaCtNatIPv6Min + i), Data: []byte(addr.To16())})
+			default:
+				return 0, options, fmt.Errorf("Ct: NAT address %s: %w", addr, ErrInvalidArg)
+			}
+		}
+	}
+	if nat.PortMax != 0 && nat.PortMin == 0 {
+		return 0, options, fmt.Errorf("Ct: NAT PortMax without PortMin: %w", ErrInvalidArg)
+	}
+	if nat.PortMin != 0 {
+		options = append(options, tcOption{Interpretation: vtUint16Be, Type: tcaCtNatPortMin, Data: nat.PortMin})
+	}
+	if nat.PortMax != 0 {
+		options = append(options, tcOption{Interpretation: vtUint16Be, Type: tcaCtNatPortMax, Data: nat.PortMax})
+	}
+	return flags, options, nil
+}
+
 // marshalCt returns the binary encoding of Ct
 func marshalCt(info *Ct) ([]byte, error) {
 	options := []tcOption{}
@@ -126,7 +289,20 @@ func marshalCt(info *Ct) ([]byte, error) {
 		multiError = concatError(multiError, err)
 		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaCtParms, Data: data})
 	}
-	if info.Action != nil {
+	if info.Nat != nil {
+		if (info.NatIPv4Min != nil || info.NatIPv4Max != nil || info.NatIPv6Min != nil ||
+			info.NatIPv6Max != nil || info.NatPortMin != nil || info.NatPortMax != nil) &&
+			!sameCtNat(ctNatOf(info), info.Nat) {
+			return []byte{}, fmt.Errorf("Ct: Nat and NAT attributes differ: %w", ErrInvalidArg)
+		}
+		flags, natOptions, err := marshalCtNat(info.Nat)
+		if err != nil {
+			return []byte{}, err
+		}
+		options = append(options, tcOption{Interpretation: vtUint16, Type: tcaCtAction,
+			Data: uint16Value(info.Action)&^ctActNatMask | flags})
+		options = append(options, natOptions...)
+	} else if info.Action != nil {
 		options = append(options, tcOption{Interpretation: vtUint16, Type: tcaCtAction, Data: uint16Value(info.Action)})
 	}
 	if info.Zone != nil {
@@ -138,20 +314,32 @@ func marshalCt(info *Ct) ([]byte, error) {
 	if info.MarkMask != nil {
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaCtMarkMask, Data: uint32Value(info.MarkMask)})
 	}
-	if info.NatIPv4Min != nil {
+	if info.NatIPv4Min != nil && info.Nat == nil {
 		tmp, err := ipToUint32(*info.NatIPv4Min)
 		multiError = concatError(multiError, err)
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaCtNatIPv4Min, Data: tmp})
 	}
-	if info.NatIPv4Max != nil {
+	if info.NatIPv4Max != nil && info.Nat == nil {
 		tmp, err := ipToUint32(*info.NatIPv4Max)
 		multiError = concatError(multiError, err)
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaCtNatIPv4Max, Data: tmp})
 	}
-	if info.NatPortMin != nil {
+	if info.NatIPv6Min != nil && info.Nat == nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaCtNatIPv6Min, Data: []byte(info.NatIPv6Min.To16())})
+	}
+	if info.NatIPv6Max != nil && info.Nat == nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaCtNatIPv6Max, Data: []byte(info.NatIPv6Max.To16())})
+	}
+	if info.Labels != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaCtLabels, Data: info.Labels[:]})
+	}
+	if info.LabelsMask != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaCtLabelsMask, Data: info.LabelsMask[:]})
+	}
+	if info.NatPortMin != nil && info.Nat == nil {
 		options = append(options, tcOption{Interpretation: vtUint16Be, Type: tcaCtNatPortMin, Data: uint16Value(info.NatPortMin)})
 	}
-	if info.NatPortMax != nil {
+	if info.NatPortMax != nil && info.Nat == nil {
 		options = append(options, tcOption{Interpretation: vtUint16Be, Type: tcaCtNatPortMax, Data: uint16Value(info.NatPortMax)})
 	}
 	if info.HelperName != nil {
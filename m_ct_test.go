@@ -11,6 +11,7 @@ import (
 func TestCt(t *testing.T) {
 	tests := map[string]struct {
 		val  Ct
+		want *Ct
 		err1 error
 		err2 error
 	}{
@@ -20,6 +21,39 @@ func TestCt(t *testing.T) {
 			NatIPv4Min: netIPPtr(net.ParseIP("1.2.3.4")), NatIPv4Max: netIPPtr(net.ParseIP("8.8.4.4")),
 			NatPortMin: uint16Ptr(42), NatPortMax: uint16Ptr(73),
 			HelperName: stringPtr("test"), HelperFamily: uint8Ptr(13), HelperProto: uint8Ptr(14)}},
+		"labels": {val: Ct{Action: uint16Ptr(CtActCommit),
+			Labels:     &CtLabel{0x1, 0x2, 15: 0x3},
+			LabelsMask: &CtLabel{0xff, 0xff, 15: 0xff}}},
+		"ipv6 range": {val: Ct{NatIPv6Min: netIPPtr(net.ParseIP("2001:db8::1")),
+			NatIPv6Max: netIPPtr(net.ParseIP("2001:db8::ff"))}},
+		"nat src ipv4": {val: Ct{Action: uint16Ptr(CtActCommit), Nat: &CtNat{Mode: CtNatSrc,
+			AddrMin: net.ParseIP("10.0.0.1"), AddrMax: net.ParseIP("10.0.0.9"), PortMin: 1024, PortMax: 2048}},
+			want: &Ct{Action: uint16Ptr(CtActCommit | CtActNat | CtActNatSrc),
+				NatIPv4Min: netIPPtr(net.ParseIP("10.0.0.1")), NatIPv4Max: netIPPtr(net.ParseIP("10.0.0.9")),
+				NatPortMin: uint16Ptr(1024), NatPortMax: uint16Ptr(2048),
+				Nat: &CtNat{Mode: CtNatSrc, AddrMin: net.ParseIP("10.0.0.1"), AddrMax: net.ParseIP("10.0.0.9"),
+					PortMin: 1024, PortMax: 2048}}},
+		"nat dst ipv6": {val: Ct{Action: uint16Ptr(CtActForce), Nat: &CtNat{Mode: CtNatDst,
+			AddrMin: net.ParseIP("2001:db8::1"), PortMin: 80}},
+			want: &Ct{Action: uint16Ptr(CtActForce | CtActNat | CtActNatDst),
+				NatIPv6Min: netIPPtr(net.ParseIP("2001:db8::1")), NatPortMin: uint16Ptr(80),
+				Nat: &CtNat{Mode: CtNatDst, AddrMin: net.ParseIP("2001:db8::1"), PortMin: 80}}},
+		"nat restore": {val: Ct{Action: uint16Ptr(0), Nat: &CtNat{}},
+			want: &Ct{Action: uint16Ptr(CtActNat), Nat: &CtNat{}}},
+		"legacy nat": {val: Ct{Action: uint16Ptr(CtActNat | CtActNatSrc),
+			NatIPv4Min: netIPPtr(net.ParseIP("10.0.0.1"))},
+			want: &Ct{Action: uint16Ptr(CtActNat | CtActNatSrc),
+				NatIPv4Min: netIPPtr(net.ParseIP("10.0.0.1")),
+				Nat:        &CtNat{Mode: CtNatSrc, AddrMin: net.ParseIP("10.0.0.1")}}},
+		"nat and same attributes": {val: Ct{Action: uint16Ptr(CtActNat | CtActNatSrc),
+			NatIPv4Min: netIPPtr(net.ParseIP("10.0.0.1")),
+			Nat:        &CtNat{Mode: CtNatSrc, AddrMin: net.ParseIP("10.0.0.1")}}},
+		"nat restore with range": {val: Ct{Nat: &CtNat{AddrMin: net.ParseIP("10.0.0.1")}},
+			err1: ErrInvalidArg},
+		"nat mixed family": {val: Ct{Nat: &CtNat{Mode: CtNatSrc, AddrMin: net.ParseIP("10.0.0.1"),
+			AddrMax: net.ParseIP("2001:db8::1")}}, err1: ErrInvalidArg},
+		"nat exclusive": {val: Ct{Nat: &CtNat{Mode: CtNatSrc}, NatPortMin: uint16Ptr(1)},
+			err1: ErrInvalidArg},
 	}
 
 	for name, testcase := range tests {
@@ -28,6 +62,9 @@ func TestCt(t *testing.T) {
 			if !errors.Is(err1, testcase.err1) {
 				t.Fatalf("Unexpected error: %v", err1)
 			}
+			if err1 != nil {
+				return
+			}
 
 			newData, tm := injectTcft(t, data, tcaCtTm)
 			newData = injectAttribute(t, newData, []byte{}, tcaCtPad)
@@ -39,10 +76,24 @@ func TestCt(t *testing.T) {
 			}
 
 			// Reinject value to expected values
-			testcase.val.Tm = tm
-			if diff := cmp.Diff(val, testcase.val); diff != "" {
+			want := testcase.val
+			if testcase.want != nil {
+				want = *testcase.want
+			}
+			want.Tm = tm
+			if diff := cmp.Diff(val, want); diff != "" {
 				t.Fatalf("Ct missmatch (want +got):\n%s", diff)
 			}
+
+			// A decoded value can be sent again.
+			val.Tm = nil
+			again, err := marshalCt(&val)
+			if err != nil {
+				t.Fatalf("could not marshal decoded value: %v", err)
+			}
+			if diff := cmp.Diff(data, again); diff != "" {
+				t.Fatalf("encoding of decoded value missmatch (want +got):\n%s", diff)
+			}
 		})
 	}
 
@@ -60,3 +111,10 @@ func TestCt(t *testing.T) {
 		}
 	})
 }
+
+func TestCtLabel(t *testing.T) {
+	label := CtLabel{0xde, 0xad, 14: 0xbe, 15: 0xef}
+	if got := label.String(); got != "dead000000000000000000000000beef" {
+		t.Fatalf("unexpected label: %s", got)
+	}
+}
@@ -34,10 +34,12 @@ type CtInfo struct {
 }
 
 // CtInfoAct as tc_ctinfo from include/uapi/linux/tc_act/tc_ctinfo.h
+//
+//structgen:binary
 type CtInfoAct struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
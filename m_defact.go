@@ -22,10 +22,12 @@ type Defact struct {
 }
 
 // DefactParms from include/uapi/linux/tc_act/tc_defact.h
+//
+//structgen:binary
 type DefactParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
@@ -22,17 +22,21 @@ type Gact struct {
 }
 
 // GactProb from include/uapi/linux/tc_act/tc_gact.h
+//
+//structgen:binary
 type GactProb struct {
 	PType   uint16
 	PVal    uint16
-	PAction uint32
+	PAction Verdict
 }
 
 // GactParms from include/uapi/linux/tc_act/tc_gact.h
+//
+//structgen:binary
 type GactParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
@@ -113,10 +113,12 @@ func unmarshalGate(data []byte, info *Gate) error {
 }
 
 // GateParms from include/uapi/linux/tc_act/tc_gate.h
+//
+//structgen:binary
 type GateParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
@@ -18,6 +18,30 @@ const (
 	tcaIfePad
 )
 
+// Flags for IfeParms.Flags.
+const (
+	IfeDecode uint16 = 0
+	IfeEncode uint16 = 1
+)
+
+// IfeMetaID identifies the skb metadata, that is carried by Ife.
+type IfeMetaID uint16
+
+// Various metadata from include/uapi/linux/ife.h that are supported by the kernel.
+const (
+	IfeMetaSkbMark IfeMetaID = 1
+	IfeMetaPrio    IfeMetaID = 3
+	IfeMetaTcIndex IfeMetaID = 5
+)
+
+// IfeMeta is a single entry of the Ife metadata list. If Value is nil, the
+// metadata is allowed and taken from each packet. Otherwise Value is used as
+// fixed value. For IfeMetaTcIndex only the lower 16 bits are valid.
+type IfeMeta struct {
+	ID    IfeMetaID
+	Value *uint32
+}
+
 // Ife contains attribute of the ife discipline
 type Ife struct {
 	Parms *IfeParms
@@ -25,13 +49,17 @@ type Ife struct {
 	DMac  *net.HardwareAddr
 	Type  *uint16
 	Tm    *Tcft
+
+	MetaList *[]IfeMeta
 }
 
 // IfeParms from include/uapi/linux/tc_act/tc_ife.h
+//
+//structgen:binary
 type IfeParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 	Flags   uint16
@@ -64,9 +92,74 @@ func marshalIfe(info *Ife) ([]byte, error) {
 	if info.Type != nil {
 		options = append(options, tcOption{Interpretation: vtUint16, Type: tcaIfeType, Data: *info.Type})
 	}
+	if info.MetaList != nil {
+		data, err := marshalIfeMetaList(info.MetaList)
+		if err != nil {
+			return []byte{}, err
+		}
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaIfeMetaList, Data: data})
+	}
+	return marshalAttributes(options)
+}
+
+// marshalIfeMetaList returns the binary encoding of the Ife metadata list
+func marshalIfeMetaList(info *[]IfeMeta) ([]byte, error) {
+	options := []tcOption{}
+	seen := make(map[IfeMetaID]bool)
+
+	for _, meta := range *info {
+		if seen[meta.ID] {
+			return []byte{}, fmt.Errorf("IfeMeta %d is used more than once: %w", meta.ID, ErrInvalidArg)
+		}
+		seen[meta.ID] = true
+		switch meta.ID {
+		case IfeMetaSkbMark, IfeMetaPrio:
+			if meta.Value == nil {
+				options = append(options, tcOption{Interpretation: vtFlag, Type: uint16(meta.ID)})
+				continue
+			}
+			options = append(options, tcOption{Interpretation: vtUint32, Type: uint16(meta.ID), Data: *meta.Value})
+		case IfeMetaTcIndex:
+			if meta.Value == nil {
+				options = append(options, tcOption{Interpretation: vtFlag, Type: uint16(meta.ID)})
+				continue
+			}
+			if *meta.Value > 0xFFFF {
+				return []byte{}, fmt.Errorf("IfeMeta tcindex %d: %w", *meta.Value, ErrInvalidArg)
+			}
+			options = append(options, tcOption{Interpretation: vtUint16, Type: uint16(meta.ID), Data: uint16(*meta.Value)})
+		default:
+			return []byte{}, fmt.Errorf("IfeMeta %d: %w", meta.ID, ErrInvalidArg)
+		}
+	}
 	return marshalAttributes(options)
 }
 
+// unmarshalIfeMetaList parses the Ife metadata list and stores the result in the value pointed to by info.
+func unmarshalIfeMetaList(data []byte, info *[]IfeMeta) error {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		return err
+	}
+	for ad.Next() {
+		meta := IfeMeta{ID: IfeMetaID(ad.Type())}
+		switch meta.ID {
+		case IfeMetaSkbMark, IfeMetaPrio:
+			if len(ad.Bytes()) != 0 {
+				meta.Value = uint32Ptr(ad.Uint32())
+			}
+		case IfeMetaTcIndex:
+			if len(ad.Bytes()) != 0 {
+				meta.Value = uint32Ptr(uint32(ad.Uint16()))
+			}
+		default:
+			return fmt.Errorf("unmarshalIfeMetaList()\t%d\n\t%v", ad.Type(), ad.Bytes())
+		}
+		*info = append(*info, meta)
+	}
+	return ad.Err()
+}
+
 // unmarshalIfe parses the ife-encoded data and stores the result in the value pointed to by info.
 func unmarshalIfe(data []byte, info *Ife) error {
 	ad, err := netlink.NewAttributeDecoder(data)
@@ -95,6 +188,11 @@ func unmarshalIfe(data []byte, info *Ife) error {
 		case tcaIfeType:
 			tmp := ad.Uint16()
 			info.Type = &tmp
+		case tcaIfeMetaList:
+			var tmp []IfeMeta
+			err = unmarshalIfeMetaList(ad.Bytes(), &tmp)
+			multiError = concatError(multiError, err)
+			info.MetaList = &tmp
 		case tcaIfePad:
 			// padding does not contain data, we just skip it
 		default:
@@ -23,6 +23,14 @@ func TestIfe(t *testing.T) {
 		"simple":          {val: Ife{Parms: &IfeParms{Index: 42, Action: 1}}},
 		"invalidArgument": {val: Ife{Tm: &Tcft{Install: 1}}, err1: ErrNoArgAlter},
 		"macs":            {val: Ife{SMac: &mac, DMac: &mac, Type: uint16Ptr(1)}},
+		"metaAllowed": {val: Ife{Parms: &IfeParms{Flags: IfeEncode},
+			MetaList: &[]IfeMeta{{ID: IfeMetaSkbMark}, {ID: IfeMetaPrio}, {ID: IfeMetaTcIndex}}}},
+		"metaFixed": {val: Ife{Parms: &IfeParms{Flags: IfeEncode},
+			MetaList: &[]IfeMeta{{ID: IfeMetaSkbMark, Value: uint32Ptr(0xC0FFEE)}, {ID: IfeMetaPrio, Value: uint32Ptr(7)},
+				{ID: IfeMetaTcIndex, Value: uint32Ptr(0x1234)}}}},
+		"metaDuplicate": {val: Ife{MetaList: &[]IfeMeta{{ID: IfeMetaPrio}, {ID: IfeMetaPrio}}}, err1: ErrInvalidArg},
+		"metaUnknown":   {val: Ife{MetaList: &[]IfeMeta{{ID: 2}}}, err1: ErrInvalidArg},
+		"metaTcIndex":   {val: Ife{MetaList: &[]IfeMeta{{ID: IfeMetaTcIndex, Value: uint32Ptr(0x10000)}}}, err1: ErrInvalidArg},
 	}
 	for name, testcase := range tests {
 		t.Run(name, func(t *testing.T) {
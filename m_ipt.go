@@ -27,6 +27,8 @@ type Ipt struct {
 }
 
 // IptCnt as tc_cnt from include/uapi/linux/pkt_cls.h
+//
+//structgen:binary
 type IptCnt struct {
 	RefCnt  uint32
 	BindCnt uint32
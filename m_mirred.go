@@ -22,10 +22,12 @@ type Mirred struct {
 }
 
 // MirredParam from include/uapi/linux/tc_act/tc_mirred.h
+//
+//structgen:binary
 type MirredParam struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 	Eaction uint32
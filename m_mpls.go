@@ -43,10 +43,12 @@ type MPLS struct {
 }
 
 // MPLSParam contains further MPLS attributes.
+//
+//structgen:binary
 type MPLSParam struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 	MAction MPLSAction
@@ -101,6 +103,16 @@ func marshalMPLS(info *MPLS) ([]byte, error) {
 		return []byte{}, ErrNoArgAlter
 	}
 	if info.Parms != nil {
+		switch info.Parms.MAction {
+		case MPLSActPush, MPLSActMACPush:
+			if info.Label == nil {
+				return []byte{}, fmt.Errorf("MPLS push requires Label: %w", ErrInvalidArg)
+			}
+		case MPLSActPop:
+			if info.Proto == nil {
+				return []byte{}, fmt.Errorf("MPLS pop requires Proto: %w", ErrInvalidArg)
+			}
+		}
 		data, err := marshalStruct(info.Parms)
 		if err != nil {
 			return []byte{}, err
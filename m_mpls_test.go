@@ -24,6 +24,13 @@ func TestMPLS(t *testing.T) {
 			TTL:   uint8Ptr(104),
 			BOS:   uint8Ptr(105),
 		}},
+		"mac push": {val: MPLS{
+			Parms: &MPLSParam{MAction: MPLSActMACPush},
+			Proto: int16Ptr(0x4788),
+			Label: uint32Ptr(42),
+		}},
+		"push without label": {val: MPLS{Parms: &MPLSParam{MAction: MPLSActPush}}, err1: ErrInvalidArg},
+		"pop without proto":  {val: MPLS{Parms: &MPLSParam{MAction: MPLSActPop}}, err1: ErrInvalidArg},
 		"tm": {
 			val: MPLS{
 				Tm: &Tcft{
@@ -41,6 +48,9 @@ func TestMPLS(t *testing.T) {
 			if !errors.Is(err1, testcase.err1) {
 				t.Fatalf("Unexpected error: %v", err1)
 			}
+			if err1 != nil && testcase.val.Tm == nil {
+				return
+			}
 			newData, tm := injectTcft(t, data, tcaMPLSTm)
 			val := MPLS{}
 			err2 := unmarshalMPLS(newData, &val)
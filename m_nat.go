@@ -20,10 +20,12 @@ type Nat struct {
 }
 
 // NatParms from include/uapi/linux/tc_act/tc_nat.h
+//
+//structgen:binary
 type NatParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 	OldAddr uint32
@@ -3,6 +3,8 @@ package tc
 import (
 	"fmt"
 
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
 	"github.com/mdlayher/netlink"
 )
 
@@ -17,10 +19,15 @@ const (
 	tcaPolicePad
 	tcaPoliceRate64
 	tcaPolicePeakRate64
+	tcaPolicePktRate64
+	tcaPolicePktBurst64
 )
 
+// TC_RTAB_SIZE from include/net/sch_generic.h
+const rateTableSize = 1024
+
 // PolicyAction defines the action that is applied by Policy.
-type PolicyAction uint32
+type PolicyAction = Verdict
 
 // Default Policy actions.
 // PolicyUnspec - skipped as it is -1
@@ -31,16 +38,87 @@ const (
 	PolicyPipe
 )
 
-// Police represents policing attributes of various filters and classes
+// Police represents policing attributes of various filters and classes.
+// Tbf.Action is applied to packets that exceed the configured rate and Result
+// to packets that conform to it.
 type Police struct {
 	Tbf        *Policy
 	Rate       *RateSpec
 	PeakRate   *RateSpec
 	AvRate     *uint32
-	Result     *uint32
+	Result     *Verdict
 	Tm         *Tcft
 	Rate64     *uint64
 	PeakRate64 *uint64
+
+	// RateTable is the table of transmission times for Tbf.Rate. It is sent as
+	// TCA_POLICE_RATE, but not reported back by the kernel.
+	RateTable *[]byte
+
+	// PktRate64 in packets per second and PktBurst64 in psched ticks configure
+	// packet rate policing. They are mutually exclusive with Tbf.Rate.
+	PktRate64  *uint64
+	PktBurst64 *uint64
+}
+
+// NewPolice returns a Police that limits traffic to rate bytes per second with a bucket
+// of burst bytes. Packets up to mtu bytes are considered. If mtu is 0, the rate table is
+// calculated for packets up to 2047 bytes. Conform is applied to packets within the
+// limit and exceed to all others.
+//
+// NewPolice requires github.com/florianl/go-tc/core.InitializeClock() to be called first.
+func NewPolice(rate uint64, burst, mtu uint32, conform, exceed PolicyAction) (*Police, error) {
+	if rate == 0 || burst == 0 {
+		return nil, fmt.Errorf("police: rate and burst are required: %w", ErrNoArg)
+	}
+	if rate >= 1<<32 {
+		return nil, fmt.Errorf("police: rate %d: %w", rate, ErrNotImplemented)
+	}
+	if !core.IsClockInitialized() {
+		return nil, fmt.Errorf("police: use github.com/florianl/go-tc/core.InitializeClock() first")
+	}
+	pol := &Policy{
+		Action: exceed,
+		Burst:  core.XmitTime(rate, burst),
+		Mtu:    mtu,
+		Rate: RateSpec{
+			CellLog:   uint8(rateTableCellLog(mtu)),
+			Linklayer: unix.LINKLAYER_ETHERNET,
+			Rate:      uint32(rate),
+		},
+	}
+	rtab, err := generateRateTable(pol)
+	if err != nil {
+		return nil, err
+	}
+	return &Police{
+		Tbf:       pol,
+		RateTable: &rtab,
+		Result:    &conform,
+	}, nil
+}
+
+// NewPktPolice returns a Police that limits traffic to pktRate packets per second with
+// a bucket of pktBurst packets. Conform is applied to packets within the limit and
+// exceed to all others.
+//
+// NewPktPolice requires github.com/florianl/go-tc/core.InitializeClock() to be called first.
+func NewPktPolice(pktRate, pktBurst uint64, conform, exceed PolicyAction) (*Police, error) {
+	if pktRate == 0 || pktBurst == 0 {
+		return nil, fmt.Errorf("police: packet rate and burst are required: %w", ErrNoArg)
+	}
+	if pktBurst >= 1<<32 {
+		return nil, fmt.Errorf("police: packet burst %d: %w", pktBurst, ErrInvalidArg)
+	}
+	if !core.IsClockInitialized() {
+		return nil, fmt.Errorf("police: use github.com/florianl/go-tc/core.InitializeClock() first")
+	}
+	return &Police{
+		Tbf:        &Policy{Action: exceed},
+		Result:     &conform,
+		PktRate64:  uint64Ptr(pktRate),
+		PktBurst64: uint64Ptr(uint64(core.XmitTime(pktRate, uint32(pktBurst)))),
+	}, nil
 }
 
 // unmarshalPolice parses the Police-encoded data and stores the result in the value pointed to by info.
@@ -58,6 +136,10 @@ func unmarshalPolice(data []byte, info *Police) error {
 			multiError = concatError(multiError, err)
 			info.Tbf = policy
 		case tcaPoliceRate:
+			if len(ad.Bytes()) == rateTableSize {
+				info.RateTable = bytesPtr(ad.Bytes())
+				continue
+			}
 			rate := &RateSpec{}
 			err = unmarshalStruct(ad.Bytes(), rate)
 			multiError = concatError(multiError, err)
@@ -70,7 +152,7 @@ func unmarshalPolice(data []byte, info *Police) error {
 		case tcaPoliceAvRate:
 			info.AvRate = uint32Ptr(ad.Uint32())
 		case tcaPoliceResult:
-			info.Result = uint32Ptr(ad.Uint32())
+			info.Result = verdictPtr(Verdict(ad.Uint32()))
 		case tcaPoliceTm:
 			tm := &Tcft{}
 			err = unmarshalStruct(ad.Bytes(), tm)
@@ -84,6 +166,10 @@ func unmarshalPolice(data []byte, info *Police) error {
 		case tcaPolicePeakRate64:
 			info.PeakRate64 = uint64Ptr(ad.Uint64())
 			return ErrNotImplemented
+		case tcaPolicePktRate64:
+			info.PktRate64 = uint64Ptr(ad.Uint64())
+		case tcaPolicePktBurst64:
+			info.PktBurst64 = uint64Ptr(ad.Uint64())
 		default:
 			return fmt.Errorf("UnmarshalPolice()\t%d\n\t%v", ad.Type(), ad.Bytes())
 
@@ -102,6 +188,21 @@ func marshalPolice(info *Police) ([]byte, error) {
 	var multiError error
 
 	// TODO: improve logic and check combinations
+	if info.Rate != nil && info.RateTable != nil {
+		return []byte{}, fmt.Errorf("police: Rate and RateTable are exclusive: %w", ErrInvalidArg)
+	}
+	if (info.PktRate64 == nil) != (info.PktBurst64 == nil) {
+		return []byte{}, fmt.Errorf("police: PktRate64 requires PktBurst64: %w", ErrInvalidArg)
+	}
+	if info.PktRate64 != nil && info.Tbf != nil && info.Tbf.Rate.Rate != 0 {
+		return []byte{}, fmt.Errorf("police: rate and packet rate are exclusive: %w", ErrInvalidArg)
+	}
+	if info.RateTable != nil {
+		if len(*info.RateTable) != rateTableSize {
+			return []byte{}, fmt.Errorf("police: rate table of %d bytes: %w", len(*info.RateTable), ErrInvalidArg)
+		}
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaPoliceRate, Data: bytesValue(info.RateTable)})
+	}
 	if info.Rate != nil {
 		data, err := marshalStruct(info.Rate)
 		multiError = concatError(multiError, err)
@@ -121,7 +222,7 @@ func marshalPolice(info *Police) ([]byte, error) {
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaPoliceAvRate, Data: uint32Value(info.AvRate)})
 	}
 	if info.Result != nil {
-		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaPoliceResult, Data: uint32Value(info.Result)})
+		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaPoliceResult, Data: uint32(verdictValue(info.Result))})
 	}
 	if info.Rate64 != nil {
 		return []byte{}, fmt.Errorf("police: rate64: %w", ErrNotImplemented)
@@ -129,6 +230,10 @@ func marshalPolice(info *Police) ([]byte, error) {
 	if info.PeakRate64 != nil {
 		return []byte{}, fmt.Errorf("police: peakrate64: %w", ErrNotImplemented)
 	}
+	if info.PktRate64 != nil {
+		options = append(options, tcOption{Interpretation: vtUint64, Type: tcaPolicePktRate64, Data: uint64Value(info.PktRate64)})
+		options = append(options, tcOption{Interpretation: vtUint64, Type: tcaPolicePktBurst64, Data: uint64Value(info.PktBurst64)})
+	}
 	if info.Tm != nil {
 		return []byte{}, ErrNoArgAlter
 	}
@@ -137,3 +242,19 @@ func marshalPolice(info *Police) ([]byte, error) {
 	}
 	return marshalAttributes(options)
 }
+
+// withoutReadOnly returns a copy of p without the attributes, that are reported by the
+// kernel but can not be altered. p is not modified.
+func (p *Police) withoutReadOnly() *Police {
+	if p == nil {
+		return nil
+	}
+	res := *p
+	res.Tm = nil
+	if res.Tbf != nil {
+		tbf := *res.Tbf
+		tbf.RefCnt, tbf.BindCnt = 0, 0
+		res.Tbf = &tbf
+	}
+	return &res
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/florianl/go-tc/core"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -13,8 +14,8 @@ func TestPolice(t *testing.T) {
 		err1 error
 		err2 error
 	}{
-		"simple":          {val: Police{AvRate: uint32Ptr(1337), Result: uint32Ptr(42)}},
-		"invalidArgument": {val: Police{AvRate: uint32Ptr(1337), Result: uint32Ptr(42), Tm: &Tcft{Install: 1}}, err1: ErrNoArgAlter},
+		"simple":          {val: Police{AvRate: uint32Ptr(1337), Result: verdictPtr(42)}},
+		"invalidArgument": {val: Police{AvRate: uint32Ptr(1337), Result: verdictPtr(42), Tm: &Tcft{Install: 1}}, err1: ErrNoArgAlter},
 		"tbfOnly": {val: Police{Tbf: &Policy{
 			Index: 0x0, Action: 0x2, Limit: 0x0, Burst: 0x4c4b40, Mtu: 0x2400,
 			Rate:     RateSpec{CellLog: 0x6, Linklayer: 0x1, Overhead: 1, CellAlign: 0xffff, Mpu: 1, Rate: 0x7d},
@@ -23,6 +24,15 @@ func TestPolice(t *testing.T) {
 		"rate64":     {val: Police{Rate64: uint64Ptr(42)}, err1: ErrNotImplemented},
 		"peakrate64": {val: Police{PeakRate64: uint64Ptr(123)}, err1: ErrNotImplemented},
 		"rates":      {val: Police{Rate: &RateSpec{Rate: 42}, PeakRate: &RateSpec{Rate: 1337}}},
+		"rateTable": {val: Police{Tbf: &Policy{Action: PolicyShot, Burst: 0x1000, Rate: RateSpec{CellLog: 3, Rate: 125000}},
+			RateTable: bytesPtr(make([]byte, 1024)), Result: verdictPtr(PolicyOk)}},
+		"pktRate": {val: Police{Tbf: &Policy{Action: PolicyShot}, Result: verdictPtr(PolicyPipe),
+			PktRate64: uint64Ptr(1000), PktBurst64: uint64Ptr(640000)}},
+		"pktRate without burst": {val: Police{PktRate64: uint64Ptr(1000)}, err1: ErrInvalidArg},
+		"pktRate and rate": {val: Police{Tbf: &Policy{Rate: RateSpec{Rate: 1}},
+			PktRate64: uint64Ptr(1000), PktBurst64: uint64Ptr(1)}, err1: ErrInvalidArg},
+		"rate and rateTable": {val: Police{Rate: &RateSpec{Rate: 42}, RateTable: bytesPtr(make([]byte, 1024))}, err1: ErrInvalidArg},
+		"short rateTable":    {val: Police{RateTable: bytesPtr(make([]byte, 12))}, err1: ErrInvalidArg},
 	}
 
 	for name, testcase := range tests {
@@ -64,3 +74,66 @@ func TestPolice(t *testing.T) {
 		}
 	})
 }
+
+func TestNewPolice(t *testing.T) {
+	if err := core.InitializeClock(); err != nil {
+		t.Logf("failed to initialize clock: %v", err)
+		t.Log("setting clock parameters to defaults")
+		core.SetClockParameters(1.0, 1.0)
+	}
+
+	t.Run("rate", func(t *testing.T) {
+		pol, err := NewPolice(125000, 10000, 1500, PolicyOk, PolicyShot)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := &Policy{
+			Action: PolicyShot,
+			Burst:  core.XmitTime(125000, 10000),
+			Mtu:    1500,
+			Rate:   RateSpec{CellLog: 3, Linklayer: 1, Rate: 125000},
+		}
+		if diff := cmp.Diff(want, pol.Tbf); diff != "" {
+			t.Fatalf("Policy missmatch (want +got):\n%s", diff)
+		}
+		rtab, err := generateRateTable(want)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(&rtab, pol.RateTable); diff != "" {
+			t.Fatalf("rate table missmatch (want +got):\n%s", diff)
+		}
+		if verdictValue(pol.Result) != PolicyOk {
+			t.Fatalf("unexpected conform action: %d", verdictValue(pol.Result))
+		}
+		if _, err := marshalPolice(pol); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+	t.Run("packet rate", func(t *testing.T) {
+		pol, err := NewPktPolice(1000, 10, PolicyPipe, PolicyShot)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := &Police{
+			Tbf:        &Policy{Action: PolicyShot},
+			Result:     verdictPtr(PolicyPipe),
+			PktRate64:  uint64Ptr(1000),
+			PktBurst64: uint64Ptr(uint64(core.XmitTime(1000, 10))),
+		}
+		if diff := cmp.Diff(want, pol); diff != "" {
+			t.Fatalf("Police missmatch (want +got):\n%s", diff)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewPolice(0, 1, 0, PolicyOk, PolicyShot); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := NewPolice(1<<32, 1, 0, PolicyOk, PolicyShot); !errors.Is(err, ErrNotImplemented) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := NewPktPolice(1, 0, PolicyOk, PolicyShot); !errors.Is(err, ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -26,10 +26,12 @@ type Sample struct {
 }
 
 // SampleParms from include/uapi/linux/tc_act/tc_sample.h
+//
+//structgen:binary
 type SampleParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
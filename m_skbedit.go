@@ -34,10 +34,12 @@ type SkbEdit struct {
 }
 
 // SkbEditParms from include/uapi/linux/tc_act/tc_skbedit.h
+//
+//structgen:binary
 type SkbEditParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 }
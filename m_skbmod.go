@@ -27,10 +27,12 @@ type SkbMod struct {
 }
 
 // SkbModParms from include/uapi/linux/tc_act/tc_skbmod.h
+//
+//structgen:binary
 type SkbModParms struct {
 	Index   uint32
 	Capab   uint32
-	Action  uint32
+	Action  Verdict
 	RefCnt  uint32
 	BindCnt uint32
 	Flags   uint64
@@ -40,10 +40,12 @@ type TunnelKey struct {
 }
 
 // TunnelParms from include/uapi/linux/tc_act/tc_tunnel_key.h
+//
+//structgen:binary
 type TunnelParms struct {
 	Index           uint32
 	Capab           uint32
-	Action          uint32
+	Action          Verdict
 	RefCnt          uint32
 	BindCnt         uint32
 	TunnelKeyAction uint32
@@ -55,6 +57,9 @@ func marshalTunnelKey(info *TunnelKey) ([]byte, error) {
 	if info == nil {
 		return []byte{}, fmt.Errorf("TunnelKey: %w", ErrNoArg)
 	}
+	if info.Tm != nil {
+		return []byte{}, ErrNoArgAlter
+	}
 
 	if info.Parms != nil {
 		data, err := marshalStruct(info.Parms)
@@ -79,11 +84,11 @@ func marshalTunnelKey(info *TunnelKey) ([]byte, error) {
 		if info.KeyEncDst.To4() != nil {
 			tmp, err := ipToUint32(*info.KeyEncDst)
 			if err != nil {
-				return []byte{}, fmt.Errorf("TunnelKey - KeyEncIPv4Src: %w", err)
+				return []byte{}, fmt.Errorf("TunnelKey - KeyEncIPv4Dst: %w", err)
 			}
 			options = append(options, tcOption{Interpretation: vtUint32, Type: tcaTunnelKeyEncIPv4Dst, Data: tmp})
 		} else {
-			tmp := ipToBytes(*info.KeyEncSrc)
+			tmp := ipToBytes(*info.KeyEncDst)
 			options = append(options, tcOption{Interpretation: vtBytes, Type: tcaTunnelKeyEncIPv6Dst, Data: tmp})
 		}
 	}
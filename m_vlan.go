@@ -2,6 +2,7 @@ package tc
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/mdlayher/netlink"
 )
@@ -14,6 +15,20 @@ const (
 	tcaVLanPushVLanProtocol
 	tcaVLanPad
 	tcaVLanPushVLanPriority
+	tcaVLanPushEthDst
+	tcaVLanPushEthSrc
+)
+
+// VLanAction defines the operation of the VLan action.
+type VLanAction uint32
+
+// Various VLan actions
+const (
+	VLanActPop     = VLanAction(1)
+	VLanActPush    = VLanAction(2)
+	VLanActModify  = VLanAction(3)
+	VLanActPopEth  = VLanAction(4)
+	VLanActPushEth = VLanAction(5)
 )
 
 // VLan contains attribute of the VLan discipline
@@ -23,16 +38,20 @@ type VLan struct {
 	PushID       *uint16
 	PushProtocol *uint16
 	PushPriority *uint32
+	PushEthDst   *net.HardwareAddr
+	PushEthSrc   *net.HardwareAddr
 }
 
 // VLanParms from include/uapi/linux/tc_act/tc_vlan.h
+//
+//structgen:binary
 type VLanParms struct {
 	Index      uint32
 	Capab      uint32
-	Action     uint32
+	Action     Verdict
 	RefCnt     uint32
 	BindCnt    uint32
-	VLanAction uint32
+	VLanAction VLanAction
 }
 
 // marshalVLan returns the binary encoding of Vlan
@@ -47,6 +66,16 @@ func marshalVlan(info *VLan) ([]byte, error) {
 		return []byte{}, ErrNoArgAlter
 	}
 	if info.Parms != nil {
+		switch info.Parms.VLanAction {
+		case VLanActPush, VLanActModify:
+			if info.PushID == nil {
+				return []byte{}, fmt.Errorf("VLan push and modify require PushID: %w", ErrInvalidArg)
+			}
+		case VLanActPushEth:
+			if info.PushEthDst == nil || info.PushEthSrc == nil {
+				return []byte{}, fmt.Errorf("VLan push_eth requires PushEthDst and PushEthSrc: %w", ErrInvalidArg)
+			}
+		}
 		data, err := marshalStruct(info.Parms)
 		if err != nil {
 			return []byte{}, err
@@ -62,6 +91,18 @@ func marshalVlan(info *VLan) ([]byte, error) {
 	if info.PushPriority != nil {
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaVLanPushVLanPriority, Data: *info.PushPriority})
 	}
+	if info.PushEthDst != nil {
+		if len(*info.PushEthDst) != 6 {
+			return []byte{}, fmt.Errorf("VLan PushEthDst %s: %w", *info.PushEthDst, ErrInvalidArg)
+		}
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaVLanPushEthDst, Data: []byte(*info.PushEthDst)})
+	}
+	if info.PushEthSrc != nil {
+		if len(*info.PushEthSrc) != 6 {
+			return []byte{}, fmt.Errorf("VLan PushEthSrc %s: %w", *info.PushEthSrc, ErrInvalidArg)
+		}
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaVLanPushEthSrc, Data: []byte(*info.PushEthSrc)})
+	}
 	return marshalAttributes(options)
 }
 
@@ -93,6 +134,12 @@ func unmarshalVLan(data []byte, info *VLan) error {
 		case tcaVLanPushVLanPriority:
 			tmp := ad.Uint32()
 			info.PushPriority = &tmp
+		case tcaVLanPushEthDst:
+			tmp := net.HardwareAddr(ad.Bytes())
+			info.PushEthDst = &tmp
+		case tcaVLanPushEthSrc:
+			tmp := net.HardwareAddr(ad.Bytes())
+			info.PushEthSrc = &tmp
 		case tcaVLanPad:
 			// padding does not contain data, we just skip it
 		default:
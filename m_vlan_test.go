@@ -2,12 +2,15 @@ package tc
 
 import (
 	"errors"
+	"net"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 )
 
 func TestVLan(t *testing.T) {
+	dst, _ := net.ParseMAC("00:11:22:33:44:55")
+	src, _ := net.ParseMAC("66:77:88:99:aa:bb")
 	tests := map[string]struct {
 		val  VLan
 		err1 error
@@ -16,6 +19,13 @@ func TestVLan(t *testing.T) {
 		"simple":          {val: VLan{Parms: &VLanParms{Index: 42, Action: 1}}},
 		"invalidArgument": {val: VLan{Tm: &Tcft{Install: 1}}, err1: ErrNoArgAlter},
 		"pushs":           {val: VLan{PushID: uint16Ptr(1), PushProtocol: uint16Ptr(2), PushPriority: uint32Ptr(3)}},
+		"pushEth":         {val: VLan{Parms: &VLanParms{VLanAction: VLanActPushEth}, PushEthDst: &dst, PushEthSrc: &src}},
+		"popEth":          {val: VLan{Parms: &VLanParms{VLanAction: VLanActPopEth}}},
+		"modify":          {val: VLan{Parms: &VLanParms{VLanAction: VLanActModify}, PushID: uint16Ptr(42)}},
+		"pushEthNoSrc":    {val: VLan{Parms: &VLanParms{VLanAction: VLanActPushEth}, PushEthDst: &dst}, err1: ErrInvalidArg},
+		"pushNoID":        {val: VLan{Parms: &VLanParms{VLanAction: VLanActPush}}, err1: ErrInvalidArg},
+		"modifyNoID":      {val: VLan{Parms: &VLanParms{VLanAction: VLanActModify}}, err1: ErrInvalidArg},
+		"invalidMac":      {val: VLan{PushEthDst: &net.HardwareAddr{0x1}}, err1: ErrInvalidArg},
 	}
 	for name, testcase := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -0,0 +1,102 @@
+package tc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// netnsRunDir is the directory, where 'ip netns add' creates named network namespaces.
+var netnsRunDir = "/var/run/netns"
+
+// netnsPath returns the path of the network namespace, that is selected by config. If
+// config does not select a network namespace by path, name or PID, it returns an empty
+// string.
+func netnsPath(config *Config) (string, error) {
+	selected := 0
+	for _, set := range []bool{config.NetNS != 0, config.NetNSPath != "", config.NetNSName != "", config.NetNSPid != 0} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return "", fmt.Errorf("more than one network namespace is selected: %w", ErrInvalidArg)
+	}
+
+	switch {
+	case config.NetNSPath != "":
+		return config.NetNSPath, nil
+	case config.NetNSName != "":
+		name := config.NetNSName
+		if name == "." || name == ".." || strings.ContainsRune(name, '/') {
+			return "", fmt.Errorf("network namespace name %q: %w", name, ErrInvalidArg)
+		}
+		return filepath.Join(netnsRunDir, name), nil
+	case config.NetNSPid < 0:
+		return "", fmt.Errorf("network namespace of PID %d: %w", config.NetNSPid, ErrInvalidArg)
+	case config.NetNSPid != 0:
+		return filepath.Join("/proc", strconv.Itoa(config.NetNSPid), "ns", "net"), nil
+	}
+	return "", nil
+}
+
+// openNetNS opens the network namespace, that is selected by config by path, name or
+// PID. It returns nil, if no such network namespace is selected.
+func openNetNS(config *Config) (*os.File, error) {
+	path, err := netnsPath(config)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	ns, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open network namespace: %w", err)
+	}
+	return ns, nil
+}
+
+// NetNSNames returns the names of the network namespaces, that were created by
+// 'ip netns add'.
+func NetNSNames() ([]string, error) {
+	entries, err := os.ReadDir(netnsRunDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// OpenNamedNetNS opens a Tc for each network namespace, that was created by
+// 'ip netns add'. The returned map is keyed by the names of the network namespaces.
+// If a single Tc can not be opened, all of them are closed again.
+func OpenNamedNetNS() (map[string]*Tc, error) {
+	names, err := NetNSNames()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]*Tc, len(names))
+	for _, name := range names {
+		tcnl, err := Open(&Config{NetNSName: name})
+		if err != nil {
+			for _, opened := range all {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("network namespace %s: %w", name, err)
+		}
+		all[name] = tcnl
+	}
+	return all, nil
+}
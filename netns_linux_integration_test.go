@@ -0,0 +1,112 @@
+//go:build integration && linux
+// +build integration,linux
+
+package tc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+// hasClsact reports whether the loopback interface of the network namespace of tcnl has
+// a clsact qdisc.
+func hasClsact(t *testing.T, tcnl *Tc) bool {
+	t.Helper()
+	qdiscs, err := tcnl.Qdisc().Get()
+	if err != nil {
+		t.Fatalf("could not get qdiscs: %v", err)
+	}
+	for _, qdisc := range qdiscs {
+		if qdisc.Ifindex == probeLoopbackIndex && qdisc.Kind == "clsact" {
+			return true
+		}
+	}
+	return false
+}
+
+func addClsact(t *testing.T, tcnl *Tc) {
+	t.Helper()
+	if err := tcnl.Qdisc().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{Kind: "clsact"},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+}
+
+func TestLinuxOpenNetNS(t *testing.T) {
+	ns, err := newScratchNetNS()
+	if err != nil {
+		t.Skipf("could not create network namespace: %v", err)
+	}
+	defer ns.Close()
+
+	byPath, err := Open(&Config{NetNSPath: fmt.Sprintf("/proc/self/fd/%d", ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open network namespace by path: %v", err)
+	}
+	addClsact(t, byPath)
+	if err := byPath.Close(); err != nil {
+		t.Fatalf("could not close: %v", err)
+	}
+
+	byFd, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open network namespace: %v", err)
+	}
+	defer byFd.Close()
+	if !hasClsact(t, byFd) {
+		t.Fatal("clsact is missing in the network namespace")
+	}
+
+	byPid, err := Open(&Config{NetNSPid: os.Getpid()})
+	if err != nil {
+		t.Fatalf("could not open network namespace by PID: %v", err)
+	}
+	defer byPid.Close()
+	if _, err := byPid.Qdisc().Get(); err != nil {
+		t.Fatalf("could not get qdiscs: %v", err)
+	}
+}
+
+func TestLinuxOpenNamedNetNS(t *testing.T) {
+	name := fmt.Sprintf("go-tc-%d", os.Getpid())
+	if out, err := exec.Command("ip", "netns", "add", name).CombinedOutput(); err != nil {
+		t.Skipf("could not create named network namespace: %v: %s", err, out)
+	}
+	defer exec.Command("ip", "netns", "del", name).Run()
+
+	all, err := OpenNamedNetNS()
+	if err != nil {
+		t.Fatalf("could not open named network namespaces: %v", err)
+	}
+	defer func() {
+		for _, tcnl := range all {
+			tcnl.Close()
+		}
+	}()
+	tcnl, ok := all[name]
+	if !ok {
+		t.Fatalf("network namespace %s is missing", name)
+	}
+	addClsact(t, tcnl)
+
+	byName, err := Open(&Config{NetNSName: name})
+	if err != nil {
+		t.Fatalf("could not open network namespace by name: %v", err)
+	}
+	defer byName.Close()
+	if !hasClsact(t, byName) {
+		t.Fatal("clsact is missing in the named network namespace")
+	}
+}
@@ -0,0 +1,85 @@
+package tc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNetNSPath(t *testing.T) {
+	tests := map[string]struct {
+		config Config
+		path   string
+		err    error
+	}{
+		"none":     {config: Config{}},
+		"fd":       {config: Config{NetNS: 3}},
+		"path":     {config: Config{NetNSPath: "/run/netns/blue"}, path: "/run/netns/blue"},
+		"name":     {config: Config{NetNSName: "blue"}, path: "/var/run/netns/blue"},
+		"pid":      {config: Config{NetNSPid: 42}, path: "/proc/42/ns/net"},
+		"fd+name":  {config: Config{NetNS: 3, NetNSName: "blue"}, err: ErrInvalidArg},
+		"path+pid": {config: Config{NetNSPath: "/run/netns/blue", NetNSPid: 42}, err: ErrInvalidArg},
+		"slash":    {config: Config{NetNSName: "../blue"}, err: ErrInvalidArg},
+		"dot":      {config: Config{NetNSName: ".."}, err: ErrInvalidArg},
+		"negative": {config: Config{NetNSPid: -1}, err: ErrInvalidArg},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := netnsPath(&testcase.config)
+			if !errors.Is(err, testcase.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if path != testcase.path {
+				t.Fatalf("expected %q but got %q", testcase.path, path)
+			}
+		})
+	}
+}
+
+func TestNetNSNames(t *testing.T) {
+	defer func(dir string) { netnsRunDir = dir }(netnsRunDir)
+
+	t.Run("missing", func(t *testing.T) {
+		netnsRunDir = filepath.Join(t.TempDir(), "netns")
+		names, err := NetNSNames()
+		if err != nil || len(names) != 0 {
+			t.Fatalf("unexpected names %v: %v", names, err)
+		}
+		all, err := OpenNamedNetNS()
+		if err != nil || len(all) != 0 {
+			t.Fatalf("unexpected Tc %v: %v", all, err)
+		}
+	})
+
+	t.Run("names", func(t *testing.T) {
+		netnsRunDir = t.TempDir()
+		for _, name := range []string{"red", "blue"} {
+			if err := os.WriteFile(filepath.Join(netnsRunDir, name), nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Mkdir(filepath.Join(netnsRunDir, "dir"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		names, err := NetNSNames()
+		if err != nil {
+			t.Fatalf("could not list network namespaces: %v", err)
+		}
+		if diff := cmp.Diff([]string{"blue", "red"}, names); diff != "" {
+			t.Fatalf("names missmatch (want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestOpenNetNS(t *testing.T) {
+	if _, err := Open(&Config{NetNSPath: filepath.Join(t.TempDir(), "missing")}); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Open(&Config{NetNS: 3, NetNSPid: 1}); !errors.Is(err, ErrInvalidArg) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,504 @@
+package tc
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"golang.org/x/net/bpf"
+)
+
+// Feature identifies an optional attribute of a kind, that is not supported by every kernel.
+type Feature string
+
+// Features, that can be probed.
+const (
+	// FeatureFlowerL2Miss is the l2_miss key of filter/flower since Linux 6.5.
+	FeatureFlowerL2Miss = Feature("flower/l2_miss")
+	// FeatureTaPrioTxTimeAssist is the txtime-assist mode of qdisc/taprio since Linux 5.3.
+	FeatureTaPrioTxTimeAssist = Feature("taprio/txtime-assist")
+)
+
+// Capabilities describes the kinds of qdiscs, filters, actions and ematches as well as the
+// optional features, that are supported by a kernel.
+type Capabilities struct {
+	Qdiscs   map[string]bool
+	Filters  map[string]bool
+	Actions  map[string]bool
+	Ematches map[EmatchKind]bool
+	Features map[Feature]bool
+}
+
+// Qdisc reports whether the qdisc kind is supported.
+func (c *Capabilities) Qdisc(kind string) bool {
+	return c != nil && c.Qdiscs[kind]
+}
+
+// Filter reports whether the filter kind is supported.
+func (c *Capabilities) Filter(kind string) bool {
+	return c != nil && c.Filters[kind]
+}
+
+// Action reports whether the action kind is supported.
+func (c *Capabilities) Action(kind string) bool {
+	return c != nil && c.Actions[kind]
+}
+
+// Ematch reports whether the ematch kind is supported.
+func (c *Capabilities) Ematch(kind EmatchKind) bool {
+	return c != nil && c.Ematches[kind]
+}
+
+// Feature reports whether the optional feature is supported.
+func (c *Capabilities) Feature(f Feature) bool {
+	return c != nil && c.Features[f]
+}
+
+const (
+	// probeHandle is the handle of the root qdiscs, that are added while probing.
+	probeHandle uint32 = 0x10000
+	// probeClassID is the class, that probed filters classify into.
+	probeClassID uint32 = 0x10001
+	// probeActionIndex is the index of the shared actions, that are added while probing.
+	probeActionIndex uint32 = 0xfff0
+	// probeClockTAI is CLOCK_TAI from include/uapi/linux/time.h
+	probeClockTAI int32 = 11
+)
+
+// probeConfig contains the minimal configuration of a kind, that is needed to pass the
+// validation of this package.
+type probeConfig struct {
+	kind      string
+	configure func(*Attribute)
+}
+
+// probeQdiscs holds the qdisc kinds, that are probed.
+var probeQdiscs = []probeConfig{
+	{"atm", func(a *Attribute) { a.Atm = &Atm{Excess: uint32Ptr(0)} }},
+	{"bfifo", func(a *Attribute) { a.Bfifo = &FifoOpt{Limit: 10240} }},
+	{"cake", func(a *Attribute) { a.Cake = &Cake{BaseRate: uint64Ptr(0)} }},
+	{"cbq", func(a *Attribute) { a.Cbq = &Cbq{LssOpt: &CbqLssOpt{Avpkt: 1000}} }},
+	{"cbs", func(a *Attribute) { a.Cbs = &Cbs{Parms: &CbsOpt{}} }},
+	{"choke", func(a *Attribute) { a.Choke = &Choke{Parms: &RedQOpt{Limit: 1000, QthMin: 100, QthMax: 300}} }},
+	{"clsact", nil},
+	{"codel", func(a *Attribute) { a.Codel = &Codel{Limit: uint32Ptr(1000)} }},
+	{"drr", func(a *Attribute) { a.Drr = &Drr{Quantum: uint32Ptr(1514)} }},
+	{"dsmark", func(a *Attribute) { a.Dsmark = &Dsmark{Indices: uint16Ptr(1)} }},
+	{"fq", func(a *Attribute) { a.Fq = &Fq{PLimit: uint32Ptr(1000)} }},
+	{"fq_codel", func(a *Attribute) { a.FqCodel = &FqCodel{Limit: uint32Ptr(1000)} }},
+	{"fq_pie", func(a *Attribute) { a.FqPie = &FqPie{Limit: uint32Ptr(1000)} }},
+	{"gred", func(a *Attribute) { a.Gred = &Gred{DPS: &GredSOpt{DPs: 1}} }},
+	{"hfsc", func(a *Attribute) { a.HfscQOpt = &HfscQOpt{DefCls: 1} }},
+	{"hhf", func(a *Attribute) { a.Hhf = &Hhf{BacklogLimit: uint32Ptr(1000)} }},
+	{"htb", func(a *Attribute) { a.Htb = &Htb{Init: &HtbGlob{Version: 3, Rate2Quantum: 10}} }},
+	{"ingress", nil},
+	{"mqprio", func(a *Attribute) { a.MqPrio = &MqPrio{Opt: &MqPrioQopt{NumTc: 1, Count: [16]uint16{1}}} }},
+	{"netem", func(a *Attribute) { a.Netem = &Netem{Qopt: NetemQopt{Limit: 1000}} }},
+	{"pfifo", func(a *Attribute) { a.Pfifo = &FifoOpt{Limit: 1000} }},
+	{"pie", func(a *Attribute) { a.Pie = &Pie{Limit: uint32Ptr(1000)} }},
+	{"plug", func(a *Attribute) { a.Plug = &Plug{Limit: 10240} }},
+	{"prio", func(a *Attribute) {
+		a.Prio = &Prio{Bands: 3, PrioMap: [16]uint8{1, 2, 2, 2, 1, 2, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1}}
+	}},
+	{"qfq", nil},
+	{"red", func(a *Attribute) { a.Red = &Red{Parms: &RedQOpt{Limit: 1000, QthMin: 100, QthMax: 300}} }},
+	{"sfb", func(a *Attribute) { a.Sfb = &Sfb{Parms: &SfbQopt{}} }},
+	{"sfq", func(a *Attribute) { a.Sfq = &Sfq{V0: SfqQopt{Limit: 127}} }},
+	{"taprio", func(a *Attribute) { a.TaPrio = probeTaPrio() }},
+	{"tbf", func(a *Attribute) {
+		a.Tbf = &Tbf{Parms: &TbfQopt{Limit: 10240, Buffer: 10240}, Burst: uint32Ptr(1514)}
+	}},
+}
+
+// probeTaPrio returns a schedule for qdisc/taprio with a single traffic class.
+func probeTaPrio() *TaPrio {
+	return &TaPrio{
+		PrioMap:      &MqPrioQopt{NumTc: 1, Count: [16]uint16{1}},
+		SchedClockID: int32Ptr(probeClockTAI),
+		SchedEntries: &[]TaPrioSchedEntry{
+			{Cmd: uint8Ptr(TaPrioCmdSetGates), GateMask: uint32Ptr(1), Interval: uint32Ptr(1000000)},
+		},
+	}
+}
+
+// probeFilters holds the filter kinds, that are probed.
+var probeFilters = []probeConfig{
+	{"basic", func(a *Attribute) { a.Basic = &Basic{ClassID: uint32Ptr(probeClassID)} }},
+	{"bpf", func(a *Attribute) {
+		a.BPF = &Bpf{ClassID: uint32Ptr(probeClassID)}
+		// A single instruction, that accepts all packets, can always be encoded.
+		_ = a.BPF.SetInstructions([]bpf.Instruction{bpf.RetConstant{Val: 0xffffffff}})
+	}},
+	{"cgroup", func(a *Attribute) { a.Cgroup = &Cgroup{Ematch: &Ematch{Hdr: &EmatchTreeHdr{}}} }},
+	{"flow", func(a *Attribute) { a.Flow = &Flow{Keys: uint32Ptr(1), Mode: uint32Ptr(0), Divisor: uint32Ptr(1)} }},
+	{"flower", func(a *Attribute) { a.Flower = &Flower{ClassID: uint32Ptr(probeClassID)} }},
+	{"fw", func(a *Attribute) { a.Fw = &Fw{ClassID: uint32Ptr(probeClassID)} }},
+	{"matchall", func(a *Attribute) { a.Matchall = &Matchall{ClassID: uint32Ptr(probeClassID)} }},
+	{"route4", func(a *Attribute) { a.Route4 = &Route4{ClassID: uint32Ptr(probeClassID)} }},
+	{"rsvp", func(a *Attribute) { a.Rsvp = &Rsvp{ClassID: uint32Ptr(probeClassID)} }},
+	{"tcindex", func(a *Attribute) { a.TcIndex = &TcIndex{ClassID: uint32Ptr(probeClassID)} }},
+	{"u32", func(a *Attribute) {
+		a.U32 = &U32{ClassID: uint32Ptr(probeClassID), Sel: &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{{}}}}
+	}},
+}
+
+// probeActions holds the action kinds, that are probed.
+var probeActions = []struct {
+	kind      string
+	configure func(*Action, uint32)
+}{
+	{"bpf", func(a *Action, _ uint32) {
+		a.Bpf = &ActBpf{Parms: &ActBpfParms{Index: probeActionIndex, Action: ActOk}}
+		// A single instruction, that accepts all packets, can always be encoded.
+		_ = a.Bpf.SetInstructions([]bpf.Instruction{bpf.RetConstant{Val: 0xffffffff}})
+	}},
+	{"connmark", func(a *Action, _ uint32) {
+		a.ConnMark = &Connmark{Parms: &ConnmarkParam{Index: probeActionIndex, Action: ActPipe}}
+	}},
+	{"csum", func(a *Action, _ uint32) { a.CSum = &Csum{Parms: &CsumParms{Index: probeActionIndex, Action: ActOk}} }},
+	{"ct", func(a *Action, _ uint32) { a.Ct = &Ct{Parms: &CtParms{Index: probeActionIndex, Action: ActPipe}} }},
+	{"ctinfo", func(a *Action, _ uint32) {
+		a.CtInfo = &CtInfo{Act: &CtInfoAct{Index: probeActionIndex, Action: ActPipe}, Zone: uint16Ptr(0)}
+	}},
+	{"defact", func(a *Action, _ uint32) {
+		a.Defact = &Defact{Parms: &DefactParms{Index: probeActionIndex, Action: ActOk}}
+	}},
+	{"gact", func(a *Action, _ uint32) { a.Gact = &Gact{Parms: &GactParms{Index: probeActionIndex, Action: ActOk}} }},
+	{"gate", func(a *Action, _ uint32) {
+		a.Gate = &Gate{Parms: &GateParms{Index: probeActionIndex, Action: ActPipe}, ClockID: int32Ptr(probeClockTAI)}
+	}},
+	{"ife", func(a *Action, _ uint32) {
+		a.Ife = &Ife{Parms: &IfeParms{Index: probeActionIndex, Action: ActPipe, Flags: IfeDecode}}
+	}},
+	{"ipt", func(a *Action, _ uint32) {
+		a.Ipt = &Ipt{Table: stringPtr("mangle"), Hook: uint32Ptr(0), Index: uint32Ptr(probeActionIndex)}
+	}},
+	{"mirred", func(a *Action, ifindex uint32) {
+		// TCA_EGRESS_REDIR from include/uapi/linux/tc_act/tc_mirred.h
+		a.Mirred = &Mirred{Parms: &MirredParam{Index: probeActionIndex, Action: ActStolen, Eaction: 1, IfIndex: ifindex}}
+	}},
+	{"mpls", func(a *Action, _ uint32) {
+		a.MPLS = &MPLS{Parms: &MPLSParam{Index: probeActionIndex, Action: ActPipe, MAction: MPLSActDecTTL}}
+	}},
+	{"nat", func(a *Action, _ uint32) { a.Nat = &Nat{Parms: &NatParms{Index: probeActionIndex, Action: ActOk}} }},
+	{"police", func(a *Action, _ uint32) {
+		a.Police = &Police{Tbf: &Policy{Index: probeActionIndex, Action: PolicyShot},
+			PktRate64: uint64Ptr(1000), PktBurst64: uint64Ptr(1000)}
+	}},
+	{"sample", func(a *Action, _ uint32) {
+		a.Sample = &Sample{Parms: &SampleParms{Index: probeActionIndex, Action: ActPipe},
+			Rate: uint32Ptr(100), SampleGroup: uint32Ptr(1)}
+	}},
+	{"skbedit", func(a *Action, _ uint32) {
+		a.SkbEdit = &SkbEdit{Parms: &SkbEditParms{Index: probeActionIndex, Action: ActPipe}, Priority: uint32Ptr(1)}
+	}},
+	{"skbmod", func(a *Action, _ uint32) {
+		a.SkbMod = &SkbMod{Parms: &SkbModParms{Index: probeActionIndex, Action: ActPipe}, EType: uint16Ptr(0x88b5)}
+	}},
+	{"tunnel_key", func(a *Action, _ uint32) {
+		// TCA_TUNNEL_KEY_ACT_RELEASE from include/uapi/linux/tc_act/tc_tunnel_key.h
+		a.TunnelKey = &TunnelKey{Parms: &TunnelParms{Index: probeActionIndex, Action: ActPipe, TunnelKeyAction: 1}}
+	}},
+	{"vlan", func(a *Action, _ uint32) {
+		a.VLan = &VLan{Parms: &VLanParms{Index: probeActionIndex, Action: ActPipe, VLanAction: VLanActPop}}
+	}},
+}
+
+// probeEmatches holds the ematch kinds, that are probed. The kernel answers to a missing
+// IP set the same way as to an unknown kind, so EmatchIPSet is not probed.
+var probeEmatches = []struct {
+	kind      EmatchKind
+	configure func(*EmatchMatch)
+}{
+	{EmatchCmp, func(m *EmatchMatch) { m.CmpMatch = &CmpMatch{Align: CmpMatchU8, Layer: EmatchLayerNetwork} }},
+	{EmatchNByte, func(m *EmatchMatch) { m.NByteMatch = &NByteMatch{Layer: 1, Needle: []byte{0x0}} }},
+	{EmatchU32, func(m *EmatchMatch) { m.U32Match = &U32Match{} }},
+	{EmatchMeta, func(m *EmatchMatch) {
+		// Compare the constant values (TCF_META_TYPE_INT<<12 | TCF_META_ID_VALUE) 0 and 0.
+		m.MetaMatch = &MetaMatch{
+			Hdr:   &MetaHdr{Left: MetaValue{Kind: 1 << 12}, Right: MetaValue{Kind: 1 << 12}},
+			Left:  &MetaValueType{Int: uint32Ptr(0)},
+			Right: &MetaValueType{Int: uint32Ptr(0)},
+		}
+	}},
+	{EmatchText, func(m *EmatchMatch) {
+		m.TextMatch = &TextMatch{Algo: TextMatchKMP, ToOffset: 0xffff, Pattern: []byte{0x0}}
+	}},
+	{EmatchVLan, func(m *EmatchMatch) { m.VLanMatch = &VLanMatch{} }},
+	{EmatchCanID, func(m *EmatchMatch) { m.CanIDMatch = &CanIDMatch{Rules: []CanFilter{{ID: 1, Mask: CanSFFMask}}} }},
+	{EmatchIPT, func(m *EmatchMatch) {
+		// The kernel allows only the matches policy and addrtype.
+		m.IptMatch = &IptMatch{MatchName: stringPtr("policy"), NFProto: uint8Ptr(2), Hook: uint32Ptr(0)}
+	}},
+}
+
+// probeResult interprets the answer of the kernel to a probe. Only ENOENT tells, that the
+// kernel does not know a kind, as long as the parent of the probe exists. Every other error of the kernel shows, that the kind is known
+// but does not accept the minimal configuration in this environment. Errors, that did not
+// come from the kernel, and errors, that show that probing is not possible at all, like
+// missing privileges or an unknown network interface, are returned.
+func probeResult(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false, err
+	}
+	switch errno {
+	case unix.EPERM, unix.EACCES, unix.ENODEV:
+		return false, err
+	}
+	return errno != unix.ENOENT, nil
+}
+
+// probe checks which kinds of qdiscs, filters, actions and ematches as well as which
+// optional features are supported by the kernel.
+//
+// For this, qdiscs, filters and shared actions are added to and removed from the network
+// interface ifindex, which must not be in use, as its root and ingress qdisc are replaced.
+func (tc *Tc) probe(ifindex uint32) (*Capabilities, error) {
+	caps := &Capabilities{
+		Qdiscs:   make(map[string]bool),
+		Filters:  make(map[string]bool),
+		Actions:  make(map[string]bool),
+		Ematches: make(map[EmatchKind]bool),
+		Features: make(map[Feature]bool),
+	}
+
+	for _, probe := range probeQdiscs {
+		ok, err := tc.probeQdisc(probeQdiscObject(ifindex, probe))
+		if err != nil {
+			return nil, err
+		}
+		caps.Qdiscs[probe.kind] = ok
+	}
+
+	for _, probe := range probeActions {
+		act := &Action{Kind: probe.kind, Index: probeActionIndex}
+		probe.configure(act, ifindex)
+		ok, err := probeResult(tc.Actions().Add([]*Action{act}))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if err := tc.Actions().Delete([]*Action{{Kind: probe.kind, Index: probeActionIndex}}); err != nil {
+				return nil, err
+			}
+		}
+		caps.Actions[probe.kind] = ok
+	}
+
+	if err := tc.probeFilters(ifindex, caps); err != nil {
+		return nil, err
+	}
+
+	ok, err := tc.probeTaPrioTxTimeAssist(ifindex, caps)
+	if err != nil {
+		return nil, err
+	}
+	caps.Features[FeatureTaPrioTxTimeAssist] = ok
+
+	return caps, nil
+}
+
+// probeQdiscObject returns the qdisc of the probe. Qdiscs are added as root qdisc,
+// except for clsact and ingress.
+func probeQdiscObject(ifindex uint32, probe probeConfig) *Object {
+	qdisc := &Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: ifindex,
+			Handle:  probeHandle,
+			Parent:  HandleRoot,
+		},
+		Attribute: Attribute{
+			Kind: probe.kind,
+		},
+	}
+	if probe.kind == "clsact" || probe.kind == "ingress" {
+		qdisc.Handle = core.BuildHandle(HandleRoot, 0x0)
+		qdisc.Parent = HandleIngress
+	}
+	if probe.configure != nil {
+		probe.configure(&qdisc.Attribute)
+	}
+	return qdisc
+}
+
+// probeQdisc adds and removes qdisc and reports whether the kind of qdisc is supported.
+func (tc *Tc) probeQdisc(qdisc *Object) (bool, error) {
+	ok, err := probeResult(tc.Qdisc().Add(qdisc))
+	if err != nil || !ok {
+		return ok, err
+	}
+	return true, tc.Qdisc().Delete(qdisc)
+}
+
+// probeFilterParent adds a qdisc, that filters can be attached to. It returns the qdisc
+// and the parent for filters. If no suitable qdisc is supported, the returned qdisc is nil.
+func (tc *Tc) probeFilterParent(ifindex uint32, caps *Capabilities) (*Object, uint32, error) {
+	for _, probe := range probeQdiscs {
+		var parent uint32
+		switch probe.kind {
+		case "clsact":
+			parent = core.BuildHandle(HandleRoot, HandleMinIngress)
+		case "ingress":
+			parent = core.BuildHandle(HandleRoot, 0x0)
+		case "htb":
+			parent = probeHandle
+		default:
+			continue
+		}
+		if !caps.Qdisc(probe.kind) {
+			continue
+		}
+		qdisc := probeQdiscObject(ifindex, probe)
+		if ok, err := probeResult(tc.Qdisc().Add(qdisc)); err != nil {
+			return nil, 0, err
+		} else if ok {
+			return qdisc, parent, nil
+		}
+	}
+	return nil, 0, nil
+}
+
+// probeFilters probes the kinds of filters and ematches as well as the features of filters.
+// Each probe uses its own priority. All filters are removed together with their parent.
+func (tc *Tc) probeFilters(ifindex uint32, caps *Capabilities) error {
+	qdisc, parent, err := tc.probeFilterParent(ifindex, caps)
+	if err != nil || qdisc == nil {
+		// Without a qdisc for filters, no filter, ematch or feature of filters can be used.
+		return err
+	}
+
+	var prio uint16
+	newFilter := func(kind string) *Object {
+		prio++
+		return &Object{
+			Msg: Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: ifindex,
+				Parent:  parent,
+				Info:    core.FilterInfo(prio, unix.ETH_P_ALL),
+			},
+			Attribute: Attribute{
+				Kind: kind,
+			},
+		}
+	}
+
+	for _, probe := range probeFilters {
+		filter := newFilter(probe.kind)
+		probe.configure(&filter.Attribute)
+		ok, err := tc.probeFilter(filter, qdisc)
+		if err != nil {
+			return err
+		}
+		caps.Filters[probe.kind] = ok
+	}
+
+	if caps.Filter("basic") {
+		for _, probe := range probeEmatches {
+			match := EmatchMatch{Hdr: EmatchHdr{Kind: probe.kind, Flags: EmatchRelEnd}}
+			probe.configure(&match)
+			filter := newFilter("basic")
+			filter.Basic = &Basic{
+				ClassID: uint32Ptr(probeClassID),
+				Ematch: &Ematch{
+					Hdr:     &EmatchTreeHdr{NMatches: 1},
+					Matches: &[]EmatchMatch{match},
+				},
+			}
+			ok, err := tc.probeFilter(filter, qdisc)
+			if err != nil {
+				return err
+			}
+			caps.Ematches[probe.kind] = ok
+		}
+	}
+
+	if caps.Filter("flower") {
+		filter := newFilter("flower")
+		filter.Flower = &Flower{ClassID: uint32Ptr(probeClassID), L2Miss: uint8Ptr(1)}
+		ok, err := tc.probeFilterFeature(filter, qdisc, func(f *Object) bool {
+			return f.Flower != nil && uint8Value(f.Flower.L2Miss) == 1
+		})
+		if err != nil {
+			return err
+		}
+		caps.Features[FeatureFlowerL2Miss] = ok
+	}
+
+	return tc.Qdisc().Delete(qdisc)
+}
+
+// probeFilter adds filter to the qdisc parent and reports whether the kind of filter is
+// supported. The kernel also answers with ENOENT, if parent does not exist. So parent is
+// confirmed, before the kind is reported as unknown.
+func (tc *Tc) probeFilter(filter, parent *Object) (bool, error) {
+	err := tc.Filter().Add(filter)
+	ok, perr := probeResult(err)
+	if perr != nil || ok {
+		return ok, perr
+	}
+	qdiscs, qerr := tc.Qdisc().Get()
+	if qerr != nil {
+		return false, qerr
+	}
+	for _, q := range qdiscs {
+		if q.Ifindex == parent.Ifindex && q.Handle == parent.Handle && q.Kind == parent.Kind {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("probe: qdisc/%s for filters was removed: %w", parent.Kind, err)
+}
+
+// probeFilterFeature adds filter to the qdisc parent and reports whether the kernel reports
+// it back, so that reported returns true.
+func (tc *Tc) probeFilterFeature(filter, parent *Object, reported func(*Object) bool) (bool, error) {
+	ok, err := tc.probeFilter(filter, parent)
+	if err != nil || !ok {
+		return false, err
+	}
+	filters, err := tc.Filter().Get(&filter.Msg)
+	if err != nil {
+		// Filters, that can not be decoded, do not show the feature.
+		return false, nil
+	}
+	for _, f := range filters {
+		if f.Kind == filter.Kind && f.Info == filter.Info && reported(&f) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// probeTaPrioTxTimeAssist reports whether qdisc/taprio supports the txtime-assist mode.
+func (tc *Tc) probeTaPrioTxTimeAssist(ifindex uint32, caps *Capabilities) (bool, error) {
+	if !caps.Qdisc("taprio") {
+		return false, nil
+	}
+	qdisc := probeQdiscObject(ifindex, probeConfig{kind: "taprio", configure: func(a *Attribute) {
+		a.TaPrio = probeTaPrio()
+		a.TaPrio.Flags = uint32Ptr(TaPrioFlagTxTimeAssist)
+		a.TaPrio.TxTimeDelay = uint32Ptr(200000)
+	}})
+	if err := tc.Qdisc().Add(qdisc); err != nil {
+		_, err = probeResult(err)
+		return false, err
+	}
+	qdiscs, err := tc.Qdisc().Get()
+	if err == nil {
+		for _, q := range qdiscs {
+			if q.Ifindex == ifindex && q.Handle == probeHandle && q.TaPrio != nil &&
+				uint32Value(q.TaPrio.Flags)&TaPrioFlagTxTimeAssist != 0 {
+				return true, tc.Qdisc().Delete(qdisc)
+			}
+		}
+	}
+	// Qdiscs, that can not be decoded, do not show the feature.
+	return false, tc.Qdisc().Delete(qdisc)
+}
@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+package tc
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// probeLinkIndex is the interface index of the dummy interface, that is used by Probe().
+	probeLinkIndex uint32 = 2
+	// probeLoopbackIndex is the interface index of the loopback interface in a new network namespace.
+	probeLoopbackIndex uint32 = 1
+	// probeTxQueues is the number of transmit queues of the dummy interface.
+	probeTxQueues uint32 = 8
+)
+
+// Probe checks which kinds of qdiscs, filters, actions and ematches as well as which
+// optional features are supported by the running kernel.
+//
+// A kind is supported, if the kernel does not reject it as unknown. A feature is supported,
+// if the kernel accepts it and reports it back. As side effect, kernel modules of supported
+// kinds are loaded.
+//
+// Probe creates a scratch network namespace with a dummy interface, that has multiple
+// transmit queues. If the dummy interface can not be created, the loopback interface of the
+// namespace is used instead, on which the multiqueue feature FeatureTaPrioTxTimeAssist is not
+// available. Creating a network namespace requires CAP_SYS_ADMIN.
+func Probe() (*Capabilities, error) {
+	ns, err := newScratchNetNS()
+	if err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	tcnl, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		return nil, err
+	}
+	defer tcnl.Close()
+
+	ifindex := probeLinkIndex
+	if err := tcnl.addDummyLink(ifindex); err != nil {
+		ifindex = probeLoopbackIndex
+	}
+	return tcnl.probe(ifindex)
+}
+
+// newScratchNetNS returns a new network namespace. It exists as long as the returned file
+// is open and no interface is moved into it.
+func newScratchNetNS() (*os.File, error) {
+	type result struct {
+		ns  *os.File
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		// The thread is not unlocked, so it is terminated together with the goroutine instead
+		// of being reused in the new network namespace.
+		runtime.LockOSThread()
+		if err := unix.Unshare(unix.CLONE_NEWNET); err != nil {
+			done <- result{err: fmt.Errorf("could not create network namespace: %w", err)}
+			return
+		}
+		ns, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", unix.Gettid()))
+		done <- result{ns: ns, err: err}
+	}()
+	res := <-done
+	return res.ns, res.err
+}
+
+// addDummyLink creates a dummy interface with ifindex.
+func (tc *Tc) addDummyLink(ifindex uint32) error {
+	info, err := marshalAttributes([]tcOption{
+		{Interpretation: vtString, Type: unix.IFLA_INFO_KIND, Data: "dummy"},
+	})
+	if err != nil {
+		return err
+	}
+	return tc.action(unix.RTM_NEWLINK, netlink.Create|netlink.Excl, unix.IfInfomsg{
+		Family: unix.AF_UNSPEC,
+		Index:  int32(ifindex),
+	}, []tcOption{
+		{Interpretation: vtString, Type: unix.IFLA_IFNAME, Data: "probe0"},
+		{Interpretation: vtUint32, Type: unix.IFLA_NUM_TX_QUEUES, Data: probeTxQueues},
+		{Interpretation: vtBytes, Type: unix.IFLA_LINKINFO | nlaFNnested, Data: info},
+	})
+}
@@ -0,0 +1,22 @@
+//go:build integration && linux
+// +build integration,linux
+
+package tc
+
+import (
+	"testing"
+)
+
+func TestLinuxProbe(t *testing.T) {
+	caps, err := Probe()
+	if err != nil {
+		t.Fatalf("could not probe kernel: %v", err)
+	}
+	// qdisc/pfifo and qdisc/bfifo are always built into the kernel.
+	for _, kind := range []string{"pfifo", "bfifo"} {
+		if !caps.Qdisc(kind) {
+			t.Fatalf("qdisc/%s is reported as not supported", kind)
+		}
+	}
+	t.Logf("%#v", caps)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package tc
+
+// Probe checks which kinds and features are supported by the running kernel.
+// It is only available on Linux.
+func Probe() (*Capabilities, error) {
+	return nil, ErrNotImplemented
+}
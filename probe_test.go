@@ -0,0 +1,190 @@
+package tc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/florianl/go-tc/tctest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
+
+// hookConn is a tc.Conn, that calls hook before each request is sent.
+type hookConn struct {
+	tc.Conn
+	hook func(req netlink.Message)
+}
+
+func (c *hookConn) Send(m netlink.Message) (netlink.Message, error) {
+	c.hook(m)
+	return c.Conn.Send(m)
+}
+
+func TestProbe(t *testing.T) {
+	const ifindex = 2
+
+	tests := map[string]struct {
+		disabled []string
+		qdiscs   map[string]bool
+		filters  map[string]bool
+		actions  map[string]bool
+		ematches map[tc.EmatchKind]bool
+		features map[tc.Feature]bool
+	}{
+		"all": {
+			qdiscs:   map[string]bool{"clsact": true, "fq_pie": true, "htb": true, "taprio": true},
+			filters:  map[string]bool{"basic": true, "flower": true, "rsvp": true, "u32": true},
+			actions:  map[string]bool{"gact": true, "ipt": true, "police": true},
+			ematches: map[tc.EmatchKind]bool{tc.EmatchCmp: true, tc.EmatchMeta: true, tc.EmatchIPSet: false},
+			features: map[tc.Feature]bool{tc.FeatureFlowerL2Miss: true, tc.FeatureTaPrioTxTimeAssist: true},
+		},
+		"old kernel": {
+			disabled: []string{"fq_pie", "taprio", "flower", "rsvp", "ipt"},
+			qdiscs:   map[string]bool{"clsact": true, "fq_pie": false, "htb": true, "taprio": false},
+			filters:  map[string]bool{"basic": true, "flower": false, "rsvp": false, "u32": true},
+			actions:  map[string]bool{"gact": true, "ipt": false, "police": true},
+			ematches: map[tc.EmatchKind]bool{tc.EmatchCmp: true, tc.EmatchMeta: true},
+			features: map[tc.Feature]bool{tc.FeatureFlowerL2Miss: false, tc.FeatureTaPrioTxTimeAssist: false},
+		},
+		"htb as parent": {
+			disabled: []string{"clsact", "ingress"},
+			qdiscs:   map[string]bool{"clsact": false, "ingress": false, "htb": true},
+			filters:  map[string]bool{"basic": true, "flower": true},
+			ematches: map[tc.EmatchKind]bool{tc.EmatchU32: true},
+			features: map[tc.Feature]bool{tc.FeatureFlowerL2Miss: true},
+		},
+		"no parent": {
+			disabled: []string{"clsact", "ingress", "htb", "gact"},
+			qdiscs:   map[string]bool{"clsact": false, "ingress": false, "htb": false, "prio": true},
+			filters:  map[string]bool{"basic": false, "u32": false},
+			actions:  map[string]bool{"gact": false, "mirred": true},
+			ematches: map[tc.EmatchKind]bool{tc.EmatchCmp: false},
+			features: map[tc.Feature]bool{tc.FeatureFlowerL2Miss: false},
+		},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			k := tctest.NewKernel()
+			k.AddLink(ifindex)
+			k.DisableKinds(testcase.disabled...)
+			tcnl := k.Open()
+			defer tcnl.Close()
+
+			caps, err := tcnl.ProbeLink(ifindex)
+			if err != nil {
+				t.Fatalf("could not probe: %v", err)
+			}
+			for kind, want := range testcase.qdiscs {
+				if got := caps.Qdisc(kind); got != want {
+					t.Errorf("qdisc/%s: want %t, got %t", kind, want, got)
+				}
+			}
+			for kind, want := range testcase.filters {
+				if got := caps.Filter(kind); got != want {
+					t.Errorf("filter/%s: want %t, got %t", kind, want, got)
+				}
+			}
+			for kind, want := range testcase.actions {
+				if got := caps.Action(kind); got != want {
+					t.Errorf("action/%s: want %t, got %t", kind, want, got)
+				}
+			}
+			for kind, want := range testcase.ematches {
+				if got := caps.Ematch(kind); got != want {
+					t.Errorf("ematch %d: want %t, got %t", kind, want, got)
+				}
+			}
+			for feature, want := range testcase.features {
+				if got := caps.Feature(feature); got != want {
+					t.Errorf("%s: want %t, got %t", feature, want, got)
+				}
+			}
+
+			// Probing leaves no objects behind.
+			qdiscs, err := tcnl.Qdisc().Get()
+			if err != nil {
+				t.Fatalf("could not get qdiscs: %v", err)
+			}
+			if diff := cmp.Diff([]tc.Object(nil), qdiscs); diff != "" {
+				t.Fatalf("qdiscs missmatch (want +got):\n%s", diff)
+			}
+			actions, err := tcnl.Actions().Get("mirred")
+			if err != nil {
+				t.Fatalf("could not get actions: %v", err)
+			}
+			if len(actions) != 0 {
+				t.Fatalf("expected no actions but got %d", len(actions))
+			}
+		})
+	}
+
+	t.Run("unknown ifindex", func(t *testing.T) {
+		k := tctest.NewKernel()
+		k.AddLink(ifindex)
+		tcnl := k.Open()
+		defer tcnl.Close()
+
+		caps, err := tcnl.ProbeLink(ifindex + 1)
+		if !errors.Is(err, unix.ENODEV) {
+			t.Fatalf("expected ENODEV but got %v", err)
+		}
+		if caps != nil {
+			t.Fatalf("unexpected capabilities: %+v", caps)
+		}
+	})
+
+	t.Run("removed parent", func(t *testing.T) {
+		k := tctest.NewKernel()
+		k.AddLink(ifindex)
+		tcnl := k.Open()
+		defer tcnl.Close()
+
+		// The clsact qdisc, that holds the probed filters, is removed by someone else, so
+		// the kernel rejects the first filter with ENOENT.
+		var filters int
+		probe := tc.NewWithConn(&hookConn{Conn: k.Dial(), hook: func(req netlink.Message) {
+			if req.Header.Type != unix.RTM_NEWTFILTER {
+				return
+			}
+			if filters++; filters > 1 {
+				return
+			}
+			if err := tcnl.Qdisc().Delete(&tc.Object{
+				Msg: tc.Msg{
+					Family:  unix.AF_UNSPEC,
+					Ifindex: ifindex,
+					Handle:  core.BuildHandle(tc.HandleRoot, 0x0),
+					Parent:  tc.HandleIngress,
+				},
+				Attribute: tc.Attribute{Kind: "clsact"},
+			}); err != nil {
+				t.Fatalf("could not delete clsact: %v", err)
+			}
+		}})
+		defer probe.Close()
+
+		caps, err := probe.ProbeLink(ifindex)
+		if !errors.Is(err, unix.ENOENT) {
+			t.Fatalf("expected ENOENT but got %v", err)
+		}
+		if caps != nil {
+			t.Fatalf("unexpected capabilities: %+v", caps)
+		}
+		// The missing parent is not mistaken for unknown kinds of filters.
+		if filters != 1 {
+			t.Fatalf("expected probing to stop after the first filter but %d were added", filters)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var caps *tc.Capabilities
+		if caps.Qdisc("pfifo") || caps.Filter("u32") || caps.Action("gact") ||
+			caps.Ematch(tc.EmatchCmp) || caps.Feature(tc.FeatureFlowerL2Miss) {
+			t.Fatal("nil Capabilities support a kind")
+		}
+	})
+}
@@ -82,6 +82,8 @@ func marshalAtm(info *Atm) ([]byte, error) {
 }
 
 // AtmPvc from include/uapi/linux/atm.h
+//
+//structgen:binary
 type AtmPvc struct {
 	SapFamily byte
 	Itf       byte
@@ -118,6 +118,8 @@ func marshalCbq(info *Cbq) ([]byte, error) {
 }
 
 // CbqLssOpt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CbqLssOpt struct {
 	Change  byte
 	Flags   byte
@@ -130,6 +132,8 @@ type CbqLssOpt struct {
 }
 
 // CbqWrrOpt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CbqWrrOpt struct {
 	Flags     byte
 	Priority  byte
@@ -140,6 +144,8 @@ type CbqWrrOpt struct {
 }
 
 // CbqFOpt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CbqFOpt struct {
 	Split     uint32
 	Defmap    uint32
@@ -147,6 +153,8 @@ type CbqFOpt struct {
 }
 
 // CbqOvl from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CbqOvl struct {
 	Strategy  byte
 	Priority2 byte
@@ -155,6 +163,8 @@ type CbqOvl struct {
 }
 
 // CbqPolice from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CbqPolice struct {
 	Police byte
 	Res1   byte
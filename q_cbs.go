@@ -12,6 +12,8 @@ const (
 )
 
 // CbsOpt contains attributes of the cbs discipline
+//
+//structgen:binary
 type CbsOpt struct {
 	Offload   uint8
 	Pad       [3]uint8
@@ -31,6 +31,8 @@ const (
 )
 
 // FqPrioQopt according to tc_prio_qopt in /include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type FqPrioQopt struct {
 	Bands   int32
 	PrioMap [16]uint8 // TC_PRIO_MAX + 1 = 16
@@ -16,6 +16,7 @@ const (
 	tcaGredVqList /* nested TCA_GRED_VQ_ENTRY */
 )
 
+//structgen:binary
 type GredQOpt struct {
 	Limit    uint32 /* HARD maximal queue length (bytes)    */
 	QthMin   uint32 /* Min average length threshold (bytes) */
@@ -35,6 +36,7 @@ type GredQOpt struct {
 	ByteSin  uint32
 }
 
+//structgen:binary
 type GredSOpt struct {
 	DPs   uint32
 	DefDP uint32
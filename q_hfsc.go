@@ -85,6 +85,8 @@ func marshalHfsc(info *Hfsc) ([]byte, error) {
 }
 
 // ServiceCurve from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type ServiceCurve struct {
 	M1 uint32
 	D  uint32
@@ -92,12 +94,17 @@ type ServiceCurve struct {
 }
 
 // HfscQOpt contains attributes of the hfsc qdisc
+//
+//structgen:binary
 type HfscQOpt struct {
 	DefCls uint16
 }
 
 // unmarshalHfscQOpt parses the HfscQOpt-encoded data and stores the result in the value pointed to by info.
 func unmarshalHfscQOpt(data []byte, info *HfscQOpt) error {
+	if len(data) < 2 {
+		return fmt.Errorf("HfscQOpt: %w", ErrInvalidArg)
+	}
 	info.DefCls = nativeEndian.Uint16(data)
 
 	return nil
@@ -90,6 +90,12 @@ func marshalHtb(info *Htb) ([]byte, error) {
 		multiError = concatError(multiError, err)
 		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaHtbInit, Data: data})
 	}
+	if info.Ctab != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaHtbCtab, Data: bytesValue(info.Ctab)})
+	}
+	if info.Rtab != nil {
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaHtbRtab, Data: bytesValue(info.Rtab)})
+	}
 	if info.DirectQlen != nil {
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaHtbDirectQlen, Data: uint32Value(info.DirectQlen)})
 	}
@@ -109,6 +115,8 @@ func marshalHtb(info *Htb) ([]byte, error) {
 }
 
 // HtbGlob from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type HtbGlob struct {
 	Version      uint32
 	Rate2Quantum uint32
@@ -118,6 +126,8 @@ type HtbGlob struct {
 }
 
 // HtbOpt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type HtbOpt struct {
 	Rate    RateSpec
 	Ceil    RateSpec
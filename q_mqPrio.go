@@ -24,6 +24,8 @@ type MqPrio struct {
 }
 
 // MqPrioQopt according to tc_mqprio_qopt in /include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type MqPrioQopt struct {
 	NumTc     uint8
 	PrioTcMap [16]uint8 //  TC_QOPT_BITMASK + 1 = 16
@@ -42,6 +44,9 @@ func unmarshalMqPrio(data []byte, info *MqPrio) error {
 
 	// The size of MqPrioQopt is 82 bytes. To align it to 4 byte boundaries
 	// we add two.
+	if len(data) <= 84 {
+		return nil
+	}
 	data = data[84:]
 
 	ad, err := netlink.NewAttributeDecoder(data)
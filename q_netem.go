@@ -43,6 +43,8 @@ type Netem struct {
 }
 
 // NetemQopt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type NetemQopt struct {
 	Latency   uint32
 	Limit     uint32
@@ -53,6 +55,8 @@ type NetemQopt struct {
 }
 
 // NetemCorr from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type NetemCorr struct {
 	Delay uint32
 	Loss  uint32
@@ -60,18 +64,24 @@ type NetemCorr struct {
 }
 
 // NetemReorder from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type NetemReorder struct {
 	Probability uint32
 	Correlation uint32
 }
 
 // NetemCorrupt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type NetemCorrupt struct {
 	Probability uint32
 	Correlation uint32
 }
 
 // NetemRate from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type NetemRate struct {
 	Rate           uint32
 	PacketOverhead int32
@@ -80,6 +90,8 @@ type NetemRate struct {
 }
 
 // NetemSlot from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type NetemSlot struct {
 	MinDelay   int64
 	MaxDelay   int64
@@ -225,7 +237,7 @@ func marshalNetem(info *Netem) ([]byte, error) {
 
 	var qoptErr error
 	var qoptData []byte
-	if qoptData, qoptErr = marshalStruct(info.Qopt); qoptErr != nil {
+	if qoptData, qoptErr = marshalStruct(&info.Qopt); qoptErr != nil {
 		return []byte{}, qoptErr
 	}
 
@@ -14,6 +14,8 @@ const (
 )
 
 // Plug contains attributes of the plug discipline
+//
+//structgen:binary
 type Plug struct {
 	Action PlugAction
 	Limit  uint32
@@ -3,6 +3,8 @@ package tc
 import "fmt"
 
 // Prio contains attributes of the prio discipline
+//
+//structgen:binary
 type Prio struct {
 	Bands   uint32
 	PrioMap [16]uint8
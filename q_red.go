@@ -85,6 +85,8 @@ func marshalRed(info *Red) ([]byte, error) {
 }
 
 // RedQOpt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type RedQOpt struct {
 	Limit    uint32
 	QthMin   uint32
@@ -57,6 +57,8 @@ func marshalSfb(info *Sfb) ([]byte, error) {
 }
 
 // SfbQopt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type SfbQopt struct {
 	RehashInterval uint32 // in ms
 	WarmupTime     uint32 //  in ms
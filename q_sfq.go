@@ -5,6 +5,8 @@ import (
 )
 
 // SfqQopt contains SFQ attributes
+//
+//structgen:binary
 type SfqQopt struct {
 	Quantum       uint32 /* Bytes per round allocated to flow */
 	PerturbPeriod int32  /* Period of hash perturbation */
@@ -15,6 +17,8 @@ type SfqQopt struct {
 
 // Sfq contains attributes of the SFQ discipline
 // https://man7.org/linux/man-pages/man8/sfq.8.html
+//
+//structgen:binary
 type Sfq struct {
 	V0 SfqQopt
 
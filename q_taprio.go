@@ -22,6 +22,32 @@ const (
 	tcaTaPrioTcEntry                 /* nest */
 )
 
+const (
+	tcaTaPrioSchedUnspec = iota
+	tcaTaPrioSchedEntry  /* nest */
+)
+
+const (
+	tcaTaPrioSchedEntryUnspec   = iota
+	tcaTaPrioSchedEntryIndex    /* u32 */
+	tcaTaPrioSchedEntryCmd      /* u8 */
+	tcaTaPrioSchedEntryGateMask /* u32 */
+	tcaTaPrioSchedEntryInterval /* u32 */
+)
+
+// Commands of TaPrioSchedEntry from include/uapi/linux/pkt_sched.h
+const (
+	TaPrioCmdSetGates      uint8 = 0x00
+	TaPrioCmdSetAndHold    uint8 = 0x01
+	TaPrioCmdSetAndRelease uint8 = 0x02
+)
+
+// Flags of TaPrio from include/uapi/linux/pkt_sched.h
+const (
+	TaPrioFlagTxTimeAssist uint32 = 1 << 0
+	TaPrioFlagFullOffload  uint32 = 1 << 1
+)
+
 // TaPrio contains TaPrio attributes
 type TaPrio struct {
 	PrioMap                 *MqPrioQopt
@@ -31,6 +57,15 @@ type TaPrio struct {
 	SchedCycleTimeExtension *int64
 	Flags                   *uint32
 	TxTimeDelay             *uint32
+	SchedEntries            *[]TaPrioSchedEntry
+}
+
+// TaPrioSchedEntry contains the attributes of an entry of the TaPrio schedule.
+type TaPrioSchedEntry struct {
+	Index    *uint32
+	Cmd      *uint8
+	GateMask *uint32
+	Interval *uint32 // in ns
 }
 
 // unmarshalTaPrio parses the TaPrio-encoded data and stores the result in the value pointed to by info.
@@ -59,6 +94,13 @@ func unmarshalTaPrio(data []byte, info *TaPrio) error {
 			info.Flags = uint32Ptr(ad.Uint32())
 		case tcaTaPrioTxTimeDelay:
 			info.TxTimeDelay = uint32Ptr(ad.Uint32())
+		case tcaTaPrioSchedEntryList:
+			entries := []TaPrioSchedEntry{}
+			err := unmarshalTaPrioSchedEntryList(ad.Bytes(), &entries)
+			multiError = concatError(multiError, err)
+			info.SchedEntries = &entries
+		case tcaTaPrioAdminSched, tcaTaPrioTcEntry:
+			// the pending schedule and per traffic class settings are not supported yet, we just skip them
 		case tcaTaPrioPad:
 			// padding does not contain data, we just skip it
 		default:
@@ -101,9 +143,85 @@ func marshalTaPrio(info *TaPrio) ([]byte, error) {
 	if info.TxTimeDelay != nil {
 		options = append(options, tcOption{Interpretation: vtUint32, Type: tcaTaPrioTxTimeDelay, Data: uint32Value(info.TxTimeDelay)})
 	}
+	if info.SchedEntries != nil {
+		data, err := marshalTaPrioSchedEntryList(info.SchedEntries)
+		multiError = concatError(multiError, err)
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaTaPrioSchedEntryList | nlaFNnested, Data: data})
+	}
 
 	if multiError != nil {
 		return []byte{}, multiError
 	}
 	return marshalAttributes(options)
 }
+
+// unmarshalTaPrioSchedEntryList parses the list of schedule entries and stores the result in the value pointed to by info.
+func unmarshalTaPrioSchedEntryList(data []byte, info *[]TaPrioSchedEntry) error {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		return err
+	}
+	var multiError error
+	for ad.Next() {
+		switch ad.Type() {
+		case tcaTaPrioSchedEntry:
+			entry := TaPrioSchedEntry{}
+			err := unmarshalTaPrioSchedEntry(ad.Bytes(), &entry)
+			multiError = concatError(multiError, err)
+			*info = append(*info, entry)
+		default:
+			return fmt.Errorf("unmarshalTaPrioSchedEntryList()\t%d\n\t%v", ad.Type(), ad.Bytes())
+		}
+	}
+	return concatError(multiError, ad.Err())
+}
+
+// unmarshalTaPrioSchedEntry parses a single schedule entry and stores the result in the value pointed to by info.
+func unmarshalTaPrioSchedEntry(data []byte, info *TaPrioSchedEntry) error {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		return err
+	}
+	for ad.Next() {
+		switch ad.Type() {
+		case tcaTaPrioSchedEntryIndex:
+			info.Index = uint32Ptr(ad.Uint32())
+		case tcaTaPrioSchedEntryCmd:
+			info.Cmd = uint8Ptr(ad.Uint8())
+		case tcaTaPrioSchedEntryGateMask:
+			info.GateMask = uint32Ptr(ad.Uint32())
+		case tcaTaPrioSchedEntryInterval:
+			info.Interval = uint32Ptr(ad.Uint32())
+		default:
+			return fmt.Errorf("unmarshalTaPrioSchedEntry()\t%d\n\t%v", ad.Type(), ad.Bytes())
+		}
+	}
+	return ad.Err()
+}
+
+// marshalTaPrioSchedEntryList returns the binary encoding of a list of schedule entries
+func marshalTaPrioSchedEntryList(info *[]TaPrioSchedEntry) ([]byte, error) {
+	options := []tcOption{}
+
+	for _, entry := range *info {
+		entryOptions := []tcOption{}
+		if entry.Index != nil {
+			entryOptions = append(entryOptions, tcOption{Interpretation: vtUint32, Type: tcaTaPrioSchedEntryIndex, Data: uint32Value(entry.Index)})
+		}
+		if entry.Cmd != nil {
+			entryOptions = append(entryOptions, tcOption{Interpretation: vtUint8, Type: tcaTaPrioSchedEntryCmd, Data: uint8Value(entry.Cmd)})
+		}
+		if entry.GateMask != nil {
+			entryOptions = append(entryOptions, tcOption{Interpretation: vtUint32, Type: tcaTaPrioSchedEntryGateMask, Data: uint32Value(entry.GateMask)})
+		}
+		if entry.Interval != nil {
+			entryOptions = append(entryOptions, tcOption{Interpretation: vtUint32, Type: tcaTaPrioSchedEntryInterval, Data: uint32Value(entry.Interval)})
+		}
+		data, err := marshalAttributes(entryOptions)
+		if err != nil {
+			return []byte{}, err
+		}
+		options = append(options, tcOption{Interpretation: vtBytes, Type: tcaTaPrioSchedEntry | nlaFNnested, Data: data})
+	}
+	return marshalAttributes(options)
+}
@@ -23,6 +23,13 @@ func TestTaPrio(t *testing.T) {
 			Flags:                   uint32Ptr(17),
 			TxTimeDelay:             uint32Ptr(19),
 		}},
+		"schedule": {val: TaPrio{
+			SchedClockID: int32Ptr(11),
+			SchedEntries: &[]TaPrioSchedEntry{
+				{Index: uint32Ptr(0), Cmd: uint8Ptr(TaPrioCmdSetGates), GateMask: uint32Ptr(1), Interval: uint32Ptr(300000)},
+				{Index: uint32Ptr(1), Cmd: uint8Ptr(TaPrioCmdSetGates), GateMask: uint32Ptr(2), Interval: uint32Ptr(700000)},
+			},
+		}},
 	}
 
 	for name, testcase := range tests {
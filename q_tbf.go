@@ -43,6 +43,8 @@ func unmarshalTbf(data []byte, info *Tbf) error {
 			info.Burst = uint32Ptr(ad.Uint32())
 		case tcaTbfPburst:
 			info.Pburst = uint32Ptr(ad.Uint32())
+		case tcaTbfRtab, tcaTbfPtab:
+			// rate tables are generated from Parms, we just skip them
 		case tcaTbfPad:
 			// padding does not contain data, we just skip it
 		default:
@@ -102,6 +104,8 @@ func marshalTbf(info *Tbf) ([]byte, error) {
 }
 
 // TbfQopt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type TbfQopt struct {
 	Rate     RateSpec
 	PeakRate RateSpec
@@ -82,6 +82,14 @@ func (qd *Qdisc) Get() ([]Object, error) {
 	return qd.get(unix.RTM_GETQDISC, &Msg{})
 }
 
+// Walk calls fn for each queueing discipline as soon as it is received.
+func (qd *Qdisc) Walk(fn WalkFunc) error {
+	if fn == nil {
+		return ErrNoArg
+	}
+	return qd.walk(unix.RTM_GETQDISC, &Msg{}, fn)
+}
+
 func validateQdiscObject(action int, info *Object) ([]tcOption, error) {
 	options := []tcOption{}
 	if info.Ifindex == 0 {
@@ -115,6 +123,9 @@ func validateQdiscObject(action int, info *Object) ([]tcOption, error) {
 	case "qfq":
 		// qfq is parameterless
 		// parameters are used in its corresponding class
+		if info.Qfq != nil {
+			data, err = marshalQfq(info.Qfq)
+		}
 	case "pie":
 		data, err = marshalPie(info.Pie)
 	case "mqprio":
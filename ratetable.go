@@ -20,13 +20,6 @@ func generateRateTable(pol *Policy) ([]byte, error) {
 	if pol == nil {
 		return []byte{}, fmt.Errorf("generateRateTable: %w", ErrNoArg)
 	}
-	mtu := pol.Mtu
-
-	var cellLog int = -1
-	if mtu == 0 {
-		mtu = 2047
-	}
-
 	var linklayer, mpu uint
 	var polRate uint64
 
@@ -42,12 +35,7 @@ func generateRateTable(pol *Policy) ([]byte, error) {
 		return []byte{}, fmt.Errorf("generateRateTable: Rate or PeakRate is required: %w", ErrNoArg)
 	}
 
-	if cellLog < 0 {
-		cellLog = 0
-		for (mtu >> uint(cellLog)) > 255 {
-			cellLog++
-		}
-	}
+	cellLog := rateTableCellLog(pol.Mtu)
 
 	for i := 0; i < 256; i++ {
 		sz := adjustSize(uint((i+1)<<uint(cellLog)), mpu, linklayer)
@@ -59,6 +47,19 @@ func generateRateTable(pol *Policy) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+// rateTableCellLog returns the cell log, so that a packet of mtu bytes fits into the
+// 256 slots of the rate table.
+func rateTableCellLog(mtu uint32) int {
+	if mtu == 0 {
+		mtu = 2047
+	}
+	cellLog := 0
+	for (mtu >> uint(cellLog)) > 255 {
+		cellLog++
+	}
+	return cellLog
+}
+
 // iproute2/tc/tc_core.c:tc_adjust_size()
 func adjustSize(sz, mpu, linklayer uint) uint32 {
 	if sz < mpu {
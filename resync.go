@@ -0,0 +1,236 @@
+package tc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+// Snapshot contains the qdiscs, classes, filters and chains of all network interfaces.
+// Filters and chains of shared blocks are reported with Msg.Ifindex set to MagicBlock
+// and Msg.Parent set to the index of the block.
+type Snapshot struct {
+	Qdiscs  []Object
+	Classes []Object
+	Filters []Object
+	Chains  []Object
+}
+
+// ResyncFunc is a function, which is called with a fresh snapshot, whenever a monitor
+// started to receive notifications anew. Return something different than 0, to stop
+// receiving messages.
+type ResyncFunc func(s *Snapshot) int
+
+const (
+	// resyncMinBackoff and resyncMaxBackoff limit the delay between two attempts to
+	// reconnect a resilient monitor.
+	resyncMinBackoff = 100 * time.Millisecond
+	resyncMaxBackoff = 10 * time.Second
+)
+
+// Snapshot fetches all qdiscs, classes, filters and chains.
+func (tc *Tc) Snapshot() (*Snapshot, error) {
+	s := &Snapshot{}
+	var err error
+	if s.Qdiscs, err = tc.Qdisc().Get(); err != nil {
+		return nil, err
+	}
+
+	// Filters and chains are attached to qdiscs, classes and shared blocks.
+	var parents []Msg
+	seen := make(map[Msg]bool)
+	addParent := func(ifindex, parent uint32) {
+		m := Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: parent}
+		if !seen[m] {
+			seen[m] = true
+			parents = append(parents, m)
+		}
+	}
+	classesOf := make(map[uint32]bool)
+	for _, qdisc := range s.Qdiscs {
+		if !classesOf[qdisc.Ifindex] {
+			classesOf[qdisc.Ifindex] = true
+			classes, err := tc.Class().Get(&Msg{Family: unix.AF_UNSPEC, Ifindex: qdisc.Ifindex})
+			if err != nil && !vanished(err) {
+				return nil, err
+			}
+			for _, class := range classes {
+				addParent(class.Ifindex, class.Handle)
+			}
+			s.Classes = append(s.Classes, classes...)
+		}
+
+		switch qdisc.Kind {
+		case "ingress", "clsact":
+			if block := uint32Value(qdisc.IngressBlock); block != 0 {
+				addParent(MagicBlock, block)
+			} else {
+				addParent(qdisc.Ifindex, core.BuildHandle(HandleRoot, HandleMinIngress))
+			}
+			if qdisc.Kind != "clsact" {
+				break
+			}
+			if block := uint32Value(qdisc.EgressBlock); block != 0 {
+				addParent(MagicBlock, block)
+			} else {
+				addParent(qdisc.Ifindex, core.BuildHandle(HandleRoot, HandleMinEgress))
+			}
+		default:
+			// Qdiscs without handle, like the default qdiscs of mq, can not hold filters.
+			// Parent 0 would refer to the root qdisc instead.
+			if qdisc.Handle != 0 {
+				addParent(qdisc.Ifindex, qdisc.Handle)
+			}
+		}
+	}
+
+	for i := range parents {
+		filters, err := tc.Filter().Get(&parents[i])
+		if err != nil && !vanished(err) {
+			return nil, err
+		}
+		s.Filters = append(s.Filters, filters...)
+
+		chains, err := tc.Chain().Get(&parents[i])
+		if err != nil && !vanished(err) {
+			return nil, err
+		}
+		s.Chains = append(s.Chains, chains...)
+	}
+	return s, nil
+}
+
+// vanished reports whether err is caused by an object, that was removed meanwhile, or by
+// a classless parent, that does not support filters.
+func vanished(err error) bool {
+	return errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENODEV) ||
+		errors.Is(err, unix.EOPNOTSUPP)
+}
+
+// MonitorResilient handles NETLINK_ROUTE messages like MonitorWithErrorFunc. Each time
+// it starts to receive notifications, it fetches a snapshot of all qdiscs, classes,
+// filters and chains and calls resync with it. If receiving fails, e.g. because the
+// kernel dropped notifications (ENOBUFS) or the connection was closed, errfn is called
+// and unless it returns something different than 0, the monitor reconnects and calls
+// resync again. So fn and resync allow to maintain an accurate copy of the state of the
+// kernel.
+//
+// The monitor stops, if ctx is done or Tc is closed. It requires a Tc, that was created
+// by Open or NewWithDialer.
+func (tc *Tc) MonitorResilient(ctx context.Context, fn HookFunc, resync ResyncFunc, errfn ErrorFunc) error {
+	if fn == nil || resync == nil || errfn == nil {
+		return ErrNoArg
+	}
+	if tc.shared.dial == nil {
+		return fmt.Errorf("resilient monitor requires a Tc created by Open or NewWithDialer: %w", ErrInvalidArg)
+	}
+
+	con, release, snapshot, err := tc.resubscribe()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		backoff := resyncMinBackoff
+		for {
+			if !tc.receiveNotifications(ctx, con, release, snapshot, fn, resync, errfn) {
+				return
+			}
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				con, release, snapshot, err = tc.resubscribe()
+				if err == nil {
+					backoff = resyncMinBackoff
+					break
+				}
+				if errors.Is(err, net.ErrClosed) || ctx.Err() != nil || errfn(err) != 0 {
+					return
+				}
+				if backoff *= 2; backoff > resyncMaxBackoff {
+					backoff = resyncMaxBackoff
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// resubscribe opens a connection, that receives notifications, and fetches a snapshot
+// afterwards. So no change after the snapshot is missed.
+func (tc *Tc) resubscribe() (Conn, func(), *Snapshot, error) {
+	con, release, err := tc.monitorConn()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := con.JoinGroup(unix.RTNLGRP_TC); err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	snapshot, err := tc.Snapshot()
+	if err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	return con, release, snapshot, nil
+}
+
+// receiveNotifications calls resync with snapshot and fn for each notification received
+// on con. It reports whether the monitor should reconnect.
+func (tc *Tc) receiveNotifications(ctx context.Context, con Conn, release func(), snapshot *Snapshot,
+	fn HookFunc, resync ResyncFunc, errfn ErrorFunc) bool {
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		release()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Unblock Receive.
+			con.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	if resync(snapshot) != 0 {
+		return false
+	}
+	for {
+		msgs, err := con.Receive()
+		if ctx.Err() != nil {
+			return false
+		}
+		if err != nil {
+			if errfn(err) != 0 {
+				return false
+			}
+			// Notifications might have been lost. So start over with a new connection.
+			return !tc.isClosed()
+		}
+		for _, msg := range msgs {
+			monitored, ok := decodeNotification(msg)
+			if !ok {
+				continue
+			}
+			if fn(uint16(msg.Header.Type), monitored) != 0 {
+				return false
+			}
+		}
+	}
+}
+
+// isClosed reports whether Tc was closed.
+func (tc *Tc) isClosed() bool {
+	tc.shared.monitorsMu.Lock()
+	defer tc.shared.monitorsMu.Unlock()
+	return tc.shared.closed
+}
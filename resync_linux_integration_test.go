@@ -0,0 +1,152 @@
+//go:build integration && linux
+// +build integration,linux
+
+package tc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+func TestLinuxSnapshot(t *testing.T) {
+	ns, err := newScratchNetNS()
+	if err != nil {
+		t.Skipf("could not create network namespace: %v", err)
+	}
+	defer ns.Close()
+
+	tcSocket, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open socket for TC: %v", err)
+	}
+	defer tcSocket.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshots := make(chan *Snapshot, 2)
+	if err := tcSocket.MonitorResilient(ctx, func(uint16, Object) int { return 0 }, func(s *Snapshot) int {
+		snapshots <- s
+		return 0
+	}, func(err error) int {
+		if ctx.Err() == nil {
+			t.Errorf("monitor failed: %v", err)
+		}
+		return 1
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+	select {
+	case s := <-snapshots:
+		if len(s.Filters) != 0 {
+			t.Fatalf("unexpected filters in initial snapshot: %+v", s.Filters)
+		}
+	case <-ctx.Done():
+		t.Fatal("missing initial snapshot")
+	}
+
+	root := core.BuildHandle(0x1, 0x0)
+	if err := tcSocket.Qdisc().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  root,
+			Parent:  HandleRoot,
+		},
+		Attribute: Attribute{
+			Kind: "htb",
+			Htb:  &Htb{Init: &HtbGlob{Version: 3, Rate2Quantum: 10}},
+		},
+	}); err != nil {
+		t.Fatalf("could not add htb: %v", err)
+	}
+	class := core.BuildHandle(0x1, 0x1)
+	if err := tcSocket.Class().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  class,
+			Parent:  root,
+		},
+		Attribute: Attribute{
+			Kind: "htb",
+			Htb: &Htb{Parms: &HtbOpt{
+				Rate:    RateSpec{Rate: 125000},
+				Ceil:    RateSpec{Rate: 125000},
+				Buffer:  10000,
+				Cbuffer: 10000,
+			}},
+		},
+	}); err != nil {
+		t.Fatalf("could not add class: %v", err)
+	}
+	if err := tcSocket.Qdisc().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{
+			Kind: "clsact",
+		},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+	for _, parent := range []uint32{class, core.BuildHandle(HandleRoot, HandleMinEgress)} {
+		if err := tcSocket.Filter().Add(&Object{
+			Msg: Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: probeLoopbackIndex,
+				Parent:  parent,
+				Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+			},
+			Attribute: Attribute{
+				Kind: "u32",
+				U32: &U32{
+					ClassID: uint32Ptr(class),
+					Sel:     &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{{}}},
+				},
+			},
+		}); err != nil {
+			t.Fatalf("could not add u32 to %x: %v", parent, err)
+		}
+	}
+
+	s, err := tcSocket.Snapshot()
+	if err != nil {
+		t.Fatalf("could not get snapshot: %v", err)
+	}
+	kinds := make(map[string]bool)
+	for _, qdisc := range s.Qdiscs {
+		kinds[qdisc.Kind] = true
+	}
+	if !kinds["htb"] || !kinds["clsact"] {
+		t.Fatalf("unexpected qdiscs: %v", kinds)
+	}
+	classes := 0
+	for _, c := range s.Classes {
+		if c.Ifindex == probeLoopbackIndex && c.Handle == class {
+			classes++
+		}
+	}
+	if classes != 1 {
+		t.Fatalf("class %x is missing", class)
+	}
+	parents := make(map[uint32]bool)
+	for _, f := range s.Filters {
+		if f.Kind == "u32" && f.U32 != nil && f.U32.ClassID != nil {
+			parents[f.Parent] = true
+		}
+	}
+	if !parents[class] || !parents[core.BuildHandle(HandleRoot, HandleMinEgress)] {
+		t.Fatalf("filters are missing: %v", parents)
+	}
+	if len(s.Chains) == 0 {
+		t.Fatal("chains are missing")
+	}
+}
@@ -0,0 +1,300 @@
+package tc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/florianl/go-tc/tctest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/josharian/native"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nltest"
+)
+
+// handles returns the handles of objs.
+func handles(objs []tc.Object) []uint32 {
+	var h []uint32
+	for _, obj := range objs {
+		h = append(h, obj.Handle)
+	}
+	return h
+}
+
+func TestSnapshot(t *testing.T) {
+	_, tcnl := batchKernel(t)
+	if err := tcnl.Filter().Add(batchFilter(2, 0x10002)); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+
+	s, err := tcnl.Snapshot()
+	if err != nil {
+		t.Fatalf("could not get snapshot: %v", err)
+	}
+	if len(s.Qdiscs) != 1 || s.Qdiscs[0].Kind != "clsact" {
+		t.Fatalf("unexpected qdiscs: %+v", s.Qdiscs)
+	}
+	// Like the kernel, each classifier is reported with handle 0 ahead of its filters.
+	if diff := cmp.Diff([]uint32{0, 1, 2}, handles(s.Filters)); diff != "" {
+		t.Fatalf("filters missmatch (want +got):\n%s", diff)
+	}
+}
+
+// replyConn is a tc.Conn, that lets reply answer requests instead of the kernel and that
+// rewrites the received messages with rewrite.
+type replyConn struct {
+	tc.Conn
+	reply   func(req netlink.Message) []netlink.Message
+	rewrite func(msg *netlink.Message)
+	pending []netlink.Message
+}
+
+func (c *replyConn) Send(m netlink.Message) (netlink.Message, error) {
+	m, err := c.Conn.Send(m)
+	if err == nil {
+		c.pending = c.reply(m)
+	}
+	return m, err
+}
+
+func (c *replyConn) Receive() ([]netlink.Message, error) {
+	msgs, err := c.Conn.Receive()
+	if c.pending != nil {
+		msgs, c.pending = c.pending, nil
+	}
+	for i := range msgs {
+		c.rewrite(&msgs[i])
+	}
+	return msgs, err
+}
+
+func TestSnapshotParents(t *testing.T) {
+	k, _ := batchKernel(t)
+	root := core.BuildHandle(0x1, 0x0)
+	htb := func(handle, parent uint32, htb *tc.Htb) *tc.Object {
+		return &tc.Object{
+			Msg: tc.Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: batchIfindex,
+				Handle:  handle,
+				Parent:  parent,
+			},
+			Attribute: tc.Attribute{Kind: "htb", Htb: htb},
+		}
+	}
+	tcnl := k.Open()
+	defer tcnl.Close()
+	if err := tcnl.Qdisc().Add(htb(root, tc.HandleRoot, &tc.Htb{
+		Init: &tc.HtbGlob{Version: 3, Rate2Quantum: 10},
+	})); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	if err := tcnl.Class().Add(htb(core.BuildHandle(0x1, 0x1), root, &tc.Htb{
+		Parms: &tc.HtbOpt{Rate: tc.RateSpec{Rate: 125000}, Ceil: tc.RateSpec{Rate: 125000}},
+	})); err != nil {
+		t.Fatalf("could not add class: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(htb(core.BuildHandle(0x10, 0x0), core.BuildHandle(0x1, 0x1), &tc.Htb{
+		Init: &tc.HtbGlob{Version: 3, Rate2Quantum: 10},
+	})); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	filter := batchFilter(7, 0x10007)
+	filter.Parent = root
+	if err := tcnl.Filter().Add(filter); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+
+	snapshot := func(reply func(req netlink.Message) []netlink.Message) (*tc.Snapshot, error) {
+		tcnl := tc.NewWithConn(&replyConn{
+			Conn:  k.Dial(),
+			reply: reply,
+			rewrite: func(msg *netlink.Message) {
+				// Report the child qdisc without handle, like the default qdiscs of mq.
+				if msg.Header.Type == unix.RTM_NEWQDISC && len(msg.Data) >= 20 &&
+					native.Endian.Uint32(msg.Data[8:12]) == core.BuildHandle(0x10, 0x0) {
+					native.Endian.PutUint32(msg.Data[8:12], 0)
+				}
+			},
+		})
+		defer tcnl.Close()
+		return tcnl.Snapshot()
+	}
+
+	t.Run("handle-less qdisc", func(t *testing.T) {
+		s, err := snapshot(func(netlink.Message) []netlink.Message { return nil })
+		if err != nil {
+			t.Fatalf("could not get snapshot: %v", err)
+		}
+		var rootFilters []uint32
+		for _, f := range s.Filters {
+			if f.Parent == root {
+				rootFilters = append(rootFilters, f.Handle)
+			}
+		}
+		// The filters of the root qdisc are reported once.
+		if diff := cmp.Diff([]uint32{0, 7}, rootFilters); diff != "" {
+			t.Fatalf("filters missmatch (want +got):\n%s", diff)
+		}
+	})
+
+	for name, testcase := range map[string]struct {
+		errno int
+		err   error
+	}{
+		"vanished":    {errno: int(unix.ENOENT)},
+		"classless":   {errno: int(unix.EOPNOTSUPP)},
+		"invalid":     {errno: int(unix.EINVAL), err: unix.EINVAL},
+		"not allowed": {errno: int(unix.EPERM), err: unix.EPERM},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, err := snapshot(func(req netlink.Message) []netlink.Message {
+				if req.Header.Type != unix.RTM_GETTFILTER ||
+					native.Endian.Uint32(req.Data[12:16]) != core.BuildHandle(0x1, 0x1) {
+					return nil
+				}
+				msgs, _ := nltest.Error(testcase.errno, []netlink.Message{req})
+				return msgs
+			})
+			if testcase.err != nil {
+				if !errors.Is(err, testcase.err) {
+					t.Fatalf("expected %v but got: %v", testcase.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("could not get snapshot: %v", err)
+			}
+			if len(s.Filters) == 0 {
+				t.Fatal("missing filters")
+			}
+		})
+	}
+}
+
+func TestMonitorResilient(t *testing.T) {
+	k, tcnl := batchKernel(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshots := make(chan *tc.Snapshot, 4)
+	events := make(chan uint32, 4)
+	errs := make(chan error, 4)
+	if err := tcnl.MonitorResilient(ctx, func(action uint16, m tc.Object) int {
+		if action == unix.RTM_NEWTFILTER {
+			events <- m.Handle
+		}
+		return 0
+	}, func(s *tc.Snapshot) int {
+		snapshots <- s
+		return 0
+	}, func(err error) int {
+		errs <- err
+		return 0
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+
+	nextSnapshot := func() *tc.Snapshot {
+		t.Helper()
+		select {
+		case s := <-snapshots:
+			return s
+		case <-ctx.Done():
+			t.Fatal("missing snapshot")
+		}
+		return nil
+	}
+
+	if diff := cmp.Diff([]uint32{0, 1}, handles(nextSnapshot().Filters)); diff != "" {
+		t.Fatalf("filters of initial snapshot missmatch (want +got):\n%s", diff)
+	}
+
+	if err := tcnl.Filter().Add(batchFilter(2, 0x10002)); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+	select {
+	case handle := <-events:
+		if handle != 2 {
+			t.Fatalf("unexpected event for filter %d", handle)
+		}
+	case <-ctx.Done():
+		t.Fatal("missing event")
+	}
+
+	// Notifications, that are dropped by the kernel, are recovered by a new snapshot.
+	k.Overrun()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, unix.ENOBUFS) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("missing error")
+	}
+	if diff := cmp.Diff([]uint32{0, 1, 2}, handles(nextSnapshot().Filters)); diff != "" {
+		t.Fatalf("filters of snapshot after overrun missmatch (want +got):\n%s", diff)
+	}
+
+	if err := tcnl.Filter().Add(batchFilter(3, 0x10003)); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+	select {
+	case handle := <-events:
+		if handle != 3 {
+			t.Fatalf("unexpected event for filter %d", handle)
+		}
+	case <-ctx.Done():
+		t.Fatal("missing event after resync")
+	}
+}
+
+func TestMonitorResilientStop(t *testing.T) {
+	t.Run("close", func(t *testing.T) {
+		_, tcnl := batchKernel(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		resyncs := make(chan struct{}, 4)
+		errs := make(chan error, 4)
+		if err := tcnl.MonitorResilient(ctx, func(uint16, tc.Object) int { return 0 }, func(*tc.Snapshot) int {
+			resyncs <- struct{}{}
+			return 0
+		}, func(err error) int {
+			errs <- err
+			return 0
+		}); err != nil {
+			t.Fatalf("could not start monitor: %v", err)
+		}
+		<-resyncs
+		if err := tcnl.Close(); err != nil {
+			t.Fatalf("could not close: %v", err)
+		}
+		select {
+		case <-errs:
+		case <-ctx.Done():
+			t.Fatal("monitor did not notice the closed connection")
+		}
+		// A closed Tc is not reconnected.
+		time.Sleep(300 * time.Millisecond)
+		if len(resyncs) != 0 {
+			t.Fatal("monitor reconnected after Close")
+		}
+	})
+
+	t.Run("without dialer", func(t *testing.T) {
+		k := tctest.NewKernel()
+		tcnl := tc.NewWithConn(k.Dial())
+		defer tcnl.Close()
+		err := tcnl.MonitorResilient(context.Background(), func(uint16, tc.Object) int { return 0 },
+			func(*tc.Snapshot) int { return 0 }, func(error) int { return 0 })
+		if !errors.Is(err, tc.ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
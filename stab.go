@@ -13,6 +13,8 @@ const (
 )
 
 // SizeSpec implements tc_sizespec
+//
+//structgen:binary
 type SizeSpec struct {
 	CellLog   uint8
 	SizeLog   uint8
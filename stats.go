@@ -28,24 +28,32 @@ type GenStats struct {
 }
 
 // GenBasic from include/uapi/linux/gen_stats.h
+//
+//structgen:binary
 type GenBasic struct {
 	Bytes   uint64
 	Packets uint32
 }
 
 // GenRateEst from include/uapi/linux/gen_stats.h
+//
+//structgen:binary
 type GenRateEst struct {
 	BytePerSecond   uint32
 	PacketPerSecond uint32
 }
 
 // GenRateEst64 from include/uapi/linux/gen_stats.h
+//
+//structgen:binary
 type GenRateEst64 struct {
 	BytePerSecond   uint64
 	PacketPerSecond uint64
 }
 
 // GenQueue from include/uapi/linux/gen_stats.h
+//
+//structgen:binary
 type GenQueue struct {
 	QueueLen   uint32
 	Backlog    uint32
@@ -0,0 +1,253 @@
+package tc
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/mdlayher/netlink"
+)
+
+// WalkFunc is a function, which is called for each object of a dump.
+// Return something different than 0, to stop the dump. The Tc, that performs the
+// dump, can not be used for further requests until the dump is done.
+type WalkFunc func(m Object) int
+
+// ActionWalkFunc is a function, which is called for each action of a dump.
+// Return something different than 0, to stop the dump. The Tc, that performs the
+// dump, can not be used for further requests until the dump is done.
+type ActionWalkFunc func(a *Action) int
+
+const (
+	// nlmsgHeaderLen is the size of struct nlmsghdr from include/uapi/linux/netlink.h
+	nlmsgHeaderLen = 16
+	// nlmsgAlignTo is NLMSG_ALIGNTO from include/uapi/linux/netlink.h
+	nlmsgAlignTo = 4
+
+	// Attributes of extended acknowledgements from include/uapi/linux/netlink.h
+	nlmsgerrAttrMsg  = 1
+	nlmsgerrAttrOffs = 2
+)
+
+// walk requests a dump of objects and calls fn for each object as soon as it is received.
+func (tc *Tc) walk(action int, i *Msg, fn WalkFunc) error {
+	tcminfo, err := marshalStruct(i)
+	if err != nil {
+		return err
+	}
+
+	req := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(action),
+			Flags: netlink.Request | netlink.Dump,
+		},
+		Data: tcminfo,
+	}
+
+	return tc.dump(req, func(msg netlink.Message) (bool, error) {
+		var result Object
+		if len(msg.Data) < 20 {
+			return true, fmt.Errorf("short message: %w", ErrInvalidArg)
+		}
+		if err := unmarshalStruct(msg.Data[:20], &result.Msg); err != nil {
+			return true, err
+		}
+		if err := extractTcmsgAttributes(action, msg.Data[20:], &result.Attribute); err != nil {
+			return true, err
+		}
+		return fn(result) != 0, nil
+	})
+}
+
+// dump sends req and calls fn for each message of the multipart reply.
+//
+// If the connection provides access to its socket, the reply is received and handed to fn
+// one datagram at a time. So only a single datagram of the reply is held in memory. Once fn
+// returns true or an error, the remaining messages of the reply are received and dropped,
+// so that the connection can be used for further requests.
+func (tc *Tc) dump(req netlink.Message, fn func(netlink.Message) (bool, error)) error {
+	tc.shared.mu.Lock()
+	defer tc.shared.mu.Unlock()
+
+	verify, err := tc.con.Send(req)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.Validate(req, []netlink.Message{verify}); err != nil {
+		return err
+	}
+
+	receive := batchReceiver(tc.con)
+	if receive == nil {
+		// The connection receives the complete reply at once.
+		for {
+			msgs, err := tc.con.Receive()
+			if err != nil {
+				return err
+			}
+			replies := repliesTo(verify, msgs)
+			if len(msgs) > 0 && len(replies) == 0 {
+				// Only stale replies to earlier requests were received.
+				continue
+			}
+			for _, msg := range replies {
+				if err := checkMessage(msg); err != nil {
+					return err
+				}
+				if msg.Header.Type == netlink.Done || msg.Header.Type == netlink.Error {
+					continue
+				}
+				if stop, err := fn(msg); err != nil || stop {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return dumpBatches(verify, receive, fn)
+}
+
+// repliesTo returns the messages of msgs, whose sequence number and PID match the ones
+// of the request verify. Other messages are stale replies to earlier requests.
+func repliesTo(verify netlink.Message, msgs []netlink.Message) []netlink.Message {
+	var replies []netlink.Message
+	for _, msg := range msgs {
+		if netlink.Validate(verify, []netlink.Message{msg}) == nil {
+			replies = append(replies, msg)
+		}
+	}
+	return replies
+}
+
+// dumpBatches calls fn for each message of the multipart reply to the request verify, that
+// is received one datagram at a time by receive. Messages, that do not belong to the reply,
+// are dropped.
+func dumpBatches(verify netlink.Message, receive func() ([]netlink.Message, error),
+	fn func(netlink.Message) (bool, error)) error {
+	var fnErr error
+	stopped := false
+	for {
+		msgs, err := receive()
+		if err != nil {
+			return err
+		}
+
+		// Like netlink.Conn, continue to receive until the multipart reply is done.
+		done := false
+		for _, msg := range repliesTo(verify, msgs) {
+			done = msg.Header.Flags&netlink.Multi == 0 || msg.Header.Type == netlink.Done
+			if stopped {
+				continue
+			}
+			if err := checkMessage(msg); err != nil {
+				// Drain the rest of the reply, so the connection stays usable.
+				fnErr, stopped = err, true
+				continue
+			}
+			if msg.Header.Type == netlink.Done || msg.Header.Type == netlink.Error {
+				continue
+			}
+			var stop bool
+			stop, fnErr = fn(msg)
+			stopped = stop || fnErr != nil
+		}
+
+		if done {
+			return fnErr
+		}
+	}
+}
+
+// checkMessage returns the error, that is reported by the kernel in msg.
+func checkMessage(msg netlink.Message) error {
+	switch {
+	case msg.Header.Type == netlink.Error:
+	case msg.Header.Type == netlink.Done && msg.Header.Flags&netlink.Multi != 0:
+		if len(msg.Data) == 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if len(msg.Data) < 4 {
+		return &netlink.OpError{Op: "receive", Err: fmt.Errorf("short error message: %w", ErrInvalidArg)}
+	}
+	errCode := int32(nativeEndian.Uint32(msg.Data[:4]))
+	if errCode == 0 {
+		return nil
+	}
+	opErr := &netlink.OpError{Op: "receive", Err: syscall.Errno(-errCode)}
+	opErr.Message, opErr.Offset = extendedAck(msg)
+	return opErr
+}
+
+// extendedAck returns the message and the offset of the extended acknowledgement in msg.
+// It requires netlink.ExtendedAcknowledge to be enabled on the connection.
+func extendedAck(msg netlink.Message) (string, int) {
+	if msg.Header.Flags&netlink.AcknowledgeTLVs == 0 || len(msg.Data) < 4 {
+		return "", 0
+	}
+	offset := 4
+	if msg.Header.Type == netlink.Error {
+		// The error code is followed by the header of the request and, unless the
+		// kernel capped it, its payload.
+		if len(msg.Data) < 4+nlmsgHeaderLen {
+			return "", 0
+		}
+		if msg.Header.Flags&netlink.Capped != 0 {
+			offset += nlmsgHeaderLen
+		} else {
+			offset += nlmsgAlign(int(nativeEndian.Uint32(msg.Data[4:8])))
+		}
+	}
+	if offset > len(msg.Data) {
+		return "", 0
+	}
+
+	ad, err := netlink.NewAttributeDecoder(msg.Data[offset:])
+	if err != nil {
+		return "", 0
+	}
+	var message string
+	var errOffset int
+	for ad.Next() {
+		switch ad.Type() {
+		case nlmsgerrAttrMsg:
+			message = ad.String()
+		case nlmsgerrAttrOffs:
+			errOffset = int(ad.Uint32())
+		}
+	}
+	return message, errOffset
+}
+
+// parseMessages returns the netlink messages in the datagram b.
+func parseMessages(b []byte) ([]netlink.Message, error) {
+	var msgs []netlink.Message
+	for len(b) >= nlmsgHeaderLen {
+		l := int(nativeEndian.Uint32(b[:4]))
+		if l < nlmsgHeaderLen || l > len(b) {
+			return nil, fmt.Errorf("message of %d bytes: %w", l, ErrInvalidArg)
+		}
+		msgs = append(msgs, netlink.Message{
+			Header: netlink.Header{
+				Length:   uint32(l),
+				Type:     netlink.HeaderType(nativeEndian.Uint16(b[4:6])),
+				Flags:    netlink.HeaderFlags(nativeEndian.Uint16(b[6:8])),
+				Sequence: nativeEndian.Uint32(b[8:12]),
+				PID:      nativeEndian.Uint32(b[12:16]),
+			},
+			Data: b[nlmsgHeaderLen:l],
+		})
+		if nlmsgAlign(l) >= len(b) {
+			break
+		}
+		b = b[nlmsgAlign(l):]
+	}
+	return msgs, nil
+}
+
+// nlmsgAlign returns l aligned to nlmsgAlignTo.
+func nlmsgAlign(l int) int {
+	return (l + nlmsgAlignTo - 1) & ^(nlmsgAlignTo - 1)
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package tc
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// batchReceiver returns a function, that receives the messages of a single datagram from the
+// socket of con. If con does not provide access to its socket, nil is returned.
+func batchReceiver(con Conn) func() ([]netlink.Message, error) {
+	sc, ok := con.(interface {
+		SyscallConn() (syscall.RawConn, error)
+	})
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	return func() ([]netlink.Message, error) {
+		b, err := receiveDatagram(rc)
+		if err != nil {
+			return nil, &netlink.OpError{Op: "receive", Err: err}
+		}
+		return parseMessages(b)
+	}
+}
+
+// receiveDatagram returns the next datagram, that is received on rc.
+func receiveDatagram(rc syscall.RawConn) ([]byte, error) {
+	b := make([]byte, os.Getpagesize())
+	var n int
+	var recvErr error
+	recv := func(flags int) error {
+		err := rc.Read(func(fd uintptr) bool {
+			n, _, recvErr = unix.Recvfrom(int(fd), b, flags|unix.MSG_DONTWAIT)
+			// Wait for the socket to become readable again.
+			return recvErr != unix.EAGAIN && recvErr != unix.EWOULDBLOCK
+		})
+		if err != nil {
+			return err
+		}
+		return recvErr
+	}
+
+	// Peek at the datagram to learn its size.
+	if err := recv(unix.MSG_PEEK | unix.MSG_TRUNC); err != nil {
+		return nil, err
+	}
+	if n > len(b) {
+		b = make([]byte, n)
+	}
+	if err := recv(0); err != nil {
+		return nil, err
+	}
+	return b[:n], nil
+}
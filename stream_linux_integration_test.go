@@ -0,0 +1,94 @@
+//go:build integration && linux
+// +build integration,linux
+
+package tc
+
+import (
+	"testing"
+
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+)
+
+func TestLinuxWalk(t *testing.T) {
+	const filters = 2000
+
+	ns, err := newScratchNetNS()
+	if err != nil {
+		t.Skipf("could not create network namespace: %v", err)
+	}
+	defer ns.Close()
+
+	tcSocket, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open socket for TC: %v", err)
+	}
+	defer tcSocket.Close()
+
+	qdisc := &Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{
+			Kind: "clsact",
+		},
+	}
+	if err := tcSocket.Qdisc().Add(qdisc); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+
+	parent := core.BuildHandle(HandleRoot, HandleMinIngress)
+	for i := 0; i < filters; i++ {
+		filter := &Object{
+			Msg: Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: probeLoopbackIndex,
+				Parent:  parent,
+				Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+			},
+			Attribute: Attribute{
+				Kind: "u32",
+				U32: &U32{
+					ClassID: uint32Ptr(0x10001),
+					Sel:     &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{{}}},
+				},
+			},
+		}
+		if err := tcSocket.Filter().Add(filter); err != nil {
+			t.Fatalf("could not add filter %d: %v", i, err)
+		}
+	}
+
+	msg := &Msg{Family: unix.AF_UNSPEC, Ifindex: probeLoopbackIndex, Parent: parent}
+	var walked int
+	if err := tcSocket.Filter().Walk(msg, func(m Object) int {
+		walked++
+		if walked == 10 {
+			return 1
+		}
+		return 0
+	}); err != nil {
+		t.Fatalf("could not walk filters: %v", err)
+	}
+	if walked != 10 {
+		t.Fatalf("expected 10 filters but walked %d", walked)
+	}
+
+	// The remainder of the stopped dump must not show up in the next reply.
+	all, err := tcSocket.Filter().Get(msg)
+	if err != nil {
+		t.Fatalf("could not get filters: %v", err)
+	}
+	// The u32 classifier reports its hash table in addition to the filters.
+	if len(all) < filters {
+		t.Fatalf("expected at least %d filters but got %d", filters, len(all))
+	}
+	for _, f := range all {
+		if f.Kind != "u32" {
+			t.Fatalf("unexpected filter: %#v", f)
+		}
+	}
+}
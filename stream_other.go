@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package tc
+
+import "github.com/mdlayher/netlink"
+
+// batchReceiver returns nil, as datagrams can not be received on their own on this OS.
+func batchReceiver(con Conn) func() ([]netlink.Message, error) {
+	return nil
+}
@@ -0,0 +1,341 @@
+package tc
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
+
+func TestWalk(t *testing.T) {
+	var filters []Object
+	for i := uint32(1); i <= 5; i++ {
+		filters = append(filters, Object{
+			Msg: Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: 2,
+				Handle:  i,
+				Parent:  0xffff0000,
+				Info:    0x10300,
+			},
+			Attribute: Attribute{
+				Kind: "fw",
+				Fw:   &Fw{ClassID: uint32Ptr(0x10001)},
+			},
+		})
+	}
+
+	tests := map[string]struct {
+		stop int
+		want []uint32
+	}{
+		"all":   {stop: 0, want: []uint32{1, 2, 3, 4, 5}},
+		"first": {stop: 1, want: []uint32{1}},
+		"three": {stop: 3, want: []uint32{1, 2, 3}},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var reqs []netlink.Message
+			tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+				unix.RTM_GETTFILTER: bpfAttachDump(t, unix.RTM_NEWTFILTER, filters...),
+			}, &reqs)
+
+			var handles []uint32
+			err := tcSocket.Filter().Walk(&Msg{Ifindex: 2}, func(m Object) int {
+				handles = append(handles, m.Handle)
+				if len(handles) == testcase.stop {
+					return 1
+				}
+				return 0
+			})
+			if err != nil {
+				t.Fatalf("could not walk filters: %v", err)
+			}
+			if diff := cmp.Diff(testcase.want, handles); diff != "" {
+				t.Fatalf("handles missmatch (want +got):\n%s", diff)
+			}
+			if reqs[0].Header.Flags != netlink.Request|netlink.Dump {
+				t.Fatalf("unexpected flags: %v", reqs[0].Header.Flags)
+			}
+		})
+	}
+
+	t.Run("stale reply", func(t *testing.T) {
+		var reqs []netlink.Message
+		dump := bpfAttachDump(t, unix.RTM_NEWTFILTER, filters[:2]...)
+		tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+			unix.RTM_GETTFILTER: func(req netlink.Message) ([]netlink.Message, error) {
+				msgs, err := dump(req)
+				if err != nil {
+					return nil, err
+				}
+				// A reply to another request is not part of the dump.
+				stale := msgs[0]
+				stale.Header.Sequence = req.Header.Sequence + 1
+				return append([]netlink.Message{stale}, msgs[1:]...), nil
+			},
+		}, &reqs)
+
+		var handles []uint32
+		if err := tcSocket.Filter().Walk(&Msg{Ifindex: 2}, func(m Object) int {
+			handles = append(handles, m.Handle)
+			return 0
+		}); err != nil {
+			t.Fatalf("could not walk filters: %v", err)
+		}
+		if diff := cmp.Diff([]uint32{2}, handles); diff != "" {
+			t.Fatalf("handles missmatch (want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		var reqs []netlink.Message
+		tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+			unix.RTM_GETQDISC: func(req netlink.Message) ([]netlink.Message, error) {
+				return []netlink.Message{{Header: netlink.Header{Sequence: req.Header.Sequence}, Data: []byte{0x0}}}, nil
+			},
+		}, &reqs)
+		err := tcSocket.Qdisc().Walk(func(m Object) int { return 0 })
+		if !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		gacts := []*Action{
+			{Kind: "gact", Index: 1, Gact: &Gact{Parms: &GactParms{Index: 1, Action: ActShot}}},
+			{Kind: "gact", Index: 2, Gact: &Gact{Parms: &GactParms{Index: 2, Action: ActOk}}},
+		}
+		var reqs []netlink.Message
+		tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+			unix.RTM_GETACTION: actionsReply(t, gacts),
+		}, &reqs)
+		var walked []*Action
+		err := tcSocket.Actions().Walk("gact", func(a *Action) int {
+			walked = append(walked, a)
+			return 1
+		})
+		if err != nil {
+			t.Fatalf("could not walk actions: %v", err)
+		}
+		if len(walked) != 1 {
+			t.Fatalf("expected 1 action but got %d", len(walked))
+		}
+		if diff := cmp.Diff(gacts[0].Gact, walked[0].Gact); diff != "" {
+			t.Fatalf("action missmatch (want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		tcSocket := &Tc{}
+		fn := func(m Object) int { return 0 }
+		for name, err := range map[string]error{
+			"Filter":  tcSocket.Filter().Walk(nil, fn),
+			"Class":   tcSocket.Class().Walk(nil, fn),
+			"Chain":   tcSocket.Chain().Walk(nil, fn),
+			"Qdisc":   tcSocket.Qdisc().Walk(nil),
+			"Actions": tcSocket.Actions().Walk("gact", nil),
+		} {
+			if !errors.Is(err, ErrNoArg) {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+		}
+	})
+}
+
+// batches returns a function, that returns one of the given batches on each call.
+func batches(calls *int, msgs ...[]netlink.Message) func() ([]netlink.Message, error) {
+	return func() ([]netlink.Message, error) {
+		if *calls >= len(msgs) {
+			return nil, errors.New("no more batches")
+		}
+		*calls++
+		return msgs[*calls-1], nil
+	}
+}
+
+func TestDumpBatches(t *testing.T) {
+	verify := netlink.Message{Header: netlink.Header{Sequence: 7, PID: 42}}
+	multi := func(data byte) netlink.Message {
+		return netlink.Message{Header: netlink.Header{Type: unix.RTM_NEWTFILTER, Flags: netlink.Multi,
+			Sequence: 7, PID: 42}, Data: []byte{data}}
+	}
+	done := netlink.Message{Header: netlink.Header{Type: netlink.Done, Flags: netlink.Multi, Sequence: 7, PID: 42},
+		Data: []byte{0, 0, 0, 0}}
+	ebusy := -int32(unix.EBUSY)
+	errno := netlink.Message{Header: netlink.Header{Type: netlink.Done, Flags: netlink.Multi, Sequence: 7, PID: 42},
+		Data: make([]byte, 4)}
+	nativeEndian.PutUint32(errno.Data, uint32(ebusy))
+	// A multipart error, that is followed by further messages of the reply.
+	multiErr := netlink.Message{Header: netlink.Header{Type: netlink.Error, Flags: netlink.Multi, Sequence: 7, PID: 42},
+		Data: errno.Data}
+	// Replies to earlier requests.
+	staleAck := netlink.Message{Header: netlink.Header{Type: netlink.Error, Sequence: 6, PID: 42}, Data: make([]byte, 4)}
+	staleMulti := multi(9)
+	staleMulti.Header.Sequence = 6
+	otherPID := multi(8)
+	otherPID.Header.PID = 43
+	dump := [][]netlink.Message{
+		{multi(1), multi(2)},
+		{multi(3)},
+		{multi(4), done},
+	}
+
+	tests := map[string]struct {
+		batches [][]netlink.Message
+		stop    byte
+		want    []byte
+		calls   int
+		err     error
+	}{
+		"all":  {batches: dump, want: []byte{1, 2, 3, 4}, calls: 3},
+		"stop": {batches: dump, stop: 2, want: []byte{1, 2}, calls: 3},
+		"single": {batches: [][]netlink.Message{{{Header: verify.Header, Data: []byte{1}}}}, want: []byte{1},
+			calls: 1},
+		"no reply": {batches: [][]netlink.Message{{done}}, calls: 1},
+		"errno": {batches: [][]netlink.Message{{multi(1)}, {errno}}, want: []byte{1}, calls: 2,
+			err: syscall.Errno(unix.EBUSY)},
+		"drain after error": {batches: [][]netlink.Message{{multi(1), multiErr}, {multi(2), done}}, want: []byte{1},
+			calls: 2, err: syscall.Errno(unix.EBUSY)},
+		"stale ack": {batches: [][]netlink.Message{{staleAck}, {multi(1)}, {done}}, want: []byte{1}, calls: 3},
+		"stale multipart": {batches: [][]netlink.Message{{staleMulti, multi(1)}, {otherPID, done}}, want: []byte{1},
+			calls: 2},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			var got []byte
+			err := dumpBatches(verify, batches(&calls, testcase.batches...), func(msg netlink.Message) (bool, error) {
+				got = append(got, msg.Data[0])
+				return msg.Data[0] == testcase.stop, nil
+			})
+			if !errors.Is(err, testcase.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testcase.want, got); diff != "" {
+				t.Fatalf("messages missmatch (want +got):\n%s", diff)
+			}
+			// The remaining batches of a stopped dump are received as well.
+			if calls != testcase.calls {
+				t.Fatalf("expected %d calls but got %d", testcase.calls, calls)
+			}
+		})
+	}
+
+	t.Run("fn error", func(t *testing.T) {
+		var calls int
+		err := dumpBatches(verify, batches(&calls, dump...), func(msg netlink.Message) (bool, error) {
+			return false, ErrInvalidArg
+		})
+		if !errors.Is(err, ErrInvalidArg) || calls != 3 {
+			t.Fatalf("unexpected error after %d calls: %v", calls, err)
+		}
+	})
+}
+
+func TestParseMessages(t *testing.T) {
+	msgs := []netlink.Message{
+		{Header: netlink.Header{Type: unix.RTM_NEWQDISC, Flags: netlink.Multi, Sequence: 1, PID: 2}, Data: []byte{1, 2, 3, 4}},
+		{Header: netlink.Header{Type: netlink.Done, Flags: netlink.Multi, Sequence: 1, PID: 2}, Data: []byte{0, 0, 0, 0}},
+	}
+	var datagram []byte
+	for i := range msgs {
+		msgs[i].Header.Length = uint32(nlmsgHeaderLen + len(msgs[i].Data))
+		b, err := msgs[i].MarshalBinary()
+		if err != nil {
+			t.Fatalf("could not encode message: %v", err)
+		}
+		datagram = append(datagram, b...)
+	}
+
+	got, err := parseMessages(datagram)
+	if err != nil {
+		t.Fatalf("could not parse messages: %v", err)
+	}
+	if diff := cmp.Diff(msgs, got); diff != "" {
+		t.Fatalf("messages missmatch (want +got):\n%s", diff)
+	}
+
+	t.Run("invalid length", func(t *testing.T) {
+		invalid := append([]byte{}, datagram...)
+		nativeEndian.PutUint32(invalid[0:], 0xff)
+		if _, err := parseMessages(invalid); !errors.Is(err, ErrInvalidArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCheckMessage(t *testing.T) {
+	tlvs, err := netlink.MarshalAttributes([]netlink.Attribute{
+		{Type: nlmsgerrAttrMsg, Data: []byte("invalid handle\x00")},
+		{Type: nlmsgerrAttrOffs, Data: []byte{20, 0, 0, 0}},
+	})
+	if err != nil {
+		t.Fatalf("could not marshal attributes: %v", err)
+	}
+	einval := -int32(unix.EINVAL)
+	errno := make([]byte, 4)
+	nativeEndian.PutUint32(errno, uint32(einval))
+	request := make([]byte, nlmsgHeaderLen+8)
+	nativeEndian.PutUint32(request, uint32(len(request)))
+
+	concat := func(parts ...[]byte) []byte {
+		var b []byte
+		for _, p := range parts {
+			b = append(b, p...)
+		}
+		return b
+	}
+
+	tests := map[string]struct {
+		msg     netlink.Message
+		message string
+		offset  int
+	}{
+		"without extended ack": {
+			msg: netlink.Message{Header: netlink.Header{Type: netlink.Error}, Data: concat(errno, request)},
+		},
+		"with request": {
+			msg: netlink.Message{
+				Header: netlink.Header{Type: netlink.Error, Flags: netlink.AcknowledgeTLVs},
+				Data:   concat(errno, request, tlvs),
+			},
+			message: "invalid handle",
+			offset:  20,
+		},
+		"capped": {
+			msg: netlink.Message{
+				Header: netlink.Header{Type: netlink.Error, Flags: netlink.AcknowledgeTLVs | netlink.Capped},
+				Data:   concat(errno, request[:nlmsgHeaderLen], tlvs),
+			},
+			message: "invalid handle",
+			offset:  20,
+		},
+		"done": {
+			msg: netlink.Message{
+				Header: netlink.Header{Type: netlink.Done, Flags: netlink.Multi | netlink.AcknowledgeTLVs},
+				Data:   concat(errno, tlvs),
+			},
+			message: "invalid handle",
+			offset:  20,
+		},
+	}
+
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkMessage(testcase.msg)
+			var opErr *netlink.OpError
+			if !errors.As(err, &opErr) || !errors.Is(err, syscall.Errno(unix.EINVAL)) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opErr.Message != testcase.message || opErr.Offset != testcase.offset {
+				t.Fatalf("unexpected extended ack: %q at %d", opErr.Message, opErr.Offset)
+			}
+		})
+	}
+}
@@ -4,9 +4,34 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
+//go:generate go run ./internal/structgen -output structs_gen.go
+
+// binaryStruct is implemented by the fixed-size structs of this package. The implementations
+// in structs_gen.go encode the structs like encoding/binary does, but without reflection.
+type binaryStruct interface {
+	// binaryLen returns the size of the encoded struct or -1, if the struct is nil.
+	binaryLen() int
+	putBinary(b []byte)
+	getBinary(b []byte)
+}
+
 func unmarshalStruct(data []byte, s interface{}) error {
+	if v, ok := s.(binaryStruct); ok {
+		if n := v.binaryLen(); n >= 0 {
+			// Return the same errors as binary.Read for incomplete data.
+			if len(data) == 0 && n > 0 {
+				return io.EOF
+			}
+			if len(data) < n {
+				return io.ErrUnexpectedEOF
+			}
+			v.getBinary(data[:n])
+			return nil
+		}
+	}
 	b := bytes.NewReader(data)
 	return binary.Read(b, nativeEndian, s)
 }
@@ -16,6 +41,13 @@ const (
 )
 
 func marshalAndAlignStruct(s interface{}) ([]byte, error) {
+	if v, ok := s.(binaryStruct); ok {
+		if n := v.binaryLen(); n >= 0 {
+			data := make([]byte, (n+(rtaAlignTo-1)) & ^(rtaAlignTo-1))
+			v.putBinary(data[:n])
+			return data, nil
+		}
+	}
 	var buf bytes.Buffer
 	err := binary.Write(&buf, nativeEndian, s)
 	if err != nil {
@@ -32,12 +64,21 @@ func marshalAndAlignStruct(s interface{}) ([]byte, error) {
 }
 
 func marshalStruct(s interface{}) ([]byte, error) {
+	if v, ok := s.(binaryStruct); ok {
+		if n := v.binaryLen(); n >= 0 {
+			data := make([]byte, n)
+			v.putBinary(data)
+			return data, nil
+		}
+	}
 	var buf bytes.Buffer
 	err := binary.Write(&buf, nativeEndian, s)
 	return buf.Bytes(), err
 }
 
 // Stats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type Stats struct {
 	Bytes      uint64 /* Number of enqueued bytes */
 	Packets    uint32 /* Number of enqueued packets	*/
@@ -51,6 +92,8 @@ type Stats struct {
 }
 
 // Stats2 from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type Stats2 struct {
 	// gnet_stats_basic
 	Bytes   uint64
@@ -64,6 +107,8 @@ type Stats2 struct {
 }
 
 // Tcft from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type Tcft struct {
 	Install  uint64
 	LastUse  uint64
@@ -72,6 +117,8 @@ type Tcft struct {
 }
 
 // RateSpec from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type RateSpec struct {
 	CellLog   uint8
 	Linklayer uint8
@@ -82,6 +129,8 @@ type RateSpec struct {
 }
 
 // Policy from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type Policy struct {
 	Index    uint32
 	Action   PolicyAction
@@ -96,16 +145,22 @@ type Policy struct {
 }
 
 // FifoOpt from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type FifoOpt struct {
 	Limit uint32
 }
 
 // SfqXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type SfqXStats struct {
 	Allot int32
 }
 
 // RedXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type RedXStats struct {
 	Early  uint32
 	PDrop  uint32
@@ -114,6 +169,8 @@ type RedXStats struct {
 }
 
 // ChokeXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type ChokeXStats struct {
 	Early   uint32
 	PDrop   uint32
@@ -123,6 +180,8 @@ type ChokeXStats struct {
 }
 
 // HtbXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type HtbXStats struct {
 	Lends   uint32
 	Borrows uint32
@@ -132,6 +191,8 @@ type HtbXStats struct {
 }
 
 // CbqXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CbqXStats struct {
 	Borrows     uint32
 	Overactions uint32
@@ -140,6 +201,8 @@ type CbqXStats struct {
 }
 
 // SfbXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type SfbXStats struct {
 	EarlyDrop   uint32
 	PenaltyDrop uint32
@@ -153,6 +216,8 @@ type SfbXStats struct {
 }
 
 // CodelXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type CodelXStats struct {
 	MaxPacket     uint32
 	Count         uint32
@@ -166,6 +231,8 @@ type CodelXStats struct {
 }
 
 // HhfXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type HhfXStats struct {
 	DropOverlimit uint32
 	HhOverlimit   uint32
@@ -174,6 +241,8 @@ type HhfXStats struct {
 }
 
 // PieXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type PieXStats struct {
 	Prob      uint64
 	Delay     uint32
@@ -186,6 +255,8 @@ type PieXStats struct {
 }
 
 // HfscXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type HfscXStats struct {
 	Work   uint64
 	RtWork uint64
@@ -194,6 +265,8 @@ type HfscXStats struct {
 }
 
 // FqCodelQdStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type FqCodelQdStats struct {
 	MaxPacket      uint32
 	DropOverlimit  uint32
@@ -207,6 +280,8 @@ type FqCodelQdStats struct {
 }
 
 // FqCodelClStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type FqCodelClStats struct {
 	Deficit   int32
 	LDelay    uint32
@@ -224,18 +299,19 @@ type FqCodelXStats struct {
 }
 
 func unmarshalFqCodelXStats(data []byte, info *FqCodelXStats) error {
+	if len(data) < 4 {
+		return fmt.Errorf("unmarshalFqCodelXStats: incomplete data: %w", ErrInvalidArg)
+	}
 	info.Type = nativeEndian.Uint32(data[:4])
 	var err error
 	switch info.Type {
 	case tcaFqCodelXStatsQdisc:
-		b := bytes.NewReader(data[4:])
 		stats := &FqCodelQdStats{}
-		err = binary.Read(b, nativeEndian, stats)
+		err = unmarshalStruct(data[4:], stats)
 		info.Qd = stats
 	case tcaFqCodelXStatsClass:
-		b := bytes.NewReader(data[4:])
 		stats := &FqCodelClStats{}
-		err = binary.Read(b, nativeEndian, stats)
+		err = unmarshalStruct(data[4:], stats)
 		info.Cl = stats
 	default:
 		err = fmt.Errorf("extractFqCodelXStats(): unsupported type: %d: %w",
@@ -269,6 +345,8 @@ func marshalFqCodelXStats(v *FqCodelXStats) ([]byte, error) {
 }
 
 // FqQdStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type FqQdStats struct {
 	GcFlows             uint64
 	HighPrioPackets     uint64
@@ -292,6 +370,8 @@ type FqQdStats struct {
 }
 
 // FqPieXStats from include/uapi/linux/pkt_sched.h
+//
+//structgen:binary
 type FqPieXStats struct {
 	PacketsIn     uint32
 	Dropped       uint32
@@ -0,0 +1,2260 @@
+// Code generated by "structgen"; DO NOT EDIT.
+
+package tc
+
+func (s *ActBpfParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *ActBpfParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.Refcnt)
+	nativeEndian.PutUint32(b[16:], s.Bindcnt)
+}
+
+func (s *ActBpfParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.Refcnt = nativeEndian.Uint32(b[12:])
+	s.Bindcnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *AtmPvc) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *AtmPvc) putBinary(b []byte) {
+	_ = b[3]
+	b[0] = s.SapFamily
+	b[1] = s.Itf
+	b[2] = s.Vpi
+	b[3] = s.Vci
+}
+
+func (s *AtmPvc) getBinary(b []byte) {
+	_ = b[3]
+	s.SapFamily = b[0]
+	s.Itf = b[1]
+	s.Vpi = b[2]
+	s.Vci = b[3]
+}
+
+func (s *CanFilter) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *CanFilter) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint32(b[0:], s.ID)
+	nativeEndian.PutUint32(b[4:], s.Mask)
+}
+
+func (s *CanFilter) getBinary(b []byte) {
+	_ = b[7]
+	s.ID = nativeEndian.Uint32(b[0:])
+	s.Mask = nativeEndian.Uint32(b[4:])
+}
+
+func (s *CbqFOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *CbqFOpt) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.Split)
+	nativeEndian.PutUint32(b[4:], s.Defmap)
+	nativeEndian.PutUint32(b[8:], s.Defchange)
+}
+
+func (s *CbqFOpt) getBinary(b []byte) {
+	_ = b[11]
+	s.Split = nativeEndian.Uint32(b[0:])
+	s.Defmap = nativeEndian.Uint32(b[4:])
+	s.Defchange = nativeEndian.Uint32(b[8:])
+}
+
+func (s *CbqLssOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *CbqLssOpt) putBinary(b []byte) {
+	_ = b[19]
+	b[0] = s.Change
+	b[1] = s.Flags
+	b[2] = s.EwmaLog
+	b[3] = s.Level
+	nativeEndian.PutUint32(b[4:], s.Maxidle)
+	nativeEndian.PutUint32(b[8:], s.Minidle)
+	nativeEndian.PutUint32(b[12:], s.OffTime)
+	nativeEndian.PutUint32(b[16:], s.Avpkt)
+}
+
+func (s *CbqLssOpt) getBinary(b []byte) {
+	_ = b[19]
+	s.Change = b[0]
+	s.Flags = b[1]
+	s.EwmaLog = b[2]
+	s.Level = b[3]
+	s.Maxidle = nativeEndian.Uint32(b[4:])
+	s.Minidle = nativeEndian.Uint32(b[8:])
+	s.OffTime = nativeEndian.Uint32(b[12:])
+	s.Avpkt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *CbqOvl) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *CbqOvl) putBinary(b []byte) {
+	_ = b[7]
+	b[0] = s.Strategy
+	b[1] = s.Priority2
+	nativeEndian.PutUint16(b[2:], s.Pad)
+	nativeEndian.PutUint32(b[4:], s.Penalty)
+}
+
+func (s *CbqOvl) getBinary(b []byte) {
+	_ = b[7]
+	s.Strategy = b[0]
+	s.Priority2 = b[1]
+	s.Pad = nativeEndian.Uint16(b[2:])
+	s.Penalty = nativeEndian.Uint32(b[4:])
+}
+
+func (s *CbqPolice) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *CbqPolice) putBinary(b []byte) {
+	_ = b[3]
+	b[0] = s.Police
+	b[1] = s.Res1
+	nativeEndian.PutUint16(b[2:], s.Res2)
+}
+
+func (s *CbqPolice) getBinary(b []byte) {
+	_ = b[3]
+	s.Police = b[0]
+	s.Res1 = b[1]
+	s.Res2 = nativeEndian.Uint16(b[2:])
+}
+
+func (s *CbqWrrOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *CbqWrrOpt) putBinary(b []byte) {
+	_ = b[11]
+	b[0] = s.Flags
+	b[1] = s.Priority
+	b[2] = s.CPriority
+	b[3] = s.Reserved
+	nativeEndian.PutUint32(b[4:], s.Allot)
+	nativeEndian.PutUint32(b[8:], s.Weight)
+}
+
+func (s *CbqWrrOpt) getBinary(b []byte) {
+	_ = b[11]
+	s.Flags = b[0]
+	s.Priority = b[1]
+	s.CPriority = b[2]
+	s.Reserved = b[3]
+	s.Allot = nativeEndian.Uint32(b[4:])
+	s.Weight = nativeEndian.Uint32(b[8:])
+}
+
+func (s *CbqXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *CbqXStats) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.Borrows)
+	nativeEndian.PutUint32(b[4:], s.Overactions)
+	nativeEndian.PutUint32(b[8:], uint32(s.AvgIdle))
+	nativeEndian.PutUint32(b[12:], uint32(s.Undertime))
+}
+
+func (s *CbqXStats) getBinary(b []byte) {
+	_ = b[15]
+	s.Borrows = nativeEndian.Uint32(b[0:])
+	s.Overactions = nativeEndian.Uint32(b[4:])
+	s.AvgIdle = int32(nativeEndian.Uint32(b[8:]))
+	s.Undertime = int32(nativeEndian.Uint32(b[12:]))
+}
+
+func (s *CbsOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *CbsOpt) putBinary(b []byte) {
+	_ = b[19]
+	b[0] = s.Offload
+	copy(b[1:], s.Pad[:])
+	nativeEndian.PutUint32(b[4:], uint32(s.HiCredit))
+	nativeEndian.PutUint32(b[8:], uint32(s.LoCredit))
+	nativeEndian.PutUint32(b[12:], uint32(s.IdleSlope))
+	nativeEndian.PutUint32(b[16:], uint32(s.SendSlope))
+}
+
+func (s *CbsOpt) getBinary(b []byte) {
+	_ = b[19]
+	s.Offload = b[0]
+	copy(s.Pad[:], b[1:])
+	s.HiCredit = int32(nativeEndian.Uint32(b[4:]))
+	s.LoCredit = int32(nativeEndian.Uint32(b[8:]))
+	s.IdleSlope = int32(nativeEndian.Uint32(b[12:]))
+	s.SendSlope = int32(nativeEndian.Uint32(b[16:]))
+}
+
+func (s *ChokeXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *ChokeXStats) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Early)
+	nativeEndian.PutUint32(b[4:], s.PDrop)
+	nativeEndian.PutUint32(b[8:], s.Other)
+	nativeEndian.PutUint32(b[12:], s.Marked)
+	nativeEndian.PutUint32(b[16:], s.Matched)
+}
+
+func (s *ChokeXStats) getBinary(b []byte) {
+	_ = b[19]
+	s.Early = nativeEndian.Uint32(b[0:])
+	s.PDrop = nativeEndian.Uint32(b[4:])
+	s.Other = nativeEndian.Uint32(b[8:])
+	s.Marked = nativeEndian.Uint32(b[12:])
+	s.Matched = nativeEndian.Uint32(b[16:])
+}
+
+func (s *CmpMatch) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 14
+}
+
+func (s *CmpMatch) putBinary(b []byte) {
+	_ = b[13]
+	nativeEndian.PutUint32(b[0:], s.Val)
+	nativeEndian.PutUint32(b[4:], s.Mask)
+	nativeEndian.PutUint16(b[8:], s.Off)
+	b[10] = byte(s.Align)
+	b[11] = byte(s.Flags)
+	b[12] = byte(s.Layer)
+	b[13] = byte(s.Opnd)
+}
+
+func (s *CmpMatch) getBinary(b []byte) {
+	_ = b[13]
+	s.Val = nativeEndian.Uint32(b[0:])
+	s.Mask = nativeEndian.Uint32(b[4:])
+	s.Off = nativeEndian.Uint16(b[8:])
+	s.Align = CmpMatchAlign(b[10])
+	s.Flags = CmpMatchFlag(b[11])
+	s.Layer = EmatchLayer(b[12])
+	s.Opnd = EmatchOpnd(b[13])
+}
+
+func (s *CodelXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *CodelXStats) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint32(b[0:], s.MaxPacket)
+	nativeEndian.PutUint32(b[4:], s.Count)
+	nativeEndian.PutUint32(b[8:], s.LastCount)
+	nativeEndian.PutUint32(b[12:], s.LDelay)
+	nativeEndian.PutUint32(b[16:], uint32(s.DropNext))
+	nativeEndian.PutUint32(b[20:], s.DropOverlimit)
+	nativeEndian.PutUint32(b[24:], s.EcnMark)
+	nativeEndian.PutUint32(b[28:], s.Dropping)
+	nativeEndian.PutUint32(b[32:], s.CeMark)
+}
+
+func (s *CodelXStats) getBinary(b []byte) {
+	_ = b[35]
+	s.MaxPacket = nativeEndian.Uint32(b[0:])
+	s.Count = nativeEndian.Uint32(b[4:])
+	s.LastCount = nativeEndian.Uint32(b[8:])
+	s.LDelay = nativeEndian.Uint32(b[12:])
+	s.DropNext = int32(nativeEndian.Uint32(b[16:]))
+	s.DropOverlimit = nativeEndian.Uint32(b[20:])
+	s.EcnMark = nativeEndian.Uint32(b[24:])
+	s.Dropping = nativeEndian.Uint32(b[28:])
+	s.CeMark = nativeEndian.Uint32(b[32:])
+}
+
+func (s *ConnmarkParam) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 22
+}
+
+func (s *ConnmarkParam) putBinary(b []byte) {
+	_ = b[21]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint16(b[20:], s.Zone)
+}
+
+func (s *ConnmarkParam) getBinary(b []byte) {
+	_ = b[21]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.Zone = nativeEndian.Uint16(b[20:])
+}
+
+func (s *ContainerMatch) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *ContainerMatch) putBinary(b []byte) {
+	_ = b[3]
+	nativeEndian.PutUint32(b[0:], s.Pos)
+}
+
+func (s *ContainerMatch) getBinary(b []byte) {
+	_ = b[3]
+	s.Pos = nativeEndian.Uint32(b[0:])
+}
+
+func (s *CsumParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *CsumParms) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint32(b[20:], s.UpdateFlags)
+}
+
+func (s *CsumParms) getBinary(b []byte) {
+	_ = b[23]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.UpdateFlags = nativeEndian.Uint32(b[20:])
+}
+
+func (s *CtInfoAct) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *CtInfoAct) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *CtInfoAct) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *CtParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *CtParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *CtParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *DefactParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *DefactParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *DefactParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *EmatchHdr) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *EmatchHdr) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint16(b[0:], s.MatchID)
+	nativeEndian.PutUint16(b[2:], uint16(s.Kind))
+	nativeEndian.PutUint16(b[4:], s.Flags)
+	nativeEndian.PutUint16(b[6:], s.Pad)
+}
+
+func (s *EmatchHdr) getBinary(b []byte) {
+	_ = b[7]
+	s.MatchID = nativeEndian.Uint16(b[0:])
+	s.Kind = EmatchKind(nativeEndian.Uint16(b[2:]))
+	s.Flags = nativeEndian.Uint16(b[4:])
+	s.Pad = nativeEndian.Uint16(b[6:])
+}
+
+func (s *EmatchTreeHdr) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *EmatchTreeHdr) putBinary(b []byte) {
+	_ = b[3]
+	nativeEndian.PutUint16(b[0:], s.NMatches)
+	nativeEndian.PutUint16(b[2:], s.ProgID)
+}
+
+func (s *EmatchTreeHdr) getBinary(b []byte) {
+	_ = b[3]
+	s.NMatches = nativeEndian.Uint16(b[0:])
+	s.ProgID = nativeEndian.Uint16(b[2:])
+}
+
+func (s *FifoOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *FifoOpt) putBinary(b []byte) {
+	_ = b[3]
+	nativeEndian.PutUint32(b[0:], s.Limit)
+}
+
+func (s *FifoOpt) getBinary(b []byte) {
+	_ = b[3]
+	s.Limit = nativeEndian.Uint32(b[0:])
+}
+
+func (s *FqCodelClStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *FqCodelClStats) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint32(b[0:], uint32(s.Deficit))
+	nativeEndian.PutUint32(b[4:], s.LDelay)
+	nativeEndian.PutUint32(b[8:], s.Count)
+	nativeEndian.PutUint32(b[12:], s.LastCount)
+	nativeEndian.PutUint32(b[16:], s.Dropping)
+	nativeEndian.PutUint32(b[20:], uint32(s.DropNext))
+}
+
+func (s *FqCodelClStats) getBinary(b []byte) {
+	_ = b[23]
+	s.Deficit = int32(nativeEndian.Uint32(b[0:]))
+	s.LDelay = nativeEndian.Uint32(b[4:])
+	s.Count = nativeEndian.Uint32(b[8:])
+	s.LastCount = nativeEndian.Uint32(b[12:])
+	s.Dropping = nativeEndian.Uint32(b[16:])
+	s.DropNext = int32(nativeEndian.Uint32(b[20:]))
+}
+
+func (s *FqCodelQdStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *FqCodelQdStats) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint32(b[0:], s.MaxPacket)
+	nativeEndian.PutUint32(b[4:], s.DropOverlimit)
+	nativeEndian.PutUint32(b[8:], s.EcnMark)
+	nativeEndian.PutUint32(b[12:], s.NewFlowCount)
+	nativeEndian.PutUint32(b[16:], s.NewFlowsLen)
+	nativeEndian.PutUint32(b[20:], s.OldFlowsLen)
+	nativeEndian.PutUint32(b[24:], s.CeMark)
+	nativeEndian.PutUint32(b[28:], s.MemoryUsage)
+	nativeEndian.PutUint32(b[32:], s.DropOvermemory)
+}
+
+func (s *FqCodelQdStats) getBinary(b []byte) {
+	_ = b[35]
+	s.MaxPacket = nativeEndian.Uint32(b[0:])
+	s.DropOverlimit = nativeEndian.Uint32(b[4:])
+	s.EcnMark = nativeEndian.Uint32(b[8:])
+	s.NewFlowCount = nativeEndian.Uint32(b[12:])
+	s.NewFlowsLen = nativeEndian.Uint32(b[16:])
+	s.OldFlowsLen = nativeEndian.Uint32(b[20:])
+	s.CeMark = nativeEndian.Uint32(b[24:])
+	s.MemoryUsage = nativeEndian.Uint32(b[28:])
+	s.DropOvermemory = nativeEndian.Uint32(b[32:])
+}
+
+func (s *FqPieXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 32
+}
+
+func (s *FqPieXStats) putBinary(b []byte) {
+	_ = b[31]
+	nativeEndian.PutUint32(b[0:], s.PacketsIn)
+	nativeEndian.PutUint32(b[4:], s.Dropped)
+	nativeEndian.PutUint32(b[8:], s.OverLimit)
+	nativeEndian.PutUint32(b[12:], s.OverMemory)
+	nativeEndian.PutUint32(b[16:], s.EcnMark)
+	nativeEndian.PutUint32(b[20:], s.NewFlowsCount)
+	nativeEndian.PutUint32(b[24:], s.OldFlowsCount)
+	nativeEndian.PutUint32(b[28:], s.MemoryUsage)
+}
+
+func (s *FqPieXStats) getBinary(b []byte) {
+	_ = b[31]
+	s.PacketsIn = nativeEndian.Uint32(b[0:])
+	s.Dropped = nativeEndian.Uint32(b[4:])
+	s.OverLimit = nativeEndian.Uint32(b[8:])
+	s.OverMemory = nativeEndian.Uint32(b[12:])
+	s.EcnMark = nativeEndian.Uint32(b[16:])
+	s.NewFlowsCount = nativeEndian.Uint32(b[20:])
+	s.OldFlowsCount = nativeEndian.Uint32(b[24:])
+	s.MemoryUsage = nativeEndian.Uint32(b[28:])
+}
+
+func (s *FqPrioQopt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *FqPrioQopt) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], uint32(s.Bands))
+	copy(b[4:], s.PrioMap[:])
+}
+
+func (s *FqPrioQopt) getBinary(b []byte) {
+	_ = b[19]
+	s.Bands = int32(nativeEndian.Uint32(b[0:]))
+	copy(s.PrioMap[:], b[4:])
+}
+
+func (s *FqQdStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 152
+}
+
+func (s *FqQdStats) putBinary(b []byte) {
+	_ = b[151]
+	nativeEndian.PutUint64(b[0:], s.GcFlows)
+	nativeEndian.PutUint64(b[8:], s.HighPrioPackets)
+	nativeEndian.PutUint64(b[16:], s.TCPRetrans)
+	nativeEndian.PutUint64(b[24:], s.Throttled)
+	nativeEndian.PutUint64(b[32:], s.FlowsPlimit)
+	nativeEndian.PutUint64(b[40:], s.PktsTooLong)
+	nativeEndian.PutUint64(b[48:], s.AllocationErrors)
+	nativeEndian.PutUint64(b[56:], uint64(s.TimeNextDelayedFlow))
+	nativeEndian.PutUint32(b[64:], s.Flows)
+	nativeEndian.PutUint32(b[68:], s.InactiveFlows)
+	nativeEndian.PutUint32(b[72:], s.ThrottledFlows)
+	nativeEndian.PutUint32(b[76:], s.UnthrottleLatencyNs)
+	nativeEndian.PutUint64(b[80:], s.CEMark)
+	nativeEndian.PutUint64(b[88:], s.HorizonDrops)
+	nativeEndian.PutUint64(b[96:], s.HorizonCaps)
+	nativeEndian.PutUint64(b[104:], s.FastpathPackets)
+	for i := range s.BandDrops {
+		nativeEndian.PutUint64(b[112+i*8:], s.BandDrops[i])
+	}
+	for i := range s.BandPktCount {
+		nativeEndian.PutUint32(b[136+i*4:], s.BandPktCount[i])
+	}
+}
+
+func (s *FqQdStats) getBinary(b []byte) {
+	_ = b[151]
+	s.GcFlows = nativeEndian.Uint64(b[0:])
+	s.HighPrioPackets = nativeEndian.Uint64(b[8:])
+	s.TCPRetrans = nativeEndian.Uint64(b[16:])
+	s.Throttled = nativeEndian.Uint64(b[24:])
+	s.FlowsPlimit = nativeEndian.Uint64(b[32:])
+	s.PktsTooLong = nativeEndian.Uint64(b[40:])
+	s.AllocationErrors = nativeEndian.Uint64(b[48:])
+	s.TimeNextDelayedFlow = int64(nativeEndian.Uint64(b[56:]))
+	s.Flows = nativeEndian.Uint32(b[64:])
+	s.InactiveFlows = nativeEndian.Uint32(b[68:])
+	s.ThrottledFlows = nativeEndian.Uint32(b[72:])
+	s.UnthrottleLatencyNs = nativeEndian.Uint32(b[76:])
+	s.CEMark = nativeEndian.Uint64(b[80:])
+	s.HorizonDrops = nativeEndian.Uint64(b[88:])
+	s.HorizonCaps = nativeEndian.Uint64(b[96:])
+	s.FastpathPackets = nativeEndian.Uint64(b[104:])
+	for i := range s.BandDrops {
+		s.BandDrops[i] = nativeEndian.Uint64(b[112+i*8:])
+	}
+	for i := range s.BandPktCount {
+		s.BandPktCount[i] = nativeEndian.Uint32(b[136+i*4:])
+	}
+}
+
+func (s *GactParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *GactParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *GactParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *GactProb) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *GactProb) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint16(b[0:], s.PType)
+	nativeEndian.PutUint16(b[2:], s.PVal)
+	nativeEndian.PutUint32(b[4:], uint32(s.PAction))
+}
+
+func (s *GactProb) getBinary(b []byte) {
+	_ = b[7]
+	s.PType = nativeEndian.Uint16(b[0:])
+	s.PVal = nativeEndian.Uint16(b[2:])
+	s.PAction = Verdict(nativeEndian.Uint32(b[4:]))
+}
+
+func (s *GateParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *GateParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *GateParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *GenBasic) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *GenBasic) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint64(b[0:], s.Bytes)
+	nativeEndian.PutUint32(b[8:], s.Packets)
+}
+
+func (s *GenBasic) getBinary(b []byte) {
+	_ = b[11]
+	s.Bytes = nativeEndian.Uint64(b[0:])
+	s.Packets = nativeEndian.Uint32(b[8:])
+}
+
+func (s *GenQueue) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *GenQueue) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.QueueLen)
+	nativeEndian.PutUint32(b[4:], s.Backlog)
+	nativeEndian.PutUint32(b[8:], s.Drops)
+	nativeEndian.PutUint32(b[12:], s.Requeues)
+	nativeEndian.PutUint32(b[16:], s.Overlimits)
+}
+
+func (s *GenQueue) getBinary(b []byte) {
+	_ = b[19]
+	s.QueueLen = nativeEndian.Uint32(b[0:])
+	s.Backlog = nativeEndian.Uint32(b[4:])
+	s.Drops = nativeEndian.Uint32(b[8:])
+	s.Requeues = nativeEndian.Uint32(b[12:])
+	s.Overlimits = nativeEndian.Uint32(b[16:])
+}
+
+func (s *GenRateEst) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *GenRateEst) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint32(b[0:], s.BytePerSecond)
+	nativeEndian.PutUint32(b[4:], s.PacketPerSecond)
+}
+
+func (s *GenRateEst) getBinary(b []byte) {
+	_ = b[7]
+	s.BytePerSecond = nativeEndian.Uint32(b[0:])
+	s.PacketPerSecond = nativeEndian.Uint32(b[4:])
+}
+
+func (s *GenRateEst64) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *GenRateEst64) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint64(b[0:], s.BytePerSecond)
+	nativeEndian.PutUint64(b[8:], s.PacketPerSecond)
+}
+
+func (s *GenRateEst64) getBinary(b []byte) {
+	_ = b[15]
+	s.BytePerSecond = nativeEndian.Uint64(b[0:])
+	s.PacketPerSecond = nativeEndian.Uint64(b[8:])
+}
+
+func (s *GredQOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 52
+}
+
+func (s *GredQOpt) putBinary(b []byte) {
+	_ = b[51]
+	nativeEndian.PutUint32(b[0:], s.Limit)
+	nativeEndian.PutUint32(b[4:], s.QthMin)
+	nativeEndian.PutUint32(b[8:], s.QthMax)
+	nativeEndian.PutUint32(b[12:], s.DP)
+	nativeEndian.PutUint32(b[16:], s.Backlog)
+	nativeEndian.PutUint32(b[20:], s.Qave)
+	nativeEndian.PutUint32(b[24:], s.Forced)
+	nativeEndian.PutUint32(b[28:], s.Early)
+	nativeEndian.PutUint32(b[32:], s.Other)
+	nativeEndian.PutUint32(b[36:], s.Pdrop)
+	b[40] = s.Wlog
+	b[41] = s.Plog
+	b[42] = s.ScellLog
+	b[43] = s.Prio
+	nativeEndian.PutUint32(b[44:], s.Packets)
+	nativeEndian.PutUint32(b[48:], s.ByteSin)
+}
+
+func (s *GredQOpt) getBinary(b []byte) {
+	_ = b[51]
+	s.Limit = nativeEndian.Uint32(b[0:])
+	s.QthMin = nativeEndian.Uint32(b[4:])
+	s.QthMax = nativeEndian.Uint32(b[8:])
+	s.DP = nativeEndian.Uint32(b[12:])
+	s.Backlog = nativeEndian.Uint32(b[16:])
+	s.Qave = nativeEndian.Uint32(b[20:])
+	s.Forced = nativeEndian.Uint32(b[24:])
+	s.Early = nativeEndian.Uint32(b[28:])
+	s.Other = nativeEndian.Uint32(b[32:])
+	s.Pdrop = nativeEndian.Uint32(b[36:])
+	s.Wlog = b[40]
+	s.Plog = b[41]
+	s.ScellLog = b[42]
+	s.Prio = b[43]
+	s.Packets = nativeEndian.Uint32(b[44:])
+	s.ByteSin = nativeEndian.Uint32(b[48:])
+}
+
+func (s *GredSOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *GredSOpt) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.DPs)
+	nativeEndian.PutUint32(b[4:], s.DefDP)
+	b[8] = s.Grio
+	b[9] = s.Flags
+	nativeEndian.PutUint16(b[10:], s.Pad)
+}
+
+func (s *GredSOpt) getBinary(b []byte) {
+	_ = b[11]
+	s.DPs = nativeEndian.Uint32(b[0:])
+	s.DefDP = nativeEndian.Uint32(b[4:])
+	s.Grio = b[8]
+	s.Flags = b[9]
+	s.Pad = nativeEndian.Uint16(b[10:])
+}
+
+func (s *HfscQOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 2
+}
+
+func (s *HfscQOpt) putBinary(b []byte) {
+	_ = b[1]
+	nativeEndian.PutUint16(b[0:], s.DefCls)
+}
+
+func (s *HfscQOpt) getBinary(b []byte) {
+	_ = b[1]
+	s.DefCls = nativeEndian.Uint16(b[0:])
+}
+
+func (s *HfscXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *HfscXStats) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint64(b[0:], s.Work)
+	nativeEndian.PutUint64(b[8:], s.RtWork)
+	nativeEndian.PutUint32(b[16:], s.Period)
+	nativeEndian.PutUint32(b[20:], s.Level)
+}
+
+func (s *HfscXStats) getBinary(b []byte) {
+	_ = b[23]
+	s.Work = nativeEndian.Uint64(b[0:])
+	s.RtWork = nativeEndian.Uint64(b[8:])
+	s.Period = nativeEndian.Uint32(b[16:])
+	s.Level = nativeEndian.Uint32(b[20:])
+}
+
+func (s *HhfXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *HhfXStats) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.DropOverlimit)
+	nativeEndian.PutUint32(b[4:], s.HhOverlimit)
+	nativeEndian.PutUint32(b[8:], s.HhTotCount)
+	nativeEndian.PutUint32(b[12:], s.HhCurCount)
+}
+
+func (s *HhfXStats) getBinary(b []byte) {
+	_ = b[15]
+	s.DropOverlimit = nativeEndian.Uint32(b[0:])
+	s.HhOverlimit = nativeEndian.Uint32(b[4:])
+	s.HhTotCount = nativeEndian.Uint32(b[8:])
+	s.HhCurCount = nativeEndian.Uint32(b[12:])
+}
+
+func (s *HtbGlob) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *HtbGlob) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Version)
+	nativeEndian.PutUint32(b[4:], s.Rate2Quantum)
+	nativeEndian.PutUint32(b[8:], s.Defcls)
+	nativeEndian.PutUint32(b[12:], s.Debug)
+	nativeEndian.PutUint32(b[16:], s.DirectPkts)
+}
+
+func (s *HtbGlob) getBinary(b []byte) {
+	_ = b[19]
+	s.Version = nativeEndian.Uint32(b[0:])
+	s.Rate2Quantum = nativeEndian.Uint32(b[4:])
+	s.Defcls = nativeEndian.Uint32(b[8:])
+	s.Debug = nativeEndian.Uint32(b[12:])
+	s.DirectPkts = nativeEndian.Uint32(b[16:])
+}
+
+func (s *HtbOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 44
+}
+
+func (s *HtbOpt) putBinary(b []byte) {
+	_ = b[43]
+	s.Rate.putBinary(b[0:])
+	s.Ceil.putBinary(b[12:])
+	nativeEndian.PutUint32(b[24:], s.Buffer)
+	nativeEndian.PutUint32(b[28:], s.Cbuffer)
+	nativeEndian.PutUint32(b[32:], s.Quantum)
+	nativeEndian.PutUint32(b[36:], s.Level)
+	nativeEndian.PutUint32(b[40:], s.Prio)
+}
+
+func (s *HtbOpt) getBinary(b []byte) {
+	_ = b[43]
+	s.Rate.getBinary(b[0:])
+	s.Ceil.getBinary(b[12:])
+	s.Buffer = nativeEndian.Uint32(b[24:])
+	s.Cbuffer = nativeEndian.Uint32(b[28:])
+	s.Quantum = nativeEndian.Uint32(b[32:])
+	s.Level = nativeEndian.Uint32(b[36:])
+	s.Prio = nativeEndian.Uint32(b[40:])
+}
+
+func (s *HtbXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *HtbXStats) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Lends)
+	nativeEndian.PutUint32(b[4:], s.Borrows)
+	nativeEndian.PutUint32(b[8:], s.Giants)
+	nativeEndian.PutUint32(b[12:], s.Tokens)
+	nativeEndian.PutUint32(b[16:], s.CTokens)
+}
+
+func (s *HtbXStats) getBinary(b []byte) {
+	_ = b[19]
+	s.Lends = nativeEndian.Uint32(b[0:])
+	s.Borrows = nativeEndian.Uint32(b[4:])
+	s.Giants = nativeEndian.Uint32(b[8:])
+	s.Tokens = nativeEndian.Uint32(b[12:])
+	s.CTokens = nativeEndian.Uint32(b[16:])
+}
+
+func (s *IfeParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 22
+}
+
+func (s *IfeParms) putBinary(b []byte) {
+	_ = b[21]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint16(b[20:], s.Flags)
+}
+
+func (s *IfeParms) getBinary(b []byte) {
+	_ = b[21]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.Flags = nativeEndian.Uint16(b[20:])
+}
+
+func (s *IptCnt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *IptCnt) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint32(b[0:], s.RefCnt)
+	nativeEndian.PutUint32(b[4:], s.BindCnt)
+}
+
+func (s *IptCnt) getBinary(b []byte) {
+	_ = b[7]
+	s.RefCnt = nativeEndian.Uint32(b[0:])
+	s.BindCnt = nativeEndian.Uint32(b[4:])
+}
+
+func (s *MPLSParam) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *MPLSParam) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint32(b[20:], uint32(s.MAction))
+}
+
+func (s *MPLSParam) getBinary(b []byte) {
+	_ = b[23]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.MAction = MPLSAction(nativeEndian.Uint32(b[20:]))
+}
+
+func (s *MetaHdr) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *MetaHdr) putBinary(b []byte) {
+	_ = b[7]
+	s.Left.putBinary(b[0:])
+	s.Right.putBinary(b[4:])
+}
+
+func (s *MetaHdr) getBinary(b []byte) {
+	_ = b[7]
+	s.Left.getBinary(b[0:])
+	s.Right.getBinary(b[4:])
+}
+
+func (s *MetaValue) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *MetaValue) putBinary(b []byte) {
+	_ = b[3]
+	nativeEndian.PutUint16(b[0:], s.Kind)
+	b[2] = s.Shift
+	b[3] = s.Op
+}
+
+func (s *MetaValue) getBinary(b []byte) {
+	_ = b[3]
+	s.Kind = nativeEndian.Uint16(b[0:])
+	s.Shift = b[2]
+	s.Op = b[3]
+}
+
+func (s *MirredParam) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 28
+}
+
+func (s *MirredParam) putBinary(b []byte) {
+	_ = b[27]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint32(b[20:], s.Eaction)
+	nativeEndian.PutUint32(b[24:], s.IfIndex)
+}
+
+func (s *MirredParam) getBinary(b []byte) {
+	_ = b[27]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.Eaction = nativeEndian.Uint32(b[20:])
+	s.IfIndex = nativeEndian.Uint32(b[24:])
+}
+
+func (s *MqPrioQopt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 82
+}
+
+func (s *MqPrioQopt) putBinary(b []byte) {
+	_ = b[81]
+	b[0] = s.NumTc
+	copy(b[1:], s.PrioTcMap[:])
+	b[17] = s.Hw
+	for i := range s.Count {
+		nativeEndian.PutUint16(b[18+i*2:], s.Count[i])
+	}
+	for i := range s.Offset {
+		nativeEndian.PutUint16(b[50+i*2:], s.Offset[i])
+	}
+}
+
+func (s *MqPrioQopt) getBinary(b []byte) {
+	_ = b[81]
+	s.NumTc = b[0]
+	copy(s.PrioTcMap[:], b[1:])
+	s.Hw = b[17]
+	for i := range s.Count {
+		s.Count[i] = nativeEndian.Uint16(b[18+i*2:])
+	}
+	for i := range s.Offset {
+		s.Offset[i] = nativeEndian.Uint16(b[50+i*2:])
+	}
+}
+
+func (s *Msg) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *Msg) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Family)
+	nativeEndian.PutUint32(b[4:], s.Ifindex)
+	nativeEndian.PutUint32(b[8:], s.Handle)
+	nativeEndian.PutUint32(b[12:], s.Parent)
+	nativeEndian.PutUint32(b[16:], s.Info)
+}
+
+func (s *Msg) getBinary(b []byte) {
+	_ = b[19]
+	s.Family = nativeEndian.Uint32(b[0:])
+	s.Ifindex = nativeEndian.Uint32(b[4:])
+	s.Handle = nativeEndian.Uint32(b[8:])
+	s.Parent = nativeEndian.Uint32(b[12:])
+	s.Info = nativeEndian.Uint32(b[16:])
+}
+
+func (s *NatParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *NatParms) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint32(b[20:], s.OldAddr)
+	nativeEndian.PutUint32(b[24:], s.NewAddr)
+	nativeEndian.PutUint32(b[28:], s.Mask)
+	nativeEndian.PutUint32(b[32:], s.Flags)
+}
+
+func (s *NatParms) getBinary(b []byte) {
+	_ = b[35]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.OldAddr = nativeEndian.Uint32(b[20:])
+	s.NewAddr = nativeEndian.Uint32(b[24:])
+	s.Mask = nativeEndian.Uint32(b[28:])
+	s.Flags = nativeEndian.Uint32(b[32:])
+}
+
+func (s *NetemCorr) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *NetemCorr) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.Delay)
+	nativeEndian.PutUint32(b[4:], s.Loss)
+	nativeEndian.PutUint32(b[8:], s.Dup)
+}
+
+func (s *NetemCorr) getBinary(b []byte) {
+	_ = b[11]
+	s.Delay = nativeEndian.Uint32(b[0:])
+	s.Loss = nativeEndian.Uint32(b[4:])
+	s.Dup = nativeEndian.Uint32(b[8:])
+}
+
+func (s *NetemCorrupt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *NetemCorrupt) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint32(b[0:], s.Probability)
+	nativeEndian.PutUint32(b[4:], s.Correlation)
+}
+
+func (s *NetemCorrupt) getBinary(b []byte) {
+	_ = b[7]
+	s.Probability = nativeEndian.Uint32(b[0:])
+	s.Correlation = nativeEndian.Uint32(b[4:])
+}
+
+func (s *NetemQopt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *NetemQopt) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint32(b[0:], s.Latency)
+	nativeEndian.PutUint32(b[4:], s.Limit)
+	nativeEndian.PutUint32(b[8:], s.Loss)
+	nativeEndian.PutUint32(b[12:], s.Gap)
+	nativeEndian.PutUint32(b[16:], s.Duplicate)
+	nativeEndian.PutUint32(b[20:], s.Jitter)
+}
+
+func (s *NetemQopt) getBinary(b []byte) {
+	_ = b[23]
+	s.Latency = nativeEndian.Uint32(b[0:])
+	s.Limit = nativeEndian.Uint32(b[4:])
+	s.Loss = nativeEndian.Uint32(b[8:])
+	s.Gap = nativeEndian.Uint32(b[12:])
+	s.Duplicate = nativeEndian.Uint32(b[16:])
+	s.Jitter = nativeEndian.Uint32(b[20:])
+}
+
+func (s *NetemRate) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *NetemRate) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.Rate)
+	nativeEndian.PutUint32(b[4:], uint32(s.PacketOverhead))
+	nativeEndian.PutUint32(b[8:], uint32(s.CellSize))
+	nativeEndian.PutUint32(b[12:], uint32(s.CellOverhead))
+}
+
+func (s *NetemRate) getBinary(b []byte) {
+	_ = b[15]
+	s.Rate = nativeEndian.Uint32(b[0:])
+	s.PacketOverhead = int32(nativeEndian.Uint32(b[4:]))
+	s.CellSize = int32(nativeEndian.Uint32(b[8:]))
+	s.CellOverhead = int32(nativeEndian.Uint32(b[12:]))
+}
+
+func (s *NetemReorder) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *NetemReorder) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint32(b[0:], s.Probability)
+	nativeEndian.PutUint32(b[4:], s.Correlation)
+}
+
+func (s *NetemReorder) getBinary(b []byte) {
+	_ = b[7]
+	s.Probability = nativeEndian.Uint32(b[0:])
+	s.Correlation = nativeEndian.Uint32(b[4:])
+}
+
+func (s *NetemSlot) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 40
+}
+
+func (s *NetemSlot) putBinary(b []byte) {
+	_ = b[39]
+	nativeEndian.PutUint64(b[0:], uint64(s.MinDelay))
+	nativeEndian.PutUint64(b[8:], uint64(s.MaxDelay))
+	nativeEndian.PutUint32(b[16:], uint32(s.MaxPackets))
+	nativeEndian.PutUint32(b[20:], uint32(s.MaxBytes))
+	nativeEndian.PutUint64(b[24:], uint64(s.DistDelay))
+	nativeEndian.PutUint64(b[32:], uint64(s.DistJitter))
+}
+
+func (s *NetemSlot) getBinary(b []byte) {
+	_ = b[39]
+	s.MinDelay = int64(nativeEndian.Uint64(b[0:]))
+	s.MaxDelay = int64(nativeEndian.Uint64(b[8:]))
+	s.MaxPackets = int32(nativeEndian.Uint32(b[16:]))
+	s.MaxBytes = int32(nativeEndian.Uint32(b[20:]))
+	s.DistDelay = int64(nativeEndian.Uint64(b[24:]))
+	s.DistJitter = int64(nativeEndian.Uint64(b[32:]))
+}
+
+func (s *PieXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *PieXStats) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint64(b[0:], s.Prob)
+	nativeEndian.PutUint32(b[8:], s.Delay)
+	nativeEndian.PutUint32(b[12:], s.AvgDqRate)
+	nativeEndian.PutUint32(b[16:], s.PacketsIn)
+	nativeEndian.PutUint32(b[20:], s.Dropped)
+	nativeEndian.PutUint32(b[24:], s.Overlimit)
+	nativeEndian.PutUint32(b[28:], s.Maxq)
+	nativeEndian.PutUint32(b[32:], s.EcnMark)
+}
+
+func (s *PieXStats) getBinary(b []byte) {
+	_ = b[35]
+	s.Prob = nativeEndian.Uint64(b[0:])
+	s.Delay = nativeEndian.Uint32(b[8:])
+	s.AvgDqRate = nativeEndian.Uint32(b[12:])
+	s.PacketsIn = nativeEndian.Uint32(b[16:])
+	s.Dropped = nativeEndian.Uint32(b[20:])
+	s.Overlimit = nativeEndian.Uint32(b[24:])
+	s.Maxq = nativeEndian.Uint32(b[28:])
+	s.EcnMark = nativeEndian.Uint32(b[32:])
+}
+
+func (s *Plug) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 8
+}
+
+func (s *Plug) putBinary(b []byte) {
+	_ = b[7]
+	nativeEndian.PutUint32(b[0:], uint32(s.Action))
+	nativeEndian.PutUint32(b[4:], s.Limit)
+}
+
+func (s *Plug) getBinary(b []byte) {
+	_ = b[7]
+	s.Action = PlugAction(nativeEndian.Uint32(b[0:]))
+	s.Limit = nativeEndian.Uint32(b[4:])
+}
+
+func (s *Policy) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 56
+}
+
+func (s *Policy) putBinary(b []byte) {
+	_ = b[55]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], uint32(s.Action))
+	nativeEndian.PutUint32(b[8:], s.Limit)
+	nativeEndian.PutUint32(b[12:], s.Burst)
+	nativeEndian.PutUint32(b[16:], s.Mtu)
+	s.Rate.putBinary(b[20:])
+	s.PeakRate.putBinary(b[32:])
+	nativeEndian.PutUint32(b[44:], s.RefCnt)
+	nativeEndian.PutUint32(b[48:], s.BindCnt)
+	nativeEndian.PutUint32(b[52:], s.Capab)
+}
+
+func (s *Policy) getBinary(b []byte) {
+	_ = b[55]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Action = PolicyAction(nativeEndian.Uint32(b[4:]))
+	s.Limit = nativeEndian.Uint32(b[8:])
+	s.Burst = nativeEndian.Uint32(b[12:])
+	s.Mtu = nativeEndian.Uint32(b[16:])
+	s.Rate.getBinary(b[20:])
+	s.PeakRate.getBinary(b[32:])
+	s.RefCnt = nativeEndian.Uint32(b[44:])
+	s.BindCnt = nativeEndian.Uint32(b[48:])
+	s.Capab = nativeEndian.Uint32(b[52:])
+}
+
+func (s *Prio) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *Prio) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Bands)
+	copy(b[4:], s.PrioMap[:])
+}
+
+func (s *Prio) getBinary(b []byte) {
+	_ = b[19]
+	s.Bands = nativeEndian.Uint32(b[0:])
+	copy(s.PrioMap[:], b[4:])
+}
+
+func (s *RateSpec) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *RateSpec) putBinary(b []byte) {
+	_ = b[11]
+	b[0] = s.CellLog
+	b[1] = s.Linklayer
+	nativeEndian.PutUint16(b[2:], s.Overhead)
+	nativeEndian.PutUint16(b[4:], s.CellAlign)
+	nativeEndian.PutUint16(b[6:], s.Mpu)
+	nativeEndian.PutUint32(b[8:], s.Rate)
+}
+
+func (s *RateSpec) getBinary(b []byte) {
+	_ = b[11]
+	s.CellLog = b[0]
+	s.Linklayer = b[1]
+	s.Overhead = nativeEndian.Uint16(b[2:])
+	s.CellAlign = nativeEndian.Uint16(b[4:])
+	s.Mpu = nativeEndian.Uint16(b[6:])
+	s.Rate = nativeEndian.Uint32(b[8:])
+}
+
+func (s *RedQOpt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *RedQOpt) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.Limit)
+	nativeEndian.PutUint32(b[4:], s.QthMin)
+	nativeEndian.PutUint32(b[8:], s.QthMax)
+	b[12] = s.Wlog
+	b[13] = s.Plog
+	b[14] = s.ScellLog
+	b[15] = s.Flags
+}
+
+func (s *RedQOpt) getBinary(b []byte) {
+	_ = b[15]
+	s.Limit = nativeEndian.Uint32(b[0:])
+	s.QthMin = nativeEndian.Uint32(b[4:])
+	s.QthMax = nativeEndian.Uint32(b[8:])
+	s.Wlog = b[12]
+	s.Plog = b[13]
+	s.ScellLog = b[14]
+	s.Flags = b[15]
+}
+
+func (s *RedXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *RedXStats) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.Early)
+	nativeEndian.PutUint32(b[4:], s.PDrop)
+	nativeEndian.PutUint32(b[8:], s.Other)
+	nativeEndian.PutUint32(b[12:], s.Marked)
+}
+
+func (s *RedXStats) getBinary(b []byte) {
+	_ = b[15]
+	s.Early = nativeEndian.Uint32(b[0:])
+	s.PDrop = nativeEndian.Uint32(b[4:])
+	s.Other = nativeEndian.Uint32(b[8:])
+	s.Marked = nativeEndian.Uint32(b[12:])
+}
+
+func (s *RsvpGpi) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *RsvpGpi) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.Key)
+	nativeEndian.PutUint32(b[4:], s.Mask)
+	nativeEndian.PutUint32(b[8:], s.Offset)
+}
+
+func (s *RsvpGpi) getBinary(b []byte) {
+	_ = b[11]
+	s.Key = nativeEndian.Uint32(b[0:])
+	s.Mask = nativeEndian.Uint32(b[4:])
+	s.Offset = nativeEndian.Uint32(b[8:])
+}
+
+func (s *RsvpPInfo) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 28
+}
+
+func (s *RsvpPInfo) putBinary(b []byte) {
+	_ = b[27]
+	s.Dpi.putBinary(b[0:])
+	s.Spi.putBinary(b[12:])
+	b[24] = s.Protocol
+	b[25] = s.TunnelID
+	b[26] = s.TunnelHdr
+	b[27] = s.Pad
+}
+
+func (s *RsvpPInfo) getBinary(b []byte) {
+	_ = b[27]
+	s.Dpi.getBinary(b[0:])
+	s.Spi.getBinary(b[12:])
+	s.Protocol = b[24]
+	s.TunnelID = b[25]
+	s.TunnelHdr = b[26]
+	s.Pad = b[27]
+}
+
+func (s *SampleParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *SampleParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *SampleParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *ServiceCurve) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *ServiceCurve) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.M1)
+	nativeEndian.PutUint32(b[4:], s.D)
+	nativeEndian.PutUint32(b[8:], s.M2)
+}
+
+func (s *ServiceCurve) getBinary(b []byte) {
+	_ = b[11]
+	s.M1 = nativeEndian.Uint32(b[0:])
+	s.D = nativeEndian.Uint32(b[4:])
+	s.M2 = nativeEndian.Uint32(b[8:])
+}
+
+func (s *SfbQopt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *SfbQopt) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint32(b[0:], s.RehashInterval)
+	nativeEndian.PutUint32(b[4:], s.WarmupTime)
+	nativeEndian.PutUint32(b[8:], s.Max)
+	nativeEndian.PutUint32(b[12:], s.BinSize)
+	nativeEndian.PutUint32(b[16:], s.Increment)
+	nativeEndian.PutUint32(b[20:], s.Decrement)
+	nativeEndian.PutUint32(b[24:], s.Limit)
+	nativeEndian.PutUint32(b[28:], s.PenaltyRate)
+	nativeEndian.PutUint32(b[32:], s.PenaltyBurst)
+}
+
+func (s *SfbQopt) getBinary(b []byte) {
+	_ = b[35]
+	s.RehashInterval = nativeEndian.Uint32(b[0:])
+	s.WarmupTime = nativeEndian.Uint32(b[4:])
+	s.Max = nativeEndian.Uint32(b[8:])
+	s.BinSize = nativeEndian.Uint32(b[12:])
+	s.Increment = nativeEndian.Uint32(b[16:])
+	s.Decrement = nativeEndian.Uint32(b[20:])
+	s.Limit = nativeEndian.Uint32(b[24:])
+	s.PenaltyRate = nativeEndian.Uint32(b[28:])
+	s.PenaltyBurst = nativeEndian.Uint32(b[32:])
+}
+
+func (s *SfbXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *SfbXStats) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint32(b[0:], s.EarlyDrop)
+	nativeEndian.PutUint32(b[4:], s.PenaltyDrop)
+	nativeEndian.PutUint32(b[8:], s.BucketDrop)
+	nativeEndian.PutUint32(b[12:], s.QueueDrop)
+	nativeEndian.PutUint32(b[16:], s.ChildDrop)
+	nativeEndian.PutUint32(b[20:], s.Marked)
+	nativeEndian.PutUint32(b[24:], s.MaxQlen)
+	nativeEndian.PutUint32(b[28:], s.MaxProb)
+	nativeEndian.PutUint32(b[32:], s.AvgProb)
+}
+
+func (s *SfbXStats) getBinary(b []byte) {
+	_ = b[35]
+	s.EarlyDrop = nativeEndian.Uint32(b[0:])
+	s.PenaltyDrop = nativeEndian.Uint32(b[4:])
+	s.BucketDrop = nativeEndian.Uint32(b[8:])
+	s.QueueDrop = nativeEndian.Uint32(b[12:])
+	s.ChildDrop = nativeEndian.Uint32(b[16:])
+	s.Marked = nativeEndian.Uint32(b[20:])
+	s.MaxQlen = nativeEndian.Uint32(b[24:])
+	s.MaxProb = nativeEndian.Uint32(b[28:])
+	s.AvgProb = nativeEndian.Uint32(b[32:])
+}
+
+func (s *Sfq) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 48
+}
+
+func (s *Sfq) putBinary(b []byte) {
+	_ = b[47]
+	s.V0.putBinary(b[0:])
+	nativeEndian.PutUint32(b[20:], s.Depth)
+	nativeEndian.PutUint32(b[24:], s.Headdrop)
+	nativeEndian.PutUint32(b[28:], s.Limit)
+	nativeEndian.PutUint32(b[32:], s.QthMin)
+	nativeEndian.PutUint32(b[36:], s.QthMax)
+	b[40] = s.Wlog
+	b[41] = s.Plog
+	b[42] = s.ScellLog
+	b[43] = s.Flags
+	nativeEndian.PutUint32(b[44:], s.MaxP)
+}
+
+func (s *Sfq) getBinary(b []byte) {
+	_ = b[47]
+	s.V0.getBinary(b[0:])
+	s.Depth = nativeEndian.Uint32(b[20:])
+	s.Headdrop = nativeEndian.Uint32(b[24:])
+	s.Limit = nativeEndian.Uint32(b[28:])
+	s.QthMin = nativeEndian.Uint32(b[32:])
+	s.QthMax = nativeEndian.Uint32(b[36:])
+	s.Wlog = b[40]
+	s.Plog = b[41]
+	s.ScellLog = b[42]
+	s.Flags = b[43]
+	s.MaxP = nativeEndian.Uint32(b[44:])
+}
+
+func (s *SfqQopt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *SfqQopt) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Quantum)
+	nativeEndian.PutUint32(b[4:], uint32(s.PerturbPeriod))
+	nativeEndian.PutUint32(b[8:], s.Limit)
+	nativeEndian.PutUint32(b[12:], s.Divisor)
+	nativeEndian.PutUint32(b[16:], s.Flows)
+}
+
+func (s *SfqQopt) getBinary(b []byte) {
+	_ = b[19]
+	s.Quantum = nativeEndian.Uint32(b[0:])
+	s.PerturbPeriod = int32(nativeEndian.Uint32(b[4:]))
+	s.Limit = nativeEndian.Uint32(b[8:])
+	s.Divisor = nativeEndian.Uint32(b[12:])
+	s.Flows = nativeEndian.Uint32(b[16:])
+}
+
+func (s *SfqXStats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *SfqXStats) putBinary(b []byte) {
+	_ = b[3]
+	nativeEndian.PutUint32(b[0:], uint32(s.Allot))
+}
+
+func (s *SfqXStats) getBinary(b []byte) {
+	_ = b[3]
+	s.Allot = int32(nativeEndian.Uint32(b[0:]))
+}
+
+func (s *SizeSpec) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *SizeSpec) putBinary(b []byte) {
+	_ = b[23]
+	b[0] = s.CellLog
+	b[1] = s.SizeLog
+	nativeEndian.PutUint16(b[2:], uint16(s.CellAlign))
+	nativeEndian.PutUint32(b[4:], uint32(s.Overhead))
+	nativeEndian.PutUint32(b[8:], s.LinkLayer)
+	nativeEndian.PutUint32(b[12:], s.MPU)
+	nativeEndian.PutUint32(b[16:], s.MTU)
+	nativeEndian.PutUint32(b[20:], s.TSize)
+}
+
+func (s *SizeSpec) getBinary(b []byte) {
+	_ = b[23]
+	s.CellLog = b[0]
+	s.SizeLog = b[1]
+	s.CellAlign = int16(nativeEndian.Uint16(b[2:]))
+	s.Overhead = int32(nativeEndian.Uint32(b[4:]))
+	s.LinkLayer = nativeEndian.Uint32(b[8:])
+	s.MPU = nativeEndian.Uint32(b[12:])
+	s.MTU = nativeEndian.Uint32(b[16:])
+	s.TSize = nativeEndian.Uint32(b[20:])
+}
+
+func (s *SkbEditParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 20
+}
+
+func (s *SkbEditParms) putBinary(b []byte) {
+	_ = b[19]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+}
+
+func (s *SkbEditParms) getBinary(b []byte) {
+	_ = b[19]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+}
+
+func (s *SkbModParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 28
+}
+
+func (s *SkbModParms) putBinary(b []byte) {
+	_ = b[27]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint64(b[20:], s.Flags)
+}
+
+func (s *SkbModParms) getBinary(b []byte) {
+	_ = b[27]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.Flags = nativeEndian.Uint64(b[20:])
+}
+
+func (s *Stats) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *Stats) putBinary(b []byte) {
+	_ = b[35]
+	nativeEndian.PutUint64(b[0:], s.Bytes)
+	nativeEndian.PutUint32(b[8:], s.Packets)
+	nativeEndian.PutUint32(b[12:], s.Drops)
+	nativeEndian.PutUint32(b[16:], s.Overlimits)
+	nativeEndian.PutUint32(b[20:], s.Bps)
+	nativeEndian.PutUint32(b[24:], s.Pps)
+	nativeEndian.PutUint32(b[28:], s.Qlen)
+	nativeEndian.PutUint32(b[32:], s.Backlog)
+}
+
+func (s *Stats) getBinary(b []byte) {
+	_ = b[35]
+	s.Bytes = nativeEndian.Uint64(b[0:])
+	s.Packets = nativeEndian.Uint32(b[8:])
+	s.Drops = nativeEndian.Uint32(b[12:])
+	s.Overlimits = nativeEndian.Uint32(b[16:])
+	s.Bps = nativeEndian.Uint32(b[20:])
+	s.Pps = nativeEndian.Uint32(b[24:])
+	s.Qlen = nativeEndian.Uint32(b[28:])
+	s.Backlog = nativeEndian.Uint32(b[32:])
+}
+
+func (s *Stats2) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 32
+}
+
+func (s *Stats2) putBinary(b []byte) {
+	_ = b[31]
+	nativeEndian.PutUint64(b[0:], s.Bytes)
+	nativeEndian.PutUint32(b[8:], s.Packets)
+	nativeEndian.PutUint32(b[12:], s.Qlen)
+	nativeEndian.PutUint32(b[16:], s.Backlog)
+	nativeEndian.PutUint32(b[20:], s.Drops)
+	nativeEndian.PutUint32(b[24:], s.Requeues)
+	nativeEndian.PutUint32(b[28:], s.Overlimits)
+}
+
+func (s *Stats2) getBinary(b []byte) {
+	_ = b[31]
+	s.Bytes = nativeEndian.Uint64(b[0:])
+	s.Packets = nativeEndian.Uint32(b[8:])
+	s.Qlen = nativeEndian.Uint32(b[12:])
+	s.Backlog = nativeEndian.Uint32(b[16:])
+	s.Drops = nativeEndian.Uint32(b[20:])
+	s.Requeues = nativeEndian.Uint32(b[24:])
+	s.Overlimits = nativeEndian.Uint32(b[28:])
+}
+
+func (s *TbfQopt) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 36
+}
+
+func (s *TbfQopt) putBinary(b []byte) {
+	_ = b[35]
+	s.Rate.putBinary(b[0:])
+	s.PeakRate.putBinary(b[12:])
+	nativeEndian.PutUint32(b[24:], s.Limit)
+	nativeEndian.PutUint32(b[28:], s.Buffer)
+	nativeEndian.PutUint32(b[32:], s.Mtu)
+}
+
+func (s *TbfQopt) getBinary(b []byte) {
+	_ = b[35]
+	s.Rate.getBinary(b[0:])
+	s.PeakRate.getBinary(b[12:])
+	s.Limit = nativeEndian.Uint32(b[24:])
+	s.Buffer = nativeEndian.Uint32(b[28:])
+	s.Mtu = nativeEndian.Uint32(b[32:])
+}
+
+func (s *Tcft) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 32
+}
+
+func (s *Tcft) putBinary(b []byte) {
+	_ = b[31]
+	nativeEndian.PutUint64(b[0:], s.Install)
+	nativeEndian.PutUint64(b[8:], s.LastUse)
+	nativeEndian.PutUint64(b[16:], s.Expires)
+	nativeEndian.PutUint64(b[24:], s.FirstUse)
+}
+
+func (s *Tcft) getBinary(b []byte) {
+	_ = b[31]
+	s.Install = nativeEndian.Uint64(b[0:])
+	s.LastUse = nativeEndian.Uint64(b[8:])
+	s.Expires = nativeEndian.Uint64(b[16:])
+	s.FirstUse = nativeEndian.Uint64(b[24:])
+}
+
+func (s *TunnelParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *TunnelParms) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint32(b[20:], s.TunnelKeyAction)
+}
+
+func (s *TunnelParms) getBinary(b []byte) {
+	_ = b[23]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.TunnelKeyAction = nativeEndian.Uint32(b[20:])
+}
+
+func (s *U32Key) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *U32Key) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.Mask)
+	nativeEndian.PutUint32(b[4:], s.Val)
+	nativeEndian.PutUint32(b[8:], s.Off)
+	nativeEndian.PutUint32(b[12:], s.OffMask)
+}
+
+func (s *U32Key) getBinary(b []byte) {
+	_ = b[15]
+	s.Mask = nativeEndian.Uint32(b[0:])
+	s.Val = nativeEndian.Uint32(b[4:])
+	s.Off = nativeEndian.Uint32(b[8:])
+	s.OffMask = nativeEndian.Uint32(b[12:])
+}
+
+func (s *U32Mark) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *U32Mark) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.Val)
+	nativeEndian.PutUint32(b[4:], s.Mask)
+	nativeEndian.PutUint32(b[8:], s.Success)
+}
+
+func (s *U32Mark) getBinary(b []byte) {
+	_ = b[11]
+	s.Val = nativeEndian.Uint32(b[0:])
+	s.Mask = nativeEndian.Uint32(b[4:])
+	s.Success = nativeEndian.Uint32(b[8:])
+}
+
+func (s *U32Match) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 16
+}
+
+func (s *U32Match) putBinary(b []byte) {
+	_ = b[15]
+	nativeEndian.PutUint32(b[0:], s.Mask)
+	nativeEndian.PutUint32(b[4:], s.Value)
+	nativeEndian.PutUint32(b[8:], uint32(s.Off))
+	nativeEndian.PutUint32(b[12:], s.OffMask)
+}
+
+func (s *U32Match) getBinary(b []byte) {
+	_ = b[15]
+	s.Mask = nativeEndian.Uint32(b[0:])
+	s.Value = nativeEndian.Uint32(b[4:])
+	s.Off = int32(nativeEndian.Uint32(b[8:]))
+	s.OffMask = nativeEndian.Uint32(b[12:])
+}
+
+func (s *VLanParms) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 24
+}
+
+func (s *VLanParms) putBinary(b []byte) {
+	_ = b[23]
+	nativeEndian.PutUint32(b[0:], s.Index)
+	nativeEndian.PutUint32(b[4:], s.Capab)
+	nativeEndian.PutUint32(b[8:], uint32(s.Action))
+	nativeEndian.PutUint32(b[12:], s.RefCnt)
+	nativeEndian.PutUint32(b[16:], s.BindCnt)
+	nativeEndian.PutUint32(b[20:], uint32(s.VLanAction))
+}
+
+func (s *VLanParms) getBinary(b []byte) {
+	_ = b[23]
+	s.Index = nativeEndian.Uint32(b[0:])
+	s.Capab = nativeEndian.Uint32(b[4:])
+	s.Action = Verdict(nativeEndian.Uint32(b[8:]))
+	s.RefCnt = nativeEndian.Uint32(b[12:])
+	s.BindCnt = nativeEndian.Uint32(b[16:])
+	s.VLanAction = VLanAction(nativeEndian.Uint32(b[20:]))
+}
+
+func (s *cmpMatch) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 12
+}
+
+func (s *cmpMatch) putBinary(b []byte) {
+	_ = b[11]
+	nativeEndian.PutUint32(b[0:], s.Val)
+	nativeEndian.PutUint32(b[4:], s.Mask)
+	nativeEndian.PutUint16(b[8:], s.Off)
+	nativeEndian.PutUint16(b[10:], s.Opts)
+}
+
+func (s *cmpMatch) getBinary(b []byte) {
+	_ = b[11]
+	s.Val = nativeEndian.Uint32(b[0:])
+	s.Mask = nativeEndian.Uint32(b[4:])
+	s.Off = nativeEndian.Uint16(b[8:])
+	s.Opts = nativeEndian.Uint16(b[10:])
+}
+
+func (s *ipsetMatch) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *ipsetMatch) putBinary(b []byte) {
+	_ = b[3]
+	nativeEndian.PutUint16(b[0:], s.ID)
+	b[2] = s.Dim
+	b[3] = s.Flags
+}
+
+func (s *ipsetMatch) getBinary(b []byte) {
+	_ = b[3]
+	s.ID = nativeEndian.Uint16(b[0:])
+	s.Dim = b[2]
+	s.Flags = b[3]
+}
+
+func (s *tcaMsg) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 4
+}
+
+func (s *tcaMsg) putBinary(b []byte) {
+	_ = b[3]
+	b[0] = s.Family
+	b[1] = s.Pad1
+	nativeEndian.PutUint16(b[2:], s.Pad2)
+}
+
+func (s *tcaMsg) getBinary(b []byte) {
+	_ = b[3]
+	s.Family = b[0]
+	s.Pad1 = b[1]
+	s.Pad2 = nativeEndian.Uint16(b[2:])
+}
+
+func (s *tcfEmNByte) binaryLen() int {
+	if s == nil {
+		return -1
+	}
+	return 5
+}
+
+func (s *tcfEmNByte) putBinary(b []byte) {
+	_ = b[4]
+	nativeEndian.PutUint16(b[0:], s.off)
+	nativeEndian.PutUint16(b[2:], s.len)
+	b[4] = s.layer
+}
+
+func (s *tcfEmNByte) getBinary(b []byte) {
+	_ = b[4]
+	s.off = nativeEndian.Uint16(b[0:])
+	s.len = nativeEndian.Uint16(b[2:])
+	s.layer = b[4]
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"io"
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -87,3 +90,135 @@ func TestMarshalAndAlignStruct(t *testing.T) {
 		t.Fatalf("Struct alignment missmatch (-want +got):\n%s", diff)
 	}
 }
+
+// binaryStructs holds a value of every type in structs_gen.go.
+var binaryStructs = []binaryStruct{
+	&ActBpfParms{}, &AtmPvc{}, &CanFilter{}, &CbqFOpt{}, &CbqLssOpt{},
+	&CbqOvl{}, &CbqPolice{}, &CbqWrrOpt{}, &CbqXStats{}, &CbsOpt{}, &ChokeXStats{}, &CmpMatch{},
+	&CodelXStats{}, &ConnmarkParam{}, &ContainerMatch{}, &CsumParms{}, &CtInfoAct{}, &CtParms{},
+	&DefactParms{}, &EmatchHdr{}, &EmatchTreeHdr{}, &FifoOpt{}, &FqCodelClStats{},
+	&FqCodelQdStats{}, &FqPieXStats{}, &FqPrioQopt{}, &FqQdStats{}, &GactParms{}, &GactProb{},
+	&GateParms{}, &GenBasic{}, &GenQueue{}, &GenRateEst{}, &GenRateEst64{}, &GredQOpt{},
+	&GredSOpt{}, &HfscQOpt{}, &HfscXStats{}, &HhfXStats{}, &HtbGlob{}, &HtbOpt{}, &HtbXStats{},
+	&IfeParms{}, &IptCnt{}, &MPLSParam{}, &MetaHdr{}, &MetaValue{}, &MirredParam{}, &MqPrioQopt{},
+	&Msg{}, &NatParms{}, &NetemCorr{}, &NetemCorrupt{}, &NetemQopt{}, &NetemRate{},
+	&NetemReorder{}, &NetemSlot{}, &PieXStats{}, &Plug{}, &Policy{}, &Prio{}, &RateSpec{},
+	&RedQOpt{}, &RedXStats{}, &RsvpGpi{}, &RsvpPInfo{}, &SampleParms{}, &ServiceCurve{},
+	&SfbQopt{}, &SfbXStats{}, &Sfq{}, &SfqQopt{}, &SfqXStats{}, &SizeSpec{}, &SkbEditParms{},
+	&SkbModParms{}, &Stats{}, &Stats2{}, &TbfQopt{}, &Tcft{}, &TunnelParms{}, &U32Key{},
+	&U32Mark{}, &U32Match{}, &VLanParms{}, &cmpMatch{}, &ipsetMatch{}, &tcaMsg{}, &tcfEmNByte{},
+}
+
+func TestBinaryStruct(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	for _, s := range binaryStructs {
+		typ := reflect.TypeOf(s).Elem()
+		t.Run(typ.Name(), func(t *testing.T) {
+			if diff := cmp.Diff(binary.Size(s), s.binaryLen()); diff != "" {
+				t.Fatalf("size missmatch (want +got):\n%s", diff)
+			}
+
+			data := make([]byte, s.binaryLen())
+			rnd.Read(data)
+
+			got := reflect.New(typ).Interface()
+			if err := unmarshalStruct(data, got); err != nil {
+				t.Fatalf("could not decode: %v", err)
+			}
+			// encoding/binary can not decode into unexported fields.
+			if exported(typ) {
+				want := reflect.New(typ).Interface()
+				if err := binary.Read(bytes.NewReader(data), nativeEndian, want); err != nil {
+					t.Fatalf("could not decode with encoding/binary: %v", err)
+				}
+				if !reflect.DeepEqual(want, got) {
+					t.Fatalf("decoded struct missmatch:\nwant: %#v\ngot:  %#v", want, got)
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := binary.Write(&buf, nativeEndian, got); err != nil {
+				t.Fatalf("could not encode with encoding/binary: %v", err)
+			}
+			encoded, err := marshalStruct(got)
+			if err != nil {
+				t.Fatalf("could not encode: %v", err)
+			}
+			if diff := cmp.Diff(buf.Bytes(), encoded); diff != "" {
+				t.Fatalf("encoded struct missmatch (want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("incomplete", func(t *testing.T) {
+		if err := unmarshalStruct([]byte{}, &Tcft{}); !errors.Is(err, io.EOF) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := unmarshalStruct([]byte{0x1, 0x2}, &Tcft{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("nil", func(t *testing.T) {
+		var tcft *Tcft
+		if _, err := marshalStruct(tcft); err == nil {
+			t.Fatal("expected error for nil struct")
+		}
+	})
+}
+
+// exported reports whether all fields of the struct typ are exported.
+func exported(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if !typ.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkMarshalStruct(b *testing.B) {
+	parms := &MirredParam{Index: 1, Action: ActStolen, Eaction: 1, IfIndex: 2}
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := marshalStruct(parms); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/binary", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			if err := binary.Write(&buf, nativeEndian, parms); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkUnmarshalStruct(b *testing.B) {
+	data, err := marshalStruct(&Policy{Index: 1, Action: PolicyShot, Burst: 1000})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var policy Policy
+			if err := unmarshalStruct(data, &policy); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/binary", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var policy Policy
+			if err := binary.Read(bytes.NewReader(data), nativeEndian, &policy); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -2,7 +2,11 @@ package tc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/florianl/go-tc/internal/unix"
@@ -10,8 +14,8 @@ import (
 	"github.com/mdlayher/netlink"
 )
 
-// tcConn defines a subset of netlink.Conn.
-type tcConn interface {
+// Conn defines the subset of netlink.Conn, that is used by Tc.
+type Conn interface {
 	Close() error
 	JoinGroup(group uint32) error
 	LeaveGroup(group uint32) error
@@ -21,43 +25,153 @@ type tcConn interface {
 	SetReadDeadline(t time.Time) error
 }
 
+// tcConn defines a subset of netlink.Conn.
+type tcConn = Conn
+
 var _ tcConn = &netlink.Conn{}
 
 // Tc represents a RTNETLINK wrapper
+//
+// A Tc is safe for concurrent use by multiple goroutines. Requests are serialised on a
+// single connection. Monitors receive notifications on connections of their own, if Tc
+// was created by Open or NewWithDialer.
 type Tc struct {
 	con tcConn
+	// shared is the state, that is shared by all copies of a Tc, like the ones embedded
+	// in Qdisc, Class, Filter, Chain and Actions.
+	shared *tcShared
+}
+
+// tcShared holds the state of a Tc, that is shared by all its copies.
+type tcShared struct {
+	// mu serialises the requests and their replies on con.
+	mu sync.Mutex
+
+	// dial opens a further connection for notifications. If it is nil, monitors use con.
+	dial func() (Conn, error)
+
+	// monitorsMu protects the following fields.
+	monitorsMu sync.Mutex
+	closed     bool
+	// dialing counts the connections for monitors, that are dialed without holding
+	// monitorsMu.
+	dialing  int
+	monitors map[Conn]struct{}
+	// options holds the socket options set by SetOption, which are applied to the
+	// connections of monitors as well.
+	options map[netlink.ConnOption]bool
+
+	// netns is the network namespace, that was opened for the connections.
+	netns *os.File
 }
 
 var nativeEndian = native.Endian
 
 // Open establishes a RTNETLINK socket for traffic control
+//
+// If config selects the network namespace by path, name or PID, the namespace is kept
+// open until Tc is closed.
 func Open(config *Config) (*Tc, error) {
-	var tc Tc
-
 	if config == nil {
 		config = &Config{}
 	}
+	ns, err := openNetNS(config)
+	if err != nil {
+		return nil, err
+	}
+	netns := config.NetNS
+	if ns != nil {
+		netns = int(ns.Fd())
+	}
 
-	con, err := netlink.Dial(unix.NETLINK_ROUTE, &netlink.Config{NetNS: config.NetNS})
+	tc, err := NewWithDialer(func() (Conn, error) {
+		return netlink.Dial(unix.NETLINK_ROUTE, &netlink.Config{NetNS: netns})
+	})
 	if err != nil {
+		if ns != nil {
+			ns.Close()
+		}
 		return nil, err
 	}
-	tc.con = con
+	tc.shared.netns = ns
+	return tc, nil
+}
 
-	return &tc, nil
+// NewWithConn returns a Tc, that uses con instead of a RTNETLINK socket. This
+// allows to use a fake kernel like github.com/florianl/go-tc/tctest.
+//
+// Monitors of the returned Tc receive notifications on con. So con should not be used
+// for other requests while a monitor is running.
+func NewWithConn(con Conn) *Tc {
+	return &Tc{con: con, shared: &tcShared{}}
 }
 
-// SetOption allows to enable or disable netlink socket options.
+// NewWithDialer returns a Tc, that uses a connection returned by dial for its requests.
+// Each monitor of the returned Tc receives notifications on a further connection
+// returned by dial.
+func NewWithDialer(dial func() (Conn, error)) (*Tc, error) {
+	if dial == nil {
+		return nil, ErrNoArg
+	}
+	con, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	return &Tc{con: con, shared: &tcShared{dial: dial}}, nil
+}
+
+// SetOption allows to enable or disable netlink socket options. The option applies to
+// the connections of monitors as well.
 func (tc *Tc) SetOption(o netlink.ConnOption, enable bool) error {
-	return tc.con.SetOption(o, enable)
+	tc.shared.mu.Lock()
+	err := tc.con.SetOption(o, enable)
+	tc.shared.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tc.shared.monitorsMu.Lock()
+	defer tc.shared.monitorsMu.Unlock()
+	if tc.shared.options == nil {
+		tc.shared.options = make(map[netlink.ConnOption]bool)
+	}
+	tc.shared.options[o] = enable
+	for con := range tc.shared.monitors {
+		if err := con.SetOption(o, enable); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Close the connection
+// Close the connection and the connections of running monitors.
 func (tc *Tc) Close() error {
-	return tc.con.Close()
+	tc.shared.monitorsMu.Lock()
+	tc.shared.closed = true
+	for con := range tc.shared.monitors {
+		con.Close()
+	}
+	tc.shared.monitors = nil
+	// A monitor, that is still dialing, uses the namespace. It is closed once the dial
+	// finished.
+	var netns *os.File
+	if tc.shared.dialing == 0 {
+		netns = tc.shared.netns
+		tc.shared.netns = nil
+	}
+	tc.shared.monitorsMu.Unlock()
+
+	err := tc.con.Close()
+	if netns != nil {
+		netns.Close()
+	}
+	return err
 }
 
 func (tc *Tc) query(req netlink.Message) ([]netlink.Message, error) {
+	tc.shared.mu.Lock()
+	defer tc.shared.mu.Unlock()
+
 	verify, err := tc.con.Send(req)
 	if err != nil {
 		return nil, err
@@ -70,10 +184,28 @@ func (tc *Tc) query(req netlink.Message) ([]netlink.Message, error) {
 	return tc.con.Receive()
 }
 
-func (tc *Tc) action(action int, flags netlink.HeaderFlags, msg interface{}, opts []tcOption) error {
+// validateObject returns the options of info for a request of type action on qdiscs,
+// classes, filters or chains.
+func validateObject(action int, info *Object) ([]tcOption, error) {
+	if isChainAction(action) {
+		return validateFilterObject(action, info)
+	}
+	switch action & actionMask {
+	case actionQdisc:
+		return validateQdiscObject(action, info)
+	case actionClass:
+		return validateClassObject(action, info)
+	case actionFilter:
+		return validateFilterObject(action, info)
+	}
+	return nil, ErrInvalidArg
+}
+
+// newRequest returns a request of type action, that is acknowledged by the kernel.
+func newRequest(action int, flags netlink.HeaderFlags, msg interface{}, opts []tcOption) (netlink.Message, error) {
 	tcminfo, err := marshalStruct(msg)
 	if err != nil {
-		return err
+		return netlink.Message{}, err
 	}
 
 	var data []byte
@@ -81,15 +213,22 @@ func (tc *Tc) action(action int, flags netlink.HeaderFlags, msg interface{}, opt
 
 	attrs, err := marshalAttributes(opts)
 	if err != nil {
-		return err
+		return netlink.Message{}, err
 	}
 	data = append(data, attrs...)
-	req := netlink.Message{
+	return netlink.Message{
 		Header: netlink.Header{
 			Type:  netlink.HeaderType(action),
 			Flags: netlink.Request | netlink.Acknowledge | flags,
 		},
 		Data: data,
+	}, nil
+}
+
+func (tc *Tc) action(action int, flags netlink.HeaderFlags, msg interface{}, opts []tcOption) error {
+	req, err := newRequest(action, flags, msg, opts)
+	if err != nil {
+		return err
 	}
 
 	msgs, err := tc.query(req)
@@ -100,6 +239,9 @@ func (tc *Tc) action(action int, flags netlink.HeaderFlags, msg interface{}, opt
 	for _, msg := range msgs {
 		switch msg.Header.Type {
 		case netlink.Error:
+			if len(msg.Data) < 4 {
+				return fmt.Errorf("received short error from netlink: %#v", msg)
+			}
 			errCode := bytesToInt32(msg.Data[:4])
 			// Check if the success message is embedded encoded as error code 0:
 			if errCode != 0 {
@@ -115,40 +257,11 @@ func (tc *Tc) action(action int, flags netlink.HeaderFlags, msg interface{}, opt
 
 func (tc *Tc) get(action int, i *Msg) ([]Object, error) {
 	var results []Object
-
-	tcminfo, err := marshalStruct(i)
-	if err != nil {
-		return results, err
-	}
-
-	var data []byte
-	data = append(data, tcminfo...)
-
-	req := netlink.Message{
-		Header: netlink.Header{
-			Type:  netlink.HeaderType(action),
-			Flags: netlink.Request | netlink.Dump,
-		},
-		Data: data,
-	}
-
-	msgs, err := tc.query(req)
-	if err != nil {
-		return results, err
-	}
-
-	for _, msg := range msgs {
-		var result Object
-		if err := unmarshalStruct(msg.Data[:20], &result.Msg); err != nil {
-			return results, err
-		}
-		if err := extractTcmsgAttributes(action, msg.Data[20:], &result.Attribute); err != nil {
-			return results, err
-		}
+	err := tc.walk(action, i, func(result Object) int {
 		results = append(results, result)
-	}
-
-	return results, nil
+		return 0
+	})
+	return results, err
 }
 
 // Object represents a generic traffic control object
@@ -158,6 +271,8 @@ type Object struct {
 }
 
 // Msg represents a Traffic Control Message
+//
+//structgen:binary
 type Msg struct {
 	Family  uint32
 	Ifindex uint32
@@ -300,6 +415,60 @@ func (tc *Tc) Monitor(ctx context.Context, deadline time.Duration, fn HookFunc)
 	})
 }
 
+// monitorConn returns the connection for a monitor and a function, that releases it once
+// the monitor is done.
+func (tc *Tc) monitorConn() (Conn, func(), error) {
+	if tc.shared.dial == nil {
+		return tc.con, func() {
+			tc.con.LeaveGroup(unix.RTNLGRP_TC)
+		}, nil
+	}
+
+	tc.shared.monitorsMu.Lock()
+	if tc.shared.closed {
+		tc.shared.monitorsMu.Unlock()
+		return nil, nil, net.ErrClosed
+	}
+	tc.shared.dialing++
+	tc.shared.monitorsMu.Unlock()
+
+	// Dialing blocks, so monitorsMu is not held meanwhile.
+	con, err := tc.shared.dial()
+
+	tc.shared.monitorsMu.Lock()
+	defer tc.shared.monitorsMu.Unlock()
+	tc.shared.dialing--
+	if tc.shared.closed && tc.shared.dialing == 0 && tc.shared.netns != nil {
+		tc.shared.netns.Close()
+		tc.shared.netns = nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Tc might have been closed during the dial.
+	if tc.shared.closed {
+		con.Close()
+		return nil, nil, net.ErrClosed
+	}
+	for o, enable := range tc.shared.options {
+		if err := con.SetOption(o, enable); err != nil {
+			con.Close()
+			return nil, nil, err
+		}
+	}
+	if tc.shared.monitors == nil {
+		tc.shared.monitors = make(map[Conn]struct{})
+	}
+	tc.shared.monitors[con] = struct{}{}
+
+	return con, func() {
+		tc.shared.monitorsMu.Lock()
+		delete(tc.shared.monitors, con)
+		tc.shared.monitorsMu.Unlock()
+		con.Close()
+	}, nil
+}
+
 func (tc *Tc) monitor(ctx context.Context, deadline time.Duration,
 	fn HookFunc, errfn ErrorFunc) error {
 	ifinfomsg, err := marshalStruct(unix.IfInfomsg{
@@ -327,46 +496,59 @@ func (tc *Tc) monitor(ctx context.Context, deadline time.Duration,
 		Data: data,
 	}
 
-	if err := tc.con.JoinGroup(unix.RTNLGRP_TC); err != nil {
+	con, release, err := tc.monitorConn()
+	if err != nil {
 		return err
 	}
 
-	verify, err := tc.con.Send(req)
+	if err := con.JoinGroup(unix.RTNLGRP_TC); err != nil {
+		release()
+		return err
+	}
+
+	verify, err := con.Send(req)
 	if err != nil {
-		tc.con.LeaveGroup(unix.RTNLGRP_TC)
+		con.LeaveGroup(unix.RTNLGRP_TC)
+		release()
 		return err
 	}
 
 	if err := netlink.Validate(req, []netlink.Message{verify}); err != nil {
-		tc.con.LeaveGroup(unix.RTNLGRP_TC)
+		con.LeaveGroup(unix.RTNLGRP_TC)
+		release()
 		return err
 	}
 
 	go func() {
+		done := make(chan struct{})
+		defer func() {
+			close(done)
+			release()
+		}()
 		go func() {
-			<-ctx.Done()
+			select {
+			case <-ctx.Done():
+			case <-done:
+				return
+			}
 			stop := time.Now().Add(deadline)
-			tc.con.SetReadDeadline(stop)
-			tc.con.LeaveGroup(unix.RTNLGRP_TC)
+			con.SetReadDeadline(stop)
+			con.LeaveGroup(unix.RTNLGRP_TC)
 		}()
 		for {
-			msgs, err := tc.con.Receive()
+			msgs, err := con.Receive()
 			if err != nil {
 				if ret := errfn(err); ret != 0 {
 					return
 				}
-				if ctx.Err() != nil {
+				if ctx.Err() != nil || errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrClosed) {
 					return
 				}
 				continue
 			}
 			for _, msg := range msgs {
-				var monitored Object
-				if err := unmarshalStruct(msg.Data[:20], &monitored.Msg); err != nil {
-					continue
-				}
-				if err := extractTcmsgAttributes(int(msg.Header.Type), msg.Data[20:],
-					&monitored.Attribute); err != nil {
+				monitored, ok := decodeNotification(msg)
+				if !ok {
 					continue
 				}
 				if fn(uint16(msg.Header.Type), monitored) != 0 {
@@ -377,3 +559,20 @@ func (tc *Tc) monitor(ctx context.Context, deadline time.Duration,
 	}()
 	return nil
 }
+
+// decodeNotification returns the object of a notification. It reports false for
+// messages, that do not contain a traffic control object.
+func decodeNotification(msg netlink.Message) (Object, bool) {
+	var monitored Object
+	if len(msg.Data) < 20 {
+		return monitored, false
+	}
+	if err := unmarshalStruct(msg.Data[:20], &monitored.Msg); err != nil {
+		return monitored, false
+	}
+	if err := extractTcmsgAttributes(int(msg.Header.Type), msg.Data[20:],
+		&monitored.Attribute); err != nil {
+		return monitored, false
+	}
+	return monitored, true
+}
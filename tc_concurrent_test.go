@@ -0,0 +1,225 @@
+package tc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/florianl/go-tc/tctest"
+)
+
+func TestConcurrentRequests(t *testing.T) {
+	const (
+		workers = 8
+		filters = 50
+	)
+
+	_, tcnl := batchKernel(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan uint32, workers*filters)
+	monitorErrs := make(chan error, 1)
+	if err := tcnl.MonitorWithErrorFunc(ctx, 10*time.Millisecond, func(action uint16, m tc.Object) int {
+		if action == unix.RTM_NEWTFILTER {
+			events <- m.Handle
+		}
+		return 0
+	}, func(err error) int {
+		monitorErrs <- err
+		return 1
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w uint32) {
+			defer wg.Done()
+			for i := uint32(0); i < filters; i++ {
+				handle := 1000 + w*filters + i
+				if err := tcnl.Filter().Add(batchFilter(handle, 0x10000|handle)); err != nil {
+					errs <- fmt.Errorf("could not add filter %d: %v", handle, err)
+					return
+				}
+				all, err := tcnl.Filter().Get(&tc.Msg{
+					Family:  unix.AF_UNSPEC,
+					Ifindex: batchIfindex,
+					Parent:  batchFilter(0, 0).Parent,
+				})
+				if err != nil {
+					errs <- fmt.Errorf("could not get filters: %v", err)
+					return
+				}
+				found := false
+				for _, f := range all {
+					found = found || f.Handle == handle
+				}
+				if !found {
+					errs <- fmt.Errorf("filter %d is missing", handle)
+					return
+				}
+				if _, err := tcnl.Qdisc().Get(); err != nil {
+					errs <- fmt.Errorf("could not get qdiscs: %v", err)
+					return
+				}
+			}
+		}(uint32(w))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	seen := make(map[uint32]bool)
+	for len(seen) < workers*filters {
+		select {
+		case handle := <-events:
+			seen[handle] = true
+		case err := <-monitorErrs:
+			t.Fatalf("monitor failed after %d events: %v", len(seen), err)
+		case <-ctx.Done():
+			t.Fatalf("monitor received %d of %d events", len(seen), workers*filters)
+		}
+	}
+}
+
+func TestCloseStopsMonitor(t *testing.T) {
+	k := tctest.NewKernel()
+	k.AddLink(batchIfindex)
+	tcnl := k.Open()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopped := make(chan error, 1)
+	if err := tcnl.MonitorWithErrorFunc(ctx, 10*time.Millisecond, func(action uint16, m tc.Object) int {
+		return 0
+	}, func(err error) int {
+		stopped <- err
+		return 0
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+
+	if err := tcnl.Close(); err != nil {
+		t.Fatalf("could not close: %v", err)
+	}
+	select {
+	case err := <-stopped:
+		if err == nil {
+			t.Fatal("expected an error for the closed connection")
+		}
+	case <-ctx.Done():
+		t.Fatal("monitor was not stopped by Close")
+	}
+}
+
+func TestMonitorAfterClose(t *testing.T) {
+	k := tctest.NewKernel()
+	k.AddLink(batchIfindex)
+
+	var mu sync.Mutex
+	var conns []tc.Conn
+	tcnl, err := tc.NewWithDialer(func() (tc.Conn, error) {
+		con := k.Dial()
+		mu.Lock()
+		conns = append(conns, con)
+		mu.Unlock()
+		return con, nil
+	})
+	if err != nil {
+		t.Fatalf("could not open: %v", err)
+	}
+	if err := tcnl.Close(); err != nil {
+		t.Fatalf("could not close: %v", err)
+	}
+	mu.Lock()
+	dialed := len(conns)
+	mu.Unlock()
+
+	// A monitor, that is started after Close, must not open a new connection.
+	err = tcnl.MonitorWithErrorFunc(context.Background(), 10*time.Millisecond, func(uint16, tc.Object) int {
+		return 0
+	}, func(error) int {
+		return 0
+	})
+	if !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed but got: %v", err)
+	}
+	err = tcnl.MonitorResilient(context.Background(), func(uint16, tc.Object) int {
+		return 0
+	}, func(*tc.Snapshot) int {
+		return 0
+	}, func(error) int {
+		return 0
+	})
+	if !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed but got: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(conns) != dialed {
+		t.Fatalf("monitor dialed %d connections after Close", len(conns)-dialed)
+	}
+}
+
+func TestCloseDuringMonitorDial(t *testing.T) {
+	k := tctest.NewKernel()
+	k.AddLink(batchIfindex)
+
+	dialing := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var conns []tc.Conn
+	tcnl, err := tc.NewWithDialer(func() (tc.Conn, error) {
+		mu.Lock()
+		first := len(conns) == 0
+		mu.Unlock()
+		if !first {
+			close(dialing)
+			<-release
+		}
+		con := k.Dial()
+		mu.Lock()
+		conns = append(conns, con)
+		mu.Unlock()
+		return con, nil
+	})
+	if err != nil {
+		t.Fatalf("could not open: %v", err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tcnl.MonitorWithErrorFunc(context.Background(), 10*time.Millisecond,
+			func(uint16, tc.Object) int { return 0 }, func(error) int { return 0 })
+	}()
+	<-dialing
+	// Close must not wait for the dial.
+	if err := tcnl.Close(); err != nil {
+		t.Fatalf("could not close: %v", err)
+	}
+	close(release)
+	if err := <-errs; !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed but got: %v", err)
+	}
+
+	// The connection, that was dialed for the monitor, is closed.
+	mu.Lock()
+	con := conns[len(conns)-1]
+	mu.Unlock()
+	if _, err := con.Receive(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed from the monitor connection but got: %v", err)
+	}
+}
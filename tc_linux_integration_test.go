@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/florianl/go-tc/core"
 	"github.com/florianl/go-tc/internal/unix"
 	"github.com/mdlayher/netlink"
 )
@@ -260,3 +262,97 @@ func TestSetOption(t *testing.T) {
 		})
 	}
 }
+
+func TestLinuxConcurrentMonitor(t *testing.T) {
+	const (
+		workers = 4
+		filters = 100
+	)
+
+	ns, err := newScratchNetNS()
+	if err != nil {
+		t.Skipf("could not create network namespace: %v", err)
+	}
+	defer ns.Close()
+
+	tcSocket, err := Open(&Config{NetNS: int(ns.Fd())})
+	if err != nil {
+		t.Fatalf("could not open socket for TC: %v", err)
+	}
+	defer tcSocket.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan uint32, workers*filters)
+	if err := tcSocket.MonitorWithErrorFunc(ctx, 10*time.Millisecond, func(action uint16, m Object) int {
+		if action == unix.RTM_NEWTFILTER {
+			events <- m.Handle
+		}
+		return 0
+	}, func(err error) int {
+		if ctx.Err() == nil {
+			t.Errorf("monitor failed: %v", err)
+		}
+		return 1
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+
+	if err := tcSocket.Qdisc().Add(&Object{
+		Msg: Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: probeLoopbackIndex,
+			Handle:  core.BuildHandle(HandleRoot, 0x0),
+			Parent:  HandleIngress,
+		},
+		Attribute: Attribute{Kind: "clsact"},
+	}); err != nil {
+		t.Fatalf("could not add clsact: %v", err)
+	}
+
+	parent := core.BuildHandle(HandleRoot, HandleMinIngress)
+	var wg sync.WaitGroup
+	for w := uint32(0); w < workers; w++ {
+		wg.Add(1)
+		go func(w uint32) {
+			defer wg.Done()
+			for i := uint32(1); i <= filters; i++ {
+				if err := tcSocket.Filter().Add(&Object{
+					Msg: Msg{
+						Family:  unix.AF_UNSPEC,
+						Ifindex: probeLoopbackIndex,
+						Handle:  0x80000000 | (w*filters + i),
+						Parent:  parent,
+						Info:    core.FilterInfo(1, unix.ETH_P_ALL),
+					},
+					Attribute: Attribute{
+						Kind: "u32",
+						U32: &U32{
+							ClassID: uint32Ptr(0x10001),
+							Sel:     &U32Sel{Flags: 0x1, NKeys: 1, Keys: []U32Key{{}}},
+						},
+					},
+				}); err != nil {
+					t.Errorf("could not add filter: %v", err)
+					return
+				}
+				if _, err := tcSocket.Filter().Get(&Msg{Family: unix.AF_UNSPEC, Ifindex: probeLoopbackIndex, Parent: parent}); err != nil {
+					t.Errorf("could not get filters: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	seen := make(map[uint32]bool)
+	for len(seen) < workers*filters {
+		select {
+		case handle := <-events:
+			seen[handle] = true
+		case <-ctx.Done():
+			t.Fatalf("monitor received %d of %d events", len(seen), workers*filters)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"testing"
 	"time"
 
@@ -18,8 +19,8 @@ func testConn(t *testing.T) (*Tc, func()) {
 
 	var reqCache []netlink.Message
 
-	c := &Tc{
-		con: nltest.Dial(func(req []netlink.Message) ([]netlink.Message, error) {
+	c := NewWithConn(
+		nltest.Dial(func(req []netlink.Message) ([]netlink.Message, error) {
 			if len(req) == 0 {
 				// skip validation requests
 				return []netlink.Message{}, nil
@@ -75,7 +76,7 @@ func testConn(t *testing.T) (*Tc, func()) {
 
 			return emptyMsg, nil
 		}),
-	}
+	)
 
 	return c, func() {
 		if err := c.Close(); err != nil {
@@ -136,7 +137,7 @@ func testHookConn(t *testing.T) (*Tc, func()) {
 	t.Helper()
 
 	hookedConn := &fakeConn{}
-	c := &Tc{con: hookedConn}
+	c := NewWithConn(hookedConn)
 
 	return c, func() {
 		if err := c.Close(); err != nil {
@@ -278,3 +279,19 @@ func alterResponses(t *testing.T, cache *[]netlink.Message) []byte {
 	}
 	return dataStream
 }
+
+func TestShortMessage(t *testing.T) {
+	var reqs []netlink.Message
+	tcSocket := bpfAttachConn(t, map[netlink.HeaderType]func(netlink.Message) ([]netlink.Message, error){
+		unix.RTM_GETQDISC: func(req netlink.Message) ([]netlink.Message, error) {
+			return []netlink.Message{{
+				Header: netlink.Header{Type: unix.RTM_NEWQDISC, Sequence: req.Header.Sequence},
+				Data:   []byte{0x0, 0x0, 0x0, 0x0},
+			}}, nil
+		},
+	}, &reqs)
+
+	if _, err := tcSocket.Qdisc().Get(); !errors.Is(err, ErrInvalidArg) {
+		t.Fatalf("expected ErrInvalidArg but got: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package tctest
+
+import (
+	"sort"
+
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
+)
+
+// Attributes of actions from include/uapi/linux/rtnetlink.h and
+// include/uapi/linux/pkt_cls.h.
+const (
+	tcaRootTab   = 1
+	tcaRootCount = 3
+
+	tcaActKind    = 1
+	tcaActOptions = 2
+	tcaActIndex   = 3
+	tcaActStats   = 4
+)
+
+// parmsAttribute holds the type of the attribute, that carries the struct with the
+// index of an action as first member.
+var parmsAttribute = map[string]uint16{
+	"bpf":        2,
+	"connmark":   1,
+	"csum":       1,
+	"ct":         1,
+	"ctinfo":     3,
+	"defact":     2,
+	"gact":       2,
+	"gate":       2,
+	"ife":        1,
+	"ipt":        3,
+	"mirred":     2,
+	"mpls":       2,
+	"nat":        1,
+	"police":     1,
+	"sample":     2,
+	"skbedit":    2,
+	"skbmod":     2,
+	"tunnel_key": 2,
+	"vlan":       2,
+}
+
+// action is a shared action.
+type action struct {
+	kind    string
+	index   uint32
+	options []netlink.Attribute
+	// extra holds further attributes like the cookie or flags of the action.
+	extra []netlink.Attribute
+}
+
+// parmsIndex returns the index, that is part of the parameters in options.
+func parmsIndex(kind string, options []netlink.Attribute) uint32 {
+	typ, ok := parmsAttribute[kind]
+	if !ok {
+		return 0
+	}
+	data, ok := attribute(options, typ)
+	if !ok {
+		return 0
+	}
+	index, err := nlUint32(data)
+	if err != nil {
+		return 0
+	}
+	return index
+}
+
+// setParmsIndex updates the index, that is part of the parameters in options.
+func setParmsIndex(kind string, options []netlink.Attribute, index uint32) {
+	typ, ok := parmsAttribute[kind]
+	if !ok {
+		return
+	}
+	for i, attr := range options {
+		if attr.Type&^(netlink.Nested|netlink.NetByteOrder) == typ && len(attr.Data) >= 4 {
+			data := append([]byte{}, attr.Data...)
+			nativeEndian.PutUint32(data, index)
+			options[i].Data = data
+		}
+	}
+}
+
+func (a *action) marshal() ([]byte, error) {
+	options, err := netlink.MarshalAttributes(a.options)
+	if err != nil {
+		return nil, err
+	}
+	attrs := []netlink.Attribute{
+		kindAttribute(a.kind),
+		{Type: tcaActOptions | netlink.Nested, Data: options},
+	}
+	return netlink.MarshalAttributes(append(attrs, a.extra...))
+}
+
+// actionsMsg returns a message with a tcamsg header and acts as TCA_ROOT_TAB.
+func actionsMsg(acts []*action, root ...netlink.Attribute) ([]byte, error) {
+	var tab []netlink.Attribute
+	for i, a := range acts {
+		data, err := a.marshal()
+		if err != nil {
+			return nil, err
+		}
+		tab = append(tab, netlink.Attribute{Type: uint16(i + 1), Data: data})
+	}
+	data, err := netlink.MarshalAttributes(tab)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := netlink.MarshalAttributes(append([]netlink.Attribute{
+		{Type: tcaRootTab | netlink.Nested, Data: data},
+	}, root...))
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]byte, tcaMsgLen), attrs...), nil
+}
+
+// parseActions returns the actions of the TCA_ROOT_TAB in a request.
+func parseActions(data []byte) ([]*action, error) {
+	if len(data) < tcaMsgLen {
+		return nil, unix.EINVAL
+	}
+	attrs, err := netlink.UnmarshalAttributes(data[tcaMsgLen:])
+	if err != nil {
+		return nil, unix.EINVAL
+	}
+	tab, ok := attribute(attrs, tcaRootTab)
+	if !ok {
+		return nil, unix.EINVAL
+	}
+	entries, err := netlink.UnmarshalAttributes(tab)
+	if err != nil {
+		return nil, unix.EINVAL
+	}
+	var acts []*action
+	for _, entry := range entries {
+		attrs, err := netlink.UnmarshalAttributes(entry.Data)
+		if err != nil {
+			return nil, unix.EINVAL
+		}
+		a := &action{kind: kindOf(attrs)}
+		if a.kind == "" {
+			return nil, unix.EINVAL
+		}
+		if data, ok := attribute(attrs, tcaActOptions); ok {
+			if a.options, err = netlink.UnmarshalAttributes(data); err != nil {
+				return nil, unix.EINVAL
+			}
+		}
+		if data, ok := attribute(attrs, tcaActIndex); ok {
+			if a.index, err = nlUint32(data); err != nil {
+				return nil, err
+			}
+		}
+		if a.index == 0 {
+			a.index = parmsIndex(a.kind, a.options)
+		}
+		for _, typ := range []uint16{tcaActKind, tcaActOptions, tcaActIndex, tcaActStats} {
+			attrs = without(attrs, typ)
+		}
+		a.extra = attrs
+		acts = append(acts, a)
+	}
+	return acts, nil
+}
+
+func (k *Kernel) sortedActions(kind string) []*action {
+	acts := make([]*action, 0, len(k.actions[kind]))
+	for _, a := range k.actions[kind] {
+		acts = append(acts, a)
+	}
+	sort.Slice(acts, func(i, j int) bool { return acts[i].index < acts[j].index })
+	return acts
+}
+
+func (k *Kernel) newActions(req netlink.Message) error {
+	acts, err := parseActions(req.Data)
+	if err != nil {
+		return err
+	}
+	for _, a := range acts {
+		if _, ok := parmsAttribute[a.kind]; !ok || k.disabled[a.kind] {
+			return unix.ENOENT
+		}
+		if a.index != 0 {
+			if _, ok := k.actions[a.kind][a.index]; ok && isExcl(req) {
+				return unix.EEXIST
+			}
+		}
+	}
+	for _, a := range acts {
+		byIndex, ok := k.actions[a.kind]
+		if !ok {
+			byIndex = make(map[uint32]*action)
+			k.actions[a.kind] = byIndex
+		}
+		if a.index == 0 {
+			a.index = 1
+			for byIndex[a.index] != nil {
+				a.index++
+			}
+		}
+		setParmsIndex(a.kind, a.options, a.index)
+		byIndex[a.index] = a
+	}
+	data, err := actionsMsg(acts)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_NEWACTION, data)
+	return nil
+}
+
+func (k *Kernel) delActions(req netlink.Message) error {
+	acts, err := parseActions(req.Data)
+	if err != nil {
+		return err
+	}
+	var deleted []*action
+	if req.Header.Flags&netlink.Root != 0 {
+		// Flush all actions of the given kinds.
+		for _, a := range acts {
+			if len(k.actions[a.kind]) == 0 {
+				return unix.ENOENT
+			}
+			deleted = append(deleted, k.sortedActions(a.kind)...)
+			delete(k.actions, a.kind)
+		}
+	} else {
+		for _, a := range acts {
+			if a.index == 0 {
+				return unix.EINVAL
+			}
+			if _, ok := k.actions[a.kind][a.index]; !ok {
+				return unix.ENOENT
+			}
+		}
+		for _, a := range acts {
+			deleted = append(deleted, k.actions[a.kind][a.index])
+			delete(k.actions[a.kind], a.index)
+		}
+	}
+	data, err := actionsMsg(deleted)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_DELACTION, data)
+	return nil
+}
+
+func (k *Kernel) getActions(req netlink.Message) ([]netlink.Message, error) {
+	acts, err := parseActions(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(acts) == 0 {
+		return nil, unix.EINVAL
+	}
+	if req.Header.Flags&netlink.Dump == netlink.Dump {
+		found := k.sortedActions(acts[0].kind)
+		if len(found) == 0 {
+			return nil, nil
+		}
+		data, err := actionsMsg(found, uint32Attribute(tcaRootCount, uint32(len(found))))
+		if err != nil {
+			return nil, err
+		}
+		return []netlink.Message{{
+			Header: netlink.Header{Type: unix.RTM_GETACTION},
+			Data:   data,
+		}}, nil
+	}
+	var found []*action
+	for _, a := range acts {
+		stored, ok := k.actions[a.kind][a.index]
+		if !ok {
+			return nil, unix.ENOENT
+		}
+		found = append(found, stored)
+	}
+	data, err := actionsMsg(found)
+	if err != nil {
+		return nil, err
+	}
+	return []netlink.Message{{
+		Header: netlink.Header{Type: unix.RTM_NEWACTION},
+		Data:   data,
+	}}, nil
+}
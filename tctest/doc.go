@@ -0,0 +1,56 @@
+/*
+Package tctest provides an in-memory fake of the traffic control part of RTNETLINK for unit tests
+of code, that is built on top of github.com/florianl/go-tc.
+
+A Kernel keeps qdiscs, classes, filters and chains per network interface as well as shared
+actions. It answers requests of a tc.Tc the way the Linux kernel does in the common cases:
+
+  - NLM_F_CREATE|NLM_F_EXCL on an existing object results in EEXIST,
+  - a missing parent qdisc or class results in ENOENT,
+  - qdisc handles, filter priorities, filter handles and action indexes are allocated if they are not set,
+  - deleting a qdisc removes its classes, filters, chains and child qdiscs,
+  - dumps are answered as multipart messages and
+  - changes are announced to connections, that joined the RTNLGRP_TC multicast group.
+
+Overrun simulates a connection, that could not keep up with the notifications of the kernel.
+Pending notifications are dropped and the next receive fails with ENOBUFS.
+
+The options of qdiscs, classes, filters and actions are stored as they are sent and are not
+validated. Interfaces need to be registered with AddLink before they can be used. Kinds,
+that are not provided by the kernel under test, can be rejected with DisableKinds.
+
+	k := tctest.NewKernel()
+	k.AddLink(2)
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+A Recorder wraps a connection to the Linux kernel and records the requests and replies of a
+session. The recording can be replayed with a Replay, which checks the requests of a test
+against the recorded ones and answers them with the recorded replies. So tests can rely on
+the exact behavior of a kernel without the need for privileges.
+
+	con, err := netlink.Dial(unix.NETLINK_ROUTE, nil)
+	if err != nil {
+		// handle error
+	}
+	rec := tctest.NewRecorder(con)
+	tcnl := tc.NewWithConn(rec)
+	// use tcnl
+	tcnl.Close()
+	if err := rec.Save("testdata/session.json"); err != nil {
+		// handle error
+	}
+
+Later on the recorded session is replayed in a test:
+
+	replay, err := tctest.OpenReplay("testdata/session.json", tctest.MatchBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcnl := tc.NewWithConn(replay)
+	// use tcnl
+	if err := replay.Done(); err != nil {
+		t.Fatal(err)
+	}
+*/
+package tctest
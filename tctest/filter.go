@@ -0,0 +1,528 @@
+package tctest
+
+import (
+	"sort"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
+)
+
+// block holds the filter chains of a qdisc or class.
+type block struct {
+	chains map[uint32]*chain
+}
+
+type chain struct {
+	index    uint32
+	explicit bool
+	// kind and attrs describe the optional filter template of the chain.
+	kind   string
+	attrs  []netlink.Attribute
+	protos []*proto
+}
+
+// proto is a classifier instance with a priority within a chain.
+type proto struct {
+	prio     uint16
+	protocol uint16
+	kind     string
+	filters  []*filter
+}
+
+type filter struct {
+	handle uint32
+	attrs  []netlink.Attribute
+}
+
+func (b *block) chain(index uint32, create bool) *chain {
+	c, ok := b.chains[index]
+	if !ok && create {
+		c = &chain{index: index}
+		b.chains[index] = c
+	}
+	return c
+}
+
+func (b *block) sortedChains() []*chain {
+	chains := make([]*chain, 0, len(b.chains))
+	for _, c := range b.chains {
+		chains = append(chains, c)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].index < chains[j].index })
+	return chains
+}
+
+func (c *chain) proto(prio uint16) *proto {
+	for _, tp := range c.protos {
+		if tp.prio == prio {
+			return tp
+		}
+	}
+	return nil
+}
+
+func (c *chain) addProto(tp *proto) {
+	c.protos = append(c.protos, tp)
+	sort.Slice(c.protos, func(i, j int) bool { return c.protos[i].prio < c.protos[j].prio })
+}
+
+func (c *chain) delProto(tp *proto) {
+	var protos []*proto
+	for _, other := range c.protos {
+		if other != tp {
+			protos = append(protos, other)
+		}
+	}
+	c.protos = protos
+}
+
+// autoPrio returns the priority for a filter without one as tcf_auto_prio() does.
+func (c *chain) autoPrio() uint16 {
+	if len(c.protos) == 0 {
+		return 0xC000
+	}
+	return c.protos[0].prio - 1
+}
+
+func (tp *proto) filter(handle uint32) *filter {
+	for _, f := range tp.filters {
+		if f.handle == handle {
+			return f
+		}
+	}
+	return nil
+}
+
+// autoHandle returns the lowest unused filter handle.
+func (tp *proto) autoHandle() uint32 {
+	handle := uint32(1)
+	if tp.kind == "u32" {
+		handle = 0x80000800
+	}
+	for tp.filter(handle) != nil {
+		handle++
+	}
+	return handle
+}
+
+func (tp *proto) info() uint32 {
+	return uint32(tp.prio)<<16 | uint32(tp.protocol)
+}
+
+// blockID returns the identifier of the block, that holds the filters for parent.
+func (l *link) blockID(parent uint32) (uint32, error) {
+	if parent == 0 {
+		q := l.qdiscByParent(tc.HandleRoot)
+		if q == nil {
+			return 0, unix.ENOENT
+		}
+		return q.handle, nil
+	}
+	q := l.qdiscByHandle(parent)
+	if q == nil {
+		return 0, unix.ENOENT
+	}
+	if q.parent == tc.HandleIngress {
+		if minor(parent) == tc.HandleMinEgress {
+			if q.kind != "clsact" {
+				return 0, unix.EOPNOTSUPP
+			}
+			return major(parent) | tc.HandleMinEgress, nil
+		}
+		return major(parent) | tc.HandleMinIngress, nil
+	}
+	if minor(parent) != 0 && explicitClasses[q.kind] {
+		if l.class(parent) == nil {
+			return 0, unix.ENOENT
+		}
+		return parent, nil
+	}
+	return q.handle, nil
+}
+
+func (l *link) block(id uint32, create bool) *block {
+	b, ok := l.blocks[id]
+	if !ok && create {
+		b = &block{chains: make(map[uint32]*chain)}
+		l.blocks[id] = b
+	}
+	return b
+}
+
+func chainIndex(attrs []netlink.Attribute) (uint32, error) {
+	data, ok := attribute(attrs, tcaChain)
+	if !ok {
+		return 0, nil
+	}
+	return nlUint32(data)
+}
+
+func (tp *proto) marshal(ifindex, parent, chain uint32, f *filter) ([]byte, error) {
+	msg := tcMsg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: parent, Info: tp.info()}
+	attrs := []netlink.Attribute{kindAttribute(tp.kind)}
+	if f != nil {
+		msg.Handle = f.handle
+		attrs = append(attrs, without(f.attrs, tcaChain)...)
+	}
+	attrs = append(attrs, uint32Attribute(tcaChain, chain))
+	return msg.marshal(attrs)
+}
+
+func (k *Kernel) newFilter(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	if msg.Ifindex == tc.MagicBlock {
+		return unix.EOPNOTSUPP
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	prio := uint16(msg.Info >> 16)
+	protocol := uint16(msg.Info)
+	if prio == 0 && !isCreate(req) {
+		return unix.ENOENT
+	}
+	id, err := l.blockID(msg.Parent)
+	if err != nil {
+		return err
+	}
+	index, err := chainIndex(attrs)
+	if err != nil {
+		return err
+	}
+	kind := kindOf(attrs)
+	attrs = without(attrs, tcaKind)
+
+	var c *chain
+	if b := l.block(id, false); b != nil {
+		c = b.chain(index, false)
+	}
+	var tp *proto
+	if c != nil && prio != 0 {
+		tp = c.proto(prio)
+	}
+	if tp != nil {
+		if kind != "" && kind != tp.kind {
+			return unix.EINVAL
+		}
+		if protocol != 0 && protocol != tp.protocol {
+			return unix.EINVAL
+		}
+		if f := tp.filter(msg.Handle); msg.Handle != 0 && f != nil {
+			if isExcl(req) {
+				return unix.EEXIST
+			}
+			f.attrs = attrs
+			data, err := tp.marshal(l.ifindex, id, index, f)
+			if err != nil {
+				return err
+			}
+			k.notify(unix.RTM_NEWTFILTER, data)
+			return nil
+		}
+	}
+	if !isCreate(req) {
+		return unix.ENOENT
+	}
+	if tp == nil {
+		if kind == "" || protocol == 0 {
+			return unix.EINVAL
+		}
+		if k.disabled[kind] {
+			return unix.ENOENT
+		}
+		if c != nil && c.kind != "" && c.kind != kind {
+			// The filter does not match the template of the chain.
+			return unix.EINVAL
+		}
+		c = l.block(id, true).chain(index, true)
+		if prio == 0 {
+			prio = c.autoPrio()
+		}
+		tp = &proto{prio: prio, protocol: protocol, kind: kind}
+		c.addProto(tp)
+	}
+	handle := msg.Handle
+	if handle == 0 {
+		handle = tp.autoHandle()
+	}
+	f := &filter{handle: handle, attrs: attrs}
+	tp.filters = append(tp.filters, f)
+	data, err := tp.marshal(l.ifindex, id, index, f)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_NEWTFILTER, data)
+	return nil
+}
+
+func (k *Kernel) delFilter(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	prio := uint16(msg.Info >> 16)
+	id, err := l.blockID(msg.Parent)
+	if err != nil {
+		return err
+	}
+	index, err := chainIndex(attrs)
+	if err != nil {
+		return err
+	}
+	var c *chain
+	if b := l.block(id, false); b != nil {
+		c = b.chain(index, false)
+	}
+
+	if prio == 0 {
+		// Flush the chain.
+		if c == nil {
+			return unix.ENOENT
+		}
+		for _, tp := range c.protos {
+			data, err := tp.marshal(l.ifindex, id, index, nil)
+			if err != nil {
+				return err
+			}
+			k.notify(unix.RTM_DELTFILTER, data)
+		}
+		c.protos = nil
+		l.cleanupChain(id, c)
+		return nil
+	}
+
+	var tp *proto
+	if c != nil {
+		tp = c.proto(prio)
+	}
+	if tp == nil {
+		return unix.ENOENT
+	}
+	if kind := kindOf(attrs); kind != "" && kind != tp.kind {
+		return unix.EINVAL
+	}
+	if msg.Handle == 0 {
+		c.delProto(tp)
+		data, err := tp.marshal(l.ifindex, id, index, nil)
+		if err != nil {
+			return err
+		}
+		k.notify(unix.RTM_DELTFILTER, data)
+		l.cleanupChain(id, c)
+		return nil
+	}
+	f := tp.filter(msg.Handle)
+	if f == nil {
+		return unix.ENOENT
+	}
+	var filters []*filter
+	for _, other := range tp.filters {
+		if other != f {
+			filters = append(filters, other)
+		}
+	}
+	tp.filters = filters
+	data, err := tp.marshal(l.ifindex, id, index, f)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_DELTFILTER, data)
+	if len(tp.filters) == 0 {
+		c.delProto(tp)
+		l.cleanupChain(id, c)
+	}
+	return nil
+}
+
+// cleanupChain removes c, if it was created implicitly and holds no filters anymore.
+func (l *link) cleanupChain(id uint32, c *chain) {
+	if c.explicit || len(c.protos) > 0 {
+		return
+	}
+	if b := l.block(id, false); b != nil {
+		delete(b.chains, c.index)
+	}
+}
+
+func (k *Kernel) getFilters(req netlink.Message) ([]netlink.Message, error) {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return nil, err
+	}
+	id, err := l.blockID(msg.Parent)
+	if err != nil {
+		return nil, err
+	}
+	_, onlyChain := attribute(attrs, tcaChain)
+	index, err := chainIndex(attrs)
+	if err != nil {
+		return nil, err
+	}
+	b := l.block(id, false)
+	if b == nil {
+		return nil, nil
+	}
+	var msgs []netlink.Message
+	for _, c := range b.sortedChains() {
+		if onlyChain && c.index != index {
+			continue
+		}
+		for _, tp := range c.protos {
+			// Like the kernel, the dump is limited to the priority and protocol of the request.
+			if prio := uint16(msg.Info >> 16); prio != 0 && prio != tp.prio {
+				continue
+			}
+			if protocol := uint16(msg.Info); protocol != 0 && protocol != tp.protocol {
+				continue
+			}
+			// Each classifier is reported first on its own, followed by its filters.
+			data, err := tp.marshal(l.ifindex, id, c.index, nil)
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, netlink.Message{
+				Header: netlink.Header{Type: unix.RTM_NEWTFILTER},
+				Data:   data,
+			})
+			for _, f := range tp.filters {
+				data, err := tp.marshal(l.ifindex, id, c.index, f)
+				if err != nil {
+					return nil, err
+				}
+				msgs = append(msgs, netlink.Message{
+					Header: netlink.Header{Type: unix.RTM_NEWTFILTER},
+					Data:   data,
+				})
+			}
+		}
+	}
+	return msgs, nil
+}
+
+func (c *chain) marshal(ifindex, parent uint32) ([]byte, error) {
+	msg := tcMsg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: parent}
+	attrs := []netlink.Attribute{uint32Attribute(tcaChain, c.index)}
+	if c.kind != "" {
+		attrs = append(attrs, kindAttribute(c.kind))
+		attrs = append(attrs, c.attrs...)
+	}
+	return msg.marshal(attrs)
+}
+
+func (k *Kernel) newChain(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	id, err := l.blockID(msg.Parent)
+	if err != nil {
+		return err
+	}
+	index, err := chainIndex(attrs)
+	if err != nil {
+		return err
+	}
+	if !isCreate(req) {
+		return unix.ENOENT
+	}
+	b := l.block(id, true)
+	if c := b.chain(index, false); c != nil {
+		return unix.EEXIST
+	}
+	c := b.chain(index, true)
+	c.explicit = true
+	c.kind = kindOf(attrs)
+	c.attrs = without(without(attrs, tcaKind), tcaChain)
+	data, err := c.marshal(l.ifindex, id)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_NEWCHAIN, data)
+	return nil
+}
+
+func (k *Kernel) delChain(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	id, err := l.blockID(msg.Parent)
+	if err != nil {
+		return err
+	}
+	index, err := chainIndex(attrs)
+	if err != nil {
+		return err
+	}
+	b := l.block(id, false)
+	if b == nil || b.chain(index, false) == nil {
+		return unix.EINVAL
+	}
+	c := b.chain(index, false)
+	for _, tp := range c.protos {
+		data, err := tp.marshal(l.ifindex, id, index, nil)
+		if err != nil {
+			return err
+		}
+		k.notify(unix.RTM_DELTFILTER, data)
+	}
+	delete(b.chains, index)
+	data, err := c.marshal(l.ifindex, id)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_DELCHAIN, data)
+	return nil
+}
+
+func (k *Kernel) getChains(req netlink.Message) ([]netlink.Message, error) {
+	msg, _, err := parseTcMsg(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return nil, err
+	}
+	id, err := l.blockID(msg.Parent)
+	if err != nil {
+		return nil, err
+	}
+	b := l.block(id, false)
+	if b == nil {
+		return nil, nil
+	}
+	var msgs []netlink.Message
+	for _, c := range b.sortedChains() {
+		data, err := c.marshal(l.ifindex, id)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, netlink.Message{
+			Header: netlink.Header{Type: unix.RTM_NEWCHAIN},
+			Data:   data,
+		})
+	}
+	return msgs, nil
+}
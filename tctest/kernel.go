@@ -0,0 +1,400 @@
+package tctest
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/josharian/native"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nltest"
+)
+
+// Attributes of struct tcmsg from include/uapi/linux/rtnetlink.h.
+const (
+	tcaKind  = 1
+	tcaChain = 11
+)
+
+const (
+	nlmsgHeaderLen = 16
+	tcMsgLen       = 20
+	tcaMsgLen      = 4
+)
+
+var nativeEndian = native.Endian
+
+// Kernel is an in-memory fake of the traffic control subsystem of the Linux kernel.
+// It is safe for concurrent use by multiple connections.
+type Kernel struct {
+	mu      sync.Mutex
+	links   map[uint32]*link
+	actions map[string]map[uint32]*action
+	sockets map[*socket]struct{}
+	nextPID uint32
+
+	// disabled holds the kinds, that are unknown to the kernel.
+	disabled map[string]bool
+
+	autoHandle uint32
+	events     []netlink.Message
+}
+
+// NewKernel returns a Kernel without any network interfaces.
+func NewKernel() *Kernel {
+	return &Kernel{
+		links:      make(map[uint32]*link),
+		actions:    make(map[string]map[uint32]*action),
+		sockets:    make(map[*socket]struct{}),
+		disabled:   make(map[string]bool),
+		nextPID:    1,
+		autoHandle: 0x80000000,
+	}
+}
+
+// AddLink registers the network interface with ifindex. Adding an existing
+// interface has no effect.
+func (k *Kernel) AddLink(ifindex uint32) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.links[ifindex]; !ok {
+		k.links[ifindex] = newLink(ifindex)
+	}
+}
+
+// DelLink removes the network interface with ifindex and all its traffic
+// control objects.
+func (k *Kernel) DelLink(ifindex uint32) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.links, ifindex)
+}
+
+// DisableKinds makes k reject new qdiscs, filters and actions of the given kinds
+// with ENOENT, like a kernel, that does not provide the corresponding modules.
+func (k *Kernel) DisableKinds(kinds ...string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, kind := range kinds {
+		k.disabled[kind] = true
+	}
+}
+
+// Dial returns a new connection to k.
+func (k *Kernel) Dial() *netlink.Conn {
+	k.mu.Lock()
+	pid := k.nextPID
+	k.nextPID++
+	s := newSocket(k, pid)
+	k.sockets[s] = struct{}{}
+	k.mu.Unlock()
+	return netlink.NewConn(s, pid)
+}
+
+// Open returns a tc.Tc, that is connected to k. Like a tc.Tc returned by tc.Open, its
+// monitors receive notifications on connections of their own.
+func (k *Kernel) Open() *tc.Tc {
+	tcnl, _ := tc.NewWithDialer(func() (tc.Conn, error) {
+		return k.Dial(), nil
+	})
+	return tcnl
+}
+
+// Overrun drops the pending notifications of all connections, that joined RTNLGRP_TC, and
+// lets their next Receive fail with ENOBUFS. This is what the Linux kernel does, if the
+// notifications do not fit into the receive buffer of a connection.
+func (k *Kernel) Overrun() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for s := range k.sockets {
+		if s.joined(unix.RTNLGRP_TC) {
+			s.overflow()
+		}
+	}
+}
+
+// detach removes a closed socket from k.
+func (k *Kernel) detach(s *socket) {
+	k.mu.Lock()
+	delete(k.sockets, s)
+	k.mu.Unlock()
+}
+
+// handle processes a single request and returns the replies to it.
+func (k *Kernel) handle(req netlink.Message) []netlink.Message {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.events = nil
+	var msgs []netlink.Message
+	var err error
+	switch int(req.Header.Type) {
+	case unix.RTM_NEWQDISC:
+		err = k.newQdisc(req)
+	case unix.RTM_DELQDISC:
+		err = k.delQdisc(req)
+	case unix.RTM_GETQDISC:
+		msgs, err = k.getQdiscs(req)
+	case unix.RTM_NEWTCLASS:
+		err = k.newClass(req)
+	case unix.RTM_DELTCLASS:
+		err = k.delClass(req)
+	case unix.RTM_GETTCLASS:
+		msgs, err = k.getClasses(req)
+	case unix.RTM_NEWTFILTER:
+		err = k.newFilter(req)
+	case unix.RTM_DELTFILTER:
+		err = k.delFilter(req)
+	case unix.RTM_GETTFILTER:
+		msgs, err = k.getFilters(req)
+	case unix.RTM_NEWCHAIN:
+		err = k.newChain(req)
+	case unix.RTM_DELCHAIN:
+		err = k.delChain(req)
+	case unix.RTM_GETCHAIN:
+		msgs, err = k.getChains(req)
+	case unix.RTM_NEWACTION:
+		err = k.newActions(req)
+	case unix.RTM_DELACTION:
+		err = k.delActions(req)
+	case unix.RTM_GETACTION:
+		msgs, err = k.getActions(req)
+	case unix.RTM_GETLINK:
+		// Links are not reported, but the dump is answered for Tc.Monitor().
+	default:
+		err = unix.EOPNOTSUPP
+	}
+	if err != nil {
+		return errorReply(req, err)
+	}
+	k.publish()
+
+	switch {
+	case req.Header.Flags&netlink.Dump == netlink.Dump:
+		return multipart(req, msgs)
+	case len(msgs) > 0:
+		for i := range msgs {
+			msgs[i].Header.Sequence = req.Header.Sequence
+			msgs[i].Header.PID = req.Header.PID
+		}
+		return msgs
+	case req.Header.Flags&netlink.Acknowledge != 0:
+		return errorReply(req, nil)
+	}
+	return nil
+}
+
+// notify records a change, that is published to the members of RTNLGRP_TC once the
+// request succeeded.
+func (k *Kernel) notify(typ int, data []byte) {
+	k.events = append(k.events, netlink.Message{
+		Header: netlink.Header{Type: netlink.HeaderType(typ)},
+		Data:   data,
+	})
+}
+
+func (k *Kernel) publish() {
+	if len(k.events) == 0 {
+		return
+	}
+	for s := range k.sockets {
+		if !s.joined(unix.RTNLGRP_TC) {
+			continue
+		}
+		for _, event := range k.events {
+			s.enqueue([]netlink.Message{event})
+		}
+	}
+	k.events = nil
+}
+
+// link holds the traffic control objects of a network interface.
+type link struct {
+	ifindex uint32
+	qdiscs  []*qdisc
+	classes []*class
+	blocks  map[uint32]*block
+}
+
+func newLink(ifindex uint32) *link {
+	return &link{
+		ifindex: ifindex,
+		blocks:  make(map[uint32]*block),
+	}
+}
+
+func (k *Kernel) link(ifindex uint32) (*link, error) {
+	l, ok := k.links[ifindex]
+	if !ok {
+		return nil, unix.ENODEV
+	}
+	return l, nil
+}
+
+// sortedLinks returns the links of k ordered by their ifindex.
+func (k *Kernel) sortedLinks() []*link {
+	links := make([]*link, 0, len(k.links))
+	for _, l := range k.links {
+		links = append(links, l)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ifindex < links[j].ifindex })
+	return links
+}
+
+// tcMsg is the header of qdisc, class, filter and chain messages.
+type tcMsg struct {
+	Family  uint8
+	Ifindex uint32
+	Handle  uint32
+	Parent  uint32
+	Info    uint32
+}
+
+func parseTcMsg(data []byte) (tcMsg, []netlink.Attribute, error) {
+	if len(data) < tcMsgLen {
+		return tcMsg{}, nil, unix.EINVAL
+	}
+	msg := tcMsg{
+		Family:  data[0],
+		Ifindex: nativeEndian.Uint32(data[4:8]),
+		Handle:  nativeEndian.Uint32(data[8:12]),
+		Parent:  nativeEndian.Uint32(data[12:16]),
+		Info:    nativeEndian.Uint32(data[16:20]),
+	}
+	attrs, err := netlink.UnmarshalAttributes(data[tcMsgLen:])
+	if err != nil {
+		return msg, nil, unix.EINVAL
+	}
+	return msg, attrs, nil
+}
+
+func (m tcMsg) marshal(attrs []netlink.Attribute) ([]byte, error) {
+	data := make([]byte, tcMsgLen)
+	data[0] = m.Family
+	nativeEndian.PutUint32(data[4:8], m.Ifindex)
+	nativeEndian.PutUint32(data[8:12], m.Handle)
+	nativeEndian.PutUint32(data[12:16], m.Parent)
+	nativeEndian.PutUint32(data[16:20], m.Info)
+	if len(attrs) > 0 {
+		tmp, err := netlink.MarshalAttributes(attrs)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, tmp...)
+	}
+	return data, nil
+}
+
+// attribute returns the data of the first attribute of typ in attrs.
+func attribute(attrs []netlink.Attribute, typ uint16) ([]byte, bool) {
+	for _, attr := range attrs {
+		if attr.Type&^(netlink.Nested|netlink.NetByteOrder) == typ {
+			return attr.Data, true
+		}
+	}
+	return nil, false
+}
+
+// without returns attrs without the attributes of typ.
+func without(attrs []netlink.Attribute, typ uint16) []netlink.Attribute {
+	var res []netlink.Attribute
+	for _, attr := range attrs {
+		if attr.Type&^(netlink.Nested|netlink.NetByteOrder) != typ {
+			res = append(res, attr)
+		}
+	}
+	return res
+}
+
+func kindOf(attrs []netlink.Attribute) string {
+	data, ok := attribute(attrs, tcaKind)
+	if !ok {
+		return ""
+	}
+	return nlString(data)
+}
+
+func nlString(data []byte) string {
+	for i, c := range data {
+		if c == 0 {
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
+
+func nlUint32(data []byte) (uint32, error) {
+	if len(data) < 4 {
+		return 0, unix.EINVAL
+	}
+	return nativeEndian.Uint32(data), nil
+}
+
+func kindAttribute(kind string) netlink.Attribute {
+	return netlink.Attribute{Type: tcaKind, Data: append([]byte(kind), 0)}
+}
+
+func uint32Attribute(typ uint16, v uint32) netlink.Attribute {
+	data := make([]byte, 4)
+	nativeEndian.PutUint32(data, v)
+	return netlink.Attribute{Type: typ, Data: data}
+}
+
+func isCreate(req netlink.Message) bool {
+	return req.Header.Flags&netlink.Create != 0
+}
+
+func isExcl(req netlink.Message) bool {
+	return req.Header.Flags&netlink.Excl != 0
+}
+
+func isReplace(req netlink.Message) bool {
+	return req.Header.Flags&netlink.Replace != 0
+}
+
+// errorReply returns the NLMSG_ERROR for req. A nil err acknowledges req.
+func errorReply(req netlink.Message, err error) []netlink.Message {
+	errno := 0
+	if err != nil {
+		var e syscall.Errno
+		if !errors.As(err, &e) {
+			e = unix.EINVAL
+		}
+		errno = int(e)
+	}
+	msgs, _ := nltest.Error(errno, []netlink.Message{req})
+	return msgs
+}
+
+// multipart returns msgs as answer to the dump request req.
+func multipart(req netlink.Message, msgs []netlink.Message) []netlink.Message {
+	done := netlink.Message{
+		Header: netlink.Header{Type: netlink.Done},
+		Data:   make([]byte, 4),
+	}
+	msgs = append(msgs, done)
+	for i := range msgs {
+		msgs[i].Header.Flags |= netlink.Multi
+		msgs[i].Header.Sequence = req.Header.Sequence
+		msgs[i].Header.PID = req.Header.PID
+	}
+	return msgs
+}
+
+// nlmsgAlign rounds length up to the alignment of netlink messages.
+func nlmsgAlign(length int) int {
+	return (length + 3) &^ 3
+}
+
+// major returns the major part of a handle.
+func major(handle uint32) uint32 {
+	return handle & 0xFFFF0000
+}
+
+// minor returns the minor part of a handle.
+func minor(handle uint32) uint32 {
+	return handle & 0x0000FFFF
+}
@@ -0,0 +1,424 @@
+package tctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
+
+const ifindex = 2
+
+func htbQdisc(handle, parent uint32) *tc.Object {
+	return &tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: ifindex,
+			Handle:  handle,
+			Parent:  parent,
+		},
+		Attribute: tc.Attribute{
+			Kind: "htb",
+			Htb: &tc.Htb{
+				Init: &tc.HtbGlob{Version: 3, Rate2Quantum: 10},
+			},
+		},
+	}
+}
+
+func htbClass(handle, parent uint32) *tc.Object {
+	return &tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: ifindex,
+			Handle:  handle,
+			Parent:  parent,
+		},
+		Attribute: tc.Attribute{
+			Kind: "htb",
+			Htb: &tc.Htb{
+				Parms: &tc.HtbOpt{
+					Rate:    tc.RateSpec{Rate: 125000},
+					Ceil:    tc.RateSpec{Rate: 125000},
+					Buffer:  10000,
+					Cbuffer: 10000,
+				},
+			},
+		},
+	}
+}
+
+func matchall(parent, prio, handle, classID uint32) *tc.Object {
+	return &tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: ifindex,
+			Handle:  handle,
+			Parent:  parent,
+			Info:    prio<<16 | 0x0300, // ETH_P_ALL in network byte order
+		},
+		Attribute: tc.Attribute{
+			Kind:     "matchall",
+			Matchall: &tc.Matchall{ClassID: &classID},
+		},
+	}
+}
+
+func handles(objs []tc.Object) []uint32 {
+	var res []uint32
+	for _, obj := range objs {
+		res = append(res, obj.Handle)
+	}
+	return res
+}
+
+func TestQdisc(t *testing.T) {
+	k := NewKernel()
+	k.AddLink(ifindex)
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+	root := core.BuildHandle(0x1, 0x0)
+	if err := tcnl.Qdisc().Add(htbQdisc(root, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(htbQdisc(root, tc.HandleRoot)); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST but got: %v", err)
+	}
+	if err := tcnl.Qdisc().Replace(htbQdisc(root, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not replace qdisc: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(htbQdisc(0, core.BuildHandle(0x1, 0x1))); !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expected ENOENT but got: %v", err)
+	}
+	if err := tcnl.Class().Add(htbClass(core.BuildHandle(0x1, 0x1), root)); err != nil {
+		t.Fatalf("could not add class: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(htbQdisc(0, core.BuildHandle(0x1, 0x1))); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(htbQdisc(root, tc.HandleRoot)); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST but got: %v", err)
+	}
+	missing := htbQdisc(root, tc.HandleRoot)
+	missing.Ifindex = 42
+	if err := tcnl.Qdisc().Add(missing); !errors.Is(err, unix.ENODEV) {
+		t.Fatalf("expected ENODEV but got: %v", err)
+	}
+
+	qdiscs, err := tcnl.Qdisc().Get()
+	if err != nil {
+		t.Fatalf("could not get qdiscs: %v", err)
+	}
+	if diff := cmp.Diff([]uint32{root, core.BuildHandle(0x8001, 0x0)}, handles(qdiscs)); diff != "" {
+		t.Fatalf("qdiscs missmatch (want +got):\n%s", diff)
+	}
+	if qdiscs[0].Htb == nil || qdiscs[0].Htb.Init == nil || qdiscs[0].Htb.Init.Rate2Quantum != 10 {
+		t.Fatalf("unexpected options of qdisc: %#v", qdiscs[0].Htb)
+	}
+
+	classes, err := tcnl.Class().Get(&tc.Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex})
+	if err != nil {
+		t.Fatalf("could not get classes: %v", err)
+	}
+	if diff := cmp.Diff([]uint32{core.BuildHandle(0x1, 0x1)}, handles(classes)); diff != "" {
+		t.Fatalf("classes missmatch (want +got):\n%s", diff)
+	}
+
+	if err := tcnl.Qdisc().Delete(htbQdisc(root, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not delete qdisc: %v", err)
+	}
+	if err := tcnl.Qdisc().Delete(htbQdisc(root, tc.HandleRoot)); !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expected ENOENT but got: %v", err)
+	}
+	qdiscs, err = tcnl.Qdisc().Get()
+	if err != nil {
+		t.Fatalf("could not get qdiscs: %v", err)
+	}
+	if len(qdiscs) != 0 {
+		t.Fatalf("expected no qdiscs but got %d", len(qdiscs))
+	}
+	classes, err = tcnl.Class().Get(&tc.Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex})
+	if err != nil {
+		t.Fatalf("could not get classes: %v", err)
+	}
+	if len(classes) != 0 {
+		t.Fatalf("expected no classes but got %d", len(classes))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	k := NewKernel()
+	k.AddLink(ifindex)
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+	ingress := core.BuildHandle(tc.HandleRoot, tc.HandleMinIngress)
+	if err := tcnl.Filter().Add(matchall(ingress, 0, 0, 1)); !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expected ENOENT but got: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(&tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: ifindex,
+			Handle:  core.BuildHandle(tc.HandleRoot, 0x0),
+			Parent:  tc.HandleIngress,
+		},
+		Attribute: tc.Attribute{Kind: "clsact"},
+	}); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := tcnl.Filter().Add(matchall(ingress, 0, 0, uint32(i))); err != nil {
+			t.Fatalf("could not add filter: %v", err)
+		}
+	}
+	if err := tcnl.Filter().Add(matchall(ingress, 0xC000, 1, 2)); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST but got: %v", err)
+	}
+	if err := tcnl.Filter().Add(matchall(ingress, 0xC000, 2, 2)); err != nil {
+		t.Fatalf("could not add filter: %v", err)
+	}
+
+	filters, err := tcnl.Filter().Get(&tc.Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: ingress})
+	if err != nil {
+		t.Fatalf("could not get filters: %v", err)
+	}
+	type filter struct {
+		Prio   uint32
+		Handle uint32
+	}
+	var got []filter
+	for _, f := range filters {
+		got = append(got, filter{Prio: f.Info >> 16, Handle: f.Handle})
+	}
+	want := []filter{{0xBFFF, 0}, {0xBFFF, 1}, {0xC000, 0}, {0xC000, 1}, {0xC000, 2}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("filters missmatch (want +got):\n%s", diff)
+	}
+	if filters[1].Matchall == nil || filters[1].Matchall.ClassID == nil || *filters[1].Matchall.ClassID != 1 {
+		t.Fatalf("unexpected options of filter: %#v", filters[1].Matchall)
+	}
+
+	if err := tcnl.Filter().Delete(matchall(ingress, 0xC000, 3, 0)); !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expected ENOENT but got: %v", err)
+	}
+	if err := tcnl.Filter().Delete(matchall(ingress, 0xC000, 0, 0)); err != nil {
+		t.Fatalf("could not delete filters: %v", err)
+	}
+	filters, err = tcnl.Filter().Get(&tc.Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: ingress})
+	if err != nil {
+		t.Fatalf("could not get filters: %v", err)
+	}
+	if len(filters) != 2 {
+		t.Fatalf("expected 2 messages but got %d", len(filters))
+	}
+}
+
+func TestChain(t *testing.T) {
+	k := NewKernel()
+	k.AddLink(ifindex)
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+	root := core.BuildHandle(0x1, 0x0)
+	if err := tcnl.Qdisc().Add(htbQdisc(root, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	chain := func(index uint32) *tc.Object {
+		return &tc.Object{
+			Msg:       tc.Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: root},
+			Attribute: tc.Attribute{Chain: &index},
+		}
+	}
+	if err := tcnl.Chain().Add(chain(42)); err != nil {
+		t.Fatalf("could not add chain: %v", err)
+	}
+	if err := tcnl.Chain().Add(chain(42)); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST but got: %v", err)
+	}
+	chains, err := tcnl.Chain().Get(&tc.Msg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Parent: root})
+	if err != nil {
+		t.Fatalf("could not get chains: %v", err)
+	}
+	if len(chains) != 1 || chains[0].Chain == nil || *chains[0].Chain != 42 {
+		t.Fatalf("unexpected chains: %#v", chains)
+	}
+	if err := tcnl.Chain().Delete(chain(42)); err != nil {
+		t.Fatalf("could not delete chain: %v", err)
+	}
+	if err := tcnl.Chain().Delete(chain(42)); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("expected EINVAL but got: %v", err)
+	}
+}
+
+func TestActions(t *testing.T) {
+	k := NewKernel()
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+	gact := func(index uint32) *tc.Action {
+		return &tc.Action{
+			Kind: "gact",
+			Gact: &tc.Gact{Parms: &tc.GactParms{Index: index, Action: tc.ActShot}},
+		}
+	}
+	if err := tcnl.Actions().Add([]*tc.Action{gact(0), gact(7)}); err != nil {
+		t.Fatalf("could not add actions: %v", err)
+	}
+	if err := tcnl.Actions().Add([]*tc.Action{gact(7)}); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST but got: %v", err)
+	}
+	if err := tcnl.Actions().Add([]*tc.Action{gact(0)}); err != nil {
+		t.Fatalf("could not add action: %v", err)
+	}
+
+	dump, err := tcnl.Actions().Dump("gact", nil)
+	if err != nil {
+		t.Fatalf("could not dump actions: %v", err)
+	}
+	var indexes []uint32
+	for _, act := range dump.Actions {
+		indexes = append(indexes, act.Gact.Parms.Index)
+	}
+	if diff := cmp.Diff([]uint32{1, 2, 7}, indexes); diff != "" {
+		t.Fatalf("actions missmatch (want +got):\n%s", diff)
+	}
+	if dump.Count != 3 {
+		t.Fatalf("expected count 3 but got %d", dump.Count)
+	}
+
+	act, err := tcnl.Actions().GetByIndex("gact", 7)
+	if err != nil {
+		t.Fatalf("could not get action: %v", err)
+	}
+	if act.Index != 7 || act.Gact.Parms.Action != tc.ActShot {
+		t.Fatalf("unexpected action: %#v", act.Gact.Parms)
+	}
+	if _, err := tcnl.Actions().GetByIndex("gact", 3); !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expected ENOENT but got: %v", err)
+	}
+
+	if err := tcnl.Actions().Delete([]*tc.Action{gact(7)}); err != nil {
+		t.Fatalf("could not delete action: %v", err)
+	}
+	if err := tcnl.Actions().Delete([]*tc.Action{gact(7)}); !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expected ENOENT but got: %v", err)
+	}
+	if err := tcnl.Actions().Flush("gact"); err != nil {
+		t.Fatalf("could not flush actions: %v", err)
+	}
+	actions, err := tcnl.Actions().Get("gact")
+	if err != nil {
+		t.Fatalf("could not get actions: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("expected no actions but got %d", len(actions))
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	k := NewKernel()
+	k.AddLink(ifindex)
+	tcnl := k.Open()
+	defer tcnl.Close()
+	monitor := k.Open()
+	defer monitor.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan uint16, 2)
+	if err := monitor.MonitorWithErrorFunc(ctx, 10*time.Millisecond, func(action uint16, m tc.Object) int {
+		if m.Kind != "htb" {
+			t.Errorf("unexpected kind %q", m.Kind)
+		}
+		events <- action
+		return 0
+	}, func(err error) int {
+		return 1
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+
+	root := core.BuildHandle(0x1, 0x0)
+	if err := tcnl.Qdisc().Add(htbQdisc(root, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	if err := tcnl.Qdisc().Delete(htbQdisc(root, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not delete qdisc: %v", err)
+	}
+
+	var got []uint16
+	for len(got) < 2 {
+		select {
+		case action := <-events:
+			got = append(got, action)
+		case <-ctx.Done():
+			t.Fatalf("missing events: %v", got)
+		}
+	}
+	if diff := cmp.Diff([]uint16{unix.RTM_NEWQDISC, unix.RTM_DELQDISC}, got); diff != "" {
+		t.Fatalf("events missmatch (want +got):\n%s", diff)
+	}
+}
+
+func TestOverrun(t *testing.T) {
+	k := NewKernel()
+	k.AddLink(ifindex)
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	if err := tcnl.MonitorWithErrorFunc(ctx, 10*time.Millisecond, func(action uint16, m tc.Object) int {
+		return 0
+	}, func(err error) int {
+		errs <- err
+		return 1
+	}); err != nil {
+		t.Fatalf("could not start monitor: %v", err)
+	}
+
+	k.Overrun()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, unix.ENOBUFS) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("missing error")
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	k := NewKernel()
+	k.AddLink(ifindex)
+	tcnl := k.Open()
+	defer tcnl.Close()
+
+	if err := tcnl.Qdisc().Add(htbQdisc(core.BuildHandle(0x1, 0x0), tc.HandleRoot)); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	// TCA_OPTIONS, whose length does not fit into its header, can not be marshaled.
+	k.mu.Lock()
+	q := k.links[ifindex].qdiscs[0]
+	q.attrs = append(q.attrs, netlink.Attribute{Type: 2, Data: make([]byte, 0xfffc)})
+	k.mu.Unlock()
+
+	if _, err := tcnl.Qdisc().Get(); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("expected EINVAL but got: %v", err)
+	}
+	if err := tcnl.Qdisc().Delete(htbQdisc(core.BuildHandle(0x1, 0x0), tc.HandleRoot)); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("expected EINVAL but got: %v", err)
+	}
+}
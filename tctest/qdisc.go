@@ -0,0 +1,468 @@
+package tctest
+
+import (
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
+)
+
+// Qdiscs with classes, that need to be created explicitly. All other qdiscs
+// accept child qdiscs and filters for any minor.
+var explicitClasses = map[string]bool{
+	"atm":  true,
+	"cbq":  true,
+	"drr":  true,
+	"hfsc": true,
+	"htb":  true,
+	"qfq":  true,
+}
+
+type qdisc struct {
+	handle uint32
+	parent uint32
+	kind   string
+	attrs  []netlink.Attribute
+}
+
+type class struct {
+	handle uint32
+	parent uint32
+	kind   string
+	attrs  []netlink.Attribute
+}
+
+func (q *qdisc) marshal(ifindex uint32) ([]byte, error) {
+	msg := tcMsg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Handle: q.handle, Parent: q.parent}
+	return msg.marshal(append([]netlink.Attribute{kindAttribute(q.kind)}, q.attrs...))
+}
+
+func (c *class) marshal(ifindex uint32) ([]byte, error) {
+	msg := tcMsg{Family: unix.AF_UNSPEC, Ifindex: ifindex, Handle: c.handle, Parent: c.parent}
+	return msg.marshal(append([]netlink.Attribute{kindAttribute(c.kind)}, c.attrs...))
+}
+
+func (l *link) qdiscByHandle(handle uint32) *qdisc {
+	for _, q := range l.qdiscs {
+		if q.handle == major(handle) {
+			return q
+		}
+	}
+	return nil
+}
+
+func (l *link) qdiscByParent(parent uint32) *qdisc {
+	for _, q := range l.qdiscs {
+		if q.parent == parent {
+			return q
+		}
+	}
+	return nil
+}
+
+func (l *link) class(handle uint32) *class {
+	for _, c := range l.classes {
+		if c.handle == handle {
+			return c
+		}
+	}
+	return nil
+}
+
+// slot resolves the parent of a qdisc and checks, that it exists.
+func (l *link) slot(parent uint32) (uint32, error) {
+	switch {
+	case parent == tc.HandleRoot:
+		return parent, nil
+	case major(parent) == major(tc.HandleIngress):
+		return tc.HandleIngress, nil
+	}
+	q := l.qdiscByHandle(parent)
+	if q == nil {
+		return 0, unix.ENOENT
+	}
+	if explicitClasses[q.kind] && l.class(parent) == nil {
+		return 0, unix.ENOENT
+	}
+	return parent, nil
+}
+
+func (k *Kernel) newQdisc(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	kind := kindOf(attrs)
+	if k.disabled[kind] {
+		return unix.ENOENT
+	}
+	if minor(msg.Handle) != 0 {
+		return unix.EINVAL
+	}
+
+	var q *qdisc
+	var parent uint32
+	if msg.Parent != 0 {
+		if parent, err = l.slot(msg.Parent); err != nil {
+			return err
+		}
+		if parent == tc.HandleIngress {
+			if kind != "" && kind != "ingress" && kind != "clsact" {
+				return unix.EINVAL
+			}
+			if msg.Handle != 0 && msg.Handle != major(tc.HandleIngress) {
+				return unix.EINVAL
+			}
+		}
+		q = l.qdiscByParent(parent)
+		if q == nil || msg.Handle == 0 || q.handle != msg.Handle {
+			if msg.Handle != 0 {
+				if q != nil && !isReplace(req) {
+					return unix.EEXIST
+				}
+				if other := l.qdiscByHandle(msg.Handle); other != nil {
+					if isExcl(req) {
+						return unix.EEXIST
+					}
+					// Moving a qdisc to another parent is not supported.
+					return unix.EINVAL
+				}
+				return k.createQdisc(req, l, parent, msg.Handle, kind, attrs, q)
+			}
+			if q == nil || (isCreate(req) && isReplace(req) && (isExcl(req) || (kind != "" && kind != q.kind))) {
+				return k.createQdisc(req, l, parent, msg.Handle, kind, attrs, q)
+			}
+		}
+	} else {
+		if msg.Handle == 0 {
+			return unix.EINVAL
+		}
+		q = l.qdiscByHandle(msg.Handle)
+	}
+
+	// Change the existing qdisc.
+	if q == nil {
+		return unix.ENOENT
+	}
+	if isExcl(req) {
+		return unix.EEXIST
+	}
+	if kind != "" && kind != q.kind {
+		return unix.EINVAL
+	}
+	q.attrs = without(attrs, tcaKind)
+	data, err := q.marshal(l.ifindex)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_NEWQDISC, data)
+	return nil
+}
+
+// createQdisc adds a new qdisc at parent and removes old, if it is not nil.
+func (k *Kernel) createQdisc(req netlink.Message, l *link, parent, handle uint32, kind string,
+	attrs []netlink.Attribute, old *qdisc) error {
+	if !isCreate(req) {
+		return unix.ENOENT
+	}
+	if kind == "" {
+		return unix.EINVAL
+	}
+	if handle == 0 {
+		if parent == tc.HandleIngress {
+			handle = major(tc.HandleIngress)
+		} else {
+			handle = k.allocQdiscHandle(l)
+		}
+	}
+	if old != nil {
+		k.removeQdisc(l, old)
+		data, err := old.marshal(l.ifindex)
+		if err != nil {
+			return err
+		}
+		k.notify(unix.RTM_DELQDISC, data)
+	}
+	q := &qdisc{handle: handle, parent: parent, kind: kind, attrs: without(attrs, tcaKind)}
+	l.qdiscs = append(l.qdiscs, q)
+	data, err := q.marshal(l.ifindex)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_NEWQDISC, data)
+	return nil
+}
+
+// allocQdiscHandle returns an unused handle as qdisc_alloc_handle() does.
+func (k *Kernel) allocQdiscHandle(l *link) uint32 {
+	for {
+		k.autoHandle += 0x10000
+		if k.autoHandle == major(tc.HandleRoot) {
+			k.autoHandle = 0x80000000
+		}
+		if l.qdiscByHandle(k.autoHandle) == nil {
+			return k.autoHandle
+		}
+	}
+}
+
+// removeQdisc removes q with its classes, filters and child qdiscs.
+func (k *Kernel) removeQdisc(l *link, q *qdisc) {
+	var qdiscs []*qdisc
+	var children []*qdisc
+	for _, other := range l.qdiscs {
+		switch {
+		case other == q:
+		case other.parent != tc.HandleRoot && other.parent != tc.HandleIngress &&
+			major(other.parent) == q.handle:
+			children = append(children, other)
+			qdiscs = append(qdiscs, other)
+		default:
+			qdiscs = append(qdiscs, other)
+		}
+	}
+	l.qdiscs = qdiscs
+
+	var classes []*class
+	for _, c := range l.classes {
+		if major(c.handle) != q.handle {
+			classes = append(classes, c)
+		}
+	}
+	l.classes = classes
+
+	for id := range l.blocks {
+		if major(id) == q.handle {
+			delete(l.blocks, id)
+		}
+	}
+
+	for _, child := range children {
+		k.removeQdisc(l, child)
+	}
+}
+
+func (k *Kernel) delQdisc(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	var q *qdisc
+	if msg.Parent != 0 {
+		parent, err := l.slot(msg.Parent)
+		if err != nil {
+			return err
+		}
+		q = l.qdiscByParent(parent)
+		if q != nil && msg.Handle != 0 && q.handle != msg.Handle {
+			return unix.EINVAL
+		}
+	} else {
+		q = l.qdiscByHandle(msg.Handle)
+	}
+	if q == nil {
+		return unix.ENOENT
+	}
+	if kind := kindOf(attrs); kind != "" && kind != q.kind {
+		return unix.EINVAL
+	}
+	k.removeQdisc(l, q)
+	data, err := q.marshal(l.ifindex)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_DELQDISC, data)
+	return nil
+}
+
+func (k *Kernel) getQdiscs(req netlink.Message) ([]netlink.Message, error) {
+	if len(req.Data) < tcMsgLen {
+		return nil, unix.EINVAL
+	}
+	var msgs []netlink.Message
+	for _, l := range k.sortedLinks() {
+		// The root qdisc is reported first, the ingress qdisc last.
+		var ordered []*qdisc
+		if q := l.qdiscByParent(tc.HandleRoot); q != nil {
+			ordered = append(ordered, q)
+		}
+		for _, q := range l.qdiscs {
+			if q.parent != tc.HandleRoot && q.parent != tc.HandleIngress {
+				ordered = append(ordered, q)
+			}
+		}
+		if q := l.qdiscByParent(tc.HandleIngress); q != nil {
+			ordered = append(ordered, q)
+		}
+		for _, q := range ordered {
+			data, err := q.marshal(l.ifindex)
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, netlink.Message{
+				Header: netlink.Header{Type: unix.RTM_NEWQDISC},
+				Data:   data,
+			})
+		}
+	}
+	return msgs, nil
+}
+
+// classQdisc returns the qdisc, that holds the class handle with parent.
+func (l *link) classQdisc(handle, parent uint32) (*qdisc, uint32, uint32, error) {
+	var qid uint32
+	if parent != tc.HandleRoot {
+		qid = major(parent)
+	}
+	if handle != 0 {
+		if qid != 0 && qid != major(handle) {
+			return nil, 0, 0, unix.EINVAL
+		}
+		qid = major(handle)
+	}
+	var q *qdisc
+	if qid == 0 {
+		q = l.qdiscByParent(tc.HandleRoot)
+	} else {
+		q = l.qdiscByHandle(qid)
+	}
+	if q == nil {
+		return nil, 0, 0, unix.ENOENT
+	}
+	if parent != tc.HandleRoot && parent != 0 {
+		parent = q.handle | minor(parent)
+	}
+	if handle != 0 {
+		handle = q.handle | minor(handle)
+	}
+	return q, handle, parent, nil
+}
+
+func (k *Kernel) newClass(req netlink.Message) error {
+	msg, attrs, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	q, handle, parent, err := l.classQdisc(msg.Handle, msg.Parent)
+	if err != nil {
+		return err
+	}
+	if !explicitClasses[q.kind] {
+		return unix.EOPNOTSUPP
+	}
+	kind := kindOf(attrs)
+	if kind != "" && kind != q.kind {
+		return unix.EINVAL
+	}
+
+	if c := l.class(handle); handle != 0 && c != nil {
+		if isExcl(req) {
+			return unix.EEXIST
+		}
+		c.attrs = without(attrs, tcaKind)
+		data, err := c.marshal(l.ifindex)
+		if err != nil {
+			return err
+		}
+		k.notify(unix.RTM_NEWTCLASS, data)
+		return nil
+	}
+	if !isCreate(req) {
+		return unix.ENOENT
+	}
+	if minor(handle) == 0 {
+		return unix.EINVAL
+	}
+	if parent == 0 {
+		parent = tc.HandleRoot
+	}
+	if parent != tc.HandleRoot && minor(parent) != 0 && l.class(parent) == nil {
+		return unix.ENOENT
+	}
+	c := &class{handle: handle, parent: parent, kind: q.kind, attrs: without(attrs, tcaKind)}
+	l.classes = append(l.classes, c)
+	data, err := c.marshal(l.ifindex)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_NEWTCLASS, data)
+	return nil
+}
+
+func (k *Kernel) delClass(req netlink.Message) error {
+	msg, _, err := parseTcMsg(req.Data)
+	if err != nil {
+		return err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return err
+	}
+	_, handle, _, err := l.classQdisc(msg.Handle, msg.Parent)
+	if err != nil {
+		return err
+	}
+	c := l.class(handle)
+	if handle == 0 || c == nil {
+		return unix.ENOENT
+	}
+	for _, other := range l.classes {
+		if other.parent == c.handle {
+			return unix.EBUSY
+		}
+	}
+	if leaf := l.qdiscByParent(c.handle); leaf != nil {
+		k.removeQdisc(l, leaf)
+	}
+	delete(l.blocks, c.handle)
+	var classes []*class
+	for _, other := range l.classes {
+		if other != c {
+			classes = append(classes, other)
+		}
+	}
+	l.classes = classes
+	data, err := c.marshal(l.ifindex)
+	if err != nil {
+		return err
+	}
+	k.notify(unix.RTM_DELTCLASS, data)
+	return nil
+}
+
+func (k *Kernel) getClasses(req netlink.Message) ([]netlink.Message, error) {
+	msg, _, err := parseTcMsg(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	l, err := k.link(msg.Ifindex)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []netlink.Message
+	for _, c := range l.classes {
+		if msg.Parent != 0 && msg.Parent != tc.HandleRoot && major(c.handle) != major(msg.Parent) {
+			continue
+		}
+		data, err := c.marshal(l.ifindex)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, netlink.Message{
+			Header: netlink.Header{Type: unix.RTM_NEWTCLASS},
+			Data:   data,
+		})
+	}
+	return msgs, nil
+}
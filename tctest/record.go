@@ -0,0 +1,204 @@
+package tctest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/mdlayher/netlink"
+)
+
+// RecordingVersion is the version of the on-disk format, that is written by a Recorder.
+const RecordingVersion = 1
+
+// Operations of a recorded event.
+const (
+	opSend    = "send"
+	opReceive = "receive"
+)
+
+// Errors of a recorded Receive, that are not reported by the kernel with an errno.
+const (
+	errTimeout = "timeout"
+	errClosed  = "closed"
+)
+
+// event is a single recorded call to Send or Receive.
+type event struct {
+	Op string `json:"op"`
+	// Messages holds the binary encoding of the sent message or the received messages.
+	Messages [][]byte `json:"messages,omitempty"`
+	Errno    int      `json:"errno,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// recording is the on-disk format of a netlink conversation.
+type recording struct {
+	Version int     `json:"version"`
+	Events  []event `json:"events"`
+}
+
+// Recorder wraps a tc.Conn and records the requests and replies, that pass through it.
+// The recording can be replayed with a Replay.
+//
+// Requests and replies are recorded in the order of the calls to Send and Receive. So the
+// wrapped connection should not be used concurrently, e.g. by Tc.Monitor and other
+// requests at the same time.
+type Recorder struct {
+	conn tc.Conn
+
+	mu     sync.Mutex
+	events []event
+}
+
+var _ tc.Conn = (*Recorder)(nil)
+
+// NewRecorder returns a Recorder, that forwards all calls to conn.
+func NewRecorder(conn tc.Conn) *Recorder {
+	return &Recorder{conn: conn}
+}
+
+func (r *Recorder) record(e event) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// WriteTo writes the recording to w.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(recording{Version: RecordingVersion, Events: r.events}, "", "\t")
+	r.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Save writes the recording to the file with the given name.
+func (r *Recorder) Save(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Close closes the wrapped connection.
+func (r *Recorder) Close() error {
+	return r.conn.Close()
+}
+
+// JoinGroup joins the multicast group on the wrapped connection.
+func (r *Recorder) JoinGroup(group uint32) error {
+	return r.conn.JoinGroup(group)
+}
+
+// LeaveGroup leaves the multicast group on the wrapped connection.
+func (r *Recorder) LeaveGroup(group uint32) error {
+	return r.conn.LeaveGroup(group)
+}
+
+// SetOption sets the option on the wrapped connection.
+func (r *Recorder) SetOption(option netlink.ConnOption, enable bool) error {
+	return r.conn.SetOption(option, enable)
+}
+
+// SetReadDeadline sets the read deadline on the wrapped connection.
+func (r *Recorder) SetReadDeadline(t time.Time) error {
+	return r.conn.SetReadDeadline(t)
+}
+
+// Send sends m on the wrapped connection and records the message as it was sent.
+func (r *Recorder) Send(m netlink.Message) (netlink.Message, error) {
+	sent, err := r.conn.Send(m)
+	if err != nil {
+		e := recordError(err)
+		e.Op = opSend
+		r.record(e)
+		return sent, err
+	}
+	data, err := sent.MarshalBinary()
+	if err != nil {
+		return sent, err
+	}
+	r.record(event{Op: opSend, Messages: [][]byte{data}})
+	return sent, nil
+}
+
+// Receive receives messages from the wrapped connection and records them.
+func (r *Recorder) Receive() ([]netlink.Message, error) {
+	msgs, err := r.conn.Receive()
+	if err != nil {
+		e := recordError(err)
+		e.Op = opReceive
+		r.record(e)
+		return msgs, err
+	}
+	e := event{Op: opReceive}
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			return msgs, err
+		}
+		e.Messages = append(e.Messages, data)
+	}
+	r.record(e)
+	return msgs, nil
+}
+
+// recordError returns the event for err.
+func recordError(err error) event {
+	var errno syscall.Errno
+	switch {
+	case errors.As(err, &errno):
+		return event{Errno: int(errno)}
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return event{Error: errTimeout}
+	case errors.Is(err, os.ErrClosed), errors.Is(err, net.ErrClosed):
+		return event{Error: errClosed}
+	}
+	return event{Error: err.Error()}
+}
+
+// replayError returns the error of a recorded event.
+func (e event) replayError() error {
+	var err error
+	switch {
+	case e.Errno != 0:
+		err = syscall.Errno(e.Errno)
+	case e.Error == errTimeout:
+		err = os.ErrDeadlineExceeded
+	case e.Error == errClosed:
+		err = net.ErrClosed
+	case e.Error != "":
+		err = errors.New(e.Error)
+	default:
+		return nil
+	}
+	return &netlink.OpError{Op: e.Op, Err: err}
+}
+
+// readRecording parses a recording, that was written by a Recorder.
+func readRecording(r io.Reader) (*recording, error) {
+	rec := &recording{}
+	if err := json.NewDecoder(r).Decode(rec); err != nil {
+		return nil, fmt.Errorf("tctest: could not decode recording: %w", err)
+	}
+	if rec.Version != RecordingVersion {
+		return nil, fmt.Errorf("tctest: unsupported recording version %d", rec.Version)
+	}
+	return rec, nil
+}
@@ -0,0 +1,334 @@
+package tctest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
+)
+
+// ErrMismatch is returned by Replay, if a request does not match the recording.
+var ErrMismatch = errors.New("request does not match the recording")
+
+// MatchMode defines how a Replay compares requests with the recording.
+type MatchMode int
+
+// Supported MatchModes. Sequence numbers and port IDs are ignored by all of them.
+const (
+	// MatchBytes requires requests to be identical to the recorded ones.
+	MatchBytes MatchMode = iota
+	// MatchAttributes requires requests to have the same header and attributes as the
+	// recorded ones, but ignores the order of attributes.
+	MatchAttributes
+)
+
+// Replay implements tc.Conn and answers requests with the replies of a recording, that
+// was written by a Recorder.
+type Replay struct {
+	mode MatchMode
+
+	mu       sync.Mutex
+	events   []event
+	pos      int
+	deadline time.Time
+	closed   bool
+	err      error
+	// notify wakes up a Receive, that waits for the deadline or Close.
+	notify chan struct{}
+}
+
+var _ tc.Conn = (*Replay)(nil)
+
+// NewReplay returns a Replay for the recording, that is read from r.
+func NewReplay(r io.Reader, mode MatchMode) (*Replay, error) {
+	rec, err := readRecording(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Replay{mode: mode, events: rec.Events, notify: make(chan struct{}, 1)}, nil
+}
+
+// OpenReplay returns a Replay for the recording in the file with the given name.
+func OpenReplay(name string, mode MatchMode) (*Replay, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplay(f, mode)
+}
+
+// Done returns an error, if a request did not match the recording or if not all
+// recorded requests were sent.
+func (r *Replay) Done() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	for _, e := range r.events[r.pos:] {
+		if e.Op == opSend {
+			return fmt.Errorf("tctest: %d of %d recorded events were not replayed",
+				len(r.events)-r.pos, len(r.events))
+		}
+	}
+	return nil
+}
+
+// next returns the next recorded event, if it is of operation op.
+func (r *Replay) next(op string) (event, error) {
+	if r.closed {
+		return event{}, &netlink.OpError{Op: op, Err: net.ErrClosed}
+	}
+	if r.pos >= len(r.events) {
+		return event{}, &netlink.OpError{Op: op, Err: io.EOF}
+	}
+	e := r.events[r.pos]
+	if e.Op != op {
+		err := fmt.Errorf("tctest: unexpected %s, the recording continues with %s: %w", op, e.Op, ErrMismatch)
+		r.setErr(err)
+		return event{}, err
+	}
+	r.pos++
+	return e, nil
+}
+
+func (r *Replay) wakeup() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Replay) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Close closes the Replay.
+func (r *Replay) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.wakeup()
+	return nil
+}
+
+// JoinGroup has no effect as notifications are part of the recording.
+func (r *Replay) JoinGroup(uint32) error {
+	return nil
+}
+
+// LeaveGroup has no effect as notifications are part of the recording.
+func (r *Replay) LeaveGroup(uint32) error {
+	return nil
+}
+
+// SetOption has no effect.
+func (r *Replay) SetOption(netlink.ConnOption, bool) error {
+	return nil
+}
+
+// SetReadDeadline limits the time Receive waits, once the recording is exhausted.
+func (r *Replay) SetReadDeadline(t time.Time) error {
+	r.mu.Lock()
+	r.deadline = t
+	r.mu.Unlock()
+	r.wakeup()
+	return nil
+}
+
+// Send compares m with the next recorded request.
+func (r *Replay) Send(m netlink.Message) (netlink.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.next(opSend)
+	if err != nil {
+		return netlink.Message{}, err
+	}
+	if err := e.replayError(); err != nil {
+		return netlink.Message{}, err
+	}
+	if len(e.Messages) != 1 {
+		return netlink.Message{}, fmt.Errorf("tctest: invalid recording of a request")
+	}
+	var want netlink.Message
+	if err := want.UnmarshalBinary(e.Messages[0]); err != nil {
+		return netlink.Message{}, fmt.Errorf("tctest: invalid recording of a request: %w", err)
+	}
+	// Sequence numbers and port IDs differ between sessions.
+	m.Header.Sequence = want.Header.Sequence
+	m.Header.PID = want.Header.PID
+	if m.Header.Length == 0 {
+		m.Header.Length = uint32(nlmsgAlign(nlmsgHeaderLen + len(m.Data)))
+	}
+	if diff := r.diff(want, m); diff != "" {
+		err := fmt.Errorf("tctest: request %d (want -, got +):\n%s%w", r.pos, diff, ErrMismatch)
+		r.setErr(err)
+		return netlink.Message{}, err
+	}
+	return m, nil
+}
+
+// wait blocks, while the recording is exhausted, until the read deadline expires or the
+// Replay is closed. Like a netlink socket without pending messages, an exhausted Replay
+// does not return on its own. On success, wait returns with r.mu locked.
+func (r *Replay) wait() error {
+	for {
+		r.mu.Lock()
+		if r.pos < len(r.events) || r.closed {
+			return nil
+		}
+		deadline := r.deadline
+		r.mu.Unlock()
+
+		if deadline.IsZero() {
+			<-r.notify
+			continue
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return &netlink.OpError{Op: opReceive, Err: os.ErrDeadlineExceeded}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Receive returns the next recorded replies. Once the recording is exhausted, Receive
+// blocks until the read deadline expires or the Replay is closed.
+func (r *Replay) Receive() ([]netlink.Message, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	defer r.mu.Unlock()
+
+	e, err := r.next(opReceive)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.replayError(); err != nil {
+		return nil, err
+	}
+	msgs := make([]netlink.Message, 0, len(e.Messages))
+	for _, data := range e.Messages {
+		var msg netlink.Message
+		if err := msg.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("tctest: invalid recording of a reply: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// diff returns a description of the differences between want and got.
+func (r *Replay) diff(want, got netlink.Message) string {
+	wantData, err := want.MarshalBinary()
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	gotData, err := got.MarshalBinary()
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	if bytes.Equal(wantData, gotData) {
+		return ""
+	}
+	wantLines := describe(want)
+	gotLines := describe(got)
+	if r.mode == MatchBytes {
+		return diffLines(wantLines, gotLines, false) +
+			fmt.Sprintf("-%s\n+%s\n", hex.EncodeToString(wantData), hex.EncodeToString(gotData))
+	}
+	return diffLines(wantLines, gotLines, true)
+}
+
+// describe returns the header fields and attributes of a request as lines of text.
+func describe(m netlink.Message) []string {
+	lines := []string{
+		fmt.Sprintf("type: %d", m.Header.Type),
+		fmt.Sprintf("flags: %#x", uint16(m.Header.Flags)),
+	}
+	hdrLen := tcMsgLen
+	switch int(m.Header.Type) {
+	case unix.RTM_NEWACTION, unix.RTM_DELACTION, unix.RTM_GETACTION:
+		hdrLen = tcaMsgLen
+	case unix.RTM_GETLINK:
+		hdrLen = 16 // struct ifinfomsg
+	}
+	if len(m.Data) < hdrLen {
+		return append(lines, fmt.Sprintf("data: %x", m.Data))
+	}
+	lines = append(lines, fmt.Sprintf("header: %x", m.Data[:hdrLen]))
+	return append(lines, describeAttributes("", m.Data[hdrLen:])...)
+}
+
+// describeAttributes returns one line for each attribute in data. As it is unknown,
+// whether an attribute holds nested attributes, data that can be parsed as
+// attributes is treated as such.
+func describeAttributes(prefix string, data []byte) []string {
+	attrs, err := netlink.UnmarshalAttributes(data)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %x", prefix, data)}
+	}
+	var lines []string
+	for _, attr := range attrs {
+		path := fmt.Sprintf("%s/%d", prefix, attr.Type&^(netlink.Nested|netlink.NetByteOrder))
+		if len(attr.Data) == 0 {
+			lines = append(lines, path+": ")
+			continue
+		}
+		lines = append(lines, describeAttributes(path, attr.Data)...)
+	}
+	return lines
+}
+
+// diffLines returns the lines, that are only in want or only in got. If unordered is
+// set, the order of the lines is ignored.
+func diffLines(want, got []string, unordered bool) string {
+	if unordered {
+		want = append([]string{}, want...)
+		got = append([]string{}, got...)
+		sort.Strings(want)
+		sort.Strings(got)
+	}
+	count := make(map[string]int)
+	for _, line := range got {
+		count[line]++
+	}
+	var b strings.Builder
+	for _, line := range want {
+		if count[line] > 0 {
+			count[line]--
+			continue
+		}
+		fmt.Fprintf(&b, "-%s\n", line)
+	}
+	for _, line := range got {
+		if count[line] > 0 {
+			count[line]--
+			fmt.Fprintf(&b, "+%s\n", line)
+		}
+	}
+	if !unordered && b.Len() == 0 {
+		b.WriteString("attributes differ in order\n")
+	}
+	return b.String()
+}
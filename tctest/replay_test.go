@@ -0,0 +1,195 @@
+package tctest
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/florianl/go-tc"
+	"github.com/florianl/go-tc/core"
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink"
+)
+
+// session runs a fixed sequence of requests and returns the handles of the dumped qdiscs.
+func session(t *testing.T, tcnl *tc.Tc, handle uint32) []uint32 {
+	t.Helper()
+	if err := tcnl.Qdisc().Add(htbQdisc(handle, tc.HandleRoot)); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	if err := tcnl.Qdisc().Add(htbQdisc(handle, tc.HandleRoot)); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST but got: %v", err)
+	}
+	qdiscs, err := tcnl.Qdisc().Get()
+	if err != nil {
+		t.Fatalf("could not get qdiscs: %v", err)
+	}
+	return handles(qdiscs)
+}
+
+func record(t *testing.T) []byte {
+	t.Helper()
+	k := NewKernel()
+	k.AddLink(ifindex)
+	rec := NewRecorder(k.Dial())
+	tcnl := tc.NewWithConn(rec)
+	defer tcnl.Close()
+
+	session(t, tcnl, core.BuildHandle(0x1, 0x0))
+	var buf bytes.Buffer
+	if _, err := rec.WriteTo(&buf); err != nil {
+		t.Fatalf("could not write recording: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReplay(t *testing.T) {
+	data := record(t)
+
+	for _, mode := range []MatchMode{MatchBytes, MatchAttributes} {
+		replay, err := NewReplay(bytes.NewReader(data), mode)
+		if err != nil {
+			t.Fatalf("could not read recording: %v", err)
+		}
+		got := session(t, tc.NewWithConn(replay), core.BuildHandle(0x1, 0x0))
+		if diff := cmp.Diff([]uint32{core.BuildHandle(0x1, 0x0)}, got); diff != "" {
+			t.Fatalf("qdiscs missmatch (want +got):\n%s", diff)
+		}
+		if err := replay.Done(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	data := record(t)
+
+	replay, err := NewReplay(bytes.NewReader(data), MatchBytes)
+	if err != nil {
+		t.Fatalf("could not read recording: %v", err)
+	}
+	tcnl := tc.NewWithConn(replay)
+	if err := tcnl.Qdisc().Add(htbQdisc(core.BuildHandle(0x2, 0x0), tc.HandleRoot)); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch but got: %v", err)
+	}
+	if err := replay.Done(); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch but got: %v", err)
+	}
+
+	replay, err = NewReplay(bytes.NewReader(data), MatchBytes)
+	if err != nil {
+		t.Fatalf("could not read recording: %v", err)
+	}
+	tcnl = tc.NewWithConn(replay)
+	if err := tcnl.Qdisc().Add(htbQdisc(core.BuildHandle(0x1, 0x0), tc.HandleRoot)); err != nil {
+		t.Fatalf("could not add qdisc: %v", err)
+	}
+	if err := replay.Done(); err == nil {
+		t.Fatal("expected an error for requests, that were not replayed")
+	}
+}
+
+func TestReplayExhausted(t *testing.T) {
+	data := record(t)
+	replay, err := NewReplay(bytes.NewReader(data), MatchBytes)
+	if err != nil {
+		t.Fatalf("could not read recording: %v", err)
+	}
+	session(t, tc.NewWithConn(replay), core.BuildHandle(0x1, 0x0))
+
+	// An exhausted recording waits for the deadline.
+	start := time.Now()
+	if err := replay.SetReadDeadline(start.Add(50 * time.Millisecond)); err != nil {
+		t.Fatalf("could not set deadline: %v", err)
+	}
+	if _, err := replay.Receive(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded but got: %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("Receive returned before the deadline")
+	}
+
+	// Without a deadline, it waits for Close.
+	if err := replay.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatalf("could not reset deadline: %v", err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := replay.Receive()
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		t.Fatalf("Receive returned without deadline: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	replay.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Fatalf("expected net.ErrClosed but got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Receive did not return after Close")
+	}
+}
+
+func TestReplayAttributeOrder(t *testing.T) {
+	attrs := []netlink.Attribute{
+		kindAttribute("htb"),
+		uint32Attribute(tcaChain, 42),
+	}
+	data, err := tcMsg{Ifindex: ifindex}.marshal(attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := netlink.Message{
+		Header: netlink.Header{Type: unix.RTM_NEWQDISC, Flags: netlink.Request | netlink.Acknowledge},
+		Data:   data,
+	}
+	data, err = tcMsg{Ifindex: ifindex}.marshal([]netlink.Attribute{attrs[1], attrs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reordered := netlink.Message{
+		Header: msg.Header,
+		Data:   data,
+	}
+	msg.Header.Length = uint32(nlmsgHeaderLen + len(msg.Data))
+	data, err = msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := recording{Version: RecordingVersion, Events: []event{{Op: opSend, Messages: [][]byte{data}}}}
+
+	tests := map[string]struct {
+		mode MatchMode
+		err  error
+	}{
+		"bytes":      {mode: MatchBytes, err: ErrMismatch},
+		"attributes": {mode: MatchAttributes},
+	}
+	for name, testcase := range tests {
+		t.Run(name, func(t *testing.T) {
+			replay := &Replay{mode: testcase.mode, events: rec.Events}
+			_, err := replay.Send(reordered)
+			if !errors.Is(err, testcase.err) {
+				t.Fatalf("expected %v but got: %v", testcase.err, err)
+			}
+		})
+	}
+}
+
+func TestReadRecording(t *testing.T) {
+	if _, err := NewReplay(strings.NewReader(`{"version": 42, "events": []}`), MatchBytes); err == nil {
+		t.Fatal("expected an error for an unsupported version")
+	}
+	if _, err := NewReplay(strings.NewReader(`{`), MatchBytes); err == nil {
+		t.Fatal("expected an error for an invalid recording")
+	}
+}
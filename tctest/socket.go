@@ -0,0 +1,183 @@
+package tctest
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/florianl/go-tc/internal/unix"
+	"github.com/mdlayher/netlink"
+)
+
+// socket implements netlink.Socket on top of a Kernel.
+type socket struct {
+	k   *Kernel
+	pid uint32
+
+	mu       sync.Mutex
+	batches  [][]netlink.Message
+	groups   map[uint32]bool
+	deadline time.Time
+	closed   bool
+	// overrun lets the next Receive fail with ENOBUFS.
+	overrun bool
+	notify  chan struct{}
+}
+
+func newSocket(k *Kernel, pid uint32) *socket {
+	return &socket{
+		k:      k,
+		pid:    pid,
+		groups: make(map[uint32]bool),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// wakeup signals a blocked Receive, that its state changed.
+func (s *socket) wakeup() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue adds a batch of messages, that is returned by a single call to Receive.
+func (s *socket) enqueue(msgs []netlink.Message) {
+	for i := range msgs {
+		if msgs[i].Header.Length == 0 {
+			msgs[i].Header.Length = uint32(nlmsgAlign(nlmsgHeaderLen + len(msgs[i].Data)))
+		}
+	}
+	s.mu.Lock()
+	s.batches = append(s.batches, msgs)
+	s.mu.Unlock()
+	s.wakeup()
+}
+
+// overflow drops the pending notifications and lets the next Receive fail with ENOBUFS.
+func (s *socket) overflow() {
+	s.mu.Lock()
+	var batches [][]netlink.Message
+	for _, batch := range s.batches {
+		// Notifications are not sent in reply to a request and carry no sequence number.
+		if len(batch) > 0 && batch[0].Header.Sequence != 0 {
+			batches = append(batches, batch)
+		}
+	}
+	s.batches = batches
+	s.overrun = true
+	s.mu.Unlock()
+	s.wakeup()
+}
+
+// joined reports whether the socket is a member of group.
+func (s *socket) joined(group uint32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.groups[group]
+}
+
+func (s *socket) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.k.detach(s)
+	s.wakeup()
+	return nil
+}
+
+func (s *socket) Send(m netlink.Message) error {
+	return s.SendMessages([]netlink.Message{m})
+}
+
+func (s *socket) SendMessages(msgs []netlink.Message) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return net.ErrClosed
+	}
+	for _, m := range msgs {
+		s.enqueue(s.k.handle(m))
+	}
+	return nil
+}
+
+func (s *socket) Receive() ([]netlink.Message, error) {
+	for {
+		s.mu.Lock()
+		if s.overrun {
+			s.overrun = false
+			s.mu.Unlock()
+			return nil, os.NewSyscallError("recvmsg", unix.ENOBUFS)
+		}
+		if len(s.batches) > 0 {
+			msgs := s.batches[0]
+			s.batches = s.batches[1:]
+			s.mu.Unlock()
+			return msgs, nil
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return nil, net.ErrClosed
+		}
+		deadline := s.deadline
+		waiting := len(s.groups) > 0
+		s.mu.Unlock()
+
+		if deadline.IsZero() {
+			if !waiting {
+				// Nothing is pending and nothing will arrive.
+				return nil, nil
+			}
+			<-s.notify
+			continue
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (s *socket) JoinGroup(group uint32) error {
+	s.mu.Lock()
+	s.groups[group] = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *socket) LeaveGroup(group uint32) error {
+	s.mu.Lock()
+	delete(s.groups, group)
+	s.mu.Unlock()
+	s.wakeup()
+	return nil
+}
+
+func (s *socket) SetOption(netlink.ConnOption, bool) error {
+	return nil
+}
+
+func (s *socket) SetDeadline(t time.Time) error {
+	return s.SetReadDeadline(t)
+}
+
+func (s *socket) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.deadline = t
+	s.mu.Unlock()
+	s.wakeup()
+	return nil
+}
+
+func (s *socket) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -20,12 +20,27 @@ var (
 
 	// ErrUnknownKind is returned for unknown qdisc, filter or class types.
 	ErrUnknownKind = errors.New("unknown kind")
+
+	// ErrAborted is reported for operations of a Batch, that were not sent, because a
+	// previous operation failed.
+	ErrAborted = errors.New("operation aborted")
 )
 
 // Config contains options for RTNETLINK
+//
+// At most one of NetNS, NetNSPath, NetNSName and NetNSPid may be set.
 type Config struct {
 	// NetNS defines the network namespace
 	NetNS int
+
+	// NetNSPath is the path of a network namespace, like a bind mount in /var/run/netns.
+	NetNSPath string
+
+	// NetNSName is the name of a network namespace, that was created by 'ip netns add'.
+	NetNSName string
+
+	// NetNSPid selects the network namespace of the process with this PID.
+	NetNSPid int
 }
 
 // Constants to define the direction
@@ -57,6 +72,8 @@ const (
 
 const (
 	// mask to differentiate between classes, qdiscs and filters
-	actionMask  = 0x3c
-	actionQdisc = 0x24
+	actionMask   = 0x3c
+	actionQdisc  = 0x24
+	actionClass  = 0x28
+	actionFilter = 0x2c
 )
@@ -0,0 +1,9 @@
+/*
+Package u32 contains helper functions to construct and interpret u32 filters of the package
+github.com/florianl/go-tc.
+
+It covers the semantics of tc-u32(8) for hash tables ("ht", "link", "divisor", "hashkey"),
+variable offsets ("offset at ... mask ... shift ...") and common matches on IPv4, IPv6 and
+L4 headers.
+*/
+package u32
@@ -0,0 +1,464 @@
+package u32
+
+import (
+	"fmt"
+	"math/bits"
+	"net/netip"
+
+	"github.com/florianl/go-tc"
+)
+
+// Match is a semantic match of a u32 filter.
+type Match interface {
+	fmt.Stringer
+	keys() ([]key, error)
+}
+
+// IPSrc matches the source address of an IPv4 or IPv6 header.
+type IPSrc struct {
+	Prefix netip.Prefix
+}
+
+// IPDst matches the destination address of an IPv4 or IPv6 header.
+type IPDst struct {
+	Prefix netip.Prefix
+}
+
+// IPProto matches the protocol of an IPv4 header.
+type IPProto struct {
+	Proto uint8
+}
+
+// IPv6Proto matches the next header field of an IPv6 header.
+type IPv6Proto struct {
+	Proto uint8
+}
+
+// SrcPort matches the source port of a TCP, UDP or SCTP header at the next header offset.
+// If Mask is 0, the port has to match exactly.
+type SrcPort struct {
+	Port uint16
+	Mask uint16
+}
+
+// DstPort matches the destination port of a TCP, UDP or SCTP header at the next header offset.
+// If Mask is 0, the port has to match exactly.
+type DstPort struct {
+	Port uint16
+	Mask uint16
+}
+
+// Raw matches the 32-bit word at offset Off. If NextHdr is set, Off is relative to the
+// next header offset.
+type Raw struct {
+	Val     uint32
+	Mask    uint32
+	Off     int32
+	NextHdr bool
+}
+
+func (m IPSrc) String() string     { return fmt.Sprintf("%s src %s", ipName(m.Prefix), m.Prefix) }
+func (m IPDst) String() string     { return fmt.Sprintf("%s dst %s", ipName(m.Prefix), m.Prefix) }
+func (m IPProto) String() string   { return fmt.Sprintf("ip protocol %d 0xff", m.Proto) }
+func (m IPv6Proto) String() string { return fmt.Sprintf("ip6 protocol %d 0xff", m.Proto) }
+func (m SrcPort) String() string   { return fmt.Sprintf("src port %d 0x%04x", m.Port, portMask(m.Mask)) }
+func (m DstPort) String() string   { return fmt.Sprintf("dst port %d 0x%04x", m.Port, portMask(m.Mask)) }
+
+func (m Raw) String() string {
+	at := fmt.Sprintf("%d", m.Off)
+	if m.NextHdr {
+		at = fmt.Sprintf("nexthdr+%d", m.Off)
+	}
+	return fmt.Sprintf("u32 0x%08x 0x%08x at %s", m.Val, m.Mask, at)
+}
+
+func ipName(p netip.Prefix) string {
+	if p.Addr().Is4() {
+		return "ip"
+	}
+	return "ip6"
+}
+
+func portMask(mask uint16) uint16 {
+	if mask == 0 {
+		return 0xFFFF
+	}
+	return mask
+}
+
+func (m IPSrc) keys() ([]key, error) {
+	if m.Prefix.Addr().Is4() {
+		return prefixKeys(m.Prefix, 12)
+	}
+	return prefixKeys(m.Prefix, 8)
+}
+
+func (m IPDst) keys() ([]key, error) {
+	if m.Prefix.Addr().Is4() {
+		return prefixKeys(m.Prefix, 16)
+	}
+	return prefixKeys(m.Prefix, 24)
+}
+
+func (m IPProto) keys() ([]key, error) {
+	return []key{{val: uint32(m.Proto) << 16, mask: 0x00FF0000, off: 8}}, nil
+}
+
+func (m IPv6Proto) keys() ([]key, error) {
+	return []key{{val: uint32(m.Proto) << 8, mask: 0x0000FF00, off: 4}}, nil
+}
+
+func (m SrcPort) keys() ([]key, error) {
+	mask := uint32(portMask(m.Mask)) << 16
+	return []key{{val: uint32(m.Port) << 16, mask: mask, nexthdr: true}}, nil
+}
+
+func (m DstPort) keys() ([]key, error) {
+	return []key{{val: uint32(m.Port), mask: uint32(portMask(m.Mask)), nexthdr: true}}, nil
+}
+
+func (m Raw) keys() ([]key, error) {
+	if m.Off%4 != 0 {
+		return nil, fmt.Errorf("offset %d is not 32-bit aligned: %w", m.Off, tc.ErrInvalidArg)
+	}
+	return []key{{val: m.Val, mask: m.Mask, off: m.Off, nexthdr: m.NextHdr}}, nil
+}
+
+// prefixKeys returns the keys to match prefix at the given offset.
+func prefixKeys(prefix netip.Prefix, off int32) ([]key, error) {
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("prefix %v: %w", prefix, tc.ErrInvalidArg)
+	}
+	prefix = prefix.Masked()
+	addr := prefix.Addr().AsSlice()
+	var keys []key
+	for i := 0; i < len(addr)/4; i++ {
+		ones := prefix.Bits() - i*32
+		if ones <= 0 {
+			break
+		}
+		if ones > 32 {
+			ones = 32
+		}
+		mask := uint32(0xFFFFFFFF) << (32 - ones)
+		val := uint32(addr[i*4])<<24 | uint32(addr[i*4+1])<<16 | uint32(addr[i*4+2])<<8 | uint32(addr[i*4+3])
+		keys = append(keys, key{val: val, mask: mask, off: off + int32(i*4)})
+	}
+	if len(keys) == 0 {
+		keys = append(keys, key{off: off})
+	}
+	return keys, nil
+}
+
+// Selector constructs a tc.U32Sel from semantic matches.
+type Selector struct {
+	keys     []key
+	hashKey  *HashKey
+	nextHdr  *NextHdr
+	terminal bool
+	err      error
+}
+
+// NewSelector returns a Selector for the given matches.
+func NewSelector(matches ...Match) *Selector {
+	s := &Selector{}
+	return s.Match(matches...)
+}
+
+// Match adds further matches to the selector. Matches on the same 32-bit word are
+// merged like tc-u32(8) does it. Contradicting matches result in an error on Build.
+func (s *Selector) Match(matches ...Match) *Selector {
+	for _, m := range matches {
+		keys, err := m.keys()
+		if err != nil {
+			s.err = err
+			return s
+		}
+		for _, k := range keys {
+			if err := s.pack(k); err != nil {
+				s.err = fmt.Errorf("%v: %w", m, err)
+				return s
+			}
+		}
+	}
+	return s
+}
+
+// pack merges k into the existing keys.
+func (s *Selector) pack(k key) error {
+	k.val &= k.mask
+	for i, e := range s.keys {
+		if e.off != k.off || e.nexthdr != k.nexthdr {
+			continue
+		}
+		if (e.val^k.val)&(e.mask&k.mask) != 0 {
+			return fmt.Errorf("contradicts previous match at offset %d: %w", k.off, tc.ErrInvalidArg)
+		}
+		s.keys[i].val |= k.val
+		s.keys[i].mask |= k.mask
+		return nil
+	}
+	if len(s.keys) >= 128 {
+		return fmt.Errorf("too many keys: %w", tc.ErrInvalidArg)
+	}
+	s.keys = append(s.keys, k)
+	return nil
+}
+
+// HashKey sets the hash key to select the bucket of a linked hash table.
+func (s *Selector) HashKey(h HashKey) *Selector {
+	if h.At%4 != 0 {
+		s.err = fmt.Errorf("hashkey offset %d is not 32-bit aligned: %w", h.At, tc.ErrInvalidArg)
+		return s
+	}
+	s.hashKey = &h
+	return s
+}
+
+// NextHdr sets the offset of the next header. It is used by matches on the next header
+// within the linked hash table.
+func (s *Selector) NextHdr(n NextHdr) *Selector {
+	s.nextHdr = &n
+	return s
+}
+
+// Terminal marks the selector as terminal. This is the case for filters which
+// classify packets or execute actions.
+func (s *Selector) Terminal() *Selector {
+	s.terminal = true
+	return s
+}
+
+// Build returns the selector for tc.U32.Sel.
+func (s *Selector) Build() (*tc.U32Sel, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	sel := &tc.U32Sel{}
+	if s.terminal {
+		sel.Flags |= FlagTerminal
+	}
+	if s.hashKey != nil {
+		sel.Hmask = s.hashKey.Mask
+		sel.Hoff = s.hashKey.At
+	}
+	if s.nextHdr != nil {
+		sel.Flags |= FlagOffset
+		sel.Off = s.nextHdr.Plus
+		if s.nextHdr.Mask != 0 {
+			sel.Flags |= FlagVarOffset
+			sel.Offoff = s.nextHdr.At
+			sel.OffMask = s.nextHdr.Mask
+			sel.Offshift = s.nextHdr.Shift
+		}
+		if s.nextHdr.Eat {
+			sel.Flags |= FlagEat
+		}
+	}
+	for _, k := range s.keys {
+		sel.Keys = append(sel.Keys, k.marshal())
+	}
+	sel.NKeys = uint8(len(sel.Keys))
+	return sel, nil
+}
+
+// Filter is the semantic representation of a u32 filter.
+type Filter struct {
+	// HashTable and Bucket identify the hash table bucket, the filter is placed in.
+	HashTable uint32
+	Bucket    uint8
+	// Link is the ID of the hash table, matching packets are passed to.
+	Link *uint32
+	// Divisor is set, if the filter represents a hash table.
+	Divisor  *uint32
+	HashKey  *HashKey
+	NextHdr  *NextHdr
+	Terminal bool
+	Matches  []Match
+}
+
+// Decode converts a dumped u32 filter into its semantic representation. As the offsets
+// of IPv4 and IPv6 header fields overlap, ipv6 has to be set for filters of protocol
+// IPv6. Keys that can not be mapped to a semantic match are returned as Raw.
+func Decode(info *tc.U32, ipv6 bool) (*Filter, error) {
+	if info == nil {
+		return nil, tc.ErrNoArg
+	}
+	f := &Filter{HashTable: RootHashTable, Divisor: info.Divisor}
+	if info.Hash != nil {
+		f.HashTable, f.Bucket, _ = SplitHandle(*info.Hash)
+	}
+	if info.Link != nil {
+		link, _, _ := SplitHandle(*info.Link)
+		f.Link = &link
+	}
+	sel := info.Sel
+	if sel == nil {
+		return f, nil
+	}
+	f.Terminal = sel.Flags&FlagTerminal != 0
+	if sel.Hmask != 0 {
+		f.HashKey = &HashKey{Mask: sel.Hmask, At: sel.Hoff}
+	}
+	if sel.Flags&(FlagOffset|FlagVarOffset) != 0 {
+		n := &NextHdr{Plus: sel.Off, Eat: sel.Flags&FlagEat != 0}
+		if sel.Flags&FlagVarOffset != 0 {
+			n.At = sel.Offoff
+			n.Mask = sel.OffMask
+			n.Shift = sel.Offshift
+		}
+		f.NextHdr = n
+	}
+	keys := make([]key, 0, len(sel.Keys))
+	for _, k := range sel.Keys {
+		keys = append(keys, unmarshalKey(k))
+	}
+	if ipv6 {
+		f.Matches = decodeIPv6(keys)
+	} else {
+		f.Matches = decodeIPv4(keys)
+	}
+	return f, nil
+}
+
+// field describes a header field within a 32-bit word.
+type field struct {
+	mask    uint32
+	nexthdr bool
+	off     int32
+	match   func(val uint32) Match
+}
+
+var ipv4Fields = []field{
+	{off: 8, mask: 0x00FF0000, match: func(v uint32) Match { return IPProto{Proto: uint8(v >> 16)} }},
+}
+
+var ipv6Fields = []field{
+	{off: 4, mask: 0x0000FF00, match: func(v uint32) Match { return IPv6Proto{Proto: uint8(v >> 8)} }},
+}
+
+// takeField extracts the bits of f from k, if k covers all of them.
+func takeField(k *key, f field) (Match, bool) {
+	if k.off != f.off || k.nexthdr != f.nexthdr || k.mask&f.mask != f.mask {
+		return nil, false
+	}
+	m := f.match(k.val & f.mask)
+	k.mask &^= f.mask
+	k.val &= k.mask
+	return m, true
+}
+
+// takePort extracts port matches from a key at the next header offset.
+func takePorts(k *key) []Match {
+	if !k.nexthdr || k.off != 0 {
+		return nil
+	}
+	var matches []Match
+	if mask := uint16(k.mask >> 16); mask != 0 {
+		m := SrcPort{Port: uint16(k.val >> 16)}
+		if mask != 0xFFFF {
+			m.Mask = mask
+		}
+		matches = append(matches, m)
+	}
+	if mask := uint16(k.mask); mask != 0 {
+		m := DstPort{Port: uint16(k.val)}
+		if mask != 0xFFFF {
+			m.Mask = mask
+		}
+		matches = append(matches, m)
+	}
+	k.mask = 0
+	return matches
+}
+
+// takeAddr extracts an address prefix of the given length in bytes at off from keys.
+func takeAddr(keys []key, off int32, length int) (netip.Prefix, bool) {
+	addr := make([]byte, length)
+	var maskBits []uint32
+	found := false
+	for i := 0; i < length/4; i++ {
+		var val, mask uint32
+		for _, k := range keys {
+			if !k.nexthdr && k.off == off+int32(i*4) {
+				val, mask = k.val, k.mask
+				found = true
+			}
+		}
+		addr[i*4] = byte(val >> 24)
+		addr[i*4+1] = byte(val >> 16)
+		addr[i*4+2] = byte(val >> 8)
+		addr[i*4+3] = byte(val)
+		maskBits = append(maskBits, mask)
+	}
+	if !found {
+		return netip.Prefix{}, false
+	}
+	// The masks have to form a contiguous prefix.
+	ones := 0
+	complete := true
+	for _, m := range maskBits {
+		lead := bits.LeadingZeros32(^m)
+		if lead < 32 && m<<lead != 0 {
+			return netip.Prefix{}, false
+		}
+		if !complete && m != 0 {
+			return netip.Prefix{}, false
+		}
+		complete = lead == 32
+		ones += lead
+	}
+	a, _ := netip.AddrFromSlice(addr)
+	return netip.PrefixFrom(a, ones), true
+}
+
+func decodeAddrs(keys []key, addrLen int, srcOff, dstOff int32, matches []Match) ([]key, []Match) {
+	for _, a := range []struct {
+		off int32
+		src bool
+	}{{srcOff, true}, {dstOff, false}} {
+		prefix, ok := takeAddr(keys, a.off, addrLen)
+		if !ok {
+			continue
+		}
+		if a.src {
+			matches = append(matches, IPSrc{Prefix: prefix})
+		} else {
+			matches = append(matches, IPDst{Prefix: prefix})
+		}
+		var rest []key
+		for _, k := range keys {
+			if !k.nexthdr && k.off >= a.off && k.off < a.off+int32(addrLen) {
+				continue
+			}
+			rest = append(rest, k)
+		}
+		keys = rest
+	}
+	return keys, matches
+}
+
+func decodeFields(keys []key, fields []field, matches []Match) []Match {
+	for _, k := range keys {
+		for _, f := range fields {
+			if m, ok := takeField(&k, f); ok {
+				matches = append(matches, m)
+			}
+		}
+		matches = append(matches, takePorts(&k)...)
+		if k.mask != 0 {
+			matches = append(matches, Raw{Val: k.val & k.mask, Mask: k.mask, Off: k.off, NextHdr: k.nexthdr})
+		}
+	}
+	return matches
+}
+
+func decodeIPv4(keys []key) []Match {
+	keys, matches := decodeAddrs(keys, 4, 12, 16, nil)
+	return decodeFields(keys, ipv4Fields, matches)
+}
+
+func decodeIPv6(keys []key) []Match {
+	keys, matches := decodeAddrs(keys, 16, 8, 24, nil)
+	return decodeFields(keys, ipv6Fields, matches)
+}
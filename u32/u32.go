@@ -0,0 +1,149 @@
+package u32
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/florianl/go-tc"
+	"github.com/josharian/native"
+)
+
+// Flags of the u32 selector from include/uapi/linux/pkt_cls.h
+const (
+	FlagTerminal  uint8 = 1
+	FlagOffset    uint8 = 2
+	FlagVarOffset uint8 = 4
+	FlagEat       uint8 = 8
+)
+
+// RootHashTable is the ID of the hash table, that is created by the kernel for each u32 instance.
+const RootHashTable uint32 = 0x800
+
+// constants from include/uapi/linux/pkt_cls.h
+const (
+	htidMask   uint32 = 0xFFF00000
+	bucketMask uint32 = 0x000FF000
+	nodeMask   uint32 = 0x00000FFF
+)
+
+// Handle builds the u32 handle htid:bucket:node.
+func Handle(htid uint32, bucket uint8, node uint32) uint32 {
+	return ((htid << 20) & htidMask) | (uint32(bucket) << 12) | (node & nodeMask)
+}
+
+// SplitHandle extracts the hash table ID, bucket and node from a given u32 handle.
+func SplitHandle(handle uint32) (htid uint32, bucket uint8, node uint32) {
+	htid = (handle & htidMask) >> 20
+	bucket = uint8((handle & bucketMask) >> 12)
+	node = handle & nodeMask
+	return htid, bucket, node
+}
+
+// Table describes a u32 hash table.
+type Table struct {
+	ID      uint32
+	Divisor uint32
+}
+
+// NewTable returns a hash table with the given ID and number of buckets.
+func NewTable(id, divisor uint32) (Table, error) {
+	if id == 0 || id > 0xFFF || id == RootHashTable {
+		return Table{}, fmt.Errorf("hash table ID %x: %w", id, tc.ErrInvalidArg)
+	}
+	if divisor == 0 || divisor > 256 || divisor&(divisor-1) != 0 {
+		return Table{}, fmt.Errorf("divisor %d is not a power of two up to 256: %w",
+			divisor, tc.ErrInvalidArg)
+	}
+	return Table{ID: id, Divisor: divisor}, nil
+}
+
+// Handle returns the handle, that has to be used in tc.Msg to create the hash table.
+func (t Table) Handle() uint32 {
+	return Handle(t.ID, 0, 0)
+}
+
+// Create returns the u32 filter attributes to create the hash table.
+func (t Table) Create() *tc.U32 {
+	divisor := t.Divisor
+	return &tc.U32{Divisor: &divisor}
+}
+
+// Bucket returns the value for tc.U32.Hash to place a filter in the given bucket of the hash table.
+func (t Table) Bucket(bucket uint8) uint32 {
+	return Handle(t.ID, bucket, 0)
+}
+
+// Link returns the u32 filter attributes, that link matching packets into the hash table.
+// The bucket within the hash table is selected by sel.Hmask and sel.Hoff.
+func (t Table) Link(sel *tc.U32Sel) *tc.U32 {
+	link := t.Handle()
+	return &tc.U32{Link: &link, Sel: sel}
+}
+
+// HashKey selects the bucket of a linked hash table. Like with the option hashkey
+// of tc-u32(8), Mask is applied to the 32-bit word at offset At.
+type HashKey struct {
+	Mask uint32
+	At   uint16
+}
+
+// Bucket calculates the bucket, the kernel selects for a packet with the word value at
+// the offset of the HashKey, for a hash table with the given divisor.
+func (h HashKey) Bucket(value, divisor uint32) uint8 {
+	if h.Mask == 0 || divisor == 0 {
+		return 0
+	}
+	shift := bits.TrailingZeros32(h.Mask)
+	return uint8(((value & h.Mask) >> shift) & (divisor - 1))
+}
+
+// NextHdr describes the variable offset of the next header. Like with the option
+// offset of tc-u32(8), the 16-bit word at offset At is masked by Mask, shifted right
+// by Shift and increased by Plus.
+type NextHdr struct {
+	At    uint16
+	Mask  uint16
+	Shift uint8
+	Plus  uint16
+	Eat   bool
+}
+
+// NextHdrIPv4 calculates the offset of the header following an IPv4 header including options.
+var NextHdrIPv4 = NextHdr{At: 0, Mask: 0x0F00, Shift: 6}
+
+// NextHdrIPv6 describes the offset of the header following an IPv6 header without extension headers.
+var NextHdrIPv6 = NextHdr{Plus: 40}
+
+// key represents a tc.U32Key with values in host byte order.
+type key struct {
+	val, mask uint32
+	off       int32
+	nexthdr   bool
+}
+
+func (k key) marshal() tc.U32Key {
+	var offmask uint32
+	if k.nexthdr {
+		offmask = 0xFFFFFFFF
+	}
+	return tc.U32Key{Val: toKernel(k.val & k.mask), Mask: toKernel(k.mask), Off: uint32(k.off), OffMask: offmask}
+}
+
+func unmarshalKey(k tc.U32Key) key {
+	return key{val: fromKernel(k.Val), mask: fromKernel(k.Mask), off: int32(k.Off), nexthdr: k.OffMask != 0}
+}
+
+// toKernel converts v to the representation of a be32 in tc.U32Key.
+func toKernel(v uint32) uint32 {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return native.Endian.Uint32(b[:])
+}
+
+// fromKernel converts a be32 of tc.U32Key to its value in host byte order.
+func fromKernel(v uint32) uint32 {
+	var b [4]byte
+	native.Endian.PutUint32(b[:], v)
+	return binary.BigEndian.Uint32(b[:])
+}
@@ -0,0 +1,223 @@
+package u32
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/florianl/go-tc"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHandle(t *testing.T) {
+	tests := map[string]struct {
+		htid   uint32
+		bucket uint8
+		node   uint32
+		want   uint32
+	}{
+		"800::":      {htid: 0x800, want: 0x80000000},
+		"1:":         {htid: 1, want: 0x00100000},
+		"1:5:":       {htid: 1, bucket: 5, want: 0x00105000},
+		"fff:ff:fff": {htid: 0xFFF, bucket: 0xFF, node: 0xFFF, want: 0xFFFFFFFF},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := Handle(tt.htid, tt.bucket, tt.node)
+			if h != tt.want {
+				t.Fatalf("Handle() = 0x%x, want 0x%x", h, tt.want)
+			}
+			htid, bucket, node := SplitHandle(h)
+			if htid != tt.htid || bucket != tt.bucket || node != tt.node {
+				t.Fatalf("SplitHandle() = %x:%x:%x, want %x:%x:%x", htid, bucket, node,
+					tt.htid, tt.bucket, tt.node)
+			}
+		})
+	}
+}
+
+func TestTable(t *testing.T) {
+	tests := map[string]struct {
+		id, divisor uint32
+		err         error
+	}{
+		"valid":         {id: 1, divisor: 256},
+		"root":          {id: RootHashTable, divisor: 1, err: tc.ErrInvalidArg},
+		"zero id":       {id: 0, divisor: 1, err: tc.ErrInvalidArg},
+		"large divisor": {id: 2, divisor: 512, err: tc.ErrInvalidArg},
+		"odd divisor":   {id: 2, divisor: 3, err: tc.ErrInvalidArg},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			table, err := NewTable(tt.id, tt.divisor)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if table.Handle() != Handle(tt.id, 0, 0) {
+				t.Fatalf("unexpected handle 0x%x", table.Handle())
+			}
+			if *table.Create().Divisor != tt.divisor {
+				t.Fatalf("unexpected divisor %d", *table.Create().Divisor)
+			}
+		})
+	}
+
+	t.Run("bucket", func(t *testing.T) {
+		h := HashKey{Mask: 0x000000FF, At: 16}
+		// 10.0.0.5
+		if b := h.Bucket(0x0A000005, 256); b != 5 {
+			t.Fatalf("unexpected bucket %d", b)
+		}
+		h = HashKey{Mask: 0x0000FF00, At: 16}
+		if b := h.Bucket(0x0A000105, 16); b != 1 {
+			t.Fatalf("unexpected bucket %d", b)
+		}
+	})
+}
+
+func TestSelector(t *testing.T) {
+	tests := map[string]struct {
+		ipv6     bool
+		matches  []Match
+		hashKey  *HashKey
+		nextHdr  *NextHdr
+		terminal bool
+		keys     []key
+		decoded  []Match
+		err      error
+	}{
+		"ipv4": {
+			matches: []Match{
+				IPSrc{Prefix: netip.MustParsePrefix("10.0.0.0/8")},
+				IPDst{Prefix: netip.MustParsePrefix("192.0.2.1/32")},
+				IPProto{Proto: 6},
+				DstPort{Port: 443},
+			},
+			nextHdr:  &NextHdrIPv4,
+			terminal: true,
+			keys: []key{
+				{val: 0x0A000000, mask: 0xFF000000, off: 12},
+				{val: 0xC0000201, mask: 0xFFFFFFFF, off: 16},
+				{val: 0x00060000, mask: 0x00FF0000, off: 8},
+				{val: 443, mask: 0xFFFF, nexthdr: true},
+			},
+		},
+		"merged ports": {
+			matches: []Match{SrcPort{Port: 1024, Mask: 0xFC00}, DstPort{Port: 53}},
+			keys:    []key{{val: 0x04000035, mask: 0xFC00FFFF, nexthdr: true}},
+		},
+		"ipv6": {
+			ipv6: true,
+			matches: []Match{
+				IPSrc{Prefix: netip.MustParsePrefix("2001:db8::/36")},
+				IPv6Proto{Proto: 17},
+			},
+			hashKey: &HashKey{Mask: 0x000000FF, At: 36},
+			keys: []key{
+				{val: 0x20010DB8, mask: 0xFFFFFFFF, off: 8},
+				{val: 0x00000000, mask: 0xF0000000, off: 12},
+				{val: 0x00001100, mask: 0x0000FF00, off: 4},
+			},
+		},
+		"raw": {
+			matches: []Match{Raw{Val: 0x12, Mask: 0xFF, Off: 4}, IPProto{Proto: 1}, Raw{Val: 0x01000000, Mask: 0xFF000000, Off: 8}},
+			keys: []key{
+				{val: 0x12, mask: 0xFF, off: 4},
+				{val: 0x01010000, mask: 0xFFFF0000, off: 8},
+			},
+			decoded: []Match{Raw{Val: 0x12, Mask: 0xFF, Off: 4}, IPProto{Proto: 1}, Raw{Val: 0x01000000, Mask: 0xFF000000, Off: 8}},
+		},
+		"contradiction": {
+			matches: []Match{IPProto{Proto: 6}, IPProto{Proto: 17}},
+			err:     tc.ErrInvalidArg,
+		},
+		"unaligned": {
+			matches: []Match{Raw{Off: 3}},
+			err:     tc.ErrInvalidArg,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSelector(tt.matches...)
+			if tt.hashKey != nil {
+				s.HashKey(*tt.hashKey)
+			}
+			if tt.nextHdr != nil {
+				s.NextHdr(*tt.nextHdr)
+			}
+			if tt.terminal {
+				s.Terminal()
+			}
+			sel, err := s.Build()
+			if err != nil {
+				if errors.Is(err, tt.err) {
+					return
+				}
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var keys []key
+			for _, k := range sel.Keys {
+				keys = append(keys, unmarshalKey(k))
+			}
+			if diff := cmp.Diff(tt.keys, keys, cmp.AllowUnexported(key{})); diff != "" {
+				t.Fatalf("key missmatch (-want +got):\n%s", diff)
+			}
+			if int(sel.NKeys) != len(sel.Keys) {
+				t.Fatalf("NKeys %d does not match %d keys", sel.NKeys, len(sel.Keys))
+			}
+
+			table, _ := NewTable(1, 256)
+			f, err := Decode(table.Link(sel), tt.ipv6)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := &Filter{
+				HashTable: RootHashTable,
+				Link:      &table.ID,
+				HashKey:   tt.hashKey,
+				NextHdr:   tt.nextHdr,
+				Terminal:  tt.terminal,
+				Matches:   tt.matches,
+			}
+			if tt.decoded != nil {
+				want.Matches = tt.decoded
+			}
+			if diff := cmp.Diff(want, f, cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); diff != "" {
+				t.Fatalf("Filter missmatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		if _, err := Decode(nil, false); !errors.Is(err, tc.ErrNoArg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("hash table", func(t *testing.T) {
+		table, _ := NewTable(2, 16)
+		f, err := Decode(table.Create(), false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if f.Divisor == nil || *f.Divisor != 16 {
+			t.Fatalf("unexpected divisor: %v", f.Divisor)
+		}
+	})
+	t.Run("bucket", func(t *testing.T) {
+		table, _ := NewTable(2, 16)
+		hash := table.Bucket(3)
+		f, err := Decode(&tc.U32{Hash: &hash}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if f.HashTable != 2 || f.Bucket != 3 {
+			t.Fatalf("unexpected placement %x:%x:", f.HashTable, f.Bucket)
+		}
+	})
+}